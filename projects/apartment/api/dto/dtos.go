@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
@@ -36,10 +37,122 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
 type AuthResponse struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 }
+
+// MFAChallengeResponse is returned by sign-in in place of AuthResponse to
+// users with two-factor authentication.
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfaRequired"`
+	ChallengeToken string    `json:"challengeToken"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+type VerifyMFARequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	// Code is a code of the authenticator app or a recovery code.
+	Code string `json:"code"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+	// QRCode is the otpauth URI as a PNG image, base64 encoded.
+	QRCode []byte `json:"qrCode,omitempty" swaggertype:"string" format:"base64"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type UserProfile struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	Phone         string `json:"phone"`
+	Locale        string `json:"locale"`
+	AvatarURL     string `json:"avatarURL"`
+}
+
+// UpdateProfileRequest changes the fields present in the body; an empty
+// string clears a field.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"firstName,omitempty"`
+	LastName  *string `json:"lastName,omitempty"`
+	Phone     *string `json:"phone,omitempty"`
+	Locale    *string `json:"locale,omitempty"`
+	AvatarURL *string `json:"avatarURL,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type UserExport struct {
+	ExportedAt  time.Time          `json:"exportedAt"`
+	Profile     *UserProfile       `json:"profile"`
+	Memberships []ExportMembership `json:"memberships"`
+	BillShares  []ExportBillShare  `json:"billShares"`
+	Payments    []ExportPayment    `json:"payments"`
+}
+
+type ExportMembership struct {
+	ApartmentID string     `json:"apartmentID"`
+	Name        string     `json:"name"`
+	Address     string     `json:"address"`
+	UnitNumber  int64      `json:"unitNumber"`
+	IsAdmin     bool       `json:"isAdmin"`
+	JoinedAt    time.Time  `json:"joinedAt"`
+	LeftAt      *time.Time `json:"leftAt,omitempty"`
+}
+
+type ExportBillShare struct {
+	BillID     string `json:"billID"`
+	BillName   string `json:"billName"`
+	BillAmount int64  `json:"billAmount"`
+	Share      int64  `json:"share"`
+	Paid       int64  `json:"paid"`
+	BalanceDue int64  `json:"balanceDue"`
+}
+
+type ExportPayment struct {
+	ID          string     `json:"id"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	BillID      string     `json:"billID"`
+	Amount      int64      `json:"amount"`
+	Status      string     `json:"status"`
+	Gateway     string     `json:"gateway"`
+	PaidAt      *time.Time `json:"paidAt,omitempty"`
+	RefundOf    string     `json:"refundOf,omitempty"`
+	Description string     `json:"description,omitempty"`
+}
 type Apartment struct {
 	ID         string `json:"id"`
 	Name       string `json:"name"`
@@ -60,6 +173,10 @@ type RemoveApartmentRequest struct {
 type RemoveApartmentResponse struct {
 }
 
+type ApartmentMFAPolicyRequest struct {
+	RequireAdminMFA bool `json:"requireAdminMFA"`
+}
+
 type InviteUserToApartmentRequest struct {
 	UserEmail   common.Email `json:"userEmail"`
 	ApartmentID common.ID    `json:"apartmentID"`
@@ -117,8 +234,13 @@ type CalculateUserBillResponse struct {
 }
 
 type PayRequest struct {
-	Amount      int64  `json:"amount"`
-	CallbackURL string `json:"returnUrl"`
+	Amount        int64  `json:"amount"`
+	CallbackURL   string `json:"returnUrl"`
+	TransactionID string `json:"transactionId,omitempty"`
+	// Scenario scripts the outcome on the mock gateway, empty succeeds.
+	Scenario string `json:"scenario,omitempty"`
+	// Delay of a delayed callback, e.g. "5s".
+	Delay string `json:"delay,omitempty"`
 }
 
 type PayResponse struct {
@@ -128,29 +250,362 @@ type PayResponse struct {
 }
 
 type VerifyRequest struct {
-	Token string `json:"token"`
+	Token         string `json:"token"`
+	TransactionID string `json:"transactionId,omitempty"`
 }
 
+// Verify response codes of the mock gateway.
+const (
+	VerifyOK          = 0
+	VerifyNotComplete = 1
+	VerifyDeclined    = 2
+	VerifyCancelled   = 3
+)
+
 type VerifyResponse struct {
 	Code    int    `json:"code"`    //  ==0 success, !=0 failed
 	Message string `json:"message"` // descriptive message
+	// Amount captured for the transaction, if known.
+	Amount int64 `json:"amount,omitempty"`
+}
+
+type InquiryResponse struct {
+	TransactionID string `json:"transactionId"`
+	Status        string `json:"status"` // pending, paid, failed, cancelled
+	Amount        int64  `json:"amount,omitempty"`
+}
+
+type GatewayRefundRequest struct {
+	TransactionID string `json:"transactionId"`
+	Amount        int64  `json:"amount"`
+}
+
+type GatewayRefundResponse struct {
+	RefundID string `json:"refundId"`
+}
+
+type TokenizeRequest struct {
+	PayerID     string `json:"payerId"`
+	CallbackURL string `json:"returnUrl"`
+	// Scenario scripts the outcome of the charges on the saved card.
+	Scenario string `json:"scenario,omitempty"`
+}
+
+type TokenResponse struct {
+	Token    string `json:"token"`
+	CardMask string `json:"cardMask"`
+}
+
+type ChargeRequest struct {
+	Token         string `json:"token"`
+	Amount        int64  `json:"amount"`
+	TransactionID string `json:"transactionId"`
+}
+
+type ChargeResponse struct {
+	TransactionID string `json:"transactionId"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
 }
 
 type PayBillRequest struct {
 	BillID  string `json:"billID"`
 	Gateway string `json:"gateway"`
+	// Amount to pay, zero pays the whole balance due.
+	Amount int64 `json:"amount,omitempty"`
+	// Metadata is passed to the gateway, e.g. mock-scenario.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type PayTotalDebtRequest struct {
 	Gateway string `json:"gateway"`
+	// BillIDs limits the payment to these bills, empty pays all of them.
+	BillIDs []string `json:"billIDs,omitempty"`
+	// Amount to pay, zero pays the whole debt.
+	Amount int64 `json:"amount,omitempty"`
+	// Metadata is passed to the gateway, e.g. mock-scenario.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type RedirectGateway struct {
 	Method string         `json:"method"`
 	URL    string         `json:"url"`
 	Body   map[string]any `json:"body"`
+	// Gateway the transaction was created on, another one than requested
+	// if the requested gateway was unavailable.
+	Gateway string `json:"gateway"`
+}
+
+type PaymentGateway struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Priority    int    `json:"priority"`
+	MinAmount   int64  `json:"minAmount,omitempty"`
+	MaxAmount   int64  `json:"maxAmount,omitempty"`
 }
 
 type SupportedGatewaysResponse struct {
-	SupportedGateways []string `json:"supportedGateways"`
+	// SupportedGateways are the names of Gateways.
+	SupportedGateways []string         `json:"supportedGateways"`
+	Gateways          []PaymentGateway `json:"gateways"`
+}
+
+type RefundRequest struct {
+	PaymentID string `json:"paymentID"`
+	// Amount to refund, zero refunds whatever is left of the payment.
+	Amount int64  `json:"amount,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Offline records a refund already paid back outside the gateway.
+	Offline bool `json:"offline,omitempty"`
+}
+
+type Payment struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	BillID        string    `json:"billID"`
+	PayerID       string    `json:"payerID"`
+	Amount        int64     `json:"amount"`
+	PaidAt        time.Time `json:"paidAt"`
+	Status        string    `json:"status"`
+	Gateway       string    `json:"gateway"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	RefundOf      string    `json:"refundOf,omitempty"`
+	Description   string    `json:"description,omitempty"`
+}
+
+type PaymentRecord struct {
+	Payment
+	BillName      string `json:"billName"`
+	ApartmentID   string `json:"apartmentID"`
+	ApartmentName string `json:"apartmentName"`
+	PayerName     string `json:"payerName,omitempty"`
+	PayerEmail    string `json:"payerEmail"`
+}
+
+type PaymentHistoryResponse struct {
+	Payments []PaymentRecord `json:"payments"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+}
+
+type MemberBalance struct {
+	UserID     string `json:"userID"`
+	Name       string `json:"name,omitempty"`
+	Email      string `json:"email"`
+	BalanceDue int64  `json:"balanceDue"`
+}
+
+type ApartmentPaymentHistoryResponse struct {
+	PaymentHistoryResponse
+	// Unpaid lists the members who still owe on the bill in the filter.
+	Unpaid []MemberBalance `json:"unpaid,omitempty"`
+}
+
+type PaymentClaim struct {
+	ID           string     `json:"id"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	BillID       string     `json:"billID"`
+	PayerID      string     `json:"payerID"`
+	Amount       int64      `json:"amount"`
+	PaidAt       time.Time  `json:"paidAt"`
+	Reference    string     `json:"reference"`
+	ProofName    string     `json:"proofName,omitempty"`
+	Status       string     `json:"status"` // pending, approved, rejected
+	ReviewedBy   string     `json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+	RejectReason string     `json:"rejectReason,omitempty"`
+	// PaymentID is the offline payment an approved claim became.
+	PaymentID string `json:"paymentID,omitempty"`
+}
+
+type PaymentClaimsResponse struct {
+	Claims []PaymentClaim `json:"claims"`
+}
+
+type RejectPaymentClaimRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type Wallet struct {
+	ID          string `json:"id,omitempty"`
+	UserID      string `json:"userID"`
+	ApartmentID string `json:"apartmentID"`
+	Balance     int64  `json:"balance"`
+}
+
+type TopUpWalletRequest struct {
+	ApartmentID string `json:"apartmentID"`
+	Gateway     string `json:"gateway"`
+	Amount      int64  `json:"amount"`
+}
+
+type PayBillFromWalletRequest struct {
+	BillID string `json:"billID"`
+	// Amount to pay, zero pays as much as the wallet credit allows.
+	Amount int64 `json:"amount,omitempty"`
+}
+
+type WalletTransaction struct {
+	ID            string    `json:"id"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Type          string    `json:"type"`   // top-up, bill-payment
+	Amount        int64     `json:"amount"` // negative when credit is spent
+	Status        string    `json:"status"` // pending, completed, failed
+	Gateway       string    `json:"gateway,omitempty"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	PaymentID     string    `json:"paymentID,omitempty"`
+	BillID        string    `json:"billID,omitempty"`
+	Description   string    `json:"description,omitempty"`
+}
+
+type WalletTransactionsResponse struct {
+	Transactions []WalletTransaction `json:"transactions"`
+}
+
+type LedgerAdjustmentRequest struct {
+	Type   string `json:"type"` // fee, adjustment
+	BillID string `json:"billID"`
+	UserID string `json:"userID"`
+	// Amount charged to the member, a negative adjustment credits them.
+	Amount      int64  `json:"amount"`
+	Description string `json:"description,omitempty"`
+}
+
+type LedgerLine struct {
+	Account string `json:"account"`
+	Amount  int64  `json:"amount"` // positive for debit, negative for credit
+}
+
+type LedgerEntry struct {
+	ID          string       `json:"id"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	Type        string       `json:"type"`
+	Reference   string       `json:"reference"`
+	ApartmentID string       `json:"apartmentID"`
+	BillID      string       `json:"billID,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Lines       []LedgerLine `json:"lines"`
+}
+
+type EnrollAutopayRequest struct {
+	ApartmentID string `json:"apartmentID"`
+	Gateway     string `json:"gateway"`
+	// DaysBeforeDue is how many days before the due date a bill is charged.
+	DaysBeforeDue int `json:"daysBeforeDue"`
+	// Metadata is passed to the gateway, e.g. mock-scenario.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type AutopayEnrollment struct {
+	ID            string     `json:"id"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ApartmentID   string     `json:"apartmentID"`
+	Gateway       string     `json:"gateway"`
+	CardMask      string     `json:"cardMask,omitempty"`
+	DaysBeforeDue int        `json:"daysBeforeDue"`
+	Status        string     `json:"status"` // pending, active, cancelled, suspended
+	Failures      int        `json:"failures"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastFailedAt  *time.Time `json:"lastFailedAt,omitempty"`
+	ActivatedAt   *time.Time `json:"activatedAt,omitempty"`
+}
+
+type EnrollAutopayResponse struct {
+	Enrollment AutopayEnrollment `json:"enrollment"`
+	// Redirect sends the payer to the gateway to save a card.
+	Redirect *RedirectGateway `json:"redirect"`
+}
+
+type AutopayEnrollmentsResponse struct {
+	Enrollments []AutopayEnrollment `json:"enrollments"`
+}
+
+type CreateWebhookRequest struct {
+	ApartmentID string `json:"apartmentID"`
+	URL         string `json:"url"`
+	// EventTypes are bill.created, bill.overdue, payment.succeeded and
+	// member.joined.
+	EventTypes []string `json:"eventTypes"`
+}
+
+type Webhook struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ApartmentID string    `json:"apartmentID"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"eventTypes"`
+}
+
+type CreateWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+	// Secret signs the deliveries. It is only shown once.
+	Secret string `json:"secret"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	EventID        string          `json:"eventID"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"` // pending, succeeded, failed
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type PostAnnouncementRequest struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Notification is the data of a notification stream event.
+type Notification struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	ApartmentID string          `json:"apartmentID"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	Data        json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type CreatePersonalTokenRequest struct {
+	Name string `json:"name"`
+	// Scopes are bills:read, bills:write, payments:read and
+	// payments:write.
+	Scopes []string `json:"scopes"`
+	// ApartmentID restricts the token to an apartment, optional.
+	ApartmentID string     `json:"apartmentID,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+type PersonalToken struct {
+	ID          string     `json:"id"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	Name        string     `json:"name"`
+	Scopes      []string   `json:"scopes"`
+	ApartmentID string     `json:"apartmentID,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type CreatePersonalTokenResponse struct {
+	PersonalToken PersonalToken `json:"personalToken"`
+	// Token is sent as "Authorization: Bearer <token>". It is only shown
+	// once.
+	Token string `json:"token"`
+}
+
+type PersonalTokensResponse struct {
+	PersonalTokens []PersonalToken `json:"personalTokens"`
 }
@@ -2,10 +2,16 @@ package dto
 
 import (
 	apartmentDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/domain"
+	authd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	autopayd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
 	billDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	ledgerd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	notificationd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
 	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	walletd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+	webhookd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
 )
 
 func UserDTOToDomain(u *User) *userDomain.User {
@@ -14,6 +20,85 @@ func UserDTOToDomain(u *User) *userDomain.User {
 	return userDomain.NewUser(id, u.Email, u.Password, u.FirstName, u.LastName)
 }
 
+func TOTPEnrollmentDomainToDTO(e *authd.TOTPEnrollment) *TOTPEnrollmentResponse {
+	return &TOTPEnrollmentResponse{
+		Secret:     e.Secret,
+		OtpauthURI: e.URI,
+		QRCode:     e.QRCode,
+	}
+}
+
+func UserProfileDomainToDTO(u *userDomain.User) *UserProfile {
+	return &UserProfile{
+		ID:            u.ID.String(),
+		Email:         u.Email.String(),
+		EmailVerified: u.EmailVerifiedAt != nil,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Phone:         u.Phone,
+		Locale:        u.Locale,
+		AvatarURL:     u.AvatarURL,
+	}
+}
+
+func UpdateProfileDTOToDomain(p *UpdateProfileRequest) *userDomain.ProfileUpdate {
+	return &userDomain.ProfileUpdate{
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		Phone:     p.Phone,
+		Locale:    p.Locale,
+		AvatarURL: p.AvatarURL,
+	}
+}
+
+func UserExportDomainToDTO(e *userDomain.Export) *UserExport {
+	res := &UserExport{
+		ExportedAt:  e.ExportedAt,
+		Profile:     UserProfileDomainToDTO(e.Profile),
+		Memberships: []ExportMembership{},
+		BillShares:  []ExportBillShare{},
+		Payments:    []ExportPayment{},
+	}
+	for _, m := range e.Memberships {
+		res.Memberships = append(res.Memberships, ExportMembership{
+			ApartmentID: m.ApartmentID.String(),
+			Name:        m.Name,
+			Address:     m.Address,
+			UnitNumber:  m.UnitNumber,
+			IsAdmin:     m.IsAdmin,
+			JoinedAt:    m.JoinedAt,
+			LeftAt:      m.LeftAt,
+		})
+	}
+	for _, s := range e.BillShares {
+		res.BillShares = append(res.BillShares, ExportBillShare{
+			BillID:     s.BillID.String(),
+			BillName:   s.BillName,
+			BillAmount: s.BillAmount,
+			Share:      s.Share,
+			Paid:       s.Paid,
+			BalanceDue: s.BalanceDue,
+		})
+	}
+	for _, p := range e.Payments {
+		ep := ExportPayment{
+			ID:          p.ID.String(),
+			CreatedAt:   p.CreatedAt,
+			BillID:      p.BillID.String(),
+			Amount:      p.Amount,
+			Status:      p.Status,
+			Gateway:     p.Gateway,
+			PaidAt:      p.PaidAt,
+			Description: p.Description,
+		}
+		if p.RefundOf != nil {
+			ep.RefundOf = p.RefundOf.String()
+		}
+		res.Payments = append(res.Payments, ep)
+	}
+	return res
+}
+
 func ApartmentDTOToDomain(a *Apartment) *apartmentDomain.Apartment {
 	id := common.NilID
 	_ = id.UnmarshalText([]byte(a.ID))
@@ -42,8 +127,242 @@ func ApartmentDomainToDTO(a *apartmentDomain.Apartment) *Apartment {
 
 func RedirectGatewayDomainToDTO(rg *paymentd.RedirectGateway) *RedirectGateway {
 	return &RedirectGateway{
-		Method: rg.Method,
-		URL:    rg.URL,
-		Body:   rg.Body,
+		Method:  rg.Method,
+		URL:     rg.URL,
+		Body:    rg.Body,
+		Gateway: rg.Gateway.String(),
+	}
+}
+
+func SupportedGatewaysDomainToDTO(gws []paymentd.GatewayInfo) *SupportedGatewaysResponse {
+	resp := &SupportedGatewaysResponse{
+		SupportedGateways: make([]string, 0, len(gws)),
+		Gateways:          make([]PaymentGateway, 0, len(gws)),
+	}
+	for _, g := range gws {
+		resp.SupportedGateways = append(resp.SupportedGateways, g.Type.String())
+		resp.Gateways = append(resp.Gateways, PaymentGateway{
+			Name:        g.Type.String(),
+			DisplayName: g.DisplayName,
+			Priority:    g.Priority,
+			MinAmount:   g.MinAmount,
+			MaxAmount:   g.MaxAmount,
+		})
+	}
+	return resp
+}
+
+func PaymentDomainToDTO(p *paymentd.Payment) *Payment {
+	refundOf := ""
+	if p.RefundOf != nil {
+		refundOf = p.RefundOf.String()
+	}
+	return &Payment{
+		ID:            p.ID.String(),
+		CreatedAt:     p.CreatedAt,
+		BillID:        p.BillID.String(),
+		PayerID:       p.PayerID.String(),
+		Amount:        p.Amount,
+		PaidAt:        p.PaidAt,
+		Status:        p.Status.String(),
+		Gateway:       p.Gateway,
+		TransactionID: p.TransactionID,
+		RefundOf:      refundOf,
+		Description:   p.Description,
+	}
+}
+
+func PaymentPageDomainToDTO(p *paymentd.PaymentPage) PaymentHistoryResponse {
+	resp := PaymentHistoryResponse{
+		Payments: make([]PaymentRecord, 0, len(p.Records)),
+		Total:    p.Total,
+		Page:     p.Page,
+		PageSize: p.PageSize,
+	}
+	for _, rec := range p.Records {
+		resp.Payments = append(resp.Payments, PaymentRecord{
+			Payment:       *PaymentDomainToDTO(&rec.Payment),
+			BillName:      rec.BillName,
+			ApartmentID:   rec.ApartmentID.String(),
+			ApartmentName: rec.ApartmentName,
+			PayerName:     rec.PayerName,
+			PayerEmail:    rec.PayerEmail,
+		})
+	}
+	return resp
+}
+
+func ApartmentPaymentPageDomainToDTO(p *paymentd.ApartmentPaymentPage) ApartmentPaymentHistoryResponse {
+	resp := ApartmentPaymentHistoryResponse{
+		PaymentHistoryResponse: PaymentPageDomainToDTO(&p.PaymentPage),
+	}
+	for _, m := range p.Unpaid {
+		resp.Unpaid = append(resp.Unpaid, MemberBalance{
+			UserID:     m.UserID.String(),
+			Name:       m.Name,
+			Email:      m.Email,
+			BalanceDue: m.BalanceDue,
+		})
+	}
+	return resp
+}
+
+func PaymentClaimDomainToDTO(c *paymentd.PaymentClaim) PaymentClaim {
+	dto := PaymentClaim{
+		ID:           c.ID.String(),
+		CreatedAt:    c.CreatedAt,
+		BillID:       c.BillID.String(),
+		PayerID:      c.PayerID.String(),
+		Amount:       c.Amount,
+		PaidAt:       c.PaidAt,
+		Reference:    c.Reference,
+		Status:       c.Status.String(),
+		ReviewedAt:   c.ReviewedAt,
+		RejectReason: c.RejectReason,
+	}
+	if c.Proof != nil {
+		dto.ProofName = c.Proof.Name
+	}
+	if c.ReviewedBy != nil {
+		dto.ReviewedBy = c.ReviewedBy.String()
+	}
+	if c.PaymentID != nil {
+		dto.PaymentID = c.PaymentID.String()
+	}
+	return dto
+}
+
+func PaymentClaimsDomainToDTO(cs []paymentd.PaymentClaim) PaymentClaimsResponse {
+	resp := PaymentClaimsResponse{Claims: make([]PaymentClaim, 0, len(cs))}
+	for i := range cs {
+		resp.Claims = append(resp.Claims, PaymentClaimDomainToDTO(&cs[i]))
+	}
+	return resp
+}
+
+func WalletDomainToDTO(w *walletd.Wallet) *Wallet {
+	id := ""
+	if w.ID != common.NilID {
+		id = w.ID.String()
+	}
+	return &Wallet{
+		ID:          id,
+		UserID:      w.UserID.String(),
+		ApartmentID: w.ApartmentID.String(),
+		Balance:     w.Balance,
+	}
+}
+
+func WalletTransactionDomainToDTO(t *walletd.Transaction) WalletTransaction {
+	wt := WalletTransaction{
+		ID:            t.ID.String(),
+		CreatedAt:     t.CreatedAt,
+		Type:          t.Type.String(),
+		Amount:        t.Amount,
+		Status:        t.Status.String(),
+		Gateway:       t.Gateway,
+		TransactionID: t.TransactionID,
+		Description:   t.Description,
+	}
+	if t.PaymentID != nil {
+		wt.PaymentID = t.PaymentID.String()
+	}
+	if t.BillID != nil {
+		wt.BillID = t.BillID.String()
+	}
+	return wt
+}
+
+func LedgerEntryDomainToDTO(e *ledgerd.Entry) *LedgerEntry {
+	le := &LedgerEntry{
+		ID:          e.ID.String(),
+		CreatedAt:   e.CreatedAt,
+		Type:        e.Type.String(),
+		Reference:   e.Reference,
+		ApartmentID: e.ApartmentID.String(),
+		Description: e.Description,
+		Lines:       make([]LedgerLine, 0, len(e.Lines)),
+	}
+	if e.BillID != nil {
+		le.BillID = e.BillID.String()
+	}
+	for _, l := range e.Lines {
+		le.Lines = append(le.Lines, LedgerLine{Account: l.Account.Code(), Amount: l.Amount})
+	}
+	return le
+}
+
+func AutopayEnrollmentDomainToDTO(e *autopayd.Enrollment) AutopayEnrollment {
+	return AutopayEnrollment{
+		ID:            e.ID.String(),
+		CreatedAt:     e.CreatedAt,
+		ApartmentID:   e.ApartmentID.String(),
+		Gateway:       e.Gateway.String(),
+		CardMask:      e.CardMask,
+		DaysBeforeDue: e.DaysBeforeDue,
+		Status:        e.Status.String(),
+		Failures:      e.Failures,
+		LastError:     e.LastError,
+		LastFailedAt:  e.LastFailedAt,
+		ActivatedAt:   e.ActivatedAt,
+	}
+}
+
+func WebhookDomainToDTO(e *webhookd.Endpoint) Webhook {
+	types := make([]string, len(e.EventTypes))
+	for i, t := range e.EventTypes {
+		types[i] = t.String()
+	}
+	return Webhook{
+		ID:          e.ID.String(),
+		CreatedAt:   e.CreatedAt,
+		ApartmentID: e.ApartmentID.String(),
+		URL:         e.URL,
+		EventTypes:  types,
+	}
+}
+
+func WebhookDeliveryDomainToDTO(d *webhookd.Delivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             d.ID.String(),
+		CreatedAt:      d.CreatedAt,
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType.String(),
+		Payload:        d.Payload,
+		Status:         d.Status.String(),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+func NotificationDomainToDTO(n *notificationd.Notification) Notification {
+	return Notification{
+		ID:          n.ID.String(),
+		Type:        n.Type.String(),
+		ApartmentID: n.ApartmentID.String(),
+		CreatedAt:   n.CreatedAt,
+		Data:        n.Data,
+	}
+}
+
+func PersonalTokenDomainToDTO(t *authd.PersonalToken) PersonalToken {
+	scopes := make([]string, len(t.Scopes))
+	for i, s := range t.Scopes {
+		scopes[i] = s.String()
+	}
+	pt := PersonalToken{
+		ID:         t.ID.String(),
+		CreatedAt:  t.CreatedAt,
+		Name:       t.Name,
+		Scopes:     scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+	if t.ApartmentID != nil {
+		pt.ApartmentID = t.ApartmentID.String()
 	}
+	return pt
 }
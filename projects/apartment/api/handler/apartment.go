@@ -118,6 +118,65 @@ func InviteApartmentMember(svcGetter ServiceGetter[apartmentPort.Service], accep
 	})
 }
 
+// SetApartmentMFAPolicy
+//
+// @Summary      Require two-factor authentication for the admin
+// @Description  Sets whether the apartment admin must sign in with two-factor authentication to manage the apartment. Turning it on requires a session signed in with two-factor authentication.
+// @Tags         Apartment
+// @Accept       json
+// @Security 	 BearerAuth
+// @Param        id    path      string                         true  "Apartment ID"
+// @Param        body  body      dto.ApartmentMFAPolicyRequest  true  "Policy"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/apartment/{id}/mfa-policy [put]
+func SetApartmentMFAPolicy(svcGetter ServiceGetter[apartmentPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "SetApartmentMFAPolicy handler"
+
+		apartmentID := r.PathValue("id")
+		if err := common.ValidateID(apartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartment id")
+			return
+		}
+		var req dto.ApartmentMFAPolicyRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		// so that admins can't lock themselves out
+		if mfa, _ := r.Context().Value(appjwt.MFAKey).(bool); req.RequireAdminMFA && !mfa {
+			Error(w, r, http.StatusForbidden, "sign in with two-factor authentication first")
+			return
+		}
+
+		svc := svcGetter(r.Context())
+		err := svc.SetAdminMFAPolicy(r.Context(), adminID, common.IDFromText(apartmentID), req.RequireAdminMFA)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			switch {
+			case errors.Is(err, apartment.ErrInvalidAdmin):
+				Error(w, r, http.StatusForbidden, apartment.ErrInvalidAdmin.Error())
+			case errors.Is(err, apartment.ErrNotFound):
+				Error(w, r, http.StatusNotFound, apartment.ErrNotFound.Error())
+			default:
+				InternalServerError(w, r)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
 const InviteTokenKey string = "token"
 
 // AcceptApartmentInvite
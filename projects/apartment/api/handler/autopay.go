@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay"
+	autopayd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	autopayPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+// EnrollAutopay
+//
+// @Summary      Enroll in autopay
+// @Description  Starts paying the bills of an apartment automatically. The payer is redirected to the gateway to save a card; the enrollment is active once the gateway calls back.
+// @Tags         Autopay
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.EnrollAutopayRequest  true  "Enroll Request"
+// @Success      201   {object}  dto.EnrollAutopayResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/autopay [post]
+func EnrollAutopay(svcGtr ServiceGetter[autopayPort.Service], callbackURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "EnrollAutopay handler"
+
+		var req dto.EnrollAutopayRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.ApartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartmentID")
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		e, redirect, err := svc.Enroll(r.Context(), &autopayd.Enrollment{
+			UserID:        userID,
+			ApartmentID:   common.IDFromText(req.ApartmentID),
+			Gateway:       paymentd.GatewayType(req.Gateway),
+			DaysBeforeDue: req.DaysBeforeDue,
+		}, callbackURL, req.Metadata)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			autopayError(w, r, err)
+			return
+		}
+
+		resp := dto.EnrollAutopayResponse{
+			Enrollment: dto.AutopayEnrollmentDomainToDTO(e),
+			Redirect:   dto.RedirectGatewayDomainToDTO(redirect),
+		}
+		if err = WriteJson(w, http.StatusCreated, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// AutopayCallback
+//
+// @Summary      Autopay enrollment callback
+// @Description  Handles the gateway callback after a card is saved and activates the enrollment
+// @Tags         Autopay
+// @Produce      json
+// @Param        gateway  query    string  true  "Gateway"
+// @Param        cardToken  query  string  false "Saved Card Token"
+// @Param        autopay-enrollment-id query string true "Enrollment ID"
+// @Success      200   {object}  dto.PayResponse
+// @Failure      400   {object}  dto.Error
+// @Router       /api/v1/autopay/callback [post]
+func AutopayCallback(svcGtr ServiceGetter[autopayPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "AutopayCallback"
+
+		gatewayStr := r.URL.Query().Get(autopay.GatewayKey)
+		if gatewayStr == "" {
+			BadRequestError(w, r, "missing gateway")
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		err := svc.HandleEnrollCallback(r.Context(), paymentd.GatewayType(gatewayStr), r.URL.Query())
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			Error(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		resp := dto.PayResponse{
+			Status:  "completed",
+			Message: "autopay successfully activated",
+		}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// AutopayEnrollments
+//
+// @Summary      List autopay enrollments
+// @Description  Lists the autopay enrollments of the authenticated user
+// @Tags         Autopay
+// @Produce      json
+// @Security 	 BearerAuth
+// @Success      200   {object}  dto.AutopayEnrollmentsResponse
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/autopay [get]
+func AutopayEnrollments(svcGtr ServiceGetter[autopayPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "AutopayEnrollments handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		es, err := svc.Enrollments(r.Context(), userID)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			autopayError(w, r, err)
+			return
+		}
+
+		resp := dto.AutopayEnrollmentsResponse{
+			Enrollments: make([]dto.AutopayEnrollment, 0, len(es)),
+		}
+		for i := range es {
+			resp.Enrollments = append(resp.Enrollments, dto.AutopayEnrollmentDomainToDTO(&es[i]))
+		}
+		if err = WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// CancelAutopay
+//
+// @Summary      Cancel autopay
+// @Description  Turns off an autopay enrollment of the authenticated user
+// @Tags         Autopay
+// @Security 	 BearerAuth
+// @Param        id    path      string  true  "Enrollment ID"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/autopay/{id} [delete]
+func CancelAutopay(svcGtr ServiceGetter[autopayPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "CancelAutopay handler"
+
+		enrollmentID := r.PathValue("id")
+		if err := common.ValidateID(enrollmentID); err != nil {
+			BadRequestError(w, r, "invalid enrollment id")
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		err := svc.Cancel(r.Context(), userID, common.IDFromText(enrollmentID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			autopayError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func autopayError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, autopayd.ErrInvalidDaysBeforeDue):
+		Error(w, r, http.StatusBadRequest, autopayd.ErrInvalidDaysBeforeDue.Error())
+	case errors.Is(err, autopay.ErrUnknownGateway):
+		Error(w, r, http.StatusBadRequest, autopay.ErrUnknownGateway.Error())
+	case errors.Is(err, autopay.ErrRecurringUnsupported):
+		Error(w, r, http.StatusBadRequest, autopay.ErrRecurringUnsupported.Error())
+	case errors.Is(err, autopay.ErrNotMember):
+		Error(w, r, http.StatusForbidden, autopay.ErrNotMember.Error())
+	case errors.Is(err, autopay.ErrAlreadyEnrolled):
+		Error(w, r, http.StatusConflict, autopay.ErrAlreadyEnrolled.Error())
+	case errors.Is(err, autopay.ErrEnrollmentNotFound):
+		Error(w, r, http.StatusNotFound, autopay.ErrEnrollmentNotFound.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
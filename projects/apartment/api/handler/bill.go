@@ -152,58 +152,71 @@ func parseDateField(r *http.Request, field, layout string, log *zap.Logger, w ht
 }
 
 func handleImageUpload(r *http.Request, b *domain.Bill, log *zap.Logger, w http.ResponseWriter) error {
-	file, header, err := r.FormFile("image")
+	img, err := readImageUpload(r, "image", log, w)
+	if err != nil || img == nil {
+		return err // image is optional
+	}
+	b.HasImage = true
+	b.Image = img
+	return nil
+}
+
+// readImageUpload saves the image in the form field to a temp file. It
+// returns nil if the field is missing and writes the error response on
+// failure.
+func readImageUpload(r *http.Request, field string, log *zap.Logger, w http.ResponseWriter) (*domain.Image, error) {
+	file, header, err := r.FormFile(field)
 	if err != nil {
 		if !errors.Is(err, http.ErrMissingFile) {
 			log.Error("failed to get image", zap.Error(err))
 			InternalServerError(w, r)
-			return err
+			return nil, err
 		}
-		return nil // image is optional
+		return nil, nil
 	}
 	defer file.Close()
 
 	if header.Size > maxFileSize {
 		log.Error("uploaded file too large", zap.Int64("size", header.Size))
 		Error(w, r, http.StatusRequestEntityTooLarge, "uploaded file is too large")
-		return errors.New("file too large")
+		return nil, errors.New("file too large")
 	}
 
 	content, err := io.ReadAll(file)
 	if err != nil {
 		log.Error("failed to read file", zap.Error(err))
 		InternalServerError(w, r)
-		return err
+		return nil, err
 	}
 
 	contentType := http.DetectContentType(content)
 	if !strings.HasPrefix(contentType, "image/") {
 		Error(w, r, http.StatusBadRequest, "uploaded file is not an image")
-		return errors.New("invalid image type")
+		return nil, errors.New("invalid image type")
 	}
 
 	dir, err := os.MkdirTemp(os.TempDir(), "*")
 	if err != nil {
 		log.Error("failed to make temp dir", zap.Error(err))
-		return err
+		InternalServerError(w, r)
+		return nil, err
 	}
 
-	path := filepath.Join(dir, header.Filename)
+	path := filepath.Join(dir, filepath.Base(header.Filename))
 	err = os.WriteFile(path, content, 0644)
 	if err != nil {
 		log.Error("failed to save image", zap.Error(err))
-		return err
+		InternalServerError(w, r)
+		return nil, err
 	}
 
-	b.HasImage = true
-	b.Image = &domain.Image{
+	return &domain.Image{
 		Name:    header.Filename,
 		Path:    path,
 		Type:    contentType,
 		Size:    header.Size,
 		Content: content,
-	}
-	return nil
+	}, nil
 }
 
 // GetBill
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+)
+
+// JWKSHandler
+//
+// @Summary      Token signing keys
+// @Description  The public keys tokens are signed with, as a JSON Web Key Set, so that other services can verify access tokens without a shared secret. The kid header of a token names its key. Keys being rotated in or out are listed too. Tokens signed with AUTH_JWT_SECRET can't be verified this way.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  appjwt.JWKS
+// @Router       /.well-known/jwks.json [get]
+func JWKSHandler(keys *appjwt.KeySet) http.Handler {
+	jwks := keys.JWKS()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// verifiers may cache the keys; rotating in a new key ahead of
+		// signing with it leaves them the time to fetch it
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		WriteJson(w, http.StatusOK, jwks)
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	ledgerPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+// PostLedgerAdjustment
+//
+// @Summary      Post a fee or adjustment
+// @Description  Charges a fee to, or corrects the balance of, an apartment member on a bill. Only the apartment admin can post adjustments.
+// @Tags         Ledger
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.LedgerAdjustmentRequest  true  "Adjustment Request"
+// @Success      201   {object}  dto.LedgerEntry
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/ledger/adjustments [post]
+func PostLedgerAdjustment(svcGtr ServiceGetter[ledgerPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "PostLedgerAdjustment handler"
+
+		var req dto.LedgerAdjustmentRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.BillID); err != nil {
+			BadRequestError(w, r, "invalid billID")
+			return
+		}
+		if err := common.ValidateID(req.UserID); err != nil {
+			BadRequestError(w, r, "invalid userID")
+			return
+		}
+
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		entry, err := svc.PostAdjustment(r.Context(), adminID, domain.Adjustment{
+			Type:        domain.EntryType(req.Type),
+			BillID:      common.IDFromText(req.BillID),
+			UserID:      common.IDFromText(req.UserID),
+			Amount:      req.Amount,
+			Description: req.Description,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			switch {
+			case errors.Is(err, ledger.ErrInvalidAdjustment):
+				BadRequestError(w, r, ledger.ErrInvalidAdjustment.Error())
+			case errors.Is(err, ledger.ErrBillNotFound):
+				Error(w, r, http.StatusNotFound, ledger.ErrBillNotFound.Error())
+			case errors.Is(err, ledger.ErrPermissionDenied):
+				Error(w, r, http.StatusForbidden, ledger.ErrPermissionDenied.Error())
+			case errors.Is(err, ledger.ErrNotMember):
+				BadRequestError(w, r, ledger.ErrNotMember.Error())
+			default:
+				InternalServerError(w, r)
+			}
+			return
+		}
+
+		if err = WriteJson(w, http.StatusCreated, dto.LedgerEntryDomainToDTO(entry)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"go.uber.org/zap"
+)
+
+// VerifyMFAHandler
+//
+// @Summary      Complete two-factor sign-in
+// @Description  Exchanges the challenge returned by sign-in and a code of the authenticator app, or a recovery code, for JWT tokens. Five wrong codes in a row lock verification for 15 minutes.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.VerifyMFARequest  true  "Challenge and code"
+// @Success      201   {object}  dto.AuthResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      429   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/2fa/verify [post]
+func VerifyMFAHandler(svcGetter ServiceGetter[authPort.Service], cfg config.AuthConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "VerifyMFA handler"
+
+		var req dto.VerifyMFARequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		tokens, err := svcGetter(r.Context()).VerifyMFA(r.Context(), req.ChallengeToken, req.Code)
+		if err != nil {
+			log.Warn(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+
+		SetTokenCookie(w, cfg, tokens.AccessToken, tokens.RefreshToken)
+		err = WriteJson(w, http.StatusCreated, &dto.AuthResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// EnrollTOTPHandler
+//
+// @Summary      Start authenticator enrolment
+// @Description  Generates a secret for an authenticator app, as text, otpauth URI and QR code PNG. Two-factor authentication is enabled once a code is confirmed.
+// @Tags         Auth
+// @Produce      json
+// @Security 	 BearerAuth
+// @Success      201   {object}  dto.TOTPEnrollmentResponse
+// @Failure      401   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/2fa/totp [post]
+func EnrollTOTPHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "EnrollTOTP handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		email, _ := r.Context().Value(appjwt.UserEmailKey).(string)
+
+		e, err := svcGetter(r.Context()).EnrollTOTP(r.Context(), userID, email)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		if err = WriteJson(w, http.StatusCreated, dto.TOTPEnrollmentDomainToDTO(e)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// ConfirmTOTPHandler
+//
+// @Summary      Enable two-factor authentication
+// @Description  Confirms the pending authenticator with a code and returns the recovery codes, which are not shown again.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.MFACodeRequest  true  "Authenticator code"
+// @Success      200   {object}  dto.RecoveryCodesResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/2fa/totp/confirm [post]
+func ConfirmTOTPHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ConfirmTOTP handler"
+
+		var req dto.MFACodeRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		codes, err := svcGetter(r.Context()).ConfirmTOTP(r.Context(), userID, req.Code)
+		if err != nil {
+			log.Warn(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		if err = WriteJson(w, http.StatusOK, &dto.RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// DisableTOTPHandler
+//
+// @Summary      Disable two-factor authentication
+// @Description  Removes the authenticator and recovery codes, given a code. Admins of apartments that require two-factor authentication can't disable it.
+// @Tags         Auth
+// @Accept       json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.MFACodeRequest  true  "Authenticator or recovery code"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      429   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/2fa/totp/disable [post]
+func DisableTOTPHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "DisableTOTP handler"
+
+		var req dto.MFACodeRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		if err := svcGetter(r.Context()).DisableTOTP(r.Context(), userID, req.Code); err != nil {
+			log.Warn(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// RegenerateRecoveryCodesHandler
+//
+// @Summary      Regenerate recovery codes
+// @Description  Replaces the recovery codes, given a code. The old ones stop working.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.MFACodeRequest  true  "Authenticator or recovery code"
+// @Success      200   {object}  dto.RecoveryCodesResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      429   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodesHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "RegenerateRecoveryCodes handler"
+
+		var req dto.MFACodeRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		codes, err := svcGetter(r.Context()).RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+		if err != nil {
+			log.Warn(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		if err = WriteJson(w, http.StatusOK, &dto.RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
@@ -1,13 +1,17 @@
 package middleware
 
 import (
-	"bytes"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/handler/router"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/app"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth"
+	authd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
 	"go.uber.org/zap"
@@ -27,7 +31,6 @@ func SetRequestContext(app app.App) router.Middleware {
 type responseRecorder struct {
 	http.ResponseWriter
 	status int
-	body   bytes.Buffer
 }
 
 func (rw *responseRecorder) WriteHeader(code int) {
@@ -35,9 +38,10 @@ func (rw *responseRecorder) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func (rw *responseRecorder) Write(b []byte) (int, error) {
-	rw.body.Write(b) // Capture body
-	return rw.ResponseWriter.Write(b)
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush event streams.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
 
 func LogRequest() router.Middleware {
@@ -69,7 +73,80 @@ func LogRequest() router.Middleware {
 	}
 }
 
-func NewAuth(secret []byte) router.Middleware {
+// RequireVerifiedEmail rejects users whose email is not verified, if
+// AUTH_REQUIRE_VERIFIED_EMAIL is set. It must run after NewAuth.
+func RequireVerifiedEmail(app app.App) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		if !app.Config().Auth.RequireVerifiedEmail {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appctx.Logger(r.Context())
+
+			userID, _ := r.Context().Value(appjwt.UserIDKey).(string)
+			if common.ValidateID(userID) != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			verified, err := app.AuthService().EmailVerified(r.Context(), common.IDFromText(userID))
+			if err != nil {
+				log.Error("check email verified", zap.Error(err))
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if !verified {
+				http.Error(w, "email not verified", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdminMFA rejects admins of apartments that require two-factor
+// authentication, unless they signed in with it. It must run after NewAuth.
+func RequireAdminMFA(app app.App) router.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := appctx.Logger(r.Context())
+
+			if mfa, _ := r.Context().Value(appjwt.MFAKey).(bool); mfa {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, _ := r.Context().Value(appjwt.UserIDKey).(string)
+			if common.ValidateID(userID) != nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			required, err := app.AuthService().MFARequired(r.Context(), common.IDFromText(userID))
+			if err != nil {
+				log.Error("check two-factor required", zap.Error(err))
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if required {
+				http.Error(w, "two-factor authentication required", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenAccess lets personal access tokens holding Scope through NewAuth.
+// A token restricted to an apartment also needs Apartment to find that
+// apartment in the request, so without Apartment such tokens are refused.
+type TokenAccess struct {
+	Scope     authd.Scope
+	Apartment func(r *http.Request) string
+}
+
+// NewAuth authenticates requests by their access token, or by a personal
+// access token that one of access lets through. Without access only
+// access tokens are accepted.
+func NewAuth(app app.App, access ...TokenAccess) router.Middleware {
+	keys := app.JWTKeys()
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log := appctx.Logger(r.Context())
@@ -81,10 +158,16 @@ func NewAuth(secret []byte) router.Middleware {
 			}
 
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			claims, err := appjwt.ParseToken(token, secret)
+			if strings.HasPrefix(token, auth.PersonalTokenPrefix) {
+				if personalTokenAuth(w, r, app.AuthService(), token, access) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			claims, err := appjwt.ParseTokenOfType(token, keys, appjwt.AccessToken)
 			if err != nil {
 				switch err {
-				case appjwt.ErrInvalidToken, appjwt.ErrNilToken:
+				case appjwt.ErrInvalidToken, appjwt.ErrNilToken, appjwt.ErrWrongTokenType:
 					log.Warn("parse jwt token", zap.Error(err))
 				default:
 					log.Error("parse jwt token", zap.Error(err))
@@ -95,7 +178,57 @@ func NewAuth(secret []byte) router.Middleware {
 
 			appctx.SetValue(r.Context(), appjwt.UserIDKey, claims.UserID)
 			appctx.SetValue(r.Context(), appjwt.UserEmailKey, claims.UserEMail)
+			appctx.SetValue(r.Context(), appjwt.MFAKey, claims.MFA)
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// personalTokenAuth sets the user of a personal access token on the
+// request, if one of access lets it through, or else writes the error.
+func personalTokenAuth(
+	w http.ResponseWriter, r *http.Request, svc authPort.Service, token string, access []TokenAccess,
+) bool {
+	log := appctx.Logger(r.Context())
+
+	t, err := svc.AuthenticatePersonalToken(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidPersonalToken) {
+			log.Warn("personal access token", zap.Error(err))
+		} else {
+			log.Error("personal access token", zap.Error(err))
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	if !tokenAllowed(r, t, access) {
+		log.Warn("personal access token not allowed",
+			zap.String("tokenId", t.ID.String()),
+			zap.String("path", r.URL.Path),
+		)
+		http.Error(w, "personal access token not allowed here", http.StatusForbidden)
+		return false
+	}
+	appctx.SetValue(r.Context(), appjwt.UserIDKey, t.UserID.String())
+	appctx.SetValue(r.Context(), appjwt.MFAKey, t.MFA)
+	return true
+}
+
+func tokenAllowed(r *http.Request, t *authd.PersonalToken, access []TokenAccess) bool {
+	for _, a := range access {
+		if !t.HasScope(a.Scope) {
+			continue
+		}
+		if t.ApartmentID == nil {
+			return true
+		}
+		if a.Apartment == nil {
+			continue
+		}
+		aptID := a.Apartment(r)
+		if common.ValidateID(aptID) == nil && common.IDFromText(aptID) == *t.ApartmentID {
+			return true
+		}
+	}
+	return false
+}
@@ -6,28 +6,137 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/paygw"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	"go.uber.org/zap"
 )
 
-const mockToken = "mock-token"
+const (
+	mockToken = "mock-token"
+	// mockDefaultDelay is how long a delayed callback waits by default.
+	mockDefaultDelay = time.Second
+	mockMaxDelay     = time.Minute
+)
+
+// MockGatewayStore keeps the state of the transactions seen by the mock
+// gateway so they can be inquired later.
+type MockGatewayStore struct {
+	mu     sync.RWMutex
+	txs    map[string]*mockTransaction
+	tokens map[string]*mockCard
+}
+
+type mockTransaction struct {
+	status   string
+	amount   int64
+	refunded int64
+}
+
+// mockCard is a card saved for recurring charges.
+type mockCard struct {
+	mask     string
+	scenario paygw.MockScenario
+}
+
+func NewMockGatewayStore() *MockGatewayStore {
+	return &MockGatewayStore{
+		txs:    make(map[string]*mockTransaction),
+		tokens: make(map[string]*mockCard),
+	}
+}
+
+// SaveCard saves a card whose charges follow scenario and returns its
+// token and mask.
+func (s *MockGatewayStore) SaveCard(scenario paygw.MockScenario) (token, mask string) {
+	token = "tok_" + common.NewRandomID().String()
+	mask = "6037-****-****-" + token[len(token)-4:]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[token] = &mockCard{mask: mask, scenario: scenario}
+	return token, mask
+}
+
+func (s *MockGatewayStore) Card(token string) (mask string, scenario paygw.MockScenario, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	card, ok := s.tokens[token]
+	if !ok {
+		return "", "", false
+	}
+	return card.mask, card.scenario, true
+}
+
+func (s *MockGatewayStore) SetStatus(transactionID, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.txs[transactionID]
+	if !ok {
+		tx = &mockTransaction{}
+		s.txs[transactionID] = tx
+	}
+	tx.status = status
+}
+
+// Capture marks the transaction as paid with the given amount.
+func (s *MockGatewayStore) Capture(transactionID string, amount int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs[transactionID] = &mockTransaction{status: "paid", amount: amount}
+}
+
+func (s *MockGatewayStore) Status(transactionID string) (string, bool) {
+	status, _, ok := s.Transaction(transactionID)
+	return status, ok
+}
+
+// Transaction returns the status and captured amount of a transaction.
+func (s *MockGatewayStore) Transaction(transactionID string) (status string, amount int64, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tx, ok := s.txs[transactionID]
+	if !ok {
+		return "", 0, false
+	}
+	return tx.status, tx.amount, true
+}
+
+// Refund gives back amount of a paid transaction. found is false if the
+// transaction is unknown, ok is false if it can't be refunded that much.
+func (s *MockGatewayStore) Refund(transactionID string, amount int64) (found, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, found := s.txs[transactionID]
+	if !found {
+		return false, false
+	}
+	if tx.status != "paid" || amount <= 0 || tx.refunded+amount > tx.amount {
+		return true, false
+	}
+	tx.refunded += amount
+	return true, true
+}
 
 // MockGatewayPay
 //
 // @Summary      Mock payment gateway pay
-// @Description  Simulates payment gateway pay endpoint for testing
+// @Description  Simulates payment gateway pay endpoint for testing. The scenario scripts the outcome: success, decline, timeout, delayed-callback, duplicate-callback, amount-mismatch or cancel.
 // @Tags         Payment
 // @Accept       json
 // @Produce      json
 // @Param        body  body      dto.PayRequest  true  "Mock Payment Request"
 // @Success      200   {object}  dto.PayResponse
+// @Success      202   {object}  dto.PayResponse
 // @Failure      400   {object}  dto.Error
 // @Failure      502   {object}  dto.Error
+// @Failure      504   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
 // @Router       /api/v1/payment/mock-gateway/pay [post]
-func MockGatewayPay() http.Handler {
+func MockGatewayPay(store *MockGatewayStore) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
 		logPrefix := "mock-gateway-pay"
@@ -46,26 +155,83 @@ func MockGatewayPay() http.Handler {
 			return
 		}
 
+		scenario := paygw.MockScenario(req.Scenario)
+		if scenario == "" {
+			scenario = paygw.MockSuccess
+		}
+		if !scenario.IsValid() {
+			BadRequestError(w, r, paygw.ErrUnknownScenario.Error())
+			return
+		}
+		delay := mockDefaultDelay
+		if req.Delay != "" {
+			delay, err = time.ParseDuration(req.Delay)
+			if err != nil || delay < 0 || delay > mockMaxDelay {
+				BadRequestError(w, r, "invalid delay")
+				return
+			}
+		}
+
+		// The outcome is recorded before the user is sent back, like a
+		// real gateway. If the callback never arrives it can still be
+		// inquired.
+		if req.TransactionID != "" {
+			switch scenario {
+			case paygw.MockDecline:
+				store.SetStatus(req.TransactionID, "failed")
+			case paygw.MockUserCancel:
+				store.SetStatus(req.TransactionID, "cancelled")
+			case paygw.MockTimeout:
+				store.SetStatus(req.TransactionID, "pending")
+			case paygw.MockAmountMismatch:
+				store.Capture(req.TransactionID, req.Amount/2)
+			default:
+				store.Capture(req.TransactionID, req.Amount)
+			}
+		}
+
 		// Append mock token to query
 		query := callbackURL.Query()
 		query.Add("token", mockToken)
+		if req.TransactionID != "" {
+			query.Set("transactionId", req.TransactionID)
+		}
 		callbackURL.RawQuery = query.Encode()
 
-		// Perform callback request
-		callBackResp, err := http.Post(callbackURL.String(), "application/json", nil)
-		if err != nil {
-			InternalServerError(w, r)
-			log.Error(fmt.Sprintf("%s: CallBack", logPrefix), zap.Error(err))
+		switch scenario {
+		case paygw.MockTimeout:
+			Error(w, r, http.StatusGatewayTimeout, "gateway timed out before calling back")
+			return
+		case paygw.MockDelayedCallback:
+			go func() {
+				time.Sleep(delay)
+				resp, err := http.Post(callbackURL.String(), "application/json", nil)
+				if err != nil {
+					log.Error(fmt.Sprintf("%s: delayed CallBack", logPrefix), zap.Error(err))
+					return
+				}
+				resp.Body.Close()
+			}()
+			resp := dto.PayResponse{
+				Status:  "processing",
+				Message: fmt.Sprintf("callback in %s", delay),
+				Token:   mockToken,
+			}
+			if err := WriteJson(w, http.StatusAccepted, &resp); err != nil {
+				log.Error(fmt.Sprintf("%s: WriteJson error", logPrefix), zap.Error(err))
+			}
 			return
 		}
-		defer callBackResp.Body.Close()
 
-		// Handle non-2xx callback responses
-		if callBackResp.StatusCode >= 400 {
-			log.Error(fmt.Sprintf("%s: callback returned error status: %d", logPrefix, callBackResp.StatusCode))
-			callbackResult := fmt.Sprint(ResponseBodyToMap(callBackResp.Body))
-			Error(w, r, http.StatusBadGateway, "callback endpoint returned error", callbackResult)
-			return
+		calls := 1
+		if scenario == paygw.MockDuplicateCallback {
+			calls = 2
+		}
+		refused := scenario == paygw.MockDecline || scenario == paygw.MockUserCancel
+		for range calls {
+			if !mockCallback(w, r, callbackURL.String(), logPrefix, refused) {
+				return
+			}
 		}
 
 		// Prepare structured JSON response
@@ -74,6 +240,12 @@ func MockGatewayPay() http.Handler {
 			Message: "payment successfully completed",
 			Token:   mockToken,
 		}
+		switch scenario {
+		case paygw.MockDecline:
+			resp.Status, resp.Message = "failed", "payment declined"
+		case paygw.MockUserCancel:
+			resp.Status, resp.Message = "cancelled", "payment cancelled by user"
+		}
 
 		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
 			InternalServerError(w, r)
@@ -82,6 +254,31 @@ func MockGatewayPay() http.Handler {
 	})
 }
 
+// mockCallback calls back the merchant. A failed callback is answered
+// with an error to the caller of the pay endpoint, unless the merchant is
+// expected to refuse the payment.
+func mockCallback(w http.ResponseWriter, r *http.Request, callbackURL, logPrefix string, refused bool) bool {
+	log := appctx.Logger(r.Context())
+
+	// Perform callback request
+	callBackResp, err := http.Post(callbackURL, "application/json", nil)
+	if err != nil {
+		InternalServerError(w, r)
+		log.Error(fmt.Sprintf("%s: CallBack", logPrefix), zap.Error(err))
+		return false
+	}
+	defer callBackResp.Body.Close()
+
+	// Handle non-2xx callback responses
+	if callBackResp.StatusCode >= 400 && !refused {
+		log.Error(fmt.Sprintf("%s: callback returned error status: %d", logPrefix, callBackResp.StatusCode))
+		callbackResult := fmt.Sprint(ResponseBodyToMap(callBackResp.Body))
+		Error(w, r, http.StatusBadGateway, "callback endpoint returned error", callbackResult)
+		return false
+	}
+	return true
+}
+
 func ResponseBodyToMap(body io.ReadCloser) map[string]any {
 	content, _ := io.ReadAll(body)
 	var contentMap map[string]any
@@ -96,13 +293,14 @@ func ResponseBodyToMap(body io.ReadCloser) map[string]any {
 // @Tags         Payment
 // @Accept       json
 // @Produce      json
-// @Param        token  query    string  false "Mock Payment Token"
+// @Param        token          query    string  false "Mock Payment Token"
+// @Param        transactionId  query    string  false "Transaction ID"
 // @Param        body   body     dto.VerifyRequest false "Mock Verify Request"
 // @Success      200   {object}  dto.VerifyResponse
 // @Failure      400   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
 // @Router       /api/v1/payment/mock-gateway/verify [get]
-func MockGatewayVerify() http.Handler {
+func MockGatewayVerify(store *MockGatewayStore) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
 		logPrefix := "mock-gateway-verify"
@@ -110,6 +308,7 @@ func MockGatewayVerify() http.Handler {
 		var req dto.VerifyRequest
 		if r.URL.Query().Has("token") {
 			req.Token = r.URL.Query().Get("token")
+			req.TransactionID = r.URL.Query().Get("transactionId")
 		} else {
 			if err := BodyParse(r, &req); err != nil {
 				BadRequestError(w, r, "invalid request body")
@@ -119,12 +318,24 @@ func MockGatewayVerify() http.Handler {
 		}
 
 		resp := dto.VerifyResponse{
-			Code:    0,
+			Code:    dto.VerifyOK,
 			Message: "payment completed",
 		}
+		if req.TransactionID != "" {
+			status, amount, ok := store.Transaction(req.TransactionID)
+			resp.Amount = amount
+			switch {
+			case !ok || status == "pending":
+				resp.Code, resp.Message = dto.VerifyNotComplete, "payment not complete"
+			case status == "failed":
+				resp.Code, resp.Message = dto.VerifyDeclined, "payment declined"
+			case status == "cancelled":
+				resp.Code, resp.Message = dto.VerifyCancelled, "payment cancelled by user"
+			}
+		}
 
 		if req.Token != mockToken {
-			resp.Code = 1
+			resp.Code = dto.VerifyNotComplete
 			resp.Message = "payment not complete"
 		}
 
@@ -135,6 +346,93 @@ func MockGatewayVerify() http.Handler {
 	})
 }
 
+// MockGatewayInquiry
+//
+// @Summary      Mock payment gateway inquiry
+// @Description  Simulates payment gateway inquiry endpoint for testing
+// @Tags         Payment
+// @Produce      json
+// @Param        transactionId  query    string  true  "Transaction ID"
+// @Success      200   {object}  dto.InquiryResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/mock-gateway/inquiry [get]
+func MockGatewayInquiry(store *MockGatewayStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "mock-gateway-inquiry"
+
+		transactionID := r.URL.Query().Get("transactionId")
+		if transactionID == "" {
+			BadRequestError(w, r, "missing transactionId")
+			return
+		}
+
+		status, amount, ok := store.Transaction(transactionID)
+		if !ok {
+			Error(w, r, http.StatusNotFound, "transaction not found")
+			return
+		}
+
+		resp := dto.InquiryResponse{
+			TransactionID: transactionID,
+			Status:        status,
+			Amount:        amount,
+		}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			InternalServerError(w, r)
+			log.Error(fmt.Sprintf("%s: WriteJson", logPrefix), zap.Error(err))
+		}
+	})
+}
+
+// MockGatewayRefund
+//
+// @Summary      Mock payment gateway refund
+// @Description  Simulates payment gateway refund endpoint for testing
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.GatewayRefundRequest  true  "Mock Refund Request"
+// @Success      200   {object}  dto.GatewayRefundResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      422   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/mock-gateway/refund [post]
+func MockGatewayRefund(store *MockGatewayStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "mock-gateway-refund"
+
+		var req dto.GatewayRefundRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, "invalid request body")
+			log.Error(fmt.Sprintf("%s: BodyParse", logPrefix), zap.Error(err))
+			return
+		}
+
+		found, ok := store.Refund(req.TransactionID, req.Amount)
+		if !found {
+			Error(w, r, http.StatusNotFound, "transaction not found")
+			return
+		}
+		if !ok {
+			Error(w, r, http.StatusUnprocessableEntity, "transaction can not be refunded")
+			return
+		}
+
+		resp := dto.GatewayRefundResponse{
+			RefundID: common.NewRandomID().String(),
+		}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			InternalServerError(w, r)
+			log.Error(fmt.Sprintf("%s: WriteJson", logPrefix), zap.Error(err))
+		}
+	})
+}
+
 func ParseURL(URL string) (u *url.URL, err error) {
 	u, err = url.Parse(URL)
 	if err != nil {
@@ -146,3 +444,159 @@ func ParseURL(URL string) (u *url.URL, err error) {
 	}
 	return
 }
+
+// MockGatewayTokenize
+//
+// @Summary      Mock payment gateway card tokenization
+// @Description  Simulates saving a card for recurring charges. The card is saved and the merchant called back with its token; the scenario scripts the charges made on it.
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.TokenizeRequest  true  "Mock Tokenize Request"
+// @Success      200   {object}  dto.PayResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      502   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/mock-gateway/tokenize [post]
+func MockGatewayTokenize(store *MockGatewayStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "mock-gateway-tokenize"
+
+		var req dto.TokenizeRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, "invalid request body")
+			log.Error(fmt.Sprintf("%s: BodyParse", logPrefix), zap.Error(err))
+			return
+		}
+		callbackURL, err := ParseURL(req.CallbackURL)
+		if err != nil {
+			BadRequestError(w, r, "invalid callback url")
+			log.Error(fmt.Sprintf("%s: ParseURL", logPrefix), zap.Error(err))
+			return
+		}
+		scenario := paygw.MockScenario(req.Scenario)
+		if scenario == "" {
+			scenario = paygw.MockSuccess
+		}
+		if !scenario.IsValid() {
+			BadRequestError(w, r, paygw.ErrUnknownScenario.Error())
+			return
+		}
+
+		token, _ := store.SaveCard(scenario)
+		query := callbackURL.Query()
+		query.Set(paygw.CardTokenKey, token)
+		callbackURL.RawQuery = query.Encode()
+		if !mockCallback(w, r, callbackURL.String(), logPrefix, false) {
+			return
+		}
+
+		resp := dto.PayResponse{
+			Status:  "completed",
+			Message: "card saved",
+		}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			InternalServerError(w, r)
+			log.Error(fmt.Sprintf("%s: WriteJson error", logPrefix), zap.Error(err))
+		}
+	})
+}
+
+// MockGatewayToken
+//
+// @Summary      Mock payment gateway saved card
+// @Description  Simulates looking up a saved card by its token
+// @Tags         Payment
+// @Produce      json
+// @Param        token  query    string  true  "Card Token"
+// @Success      200   {object}  dto.TokenResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/mock-gateway/token [get]
+func MockGatewayToken(store *MockGatewayStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "mock-gateway-token"
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			BadRequestError(w, r, "missing token")
+			return
+		}
+		mask, _, ok := store.Card(token)
+		if !ok {
+			Error(w, r, http.StatusNotFound, "card not found")
+			return
+		}
+
+		resp := dto.TokenResponse{Token: token, CardMask: mask}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			InternalServerError(w, r)
+			log.Error(fmt.Sprintf("%s: WriteJson", logPrefix), zap.Error(err))
+		}
+	})
+}
+
+// MockGatewayCharge
+//
+// @Summary      Mock payment gateway recurring charge
+// @Description  Simulates charging a saved card. The card's scenario decides the outcome: decline and cancel are declined, timeout fails with 504, amount-mismatch captures half.
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ChargeRequest  true  "Mock Charge Request"
+// @Success      200   {object}  dto.ChargeResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      402   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      504   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/mock-gateway/charge [post]
+func MockGatewayCharge(store *MockGatewayStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "mock-gateway-charge"
+
+		var req dto.ChargeRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, "invalid request body")
+			log.Error(fmt.Sprintf("%s: BodyParse", logPrefix), zap.Error(err))
+			return
+		}
+		if req.TransactionID == "" || req.Amount <= 0 {
+			BadRequestError(w, r, "invalid charge")
+			return
+		}
+		_, scenario, ok := store.Card(req.Token)
+		if !ok {
+			Error(w, r, http.StatusNotFound, "card not found")
+			return
+		}
+
+		resp := dto.ChargeResponse{
+			TransactionID: req.TransactionID,
+			Status:        "paid",
+			Amount:        req.Amount,
+		}
+		switch scenario {
+		case paygw.MockDecline, paygw.MockUserCancel:
+			store.SetStatus(req.TransactionID, "failed")
+			Error(w, r, http.StatusPaymentRequired, "payment declined")
+			return
+		case paygw.MockTimeout:
+			store.SetStatus(req.TransactionID, "pending")
+			Error(w, r, http.StatusGatewayTimeout, "gateway timed out")
+			return
+		case paygw.MockAmountMismatch:
+			resp.Amount = req.Amount / 2
+		}
+		store.Capture(req.TransactionID, resp.Amount)
+
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			InternalServerError(w, r)
+			log.Error(fmt.Sprintf("%s: WriteJson", logPrefix), zap.Error(err))
+		}
+	})
+}
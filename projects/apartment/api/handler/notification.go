@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification"
+	notificationd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	notificationPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+	// streamRetry is how long, in milliseconds, clients wait before
+	// reconnecting a dropped stream.
+	streamRetry = 3000
+	// defaultHeartbeat is used when no positive heartbeat is configured.
+	defaultHeartbeat = 30 * time.Second
+)
+
+// NotificationStream
+//
+// @Summary      Notification stream
+// @Description  Streams the notifications of the authenticated user as Server-Sent Events: bill.created, payment.succeeded, member.joined and announcement.posted. Each event id is the notification id; reconnect with the Last-Event-ID header to receive the notifications missed meanwhile.
+// @Tags         Notification
+// @Produce      text/event-stream
+// @Security 	 BearerAuth
+// @Param        Last-Event-ID  header  string  false  "ID of the last notification received"
+// @Success      200   {object}  dto.Notification
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/notifications/stream [get]
+func NotificationStream(svcGtr ServiceGetter[notificationPort.Service], heartbeat time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "NotificationStream handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		lastEventID := common.NilID
+		if id := r.Header.Get(LastEventIDHeader); common.ValidateID(id) == nil {
+			lastEventID = common.IDFromText(id)
+		}
+
+		svc := svcGtr(r.Context())
+		notifications, replay, cancel := svc.Subscribe(r.Context(), userID, lastEventID)
+		defer cancel()
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry); err != nil {
+			return
+		}
+		for i := range replay {
+			if err := writeNotification(w, &replay[i]); err != nil {
+				log.Error(logPrefix, zap.Error(err))
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			return
+		}
+
+		if heartbeat <= 0 {
+			heartbeat = defaultHeartbeat
+		}
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case n, ok := <-notifications:
+				if !ok {
+					// fell behind, the client resumes with Last-Event-ID
+					return
+				}
+				err = writeNotification(w, &n)
+			case <-ticker.C:
+				_, err = io.WriteString(w, ": ping\n\n")
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				log.Debug(logPrefix, zap.Error(err))
+				return
+			}
+		}
+	})
+}
+
+func writeNotification(w io.Writer, n *notificationd.Notification) error {
+	data, err := json.Marshal(dto.NotificationDomainToDTO(n))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", n.ID, n.Type, data)
+	return err
+}
+
+// PostAnnouncement
+//
+// @Summary      Post an announcement
+// @Description  Sends an announcement to the members of an apartment. Only the apartment admin can post announcements.
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id    path      string                        true  "Apartment ID"
+// @Param        body  body      dto.PostAnnouncementRequest  true  "Announcement"
+// @Success      202
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/apartment/{id}/announcements [post]
+func PostAnnouncement(svcGtr ServiceGetter[notificationPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "PostAnnouncement handler"
+
+		apartmentID := r.PathValue("id")
+		if err := common.ValidateID(apartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartment id")
+			return
+		}
+		var req dto.PostAnnouncementRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		err := svc.Announce(r.Context(), adminID, &notificationd.Announcement{
+			ApartmentID: common.IDFromText(apartmentID),
+			Title:       req.Title,
+			Message:     req.Message,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			notificationError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func notificationError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, notificationd.ErrEmptyTitle):
+		Error(w, r, http.StatusBadRequest, notificationd.ErrEmptyTitle.Error())
+	case errors.Is(err, notificationd.ErrEmptyMessage):
+		Error(w, r, http.StatusBadRequest, notificationd.ErrEmptyMessage.Error())
+	case errors.Is(err, notification.ErrNotAdmin):
+		Error(w, r, http.StatusForbidden, notification.ErrNotAdmin.Error())
+	case errors.Is(err, notification.ErrApartmentNotFound):
+		Error(w, r, http.StatusNotFound, notification.ErrApartmentNotFound.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+const (
+	// OIDCStateCookie keeps the sign-in state while the user is at the
+	// identity provider. It is only sent to the OIDC endpoints.
+	OIDCStateCookie = "oidc-state"
+	oidcCookiePath  = "/api/v1/auth/oidc"
+)
+
+// OIDCLoginHandler
+//
+// @Summary      Sign in with the identity provider
+// @Description  Redirects to the OpenID Connect provider, e.g. Google, which sends the user back to /api/v1/auth/oidc/callback. The sign-in has to finish within 10 minutes.
+// @Tags         Auth
+// @Success      302
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/oidc/login [get]
+func OIDCLoginHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "OIDCLogin handler"
+
+		authURL, stateToken, err := svcGetter(r.Context()).OIDCLogin(r.Context())
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:   OIDCStateCookie,
+			Value:  stateToken,
+			MaxAge: int(auth.OIDCStateExpiry.Seconds()),
+			// the provider redirects back with a top-level navigation,
+			// which Lax lets the cookie through
+			SameSite: http.SameSiteLaxMode,
+			Secure:   true,
+			HttpOnly: true,
+			Path:     oidcCookiePath,
+		})
+		http.Redirect(w, r, authURL, http.StatusFound)
+	})
+}
+
+// OIDCCallbackHandler
+//
+// @Summary      Finish signing in with the identity provider
+// @Description  The identity provider redirects here. The account at the provider signs in the user it is linked to. The first time, it is linked to the user with the same email if the provider verified the email and so did the user, or else a user is created. Returns JWT tokens, or a two-factor challenge like sign-in.
+// @Tags         Auth
+// @Produce      json
+// @Param        code   query     string  true  "Authorization code"
+// @Param        state  query     string  true  "State"
+// @Success      201    {object}  dto.AuthResponse
+// @Success      202    {object}  dto.MFAChallengeResponse
+// @Failure      400    {object}  dto.Error
+// @Failure      401    {object}  dto.Error
+// @Failure      403    {object}  dto.Error
+// @Failure      404    {object}  dto.Error
+// @Failure      409    {object}  dto.Error
+// @Failure      500    {object}  dto.Error
+// @Router       /api/v1/auth/oidc/callback [get]
+func OIDCCallbackHandler(svcGetter ServiceGetter[authPort.Service], cfg config.AuthConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "OIDCCallback handler"
+
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			log.Warn(logPrefix, zap.String("error", e), zap.String("description", q.Get("error_description")))
+			Error(w, r, http.StatusUnauthorized, auth.ErrOIDCRejected.Error())
+			return
+		}
+		cookie, err := r.Cookie(OIDCStateCookie)
+		if err != nil {
+			BadRequestError(w, r, auth.ErrInvalidOIDCState.Error())
+			return
+		}
+		// the state is for this sign-in only
+		http.SetCookie(w, &http.Cookie{
+			Name:     OIDCStateCookie,
+			MaxAge:   -1,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   true,
+			HttpOnly: true,
+			Path:     oidcCookiePath,
+		})
+
+		signIn, err := svcGetter(r.Context()).OIDCCallback(r.Context(), cookie.Value, q.Get("state"), q.Get("code"))
+		if err != nil {
+			log.Warn(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		writeSignIn(w, r, cfg, signIn)
+	})
+}
@@ -9,23 +9,31 @@ import (
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
 	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/paygw"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
 	"go.uber.org/zap"
 )
 
+// IdempotencyKeyHeader lets clients retry payment initiation safely.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // PayUserBill
 //
 // @Summary      Pay a bill
-// @Description  Initiates payment for a specific bill using a gateway
+// @Description  Initiates payment for a specific bill using a gateway. An amount pays part of the balance due.
 // @Tags         Payment
 // @Accept       json
 // @Produce      json
 // @Security 	 BearerAuth
+// @Param        Idempotency-Key  header  string  false  "Idempotency Key"
 // @Param        body  body      dto.PayBillRequest  true  "Bill Payment Request"
 // @Success      201   {object}  dto.RedirectGateway
 // @Failure      400   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      422   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
+// @Failure      503   {object}  dto.Error
 // @Router       /api/v1/payment/pay-bill [post]
 func PayUserBill(svcGtr ServiceGetter[paymentp.Service], callbackURL string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +47,10 @@ func PayUserBill(svcGtr ServiceGetter[paymentp.Service], callbackURL string) htt
 			return
 		}
 
+		if len(req.Metadata) > 0 {
+			appctx.SetValue(r.Context(), payment.MetadataKey, req.Metadata)
+		}
+
 		svc := svcGtr(r.Context())
 		gateway := paymentd.GatewayType(req.Gateway)
 		billID := common.IDFromText(req.BillID)
@@ -50,14 +62,26 @@ func PayUserBill(svcGtr ServiceGetter[paymentp.Service], callbackURL string) htt
 		}
 		userID := common.IDFromText(userId)
 
-		resp, err := svc.PayBill(r.Context(), gateway, userID, billID, callbackURL)
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+		resp, err := svc.PayBill(r.Context(), gateway, userID, billID, req.Amount, callbackURL, idempotencyKey)
 		if err != nil {
 			log.Error(logPrefix, zap.Error(err))
 			switch {
 			case errors.Is(err, payment.ErrUnknownGateway):
 				Error(w, r, http.StatusBadRequest, payment.ErrUnknownGateway.Error())
+			case errors.Is(err, paygw.ErrUnknownScenario):
+				Error(w, r, http.StatusBadRequest, paygw.ErrUnknownScenario.Error())
 			case errors.Is(err, payment.ErrNoBalanceDue):
 				Error(w, r, http.StatusConflict, err.Error())
+			case errors.Is(err, payment.ErrInvalidAmount):
+				Error(w, r, http.StatusBadRequest, payment.ErrInvalidAmount.Error())
+			case errors.Is(err, payment.ErrGatewayUnavailable):
+				Error(w, r, http.StatusServiceUnavailable, payment.ErrGatewayUnavailable.Error())
+			case errors.Is(err, payment.ErrIdempotencyKeyInProgress):
+				Error(w, r, http.StatusConflict, payment.ErrIdempotencyKeyInProgress.Error())
+			case errors.Is(err, payment.ErrIdempotencyKeyReused):
+				Error(w, r, http.StatusUnprocessableEntity, payment.ErrIdempotencyKeyReused.Error())
 			default:
 				InternalServerError(w, r)
 			}
@@ -74,15 +98,19 @@ func PayUserBill(svcGtr ServiceGetter[paymentp.Service], callbackURL string) htt
 // PayTotalDebt
 //
 // @Summary      Pay total debt
-// @Description  Initiates payment for all outstanding bills for the authenticated user
+// @Description  Initiates payment for all outstanding bills, or the selected ones, for the authenticated user. A partial amount is split between bills by the configured allocation rule.
 // @Tags         Payment
 // @Accept       json
 // @Produce      json
 // @Security 	 BearerAuth
+// @Param        Idempotency-Key  header  string  false  "Idempotency Key"
 // @Param        body  body      dto.PayTotalDebtRequest  true  "Total Debt Payment Request"
 // @Success      201   {object}  dto.RedirectGateway
 // @Failure      400   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      422   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
+// @Failure      503   {object}  dto.Error
 // @Router       /api/v1/payment/pay-total-debt [post]
 func PayTotalDebt(svcGtr ServiceGetter[paymentp.Service], callbackURL string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,6 +124,18 @@ func PayTotalDebt(svcGtr ServiceGetter[paymentp.Service], callbackURL string) ht
 			return
 		}
 
+		billIDs := make([]common.ID, 0, len(req.BillIDs))
+		for _, id := range req.BillIDs {
+			if err := common.ValidateID(id); err != nil {
+				BadRequestError(w, r, "invalid billIDs")
+				return
+			}
+			billIDs = append(billIDs, common.IDFromText(id))
+		}
+		if len(req.Metadata) > 0 {
+			appctx.SetValue(r.Context(), payment.MetadataKey, req.Metadata)
+		}
+
 		svc := svcGtr(r.Context())
 		gateway := paymentd.GatewayType(req.Gateway)
 		userId, ok := r.Context().Value(appjwt.UserIDKey).(string)
@@ -106,14 +146,26 @@ func PayTotalDebt(svcGtr ServiceGetter[paymentp.Service], callbackURL string) ht
 		}
 		userID := common.IDFromText(userId)
 
-		resp, err := svc.PayTotalDebt(r.Context(), gateway, userID, callbackURL)
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+
+		resp, err := svc.PayTotalDebt(r.Context(), gateway, userID, billIDs, req.Amount, callbackURL, idempotencyKey)
 		if err != nil {
 			log.Error(logPrefix, zap.Error(err))
 			switch {
 			case errors.Is(err, payment.ErrUnknownGateway):
 				Error(w, r, http.StatusBadRequest, payment.ErrUnknownGateway.Error())
+			case errors.Is(err, paygw.ErrUnknownScenario):
+				Error(w, r, http.StatusBadRequest, paygw.ErrUnknownScenario.Error())
 			case errors.Is(err, payment.ErrNoBalanceDue):
 				Error(w, r, http.StatusConflict, err.Error())
+			case errors.Is(err, payment.ErrInvalidAmount):
+				Error(w, r, http.StatusBadRequest, payment.ErrInvalidAmount.Error())
+			case errors.Is(err, payment.ErrGatewayUnavailable):
+				Error(w, r, http.StatusServiceUnavailable, payment.ErrGatewayUnavailable.Error())
+			case errors.Is(err, payment.ErrIdempotencyKeyInProgress):
+				Error(w, r, http.StatusConflict, payment.ErrIdempotencyKeyInProgress.Error())
+			case errors.Is(err, payment.ErrIdempotencyKeyReused):
+				Error(w, r, http.StatusUnprocessableEntity, payment.ErrIdempotencyKeyReused.Error())
 			default:
 				InternalServerError(w, r)
 			}
@@ -179,10 +231,153 @@ func CallbackHandler(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
 	})
 }
 
+// RefundPayment
+//
+// @Summary      Refund a payment
+// @Description  Refunds part or all of a paid payment. Only the admin of the bill's apartment can refund. Offline refunds record money already given back outside the gateway.
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.RefundRequest  true  "Refund Request"
+// @Success      201   {object}  dto.Payment
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      422   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/refund [post]
+func RefundPayment(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "RefundPayment handler"
+
+		var req dto.RefundRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.PaymentID); err != nil {
+			BadRequestError(w, r, "invalid paymentID")
+			return
+		}
+
+		userId, ok := r.Context().Value(appjwt.UserIDKey).(string)
+		if !ok {
+			log.Error(logPrefix, zap.String("error", "failed to get user id from request context"))
+			InternalServerError(w, r)
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		refund, err := svc.Refund(r.Context(), paymentd.Refund{
+			PaymentID: common.IDFromText(req.PaymentID),
+			AdminID:   common.IDFromText(userId),
+			Amount:    req.Amount,
+			Reason:    req.Reason,
+			Offline:   req.Offline,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			switch {
+			case errors.Is(err, payment.ErrPaymentNotFound):
+				Error(w, r, http.StatusNotFound, payment.ErrPaymentNotFound.Error())
+			case errors.Is(err, payment.ErrPermissionDenied):
+				Error(w, r, http.StatusForbidden, payment.ErrPermissionDenied.Error())
+			case errors.Is(err, payment.ErrNotRefundable):
+				Error(w, r, http.StatusConflict, payment.ErrNotRefundable.Error())
+			case errors.Is(err, payment.ErrInvalidRefundAmount):
+				Error(w, r, http.StatusBadRequest, payment.ErrInvalidRefundAmount.Error())
+			case errors.Is(err, payment.ErrRefundNotSupported):
+				Error(w, r, http.StatusUnprocessableEntity, payment.ErrRefundNotSupported.Error())
+			case errors.Is(err, payment.ErrUnknownGateway):
+				Error(w, r, http.StatusUnprocessableEntity, payment.ErrRefundNotSupported.Error())
+			default:
+				InternalServerError(w, r)
+			}
+			return
+		}
+
+		if err = WriteJson(w, http.StatusCreated, dto.PaymentDomainToDTO(refund)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetPaymentReceipt
+//
+// @Summary      Get payment receipt
+// @Description  Returns the receipt of a paid payment as HTML or PDF. Only the payer and the admins of the apartments it paid bills of can get it.
+// @Tags         Payment
+// @Produce      html
+// @Produce      application/pdf
+// @Security 	 BearerAuth
+// @Param        id      path      string  true   "Payment ID"
+// @Param        format  query     string  false  "Receipt format"  Enums(html, pdf)  default(html)
+// @Success      200     {file}    file
+// @Failure      400     {object}  dto.Error
+// @Failure      403     {object}  dto.Error
+// @Failure      404     {object}  dto.Error
+// @Failure      409     {object}  dto.Error
+// @Failure      500     {object}  dto.Error
+// @Router       /api/v1/payment/{id}/receipt [get]
+func GetPaymentReceipt(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetPaymentReceipt handler"
+
+		paymentID := r.PathValue("id")
+		if err := common.ValidateID(paymentID); err != nil {
+			BadRequestError(w, r, "invalid payment id")
+			return
+		}
+		format := paymentd.ReceiptFormat(r.URL.Query().Get("format"))
+		if format == "" {
+			format = paymentd.ReceiptHTML
+		}
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		file, err := svc.Receipt(r.Context(), userID, common.IDFromText(paymentID), format)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			switch {
+			case errors.Is(err, payment.ErrInvalidReceiptFormat):
+				BadRequestError(w, r, payment.ErrInvalidReceiptFormat.Error())
+			case errors.Is(err, payment.ErrPaymentNotFound):
+				Error(w, r, http.StatusNotFound, payment.ErrPaymentNotFound.Error())
+			case errors.Is(err, payment.ErrPermissionDenied):
+				Error(w, r, http.StatusForbidden, payment.ErrPermissionDenied.Error())
+			case errors.Is(err, payment.ErrReceiptNotAvailable):
+				Error(w, r, http.StatusConflict, payment.ErrReceiptNotAvailable.Error())
+			default:
+				InternalServerError(w, r)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", file.ContentType)
+		if format == paymentd.ReceiptPDF {
+			w.Header().Set("Content-Disposition", `attachment; filename="`+file.Name+`"`)
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err = w.Write(file.Content); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
 // SupportedGateways
 //
 // @Summary      List supported payment gateways
-// @Description  Returns a list of supported payment gateways
+// @Description  Returns the payment gateways currently available, ordered by priority, with their display name and amount limits
 // @Tags         Payment
 // @Produce      json
 // @Success      200   {object}  dto.SupportedGatewaysResponse
@@ -191,11 +386,9 @@ func CallbackHandler(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
 func SupportedGateways(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
-		logPrefix := "CallbackHandler"
+		logPrefix := "SupportedGateways handler"
 		svc := svcGtr(r.Context())
-		resp := &dto.SupportedGatewaysResponse{
-			SupportedGateways: svc.SupportedGateways(),
-		}
+		resp := dto.SupportedGatewaysDomainToDTO(svc.SupportedGateways())
 		if err := WriteJson(w, http.StatusOK, resp); err != nil {
 			log.Error(logPrefix, zap.Error(err))
 			InternalServerError(w, r)
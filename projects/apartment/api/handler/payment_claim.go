@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+// SubmitPaymentClaim
+//
+// @Summary      Submit an offline payment claim
+// @Description  Records a bill payment made outside of any gateway, e.g. by card-to-card transfer, with the image of its receipt. The apartment admin approves or rejects the claim.
+// @Tags         Payment
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        billID     formData  string   true  "Bill ID"
+// @Param        amount     formData  integer  true  "Amount"
+// @Param        paidAt     formData  string   true  "Transfer date, RFC 3339 or YYYY-MM-DD"
+// @Param        reference  formData  string   true  "Transfer reference number"
+// @Param        proof      formData  file     true  "Receipt image"
+// @Success      201        {object}  dto.PaymentClaim
+// @Failure      400        {object}  dto.Error
+// @Failure      409        {object}  dto.Error
+// @Failure      413        {object}  dto.Error
+// @Failure      500        {object}  dto.Error
+// @Failure      503        {object}  dto.Error
+// @Router       /api/v1/payment/claims [post]
+func SubmitPaymentClaim(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "SubmitPaymentClaim handler"
+
+		if err := r.ParseMultipartForm(1 * MiB); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		billID := r.FormValue("billID")
+		if err := common.ValidateID(billID); err != nil {
+			BadRequestError(w, r, "invalid billID")
+			return
+		}
+		amount, err := strconv.ParseInt(r.FormValue("amount"), 10, 64)
+		if err != nil {
+			BadRequestError(w, r, "invalid amount")
+			return
+		}
+		paidAt, err := parseQueryTime(r.FormValue("paidAt"), false)
+		if err != nil {
+			BadRequestError(w, r, "invalid paidAt")
+			return
+		}
+
+		img, err := readImageUpload(r, "proof", log, w)
+		if err != nil {
+			return
+		}
+		if img == nil {
+			BadRequestError(w, r, "proof is required")
+			return
+		}
+		defer os.RemoveAll(filepath.Dir(img.Path))
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		claim, err := svc.SubmitClaim(r.Context(), &paymentd.PaymentClaim{
+			BillID:    common.IDFromText(billID),
+			PayerID:   userID,
+			Amount:    amount,
+			PaidAt:    paidAt,
+			Reference: r.FormValue("reference"),
+			Proof: &paymentd.Proof{
+				Name: img.Name,
+				Path: img.Path,
+				Type: img.Type,
+				Size: img.Size,
+			},
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentClaimError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusCreated, dto.PaymentClaimDomainToDTO(claim)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// ApprovePaymentClaim
+//
+// @Summary      Approve an offline payment claim
+// @Description  Records the claimed amount as a paid offline payment of the member. Only the admin of the bill's apartment can approve it.
+// @Tags         Payment
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id   path      string  true  "Claim ID"
+// @Success      200  {object}  dto.PaymentClaim
+// @Failure      400  {object}  dto.Error
+// @Failure      403  {object}  dto.Error
+// @Failure      404  {object}  dto.Error
+// @Failure      409  {object}  dto.Error
+// @Failure      500  {object}  dto.Error
+// @Router       /api/v1/payment/claims/{id}/approve [post]
+func ApprovePaymentClaim(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return reviewPaymentClaim(svcGtr, true)
+}
+
+// RejectPaymentClaim
+//
+// @Summary      Reject an offline payment claim
+// @Description  Rejects a pending claim. Only the admin of the bill's apartment can reject it.
+// @Tags         Payment
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id    path      string                         true   "Claim ID"
+// @Param        body  body      dto.RejectPaymentClaimRequest  false  "Rejection reason"
+// @Success      200   {object}  dto.PaymentClaim
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/payment/claims/{id}/reject [post]
+func RejectPaymentClaim(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return reviewPaymentClaim(svcGtr, false)
+}
+
+func reviewPaymentClaim(svcGtr ServiceGetter[paymentp.Service], approve bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ReviewPaymentClaim handler"
+
+		claimID := r.PathValue("id")
+		if err := common.ValidateID(claimID); err != nil {
+			BadRequestError(w, r, "invalid claim id")
+			return
+		}
+		var req dto.RejectPaymentClaimRequest
+		if !approve && r.ContentLength != 0 {
+			if err := BodyParse(r, &req); err != nil {
+				log.Error(logPrefix, zap.Error(err))
+				BadRequestError(w, r, err.Error())
+				return
+			}
+		}
+
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		claim, err := svc.ReviewClaim(r.Context(), paymentd.ClaimReview{
+			ClaimID: common.IDFromText(claimID),
+			AdminID: adminID,
+			Approve: approve,
+			Reason:  req.Reason,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentClaimError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.PaymentClaimDomainToDTO(claim)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetUserPaymentClaims
+//
+// @Summary      Get user's offline payment claims
+// @Description  Returns the offline payment claims the authenticated user submitted, newest first
+// @Tags         Payment
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        status  query     string  false  "Claim status"  Enums(pending, approved, rejected)
+// @Success      200     {object}  dto.PaymentClaimsResponse
+// @Failure      400     {object}  dto.Error
+// @Failure      500     {object}  dto.Error
+// @Router       /api/v1/user/payment-claims [get]
+func GetUserPaymentClaims(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetUserPaymentClaims handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		status := paymentd.ClaimStatus(r.URL.Query().Get("status"))
+		claims, err := svc.UserClaims(r.Context(), userID, status)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentClaimError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.PaymentClaimsDomainToDTO(claims)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetApartmentPaymentClaims
+//
+// @Summary      Get apartment offline payment claims
+// @Description  Returns the offline payment claims on an apartment's bills, newest first. Only the apartment admin can see them.
+// @Tags         Payment
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id      path      string  true   "Apartment ID"
+// @Param        status  query     string  false  "Claim status"  Enums(pending, approved, rejected)
+// @Success      200     {object}  dto.PaymentClaimsResponse
+// @Failure      400     {object}  dto.Error
+// @Failure      403     {object}  dto.Error
+// @Failure      404     {object}  dto.Error
+// @Failure      500     {object}  dto.Error
+// @Router       /api/v1/apartment/{id}/payment-claims [get]
+func GetApartmentPaymentClaims(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetApartmentPaymentClaims handler"
+
+		apartmentID := r.PathValue("id")
+		if err := common.ValidateID(apartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartment id")
+			return
+		}
+
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		status := paymentd.ClaimStatus(r.URL.Query().Get("status"))
+		claims, err := svc.ApartmentClaims(r.Context(), adminID, common.IDFromText(apartmentID), status)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentClaimError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.PaymentClaimsDomainToDTO(claims)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetPaymentClaimProof
+//
+// @Summary      Get offline payment claim proof
+// @Description  Returns the receipt image of a claim. Only the member who submitted it and the admin of the bill's apartment can get it.
+// @Tags         Payment
+// @Produce      image/png
+// @Produce      image/jpeg
+// @Security 	 BearerAuth
+// @Param        id   path      string  true  "Claim ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  dto.Error
+// @Failure      403  {object}  dto.Error
+// @Failure      404  {object}  dto.Error
+// @Failure      500  {object}  dto.Error
+// @Router       /api/v1/payment/claims/{id}/proof [get]
+func GetPaymentClaimProof(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetPaymentClaimProof handler"
+
+		claimID := r.PathValue("id")
+		if err := common.ValidateID(claimID); err != nil {
+			BadRequestError(w, r, "invalid claim id")
+			return
+		}
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		proof, err := svc.ClaimProof(r.Context(), userID, common.IDFromText(claimID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentClaimError(w, r, err)
+			return
+		}
+		defer os.Remove(proof.Path)
+
+		file, err := os.Open(proof.Path)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", proof.Type)
+		w.Header().Set("Content-Disposition", `inline; filename="`+proof.Name+`"`)
+		if _, err := io.Copy(w, file); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
+func paymentClaimError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, payment.ErrInvalidClaim):
+		BadRequestError(w, r, payment.ErrInvalidClaim.Error())
+	case errors.Is(err, payment.ErrInvalidAmount):
+		BadRequestError(w, r, payment.ErrInvalidAmount.Error())
+	case errors.Is(err, paymentd.ErrInvalidFilter):
+		BadRequestError(w, r, "invalid claim status")
+	case errors.Is(err, payment.ErrNoBalanceDue):
+		Error(w, r, http.StatusConflict, payment.ErrNoBalanceDue.Error())
+	case errors.Is(err, payment.ErrClaimReviewed):
+		Error(w, r, http.StatusConflict, payment.ErrClaimReviewed.Error())
+	case errors.Is(err, payment.ErrClaimExceedsBalance):
+		Error(w, r, http.StatusConflict, payment.ErrClaimExceedsBalance.Error())
+	case errors.Is(err, payment.ErrClaimNotFound):
+		Error(w, r, http.StatusNotFound, payment.ErrClaimNotFound.Error())
+	case errors.Is(err, payment.ErrApartmentNotFound):
+		Error(w, r, http.StatusNotFound, payment.ErrApartmentNotFound.Error())
+	case errors.Is(err, payment.ErrPaymentNotFound):
+		Error(w, r, http.StatusNotFound, "bill not found")
+	case errors.Is(err, payment.ErrPermissionDenied):
+		Error(w, r, http.StatusForbidden, payment.ErrPermissionDenied.Error())
+	case errors.Is(err, payment.ErrProofStorageDisabled):
+		Error(w, r, http.StatusServiceUnavailable, payment.ErrProofStorageDisabled.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
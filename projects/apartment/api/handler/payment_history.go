@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+// GetUserPayments
+//
+// @Summary      Get user's payment history
+// @Description  Returns the authenticated user's payments and refunds, newest first
+// @Tags         Payment
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        status    query     string  false  "Payment status"  Enums(pending, paid, failed, cancelled, expired)
+// @Param        gateway   query     string  false  "Payment gateway"
+// @Param        billId    query     string  false  "Bill ID"
+// @Param        from      query     string  false  "Paid at or after, RFC 3339 or YYYY-MM-DD"
+// @Param        to        query     string  false  "Paid before, RFC 3339 or YYYY-MM-DD (inclusive day)"
+// @Param        page      query     int     false  "Page number"  default(1)
+// @Param        pageSize  query     int     false  "Page size"    default(20)  maximum(100)
+// @Success      200       {object}  dto.PaymentHistoryResponse
+// @Failure      400       {object}  dto.Error
+// @Failure      500       {object}  dto.Error
+// @Router       /api/v1/user/payments [get]
+func GetUserPayments(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetUserPayments handler"
+
+		filter, err := paymentFilterFromQuery(r.URL.Query())
+		if err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		page, err := svc.UserPayments(r.Context(), userID, filter)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentHistoryError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.PaymentPageDomainToDTO(page)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetApartmentPayments
+//
+// @Summary      Get apartment payment history
+// @Description  Returns the payments made for an apartment's bills, newest first. Only the apartment admin can see them. With billId, the members who still owe on the bill are listed too.
+// @Tags         Payment
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id        path      string  true   "Apartment ID"
+// @Param        status    query     string  false  "Payment status"  Enums(pending, paid, failed, cancelled, expired)
+// @Param        gateway   query     string  false  "Payment gateway"
+// @Param        billId    query     string  false  "Bill ID"
+// @Param        payerId   query     string  false  "Payer ID"
+// @Param        from      query     string  false  "Paid at or after, RFC 3339 or YYYY-MM-DD"
+// @Param        to        query     string  false  "Paid before, RFC 3339 or YYYY-MM-DD (inclusive day)"
+// @Param        page      query     int     false  "Page number"  default(1)
+// @Param        pageSize  query     int     false  "Page size"    default(20)  maximum(100)
+// @Success      200       {object}  dto.ApartmentPaymentHistoryResponse
+// @Failure      400       {object}  dto.Error
+// @Failure      403       {object}  dto.Error
+// @Failure      404       {object}  dto.Error
+// @Failure      500       {object}  dto.Error
+// @Router       /api/v1/apartment/{id}/payments [get]
+func GetApartmentPayments(svcGtr ServiceGetter[paymentp.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetApartmentPayments handler"
+
+		apartmentID := r.PathValue("id")
+		if err := common.ValidateID(apartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartment id")
+			return
+		}
+		query := r.URL.Query()
+		filter, err := paymentFilterFromQuery(query)
+		if err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if v := query.Get("payerId"); v != "" {
+			if err := common.ValidateID(v); err != nil {
+				BadRequestError(w, r, "invalid payerId")
+				return
+			}
+			filter.PayerID = common.IDFromText(v)
+		}
+
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		page, err := svc.ApartmentPayments(r.Context(), adminID, common.IDFromText(apartmentID), filter)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			paymentHistoryError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.ApartmentPaymentPageDomainToDTO(page)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+func paymentHistoryError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, paymentd.ErrInvalidFilter):
+		BadRequestError(w, r, paymentd.ErrInvalidFilter.Error())
+	case errors.Is(err, payment.ErrApartmentNotFound):
+		Error(w, r, http.StatusNotFound, payment.ErrApartmentNotFound.Error())
+	case errors.Is(err, payment.ErrPermissionDenied):
+		Error(w, r, http.StatusForbidden, payment.ErrPermissionDenied.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
+
+func paymentFilterFromQuery(q url.Values) (paymentd.PaymentFilter, error) {
+	f := paymentd.PaymentFilter{
+		Status:  paymentd.PaymentStatus(q.Get("status")),
+		Gateway: paymentd.GatewayType(q.Get("gateway")),
+	}
+	if v := q.Get("billId"); v != "" {
+		if err := common.ValidateID(v); err != nil {
+			return f, errors.New("invalid billId")
+		}
+		f.BillID = common.IDFromText(v)
+	}
+
+	var err error
+	if f.From, err = parseQueryTime(q.Get("from"), false); err != nil {
+		return f, errors.New("invalid from")
+	}
+	if f.To, err = parseQueryTime(q.Get("to"), true); err != nil {
+		return f, errors.New("invalid to")
+	}
+	for key, dst := range map[string]*int{"page": &f.Page, "pageSize": &f.PageSize} {
+		if v := q.Get(key); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil {
+				return f, fmt.Errorf("invalid %s", key)
+			}
+		}
+	}
+	return f, nil
+}
+
+// parseQueryTime accepts RFC 3339 times and dates. A date used as an end
+// bound includes the whole day.
+func parseQueryTime(v string, end bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	authd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"go.uber.org/zap"
+)
+
+// CreatePersonalToken
+//
+// @Summary      Create a personal access token
+// @Description  Creates a token for scripts and integrations, sent like an access token as "Authorization: Bearer <token>". It only reaches the endpoints of its scopes: bills:read, bills:write, payments:read and payments:write. A token restricted to an apartment only reaches the endpoints that name that apartment in the request. The token is only shown once. It counts as two-factor authenticated if the session creating it is.
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.CreatePersonalTokenRequest  true  "Token Request"
+// @Success      201   {object}  dto.CreatePersonalTokenResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/tokens [post]
+func CreatePersonalToken(svcGtr ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "CreatePersonalToken handler"
+
+		var req dto.CreatePersonalTokenRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		mfa, _ := r.Context().Value(appjwt.MFAKey).(bool)
+		t := &authd.PersonalToken{
+			UserID:    userID,
+			Name:      req.Name,
+			Scopes:    make([]authd.Scope, len(req.Scopes)),
+			MFA:       mfa,
+			ExpiresAt: req.ExpiresAt,
+		}
+		for i, s := range req.Scopes {
+			t.Scopes[i] = authd.Scope(s)
+		}
+		if req.ApartmentID != "" {
+			if err := common.ValidateID(req.ApartmentID); err != nil {
+				BadRequestError(w, r, "invalid apartmentID")
+				return
+			}
+			aptID := common.IDFromText(req.ApartmentID)
+			t.ApartmentID = &aptID
+		}
+
+		svc := svcGtr(r.Context())
+		token, err := svc.CreatePersonalToken(r.Context(), t)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+
+		resp := dto.CreatePersonalTokenResponse{
+			PersonalToken: dto.PersonalTokenDomainToDTO(t),
+			Token:         token,
+		}
+		if err = WriteJson(w, http.StatusCreated, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetPersonalTokens
+//
+// @Summary      List personal access tokens
+// @Description  Lists the personal access tokens of the authenticated user, newest first, with when each was last used
+// @Tags         User
+// @Produce      json
+// @Security 	 BearerAuth
+// @Success      200   {object}  dto.PersonalTokensResponse
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/tokens [get]
+func GetPersonalTokens(svcGtr ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetPersonalTokens handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		ts, err := svc.PersonalTokens(r.Context(), userID)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+
+		resp := dto.PersonalTokensResponse{PersonalTokens: make([]dto.PersonalToken, 0, len(ts))}
+		for i := range ts {
+			resp.PersonalTokens = append(resp.PersonalTokens, dto.PersonalTokenDomainToDTO(&ts[i]))
+		}
+		if err = WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// RevokePersonalToken
+//
+// @Summary      Revoke a personal access token
+// @Description  Deletes a personal access token of the authenticated user, which stops working right away
+// @Tags         User
+// @Security 	 BearerAuth
+// @Param        id    path      string  true  "Token ID"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/tokens/{id} [delete]
+func RevokePersonalToken(svcGtr ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "RevokePersonalToken handler"
+
+		tokenID := r.PathValue("id")
+		if err := common.ValidateID(tokenID); err != nil {
+			BadRequestError(w, r, "invalid token id")
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		if err := svc.RevokePersonalToken(r.Context(), userID, common.IDFromText(tokenID)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// pathApartment finds the apartment of /apartment/{id} routes for
+// personal access tokens restricted to an apartment.
+func pathApartment(r *http.Request) string {
+	return r.PathValue("id")
+}
+
+// queryApartment finds the apartment of routes taking it as the
+// apartmentId query parameter, like the wallet ones.
+func queryApartment(r *http.Request) string {
+	return r.URL.Query().Get(ApartmentIDKey)
+}
+
+// billFormApartment finds the apartment of the bill form AddBill reads.
+func billFormApartment(r *http.Request) string {
+	if err := r.ParseMultipartForm(1 * MiB); err != nil {
+		return ""
+	}
+	return r.FormValue("apartmentID")
+}
@@ -5,9 +5,15 @@ import (
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/app"
 	apartmentPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/port"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	autopayPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
 	billPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/port"
+	ledgerPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	notificationPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
 	userPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
+	walletPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	webhookPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
 )
 
 type ServiceGetter[T any] func(context.Context) T
@@ -35,3 +41,39 @@ func PaymentServiceGetter(app app.App) ServiceGetter[paymentp.Service] {
 		return app.PaymentService()
 	}
 }
+
+func WalletServiceGetter(app app.App) ServiceGetter[walletPort.Service] {
+	return func(ctx context.Context) walletPort.Service {
+		return app.WalletService()
+	}
+}
+
+func LedgerServiceGetter(app app.App) ServiceGetter[ledgerPort.Service] {
+	return func(ctx context.Context) ledgerPort.Service {
+		return app.LedgerService()
+	}
+}
+
+func AutopayServiceGetter(app app.App) ServiceGetter[autopayPort.Service] {
+	return func(ctx context.Context) autopayPort.Service {
+		return app.AutopayService()
+	}
+}
+
+func WebhookServiceGetter(app app.App) ServiceGetter[webhookPort.Service] {
+	return func(ctx context.Context) webhookPort.Service {
+		return app.WebhookService()
+	}
+}
+
+func NotificationServiceGetter(app app.App) ServiceGetter[notificationPort.Service] {
+	return func(ctx context.Context) notificationPort.Service {
+		return app.NotificationService()
+	}
+}
+
+func AuthServiceGetter(app app.App) ServiceGetter[authPort.Service] {
+	return func(ctx context.Context) authPort.Service {
+		return app.AuthService()
+	}
+}
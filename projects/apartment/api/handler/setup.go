@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"cmp"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/handler/middleware"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/handler/router"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/app"
 	_ "github.com/arcaptcha-internship-2025/momoein-apartment/docs"
+	authd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -26,18 +29,42 @@ func Run(app app.App) error {
 // @in header
 // @name Authorization
 func RegisterAPI(r *router.Router, app app.App) {
-	jwtSecret := []byte(app.Config().Auth.JWTSecret)
+	jwtKeys := app.JWTKeys()
+	// accepts access tokens only
+	jwtAuth := middleware.NewAuth(app)
+	// accepts personal access tokens with scope too, restricted ones if
+	// apartment finds their apartment in the request
+	tokenAuth := func(scope authd.Scope, apartment func(*http.Request) string) router.Middleware {
+		return middleware.NewAuth(app, middleware.TokenAccess{Scope: scope, Apartment: apartment})
+	}
+	billsRead := tokenAuth(authd.ScopeBillsRead, nil)
+	paymentsRead := tokenAuth(authd.ScopePaymentsRead, nil)
+	paymentsWrite := tokenAuth(authd.ScopePaymentsWrite, nil)
+	verifyURL := app.Config().BaseURL + "/api/v1/auth/verify-email"
+	// guards paying, inviting and creating apartments, see
+	// AUTH_REQUIRE_VERIFIED_EMAIL
+	verified := middleware.RequireVerifiedEmail(app)
+	// guards managing apartments, for admins of apartments that require
+	// two-factor authentication
+	adminMFA := middleware.RequireAdminMFA(app)
 
 	usrSvcGtr := UserServiceGetter(app)
+	athSvcGtr := AuthServiceGetter(app)
 	bilSvcGtr := BillServiceGetter(app)
 	aptSvcGtr := ApartmentServiceGetter(app)
 	paySvcGtr := PaymentServiceGetter(app)
+	walSvcGtr := WalletServiceGetter(app)
+	ldgSvcGtr := LedgerServiceGetter(app)
+	apySvcGtr := AutopayServiceGetter(app)
+	whkSvcGtr := WebhookServiceGetter(app)
+	ntfSvcGtr := NotificationServiceGetter(app)
 
 	r.Use(
 		middleware.SetRequestContext(app),
 		middleware.LogRequest(),
 	)
 	r.Get("/", getRootHandler())
+	r.Get("/.well-known/jwks.json", JWKSHandler(jwtKeys))
 
 	r.Group("/api/v1", func(r *router.Router) {
 
@@ -46,49 +73,134 @@ func RegisterAPI(r *router.Router, app app.App) {
 		})
 
 		r.Group("/auth", func(r *router.Router) {
-			r.Post("/sign-up", getSignUpHandler(usrSvcGtr, app.Config().Auth))
-			r.Get("/sign-in", getSignInHandler(usrSvcGtr, app.Config().Auth))
-			r.Get("/refresh-token", RefreshTokenHandler(usrSvcGtr, app.Config().Auth))
+			chain := router.Chain{jwtAuth}
+
+			r.Post("/sign-up", getSignUpHandler(usrSvcGtr, athSvcGtr, app.Config().Auth, verifyURL))
+			r.Get("/sign-in", getSignInHandler(usrSvcGtr, athSvcGtr, app.Config().Auth, app.Config().HTTP.TrustProxy))
+			r.Get("/refresh-token", RefreshTokenHandler(athSvcGtr, app.Config().Auth))
+			r.Post("/logout", LogoutHandler(athSvcGtr))
+			r.Post("/logout-all", chain.Then(LogoutAllHandler(athSvcGtr)))
+
+			resetURL := cmp.Or(app.Config().Auth.ResetURL, app.Config().BaseURL+"/reset-password")
+			r.Post("/forgot-password", ForgotPasswordHandler(athSvcGtr, resetURL))
+			r.Post("/reset-password", ResetPasswordHandler(athSvcGtr))
+			r.Get("/verify-email", VerifyEmailHandler(athSvcGtr))
+			r.Get("/oidc/login", OIDCLoginHandler(athSvcGtr))
+			r.Get("/oidc/callback", OIDCCallbackHandler(athSvcGtr, app.Config().Auth))
+			r.Post("/verify-email/resend", chain.Then(ResendVerificationHandler(athSvcGtr, verifyURL)))
+			r.Post("/2fa/verify", VerifyMFAHandler(athSvcGtr, app.Config().Auth))
+			r.Post("/2fa/totp", chain.Then(EnrollTOTPHandler(athSvcGtr)))
+			r.Post("/2fa/totp/confirm", chain.Then(ConfirmTOTPHandler(athSvcGtr)))
+			r.Post("/2fa/totp/disable", chain.Then(DisableTOTPHandler(athSvcGtr)))
+			r.Post("/2fa/recovery-codes", chain.Then(RegenerateRecoveryCodesHandler(athSvcGtr)))
 		})
 
 		r.Group("/apartment", func(r *router.Router) {
-			r.Use(middleware.NewAuth(jwtSecret))
 			acceptURL := app.Config().BaseURL + "/api/v1/apartment/invite/accept"
-
-			r.Post("/", AddApartment(aptSvcGtr))
-			r.Post("/invite", InviteApartmentMember(aptSvcGtr, acceptURL))
-			r.Get("/invite/accept", AcceptApartmentInvite(aptSvcGtr))
+			paymentsRead := tokenAuth(authd.ScopePaymentsRead, pathApartment)
+
+			r.Post("/", jwtAuth(verified(AddApartment(aptSvcGtr))))
+			r.Post("/invite", jwtAuth(verified(adminMFA(InviteApartmentMember(aptSvcGtr, acceptURL)))))
+			r.Get("/invite/accept", jwtAuth(AcceptApartmentInvite(aptSvcGtr)))
+			r.Get("/{id}/payments", paymentsRead(adminMFA(GetApartmentPayments(paySvcGtr))))
+			r.Get("/{id}/payment-claims", paymentsRead(adminMFA(GetApartmentPaymentClaims(paySvcGtr))))
+			r.Get("/{id}/webhooks", jwtAuth(adminMFA(GetApartmentWebhooks(whkSvcGtr))))
+			r.Post("/{id}/announcements", jwtAuth(adminMFA(PostAnnouncement(ntfSvcGtr))))
+			r.Put("/{id}/mfa-policy", jwtAuth(adminMFA(SetApartmentMFAPolicy(aptSvcGtr))))
 		})
 
 		r.Group("/bill", func(r *router.Router) {
-			r.Use(middleware.NewAuth(jwtSecret))
+			billsWrite := tokenAuth(authd.ScopeBillsWrite, billFormApartment)
 
-			r.Post("/", AddBill(bilSvcGtr))
-			r.Get("/", GetBill(bilSvcGtr))
-			r.Get("/image", GetBillImage(bilSvcGtr))
+			r.Post("/", billsWrite(adminMFA(AddBill(bilSvcGtr))))
+			r.Get("/", billsRead(GetBill(bilSvcGtr)))
+			r.Get("/image", billsRead(GetBillImage(bilSvcGtr)))
 		})
 
 		r.Group("/user", func(r *router.Router) {
-			r.Use(middleware.NewAuth(jwtSecret))
-
-			r.Get("/total-debt", GetUserTotalDept(bilSvcGtr))
-			r.Get("/bill-shares", GetUserBillShares(bilSvcGtr))
+			r.Get("/total-debt", billsRead(GetUserTotalDept(bilSvcGtr)))
+			r.Get("/bill-shares", billsRead(GetUserBillShares(bilSvcGtr)))
+			r.Get("/payments", paymentsRead(GetUserPayments(paySvcGtr)))
+			r.Get("/payment-claims", paymentsRead(GetUserPaymentClaims(paySvcGtr)))
+			r.Get("/me", jwtAuth(GetMe(usrSvcGtr)))
+			r.Patch("/me", jwtAuth(UpdateMe(usrSvcGtr)))
+			r.Delete("/me", jwtAuth(DeleteMe(usrSvcGtr)))
+			r.Get("/me/export", jwtAuth(ExportMe(usrSvcGtr)))
+			r.Post("/me/password", jwtAuth(ChangePassword(usrSvcGtr)))
+			r.Post("/me/email", jwtAuth(ChangeEmail(usrSvcGtr, athSvcGtr, verifyURL)))
+			r.Post("/tokens", jwtAuth(CreatePersonalToken(athSvcGtr)))
+			r.Get("/tokens", jwtAuth(GetPersonalTokens(athSvcGtr)))
+			r.Delete("/tokens/{id}", jwtAuth(RevokePersonalToken(athSvcGtr)))
 		})
 
 		r.Group("/payment", func(r *router.Router) {
 			callbackURL := app.Config().BaseURL + "/api/v1/payment/callback"
-			chain := router.Chain{middleware.NewAuth(jwtSecret)}
 
-			r.Post("/pay-bill", chain.Then(PayUserBill(paySvcGtr, callbackURL)))
-			r.Post("/pay-total-debt", chain.Then(PayTotalDebt(paySvcGtr, callbackURL)))
+			r.Post("/pay-bill", paymentsWrite(verified(PayUserBill(paySvcGtr, callbackURL))))
+			r.Post("/pay-total-debt", paymentsWrite(verified(PayTotalDebt(paySvcGtr, callbackURL))))
 			r.Post("/callback", CallbackHandler(paySvcGtr))
 			r.Get("/supported-gateways", SupportedGateways(paySvcGtr))
+			r.Post("/refund", paymentsWrite(adminMFA(RefundPayment(paySvcGtr))))
+			r.Get("/{id}/receipt", paymentsRead(GetPaymentReceipt(paySvcGtr)))
+			r.Post("/claims", paymentsWrite(verified(SubmitPaymentClaim(paySvcGtr))))
+			r.Post("/claims/{id}/approve", paymentsWrite(adminMFA(ApprovePaymentClaim(paySvcGtr))))
+			r.Post("/claims/{id}/reject", paymentsWrite(adminMFA(RejectPaymentClaim(paySvcGtr))))
+			r.Get("/claims/{id}/proof", paymentsRead(GetPaymentClaimProof(paySvcGtr)))
 
 			r.Group("/mock-gateway", func(r *router.Router) {
-				r.Post("/pay", MockGatewayPay())
-				r.Get("/verify", MockGatewayVerify())
+				store := NewMockGatewayStore()
+
+				r.Post("/pay", MockGatewayPay(store))
+				r.Get("/verify", MockGatewayVerify(store))
+				r.Get("/inquiry", MockGatewayInquiry(store))
+				r.Post("/refund", MockGatewayRefund(store))
+				r.Post("/tokenize", MockGatewayTokenize(store))
+				r.Get("/token", MockGatewayToken(store))
+				r.Post("/charge", MockGatewayCharge(store))
 			})
 		})
+
+		r.Group("/wallet", func(r *router.Router) {
+			callbackURL := app.Config().BaseURL + "/api/v1/wallet/callback"
+			paymentsRead := tokenAuth(authd.ScopePaymentsRead, queryApartment)
+
+			r.Get("/", paymentsRead(GetWallet(walSvcGtr)))
+			r.Post("/top-up", paymentsWrite(verified(TopUpWallet(walSvcGtr, callbackURL))))
+			r.Post("/callback", WalletCallback(walSvcGtr))
+			r.Post("/pay-bill", paymentsWrite(verified(PayBillFromWallet(walSvcGtr))))
+			r.Get("/transactions", paymentsRead(WalletTransactions(walSvcGtr)))
+		})
+
+		r.Group("/autopay", func(r *router.Router) {
+			callbackURL := app.Config().BaseURL + "/api/v1/autopay/callback"
+
+			r.Get("/", paymentsRead(AutopayEnrollments(apySvcGtr)))
+			r.Post("/", paymentsWrite(verified(EnrollAutopay(apySvcGtr, callbackURL))))
+			r.Post("/callback", AutopayCallback(apySvcGtr))
+			r.Delete("/{id}", paymentsWrite(CancelAutopay(apySvcGtr)))
+		})
+
+		r.Group("/webhooks", func(r *router.Router) {
+			r.Use(jwtAuth, adminMFA)
+
+			r.Post("/", CreateWebhook(whkSvcGtr))
+			r.Delete("/{id}", DeleteWebhook(whkSvcGtr))
+			r.Get("/{id}/deliveries", GetWebhookDeliveries(whkSvcGtr))
+			r.Post("/{id}/deliveries/{deliveryId}/redeliver", RedeliverWebhook(whkSvcGtr))
+		})
+
+		r.Group("/notifications", func(r *router.Router) {
+			r.Use(jwtAuth)
+			heartbeat := time.Second * time.Duration(app.Config().Notification.Heartbeat)
+
+			r.Get("/stream", NotificationStream(ntfSvcGtr, heartbeat))
+		})
+
+		r.Group("/ledger", func(r *router.Router) {
+			r.Use(jwtAuth, adminMFA)
+
+			r.Post("/adjustments", PostLedgerAdjustment(ldgSvcGtr))
+		})
 	})
 }
 
@@ -3,18 +3,21 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth"
+	authd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
 	userPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
-	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
-	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 )
 
@@ -26,7 +29,7 @@ var (
 // SignUpHandler
 //
 // @Summary      Register a new user
-// @Description  Creates a new user account and returns JWT tokens
+// @Description  Creates a new user account, emails a link that verifies the email and returns JWT tokens
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -35,7 +38,12 @@ var (
 // @Failure      400   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
 // @Router       /api/v1/auth/sign-up [post]
-func getSignUpHandler(svcGetter ServiceGetter[userPort.Service], cfg config.AuthConfig) http.Handler {
+func getSignUpHandler(
+	svcGetter ServiceGetter[userPort.Service],
+	authSvcGetter ServiceGetter[authPort.Service],
+	cfg config.AuthConfig,
+	verifyURL string,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
 
@@ -47,7 +55,12 @@ func getSignUpHandler(svcGetter ServiceGetter[userPort.Service], cfg config.Auth
 
 		service := svcGetter(r.Context())
 		u, err := service.Create(r.Context(),
-			dto.UserDTOToDomain(&dto.User{Email: req.Email, Password: req.Password}))
+			dto.UserDTOToDomain(&dto.User{
+				Email:     req.Email,
+				Password:  req.Password,
+				FirstName: req.FirstName,
+				LastName:  req.LastName,
+			}))
 		if err != nil {
 			switch {
 			case errors.Is(err, user.ErrUserOnValidate):
@@ -60,16 +73,25 @@ func getSignUpHandler(svcGetter ServiceGetter[userPort.Service], cfg config.Auth
 			return
 		}
 
-		authResp, err := GenerateAuthResponse(cfg, u.ID.String(), u.Email.String())
+		authSvc := authSvcGetter(r.Context())
+		tokens, err := authSvc.Login(r.Context(), u.ID, u.Email.String())
 		if err != nil {
 			log.Error("failed to generate jwt token", zap.Error(err))
 			Error(w, r, http.StatusInternalServerError, "InternalServerError", err.Error())
 			return
 		}
+		// the user can ask for another one
+		if err = authSvc.SendVerification(r.Context(), u.ID, verifyURL); err != nil {
+			log.Warn("send email verification", zap.Error(err))
+		}
 
-		SetTokenCookie(w, cfg, authResp.AccessToken, authResp.RefreshToken)
+		SetTokenCookie(w, cfg, tokens.AccessToken, tokens.RefreshToken)
+		authResp := dto.AuthResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		}
 
-		if err = WriteJson(w, http.StatusCreated, authResp); err != nil {
+		if err = WriteJson(w, http.StatusCreated, &authResp); err != nil {
 			log.Error("failed to write response", zap.Error(err))
 			Error(w, r, http.StatusInternalServerError, "InternalServerError", err.Error())
 		}
@@ -79,18 +101,25 @@ func getSignUpHandler(svcGetter ServiceGetter[userPort.Service], cfg config.Auth
 // SignInHandler
 //
 // @Summary      User login
-// @Description  Authenticates user and returns JWT tokens
+// @Description  Authenticates user and returns JWT tokens. Users with two-factor authentication get a short-lived challenge instead, to exchange with a code at /api/v1/auth/2fa/verify. Unknown emails and wrong passwords get the same answer. Repeated failures of an account or an IP address make further attempts wait, then lock them for a while.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        body  body      dto.SignInRequest  true  "Sign In Request"
 // @Success      201   {object}  dto.AuthResponse
+// @Success      202   {object}  dto.MFAChallengeResponse
 // @Failure      400   {object}  dto.Error
 // @Failure      401   {object}  dto.Error
-// @Failure      404   {object}  dto.Error
+// @Failure      429   {object}  dto.Error
+// @Header       429   {integer}  Retry-After  "Seconds to wait before trying again"
 // @Failure      500   {object}  dto.Error
 // @Router       /api/v1/auth/sign-in [get]
-func getSignInHandler(svcGetter ServiceGetter[userPort.Service], cfg config.AuthConfig) http.Handler {
+func getSignInHandler(
+	svcGetter ServiceGetter[userPort.Service],
+	authSvcGetter ServiceGetter[authPort.Service],
+	cfg config.AuthConfig,
+	trustProxy bool,
+) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
 
@@ -100,125 +129,652 @@ func getSignInHandler(svcGetter ServiceGetter[userPort.Service], cfg config.Auth
 			return
 		}
 
-		service := svcGetter(r.Context())
-		u, err := service.Get(r.Context(), &domain.UserFilter{Email: common.Email(req.Email)})
-		if err != nil {
-			switch {
-			case errors.Is(err, user.ErrUserNotFound):
-				http.Error(w, user.ErrUserNotFound.Error(), http.StatusNotFound)
-			default:
-				e := fmt.Errorf("%w: %w", ErrInternalServer, err)
-				http.Error(w, e.Error(), http.StatusInternalServerError)
+		email := common.Email(req.Email)
+		ip := clientIP(r, trustProxy)
+		authSvc := authSvcGetter(r.Context())
+		if wait, err := authSvc.CheckLogin(r.Context(), email, ip); err != nil {
+			if errors.Is(err, auth.ErrLoginThrottled) {
+				secs := int64((wait + time.Second - 1) / time.Second)
+				w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+				Error(w, r, http.StatusTooManyRequests, auth.ErrLoginThrottled.Error())
+				return
 			}
+			log.Error("failed to check sign-in attempts", zap.Error(err))
+			InternalServerError(w, r)
 			return
 		}
 
-		if err := u.ComparePassword([]byte(req.Password)); err != nil {
-			log.Warn("password comparison failed", zap.Error(err))
-			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		u, err := svcGetter(r.Context()).Authenticate(r.Context(), email, req.Password)
+		if err != nil {
+			if errors.Is(err, user.ErrInvalidCredentials) {
+				if err = authSvc.LoginFailed(r.Context(), email, ip); err != nil {
+					log.Error("failed to record sign-in attempt", zap.Error(err))
+				}
+				Error(w, r, http.StatusUnauthorized, "invalid credentials")
+				return
+			}
+			log.Error("failed to authenticate user", zap.Error(err))
+			InternalServerError(w, r)
 			return
 		}
+		if err = authSvc.LoginSucceeded(r.Context(), email, ip); err != nil {
+			log.Error("failed to record sign-in attempt", zap.Error(err))
+		}
 
-		authResp, err := GenerateAuthResponse(cfg, u.ID.String(), u.Email.String())
+		signIn, err := authSvc.SignIn(r.Context(), u.ID, u.Email.String())
 		if err != nil {
 			log.Error("failed to generate jwt token", zap.Error(err))
 			Error(w, r, http.StatusInternalServerError, "InternalServerError", err.Error())
 			return
 		}
+		writeSignIn(w, r, cfg, signIn)
+	})
+}
 
-		SetTokenCookie(w, cfg, authResp.AccessToken, authResp.RefreshToken)
+// writeSignIn answers a sign-in with tokens, or with the two-factor
+// challenge.
+func writeSignIn(w http.ResponseWriter, r *http.Request, cfg config.AuthConfig, signIn *authd.SignIn) {
+	log := appctx.Logger(r.Context())
 
-		if err = WriteJson(w, http.StatusCreated, authResp); err != nil {
+	if c := signIn.Challenge; c != nil {
+		err := WriteJson(w, http.StatusAccepted, &dto.MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: c.Token,
+			ExpiresAt:      c.ExpiresAt,
+		})
+		if err != nil {
 			log.Error("failed to write response", zap.Error(err))
-			Error(w, r, http.StatusInternalServerError, "InternalServerError", err.Error())
+			InternalServerError(w, r)
 		}
-	})
+		return
+	}
+
+	tokens := signIn.Tokens
+	SetTokenCookie(w, cfg, tokens.AccessToken, tokens.RefreshToken)
+	authResp := dto.AuthResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+
+	if err := WriteJson(w, http.StatusCreated, &authResp); err != nil {
+		log.Error("failed to write response", zap.Error(err))
+		Error(w, r, http.StatusInternalServerError, "InternalServerError", err.Error())
+	}
 }
 
 // RefreshTokenHandler
 //
 // @Summary      Refresh JWT token
-// @Description  Refresh access token using a valid refresh token
+// @Description  Exchanges a refresh token, from the body or the refresh-token cookie, for a new access token and a new refresh token. Each refresh token can be used once; presenting a used one again signs out the session it belongs to.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
-// @Param        body  body      dto.RefreshTokenRequest  true  "Refresh Token Request"
+// @Param        body  body      dto.RefreshTokenRequest  false  "Refresh Token Request"
 // @Success      200   {object}  dto.AuthResponse
 // @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
 // @Failure      500   {object}  dto.Error
 // @Router       /api/v1/auth/refresh-token [get]
-func RefreshTokenHandler(svcGetter ServiceGetter[userPort.Service], cfg config.AuthConfig) http.Handler {
+func RefreshTokenHandler(svcGetter ServiceGetter[authPort.Service], cfg config.AuthConfig) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.Logger(r.Context())
 
-		var req dto.RefreshTokenRequest
-		if err := BodyParse(r, &req); err != nil {
-			BadRequestError(w, r, err.Error())
+		refreshToken, ok := requestRefreshToken(w, r)
+		if !ok {
 			return
 		}
 
-		claims, err := appjwt.ParseToken(req.RefreshToken, []byte(cfg.JWTSecret))
+		tokens, err := svcGetter(r.Context()).Refresh(r.Context(), refreshToken)
 		if err != nil {
 			log.Error("refresh token", zap.Error(err))
-			BadRequestError(w, r, err.Error())
+			authError(w, r, err)
 			return
 		}
 
-		accessToken, err := createJWTToken(cfg.JWTSecret, claims.UserID, claims.UserEMail, cfg.AccessExpiry)
+		SetTokenCookie(w, cfg, tokens.AccessToken, tokens.RefreshToken)
+		err = WriteJson(w, http.StatusOK, &dto.AuthResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
 		if err != nil {
 			log.Error("refresh token", zap.Error(err))
 			InternalServerError(w, r)
+		}
+	})
+}
+
+// LogoutHandler
+//
+// @Summary      Log out
+// @Description  Revokes the session of the refresh token, from the body or the refresh-token cookie, and clears the token cookies
+// @Tags         Auth
+// @Accept       json
+// @Param        body  body      dto.RefreshTokenRequest  false  "Refresh Token Request"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/logout [post]
+func LogoutHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+
+		refreshToken, ok := requestRefreshToken(w, r)
+		if !ok {
 			return
 		}
 
-		SetTokenCookie(w, cfg, accessToken, req.RefreshToken)
-		err = WriteJson(w, http.StatusOK, &dto.AuthResponse{
-			AccessToken:  accessToken,
-			RefreshToken: req.RefreshToken,
-		})
+		if err := svcGetter(r.Context()).Logout(r.Context(), refreshToken); err != nil {
+			log.Error("logout", zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		ClearTokenCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// LogoutAllHandler
+//
+// @Summary      Log out all devices
+// @Description  Revokes every session of the authenticated user and clears the token cookies. Access tokens already issued stay valid until they expire.
+// @Tags         Auth
+// @Security 	 BearerAuth
+// @Success      204
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/logout-all [post]
+func LogoutAllHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "LogoutAll handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		if err := svcGetter(r.Context()).LogoutAll(r.Context(), userID); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		ClearTokenCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ForgotPasswordHandler
+//
+// @Summary      Forgot password
+// @Description  Emails a single-use password reset link to the address if it belongs to a user. The response is the same whether or not it does.
+// @Tags         Auth
+// @Accept       json
+// @Param        body  body      dto.ForgotPasswordRequest  true  "Forgot Password Request"
+// @Success      202
+// @Failure      400   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/forgot-password [post]
+func ForgotPasswordHandler(svcGetter ServiceGetter[authPort.Service], resetURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+
+		var req dto.ForgotPasswordRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		err := svcGetter(r.Context()).ForgotPassword(r.Context(), common.Email(req.Email), resetURL)
 		if err != nil {
-			log.Error("refresh token", zap.Error(err))
-			InternalServerError(w, r)
+			log.Error("forgot password", zap.Error(err))
+			authError(w, r, err)
+			return
 		}
+		w.WriteHeader(http.StatusAccepted)
 	})
 }
 
-func GenerateAuthResponse(cfg config.AuthConfig, id, email string) (*dto.AuthResponse, error) {
-	accessToken, err := createJWTToken(cfg.JWTSecret, id, email, cfg.AccessExpiry)
-	if err != nil {
-		return nil, err
+// ResetPasswordHandler
+//
+// @Summary      Reset password
+// @Description  Sets a new password with the token of a reset link and signs the user out of all devices
+// @Tags         Auth
+// @Accept       json
+// @Param        body  body      dto.ResetPasswordRequest  true  "Reset Password Request"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/reset-password [post]
+func ResetPasswordHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+
+		var req dto.ResetPasswordRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		if err := svcGetter(r.Context()).ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+			log.Error("reset password", zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		ClearTokenCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// VerifyEmailHandler
+//
+// @Summary      Verify email
+// @Description  Marks the email of the user verified with the token of a verification link
+// @Tags         Auth
+// @Param        token  query     string  true  "Verification token"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/verify-email [get]
+func VerifyEmailHandler(svcGetter ServiceGetter[authPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			BadRequestError(w, r, "missing token")
+			return
+		}
+		if err := svcGetter(r.Context()).VerifyEmail(r.Context(), token); err != nil {
+			log.Error("verify email", zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ResendVerificationHandler
+//
+// @Summary      Resend verification email
+// @Description  Emails the authenticated user another email verification link. Only one is sent per AUTH_VERIFY_RESEND_INTERVAL.
+// @Tags         Auth
+// @Security 	 BearerAuth
+// @Success      202
+// @Failure      401   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      429   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/auth/verify-email/resend [post]
+func ResendVerificationHandler(svcGetter ServiceGetter[authPort.Service], verifyURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ResendVerification handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		if err := svcGetter(r.Context()).SendVerification(r.Context(), userID, verifyURL); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			authError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// GetMe
+//
+// @Summary      Get own profile
+// @Tags         User
+// @Produce      json
+// @Security 	 BearerAuth
+// @Success      200   {object}  dto.UserProfile
+// @Failure      401   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me [get]
+func GetMe(svcGetter ServiceGetter[userPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetMe handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		u, err := svcGetter(r.Context()).Get(r.Context(), &domain.UserFilter{ID: userID})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		if err = WriteJson(w, http.StatusOK, dto.UserProfileDomainToDTO(u)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
+// UpdateMe
+//
+// @Summary      Update own profile
+// @Description  Changes the names, phone, locale and avatar URL present in the body. An empty string clears a field.
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.UpdateProfileRequest  true  "Profile fields"
+// @Success      200   {object}  dto.UserProfile
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me [patch]
+func UpdateMe(svcGetter ServiceGetter[userPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "UpdateMe handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		var req dto.UpdateProfileRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		u, err := svcGetter(r.Context()).UpdateProfile(r.Context(), userID, dto.UpdateProfileDTOToDomain(&req))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		if err = WriteJson(w, http.StatusOK, dto.UserProfileDomainToDTO(u)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
+// ChangePassword
+//
+// @Summary      Change password
+// @Description  Sets a new password after checking the current one
+// @Tags         User
+// @Accept       json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.ChangePasswordRequest  true  "Current and new password"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me/password [post]
+func ChangePassword(svcGetter ServiceGetter[userPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ChangePassword handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		var req dto.ChangePasswordRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		err := svcGetter(r.Context()).ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ChangeEmail
+//
+// @Summary      Change email
+// @Description  Sets a new email after checking the password and emails a link that verifies it. The email counts as unverified until then.
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.ChangeEmailRequest  true  "New email and password"
+// @Success      200   {object}  dto.UserProfile
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me/email [post]
+func ChangeEmail(
+	svcGetter ServiceGetter[userPort.Service],
+	authSvcGetter ServiceGetter[authPort.Service],
+	verifyURL string,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ChangeEmail handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		var req dto.ChangeEmailRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		u, err := svcGetter(r.Context()).ChangeEmail(r.Context(), userID, common.Email(req.Email), req.Password)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		if u.EmailVerifiedAt == nil {
+			err = authSvcGetter(r.Context()).SendVerification(r.Context(), userID, verifyURL)
+			if err != nil {
+				log.Warn(logPrefix, zap.Error(err))
+			}
+		}
+		if err = WriteJson(w, http.StatusOK, dto.UserProfileDomainToDTO(u)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
+// DeleteMe
+//
+// @Summary      Delete own account
+// @Description  Deletes the account after checking the password. The personal data is scrubbed; payments stay in the apartment's books under an anonymous user. Apartment admins and users who owe money can't delete their account.
+// @Tags         User
+// @Accept       json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.DeleteAccountRequest  true  "Password"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      401   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me [delete]
+func DeleteMe(svcGetter ServiceGetter[userPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "DeleteMe handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+		var req dto.DeleteAccountRequest
+		if err := BodyParse(r, &req); err != nil {
+			BadRequestError(w, r, err.Error())
+			return
+		}
+
+		if err := svcGetter(r.Context()).DeleteAccount(r.Context(), userID, req.Password); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		ClearTokenCookies(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ExportMe
+//
+// @Summary      Export own data
+// @Description  Returns the profile, apartment memberships, bill shares and payments of the user as a JSON file
+// @Tags         User
+// @Produce      json
+// @Security 	 BearerAuth
+// @Success      200   {object}  dto.UserExport
+// @Failure      401   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/user/me/export [get]
+func ExportMe(svcGetter ServiceGetter[userPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "ExportMe handler"
+
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		e, err := svcGetter(r.Context()).Export(r.Context(), userID)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			userError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(
+			`attachment; filename="account-export-%s.json"`, e.ExportedAt.Format("2006-01-02")))
+		if err = WriteJson(w, http.StatusOK, dto.UserExportDomainToDTO(e)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+		}
+	})
+}
+
+func userError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		Error(w, r, http.StatusNotFound, user.ErrUserNotFound.Error())
+	case errors.Is(err, user.ErrWrongPassword):
+		Error(w, r, http.StatusForbidden, user.ErrWrongPassword.Error())
+	case errors.Is(err, user.ErrEmailTaken):
+		Error(w, r, http.StatusConflict, user.ErrEmailTaken.Error())
+	case errors.Is(err, user.ErrOwnsApartments):
+		Error(w, r, http.StatusConflict, user.ErrOwnsApartments.Error())
+	case errors.Is(err, user.ErrHasDebt):
+		Error(w, r, http.StatusConflict, user.ErrHasDebt.Error())
+	case errors.Is(err, user.ErrUserOnValidate):
+		BadRequestError(w, r, err.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
+
+// requestRefreshToken returns the refresh token of the body, or of the
+// refresh-token cookie if the body has none.
+func requestRefreshToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req dto.RefreshTokenRequest
+	if err := BodyParse(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		BadRequestError(w, r, err.Error())
+		return "", false
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, true
 	}
-	refreshToken, err := createJWTToken(cfg.JWTSecret, id, email, cfg.RefreshExpiry)
-	if err != nil {
-		return nil, err
+	if c, err := r.Cookie(RefreshTokenCookie); err == nil && c.Value != "" {
+		return c.Value, true
 	}
-	return &dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	BadRequestError(w, r, "missing refresh token")
+	return "", false
 }
 
-func createJWTToken(secret, userId, userEmail string, exp int64) (string, error) {
-	return appjwt.CreateToken([]byte(secret), &appjwt.UserClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: &jwt.NumericDate{
-				Time: time.Now().Add(time.Minute * time.Duration(exp))},
-			IssuedAt: &jwt.NumericDate{
-				Time: time.Now(),
-			},
-		},
-		UserID:    userId,
-		UserEMail: userEmail,
-	})
+func authError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, auth.ErrRefreshTokenReused):
+		Error(w, r, http.StatusUnauthorized, auth.ErrRefreshTokenReused.Error())
+	case errors.Is(err, auth.ErrRefreshTokenRevoked):
+		Error(w, r, http.StatusUnauthorized, auth.ErrRefreshTokenRevoked.Error())
+	case errors.Is(err, auth.ErrInvalidRefreshToken):
+		Error(w, r, http.StatusUnauthorized, auth.ErrInvalidRefreshToken.Error())
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		BadRequestError(w, r, auth.ErrInvalidResetToken.Error())
+	case errors.Is(err, auth.ErrInvalidEmail):
+		BadRequestError(w, r, auth.ErrInvalidEmail.Error())
+	case errors.Is(err, domain.ErrUserShortPassword):
+		BadRequestError(w, r, domain.ErrUserShortPassword.Error())
+	case errors.Is(err, domain.ErrUserLongPassword):
+		BadRequestError(w, r, domain.ErrUserLongPassword.Error())
+	case errors.Is(err, auth.ErrInvalidVerificationToken):
+		BadRequestError(w, r, auth.ErrInvalidVerificationToken.Error())
+	case errors.Is(err, auth.ErrEmailAlreadyVerified):
+		Error(w, r, http.StatusConflict, auth.ErrEmailAlreadyVerified.Error())
+	case errors.Is(err, auth.ErrVerificationThrottled):
+		Error(w, r, http.StatusTooManyRequests, auth.ErrVerificationThrottled.Error())
+	case errors.Is(err, auth.ErrInvalidMFAChallenge):
+		Error(w, r, http.StatusUnauthorized, auth.ErrInvalidMFAChallenge.Error())
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		BadRequestError(w, r, auth.ErrInvalidMFACode.Error())
+	case errors.Is(err, auth.ErrMFALocked):
+		Error(w, r, http.StatusTooManyRequests, auth.ErrMFALocked.Error())
+	case errors.Is(err, auth.ErrTOTPNotFound):
+		Error(w, r, http.StatusConflict, auth.ErrTOTPNotFound.Error())
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled):
+		Error(w, r, http.StatusConflict, auth.ErrMFAAlreadyEnabled.Error())
+	case errors.Is(err, auth.ErrMFARequired):
+		Error(w, r, http.StatusForbidden, auth.ErrMFARequired.Error())
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		Error(w, r, http.StatusNotFound, auth.ErrOIDCDisabled.Error())
+	case errors.Is(err, auth.ErrInvalidOIDCState):
+		BadRequestError(w, r, auth.ErrInvalidOIDCState.Error())
+	case errors.Is(err, auth.ErrOIDCRejected):
+		Error(w, r, http.StatusUnauthorized, auth.ErrOIDCRejected.Error())
+	case errors.Is(err, auth.ErrOIDCEmailUnverified):
+		Error(w, r, http.StatusForbidden, auth.ErrOIDCEmailUnverified.Error())
+	case errors.Is(err, auth.ErrOIDCAccountUnverified):
+		Error(w, r, http.StatusConflict, auth.ErrOIDCAccountUnverified.Error())
+	case errors.Is(err, auth.ErrInvalidTokenName):
+		BadRequestError(w, r, auth.ErrInvalidTokenName.Error())
+	case errors.Is(err, auth.ErrInvalidTokenScopes):
+		BadRequestError(w, r, auth.ErrInvalidTokenScopes.Error())
+	case errors.Is(err, auth.ErrInvalidTokenExpiry):
+		BadRequestError(w, r, auth.ErrInvalidTokenExpiry.Error())
+	case errors.Is(err, auth.ErrTooManyPersonalTokens):
+		BadRequestError(w, r, auth.ErrTooManyPersonalTokens.Error())
+	case errors.Is(err, auth.ErrPersonalTokenNotFound):
+		Error(w, r, http.StatusNotFound, auth.ErrPersonalTokenNotFound.Error())
+	default:
+		InternalServerError(w, r)
+	}
 }
 
+const (
+	AccessTokenCookie  = "access-token"
+	RefreshTokenCookie = "refresh-token"
+)
+
 func SetTokenCookie(
 	w http.ResponseWriter,
 	cfg config.AuthConfig,
 	accessToken, refreshToken string,
 ) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     "access-token",
+		Name:     AccessTokenCookie,
 		Value:    accessToken,
 		Expires:  time.Now().Add(time.Minute * time.Duration(cfg.AccessExpiry)),
 		SameSite: http.SameSiteStrictMode,
@@ -227,7 +783,7 @@ func SetTokenCookie(
 		Path:     "/",
 	})
 	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh-token",
+		Name:     RefreshTokenCookie,
 		Value:    refreshToken,
 		Expires:  time.Now().Add(time.Minute * time.Duration(cfg.RefreshExpiry)),
 		SameSite: http.SameSiteStrictMode,
@@ -236,3 +792,17 @@ func SetTokenCookie(
 		Path:     "/",
 	})
 }
+
+// ClearTokenCookies makes the client drop the token cookies.
+func ClearTokenCookies(w http.ResponseWriter) {
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			MaxAge:   -1,
+			SameSite: http.SameSiteStrictMode,
+			Secure:   true,
+			HttpOnly: true,
+			Path:     "/",
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"strings"
 
@@ -54,3 +55,24 @@ func BadRequestError(w http.ResponseWriter, r *http.Request, msg ...string) {
 	m := append([]string{"Bad Request"}, msg...)
 	Error(w, r, http.StatusBadRequest, m...)
 }
+
+// clientIP returns the address of the client. Behind a trusted proxy that
+// is the last X-Forwarded-For entry, the one the proxy appended.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			last := fwd[len(fwd)-1]
+			if i := strings.LastIndexByte(last, ','); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet"
+	walletPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"go.uber.org/zap"
+)
+
+const ApartmentIDKey = "apartmentId"
+
+// GetWallet
+//
+// @Summary      Get wallet
+// @Description  Returns the authenticated user's wallet credit in an apartment
+// @Tags         Wallet
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        apartmentId  query    string  true  "Apartment ID"
+// @Success      200   {object}  dto.Wallet
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/wallet [get]
+func GetWallet(svcGtr ServiceGetter[walletPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetWallet handler"
+
+		userID, apartmentID, ok := walletOwner(w, r)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		wal, err := svc.Get(r.Context(), userID, apartmentID)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			walletError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusOK, dto.WalletDomainToDTO(wal)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// TopUpWallet
+//
+// @Summary      Top up wallet
+// @Description  Initiates a gateway payment that adds credit to the authenticated user's wallet
+// @Tags         Wallet
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.TopUpWalletRequest  true  "Top-up Request"
+// @Success      201   {object}  dto.RedirectGateway
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/wallet/top-up [post]
+func TopUpWallet(svcGtr ServiceGetter[walletPort.Service], callbackURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "TopUpWallet handler"
+
+		var req dto.TopUpWalletRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.ApartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartmentID")
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		resp, err := svc.TopUp(r.Context(), paymentd.GatewayType(req.Gateway),
+			userID, common.IDFromText(req.ApartmentID), req.Amount, callbackURL)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			walletError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusCreated, resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// WalletCallback
+//
+// @Summary      Wallet top-up callback
+// @Description  Handles payment gateway callback and credits the wallet
+// @Tags         Wallet
+// @Produce      json
+// @Param        gateway  query    string  true  "Gateway"
+// @Param        token    query    string  false "Payment Token"
+// @Param        wallet-transaction-id query string true "Wallet Transaction ID"
+// @Success      200   {object}  dto.PayResponse
+// @Failure      400   {object}  dto.Error
+// @Router       /api/v1/wallet/callback [post]
+func WalletCallback(svcGtr ServiceGetter[walletPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "WalletCallback"
+
+		gatewayStr := r.URL.Query().Get(wallet.GatewayKey)
+		if gatewayStr == "" {
+			BadRequestError(w, r, "missing gateway")
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		err := svc.HandleTopUpCallback(r.Context(), paymentd.GatewayType(gatewayStr), r.URL.Query())
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			Error(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		resp := dto.PayResponse{
+			Status:  "completed",
+			Message: "wallet successfully topped up",
+		}
+		if err := WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// PayBillFromWallet
+//
+// @Summary      Pay a bill from wallet
+// @Description  Pays the authenticated user's share of a bill from wallet credit
+// @Tags         Wallet
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.PayBillFromWalletRequest  true  "Wallet Payment Request"
+// @Success      201   {object}  dto.Payment
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/wallet/pay-bill [post]
+func PayBillFromWallet(svcGtr ServiceGetter[walletPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "PayBillFromWallet handler"
+
+		var req dto.PayBillFromWalletRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.BillID); err != nil {
+			BadRequestError(w, r, "invalid billID")
+			return
+		}
+		userID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		p, err := svc.PayBill(r.Context(), userID, common.IDFromText(req.BillID), req.Amount)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			walletError(w, r, err)
+			return
+		}
+
+		if err = WriteJson(w, http.StatusCreated, dto.PaymentDomainToDTO(p)); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// WalletTransactions
+//
+// @Summary      Wallet transaction history
+// @Description  Lists the top-ups and payments of the authenticated user's wallet in an apartment
+// @Tags         Wallet
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        apartmentId  query    string  true  "Apartment ID"
+// @Success      200   {object}  dto.WalletTransactionsResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/wallet/transactions [get]
+func WalletTransactions(svcGtr ServiceGetter[walletPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "WalletTransactions handler"
+
+		userID, apartmentID, ok := walletOwner(w, r)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		ts, err := svc.Transactions(r.Context(), userID, apartmentID)
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			walletError(w, r, err)
+			return
+		}
+
+		resp := dto.WalletTransactionsResponse{
+			Transactions: make([]dto.WalletTransaction, 0, len(ts)),
+		}
+		for i := range ts {
+			resp.Transactions = append(resp.Transactions, dto.WalletTransactionDomainToDTO(&ts[i]))
+		}
+		if err = WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// requestUserID returns the authenticated user's ID, writing an error
+// response if it's missing.
+func requestUserID(w http.ResponseWriter, r *http.Request, logPrefix string) (common.ID, bool) {
+	userId, ok := r.Context().Value(appjwt.UserIDKey).(string)
+	if !ok {
+		appctx.Logger(r.Context()).Error(logPrefix,
+			zap.String("error", "failed to get user id from request context"))
+		InternalServerError(w, r)
+		return common.NilID, false
+	}
+	return common.IDFromText(userId), true
+}
+
+func walletOwner(w http.ResponseWriter, r *http.Request) (userID, apartmentID common.ID, ok bool) {
+	aptID := r.URL.Query().Get(ApartmentIDKey)
+	if err := common.ValidateID(aptID); err != nil {
+		BadRequestError(w, r, "invalid apartmentId")
+		return common.NilID, common.NilID, false
+	}
+	userID, ok = requestUserID(w, r, "wallet")
+	return userID, common.IDFromText(aptID), ok
+}
+
+func walletError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrNotMember):
+		Error(w, r, http.StatusForbidden, wallet.ErrNotMember.Error())
+	case errors.Is(err, wallet.ErrBillNotFound):
+		Error(w, r, http.StatusNotFound, wallet.ErrBillNotFound.Error())
+	case errors.Is(err, wallet.ErrUnknownGateway):
+		Error(w, r, http.StatusBadRequest, wallet.ErrUnknownGateway.Error())
+	case errors.Is(err, wallet.ErrInvalidAmount):
+		Error(w, r, http.StatusBadRequest, wallet.ErrInvalidAmount.Error())
+	case errors.Is(err, wallet.ErrInsufficientCredit):
+		Error(w, r, http.StatusConflict, wallet.ErrInsufficientCredit.Error())
+	case errors.Is(err, wallet.ErrNoBalanceDue):
+		Error(w, r, http.StatusConflict, wallet.ErrNoBalanceDue.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook"
+	webhookd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	webhookPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"go.uber.org/zap"
+)
+
+// CreateWebhook
+//
+// @Summary      Register a webhook
+// @Description  Registers an endpoint that receives the chosen events of an apartment. Deliveries are signed with the returned secret: the X-Webhook-Signature header is "sha256=" followed by the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>". Only the apartment admin can register webhooks.
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        body  body      dto.CreateWebhookRequest  true  "Webhook Request"
+// @Success      201   {object}  dto.CreateWebhookResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/webhooks [post]
+func CreateWebhook(svcGtr ServiceGetter[webhookPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "CreateWebhook handler"
+
+		var req dto.CreateWebhookRequest
+		if err := BodyParse(r, &req); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			BadRequestError(w, r, err.Error())
+			return
+		}
+		if err := common.ValidateID(req.ApartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartmentID")
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		types := make([]eventd.Type, len(req.EventTypes))
+		for i, t := range req.EventTypes {
+			types[i] = eventd.Type(t)
+		}
+		svc := svcGtr(r.Context())
+		e, err := svc.CreateEndpoint(r.Context(), adminID, &webhookd.Endpoint{
+			ApartmentID: common.IDFromText(req.ApartmentID),
+			URL:         req.URL,
+			EventTypes:  types,
+		})
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			webhookError(w, r, err)
+			return
+		}
+
+		resp := dto.CreateWebhookResponse{
+			Webhook: dto.WebhookDomainToDTO(e),
+			Secret:  e.Secret,
+		}
+		if err = WriteJson(w, http.StatusCreated, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// GetApartmentWebhooks
+//
+// @Summary      List apartment webhooks
+// @Description  Lists the webhooks of an apartment. Only the apartment admin can list them.
+// @Tags         Webhook
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id    path      string  true  "Apartment ID"
+// @Success      200   {object}  dto.WebhooksResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      403   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/apartment/{id}/webhooks [get]
+func GetApartmentWebhooks(svcGtr ServiceGetter[webhookPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetApartmentWebhooks handler"
+
+		apartmentID := r.PathValue("id")
+		if err := common.ValidateID(apartmentID); err != nil {
+			BadRequestError(w, r, "invalid apartment id")
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		es, err := svc.Endpoints(r.Context(), adminID, common.IDFromText(apartmentID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			webhookError(w, r, err)
+			return
+		}
+
+		resp := dto.WebhooksResponse{Webhooks: make([]dto.Webhook, 0, len(es))}
+		for i := range es {
+			resp.Webhooks = append(resp.Webhooks, dto.WebhookDomainToDTO(&es[i]))
+		}
+		if err = WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// DeleteWebhook
+//
+// @Summary      Delete a webhook
+// @Description  Removes a webhook and its delivery log
+// @Tags         Webhook
+// @Security 	 BearerAuth
+// @Param        id    path      string  true  "Webhook ID"
+// @Success      204
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/webhooks/{id} [delete]
+func DeleteWebhook(svcGtr ServiceGetter[webhookPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "DeleteWebhook handler"
+
+		endpointID := r.PathValue("id")
+		if err := common.ValidateID(endpointID); err != nil {
+			BadRequestError(w, r, "invalid webhook id")
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		err := svc.DeleteEndpoint(r.Context(), adminID, common.IDFromText(endpointID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			webhookError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// GetWebhookDeliveries
+//
+// @Summary      List webhook deliveries
+// @Description  Lists the latest deliveries of a webhook, newest first
+// @Tags         Webhook
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id    path      string  true  "Webhook ID"
+// @Success      200   {object}  dto.WebhookDeliveriesResponse
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/webhooks/{id}/deliveries [get]
+func GetWebhookDeliveries(svcGtr ServiceGetter[webhookPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "GetWebhookDeliveries handler"
+
+		endpointID := r.PathValue("id")
+		if err := common.ValidateID(endpointID); err != nil {
+			BadRequestError(w, r, "invalid webhook id")
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		ds, err := svc.Deliveries(r.Context(), adminID, common.IDFromText(endpointID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			webhookError(w, r, err)
+			return
+		}
+
+		resp := dto.WebhookDeliveriesResponse{
+			Deliveries: make([]dto.WebhookDelivery, 0, len(ds)),
+		}
+		for i := range ds {
+			resp.Deliveries = append(resp.Deliveries, dto.WebhookDeliveryDomainToDTO(&ds[i]))
+		}
+		if err = WriteJson(w, http.StatusOK, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+// RedeliverWebhook
+//
+// @Summary      Redeliver a webhook delivery
+// @Description  Sends a finished delivery again with a fresh set of attempts
+// @Tags         Webhook
+// @Produce      json
+// @Security 	 BearerAuth
+// @Param        id          path      string  true  "Webhook ID"
+// @Param        deliveryId  path      string  true  "Delivery ID"
+// @Success      202   {object}  dto.WebhookDelivery
+// @Failure      400   {object}  dto.Error
+// @Failure      404   {object}  dto.Error
+// @Failure      409   {object}  dto.Error
+// @Failure      500   {object}  dto.Error
+// @Router       /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func RedeliverWebhook(svcGtr ServiceGetter[webhookPort.Service]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.Logger(r.Context())
+		logPrefix := "RedeliverWebhook handler"
+
+		endpointID := r.PathValue("id")
+		if err := common.ValidateID(endpointID); err != nil {
+			BadRequestError(w, r, "invalid webhook id")
+			return
+		}
+		deliveryID := r.PathValue("deliveryId")
+		if err := common.ValidateID(deliveryID); err != nil {
+			BadRequestError(w, r, "invalid delivery id")
+			return
+		}
+		adminID, ok := requestUserID(w, r, logPrefix)
+		if !ok {
+			return
+		}
+
+		svc := svcGtr(r.Context())
+		d, err := svc.Redeliver(r.Context(), adminID,
+			common.IDFromText(endpointID), common.IDFromText(deliveryID))
+		if err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			webhookError(w, r, err)
+			return
+		}
+
+		resp := dto.WebhookDeliveryDomainToDTO(d)
+		if err = WriteJson(w, http.StatusAccepted, &resp); err != nil {
+			log.Error(logPrefix, zap.Error(err))
+			InternalServerError(w, r)
+		}
+	})
+}
+
+func webhookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, webhookd.ErrInvalidURL):
+		Error(w, r, http.StatusBadRequest, webhookd.ErrInvalidURL.Error())
+	case errors.Is(err, webhookd.ErrPrivateURL):
+		Error(w, r, http.StatusBadRequest, webhookd.ErrPrivateURL.Error())
+	case errors.Is(err, webhook.ErrUnknownHost):
+		Error(w, r, http.StatusBadRequest, webhook.ErrUnknownHost.Error())
+	case errors.Is(err, webhookd.ErrNoEventTypes):
+		Error(w, r, http.StatusBadRequest, webhookd.ErrNoEventTypes.Error())
+	case errors.Is(err, webhookd.ErrUnknownEventType):
+		Error(w, r, http.StatusBadRequest, webhookd.ErrUnknownEventType.Error())
+	case errors.Is(err, webhook.ErrNotAdmin):
+		Error(w, r, http.StatusForbidden, webhook.ErrNotAdmin.Error())
+	case errors.Is(err, webhook.ErrApartmentNotFound):
+		Error(w, r, http.StatusNotFound, webhook.ErrApartmentNotFound.Error())
+	case errors.Is(err, webhook.ErrEndpointNotFound):
+		Error(w, r, http.StatusNotFound, webhook.ErrEndpointNotFound.Error())
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		Error(w, r, http.StatusNotFound, webhook.ErrDeliveryNotFound.Error())
+	case errors.Is(err, webhook.ErrDeliveryInProgress):
+		Error(w, r, http.StatusConflict, webhook.ErrDeliveryInProgress.Error())
+	default:
+		InternalServerError(w, r)
+	}
+}
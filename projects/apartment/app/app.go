@@ -1,40 +1,81 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment"
 	apartmentPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth"
+	authPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay"
+	autopayPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill"
+	billDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
 	billPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	eventPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger"
+	ledgerDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	ledgerPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification"
+	notificationDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	notificationPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
 	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user"
 	userPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet"
+	walletDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+	walletPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook"
+	webhookDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	webhookPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/email"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/identity"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/paygw"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/storage"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/minio"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/postgres"
 	"go.uber.org/zap"
 )
 
 type app struct {
-	cfg              config.Config
-	logger           *logger.Logger
-	db               *sql.DB
-	userService      userPort.Service
-	apartmentService apartmentPort.Service
-	apartmentMail    apartmentPort.EmailSender
-	billService      billPort.Service
-	paymentService   paymentp.Service
-	paymentGateways  map[paymentd.GatewayType]paymentp.Gateway
+	cfg                 config.Config
+	logger              *logger.Logger
+	db                  *sql.DB
+	jwtKeys             *appjwt.KeySet
+	userService         userPort.Service
+	authService         authPort.Service
+	apartmentService    apartmentPort.Service
+	apartmentMail       apartmentPort.EmailSender
+	billService         billPort.Service
+	paymentService      paymentp.Service
+	paymentGateways     map[paymentd.GatewayType]paymentp.Gateway
+	gatewayInfo         []paymentd.GatewayInfo
+	reconciler          paymentp.Reconciler
+	walletService       walletPort.Service
+	ledgerService       ledgerPort.Service
+	autopayService      autopayPort.Service
+	autopayScheduler    autopayPort.Scheduler
+	eventDispatcher     eventPort.Dispatcher
+	webhookService      webhookPort.Service
+	webhookDeliverer    webhookPort.Deliverer
+	overdueScanner      billPort.OverdueScanner
+	notificationService notificationPort.Service
 }
 
 func MustNew(ctx context.Context, cfg config.Config) App {
@@ -73,6 +114,9 @@ func New(ctx context.Context, cfg config.Config) (App, error) {
 	if err = app.setupPaymentGateways(); err != nil {
 		return nil, err
 	}
+	if err = app.setupJWTKeys(); err != nil {
+		return nil, err
+	}
 	return app, nil
 }
 
@@ -87,6 +131,10 @@ func (a *app) DB() *sql.DB {
 	return a.db
 }
 
+func (a *app) JWTKeys() *appjwt.KeySet {
+	return a.jwtKeys
+}
+
 func (a *app) UserService(ctx context.Context) userPort.Service {
 	if a.userService == nil {
 		a.userService = user.NewService(storage.NewUserRepo(a.db))
@@ -94,11 +142,62 @@ func (a *app) UserService(ctx context.Context) userPort.Service {
 	return a.userService
 }
 
+func (a *app) AuthService() authPort.Service {
+	if a.authService == nil {
+		cfg := a.cfg.Auth
+		a.authService = auth.NewService(
+			storage.NewAuthRepo(a.db),
+			a.jwtKeys,
+			auth.WithAccessExpiry(time.Minute*time.Duration(cfg.AccessExpiry)),
+			auth.WithRefreshExpiry(time.Minute*time.Duration(cfg.RefreshExpiry)),
+			auth.WithResetExpiry(time.Minute*time.Duration(cfg.ResetExpiry)),
+			auth.WithVerifyExpiry(time.Minute*time.Duration(cfg.VerifyExpiry)),
+			auth.WithResendInterval(time.Second*time.Duration(cfg.VerifyResendInterval)),
+			auth.WithMFAChallengeExpiry(time.Second*time.Duration(cfg.MFAChallengeExpiry)),
+			auth.WithTOTPIssuer(cfg.TOTPIssuer),
+			auth.WithAttemptStore(a.attemptStore()),
+			auth.WithMaxLoginAttempts(cfg.MaxLoginAttempts),
+			auth.WithMaxIPLoginAttempts(cfg.MaxIPLoginAttempts),
+			auth.WithLoginLockout(time.Minute*time.Duration(cfg.LoginLockout)),
+			auth.WithOIDCProvider(a.oidcProvider()),
+			auth.WithMailer(a.apartmentMailService()),
+		)
+	}
+	return a.authService
+}
+
+// oidcTimeout bounds each request to the identity provider.
+const oidcTimeout = 10 * time.Second
+
+// oidcProvider returns the identity provider users can sign in with, or
+// nil if none is configured.
+func (a *app) oidcProvider() authPort.OIDCProvider {
+	cfg := a.cfg.Auth
+	if cfg.OIDCIssuer == "" {
+		return nil
+	}
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cmp.Or(cfg.OIDCRedirectURL, a.cfg.BaseURL+"/api/v1/auth/oidc/callback"),
+	}, &http.Client{Timeout: oidcTimeout})
+	return identity.NewOIDCProvider(client)
+}
+
+// attemptStore returns where failed sign-ins are counted. Memory only
+// works while a single node serves sign-ins.
+func (a *app) attemptStore() authPort.AttemptStore {
+	if a.cfg.Auth.AttemptStore == "database" {
+		return storage.NewLoginAttemptRepo(a.db)
+	}
+	return storage.NewMemoryAttemptStore()
+}
+
 func (a *app) ApartmentService(ctx context.Context) apartmentPort.Service {
 	if a.apartmentService == nil {
 		a.apartmentService = apartment.NewService(
 			storage.NewApartmentRepo(a.db),
-			a.apartmentMailService(),
 		)
 	}
 	return a.apartmentService
@@ -111,6 +210,61 @@ func (a *app) apartmentMailService() apartmentPort.EmailSender {
 	return a.apartmentMail
 }
 
+// setupJWTKeys loads the keys tokens are signed and verified with. The
+// secret signs when there is no signing key, as before keys, and keeps
+// verifying its tokens after one is set.
+func (a *app) setupJWTKeys() error {
+	cfg := a.cfg.Auth
+	var verify []*appjwt.Key
+	for _, file := range cfg.JWTVerifyKeys {
+		k, err := readJWTKey(file)
+		if err != nil {
+			return err
+		}
+		verify = append(verify, k)
+	}
+
+	var signing *appjwt.Key
+	switch {
+	case cfg.JWTSigningKey != "":
+		k, err := readJWTKey(cfg.JWTSigningKey)
+		if err != nil {
+			return err
+		}
+		signing = k
+		if cfg.JWTSecret != "" {
+			verify = append(verify, appjwt.NewHMACKey([]byte(cfg.JWTSecret)))
+		}
+	case cfg.JWTSecret != "":
+		signing = appjwt.NewHMACKey([]byte(cfg.JWTSecret))
+	default:
+		return errors.New("jwt: set AUTH_JWT_SIGNING_KEY or AUTH_JWT_SECRET")
+	}
+
+	keys, err := appjwt.NewKeySet(signing,
+		appjwt.WithVerifyKeys(verify...),
+		appjwt.WithIssuer(cfg.JWTIssuer),
+		appjwt.WithAudience(cfg.JWTAudience),
+	)
+	if err != nil {
+		return fmt.Errorf("jwt: %w", err)
+	}
+	a.jwtKeys = keys
+	return nil
+}
+
+func readJWTKey(file string) (*appjwt.Key, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key: %w", err)
+	}
+	k, err := appjwt.ParseKeyPEM(b)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", file, err)
+	}
+	return k, nil
+}
+
 func checkMinio(cfg config.MinioConfig) error {
 	return minio.Ping(
 		cfg.Endpoint,
@@ -135,6 +289,17 @@ func (a *app) billObjectStorage() (billPort.ObjectStorage, error) {
 	return bos, err
 }
 
+func (a *app) paymentProofStorage() (paymentp.ObjectStorage, error) {
+	c, err := minio.NewClient(
+		a.cfg.Minio.Endpoint,
+		a.cfg.Minio.AccessKey,
+		a.cfg.Minio.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewPaymentProofStorage(c)
+}
+
 func (a *app) BillService() billPort.Service {
 	bos, err := a.billObjectStorage()
 	if err != nil {
@@ -145,28 +310,272 @@ func (a *app) BillService() billPort.Service {
 		a.billService = bill.NewService(
 			storage.NewBillRepo(a.db),
 			bos,
+			// charges must be posted before wallet credit can pay them
+			bill.WithOnBillCreated(a.postBillCharges),
+			bill.WithOnBillCreated(a.applyWalletCredit),
 		)
 	}
 	return a.billService
 }
 
+// defaultGateways is used when no payment gateway is configured.
+var defaultGateways = []config.GatewayConfig{{
+	Name:        paymentd.MockGateway,
+	Enabled:     true,
+	DisplayName: "Mock Gateway",
+}}
+
 func (a *app) setupPaymentGateways() error {
+	cfgs := a.cfg.Payment.Gateways
+	if len(cfgs) == 0 {
+		cfgs = defaultGateways
+	}
 	gateways := make(map[paymentd.GatewayType]paymentp.Gateway)
-	mockGateway, err := paygw.NewMockGateway(a.cfg.BaseURL)
-	if err != nil {
-		return err
+	for _, gc := range cfgs {
+		if !gc.Enabled {
+			continue
+		}
+		gateway, err := a.newPaymentGateway(gc)
+		if err != nil {
+			return fmt.Errorf("payment gateway %q: %w", gc.Name, err)
+		}
+		gt := paymentd.GatewayType(gc.Name)
+		gateways[gt] = gateway
+		a.gatewayInfo = append(a.gatewayInfo, paymentd.GatewayInfo{
+			Type:        gt,
+			DisplayName: cmp.Or(gc.DisplayName, gc.Name),
+			Priority:    gc.Priority,
+			MinAmount:   gc.MinAmount,
+			MaxAmount:   gc.MaxAmount,
+		})
 	}
-	gateways[paymentd.MockGateway] = mockGateway
 	a.paymentGateways = gateways
 	return nil
 }
 
+func (a *app) newPaymentGateway(gc config.GatewayConfig) (paymentp.Gateway, error) {
+	switch gc.Name {
+	case paymentd.MockGateway:
+		return paygw.NewMockGateway(cmp.Or(gc.BaseURL, a.cfg.BaseURL))
+	default:
+		return nil, payment.ErrUnknownGateway
+	}
+}
+
 func (a *app) PaymentService() paymentp.Service {
 	if a.paymentService != nil {
 		return a.paymentService
 	}
 	repo := storage.NewPaymentRepo(a.db)
 	gateways := a.paymentGateways
-	a.paymentService = payment.NewService(repo, gateways)
+	proofs, err := a.paymentProofStorage()
+	if err != nil {
+		// offline payment claims are refused without proof storage
+		a.logger.Error("payment proof storage", zap.Error(err))
+	}
+	a.paymentService = payment.NewService(repo, gateways,
+		payment.WithProofStorage(proofs),
+		payment.WithIdempotencyKeyTTL(time.Minute*time.Duration(a.cfg.Payment.IdempotencyKeyTTL)),
+		payment.WithMinPaymentAmount(a.cfg.Payment.MinAmount),
+		payment.WithAllocationRule(paymentd.AllocationRule(a.cfg.Payment.AllocationRule)),
+		payment.WithGatewayInfo(a.gatewayInfo...),
+		payment.WithCircuitBreaker(a.cfg.Payment.GatewayFailureThreshold,
+			time.Second*time.Duration(a.cfg.Payment.GatewayCooldown)),
+		payment.WithReceiptMailer(a.apartmentMailService()),
+		payment.WithOnPaymentPaid(a.postPayments),
+		payment.WithOnPaymentPaid(a.sendReceipt),
+	)
 	return a.paymentService
 }
+
+func (a *app) PaymentReconciler() paymentp.Reconciler {
+	if a.reconciler != nil {
+		return a.reconciler
+	}
+	cfg := a.cfg.Payment
+	a.reconciler = payment.NewReconciler(
+		storage.NewPaymentRepo(a.db),
+		a.paymentGateways,
+		payment.WithReconcileInterval(time.Minute*time.Duration(cfg.ReconcileInterval)),
+		payment.WithReconcileThreshold(time.Minute*time.Duration(cfg.ReconcileThreshold)),
+		payment.WithReconcilerOnPaid(a.postPayments),
+		payment.WithReconcilerOnPaid(a.sendReceipt),
+	)
+	return a.reconciler
+}
+
+func (a *app) WalletService() walletPort.Service {
+	if a.walletService == nil {
+		a.walletService = wallet.NewService(
+			storage.NewWalletRepo(a.db),
+			a.paymentGateways,
+			wallet.WithOnPaymentPaid(a.postPayment),
+			wallet.WithOnPaymentPaid(func(ctx context.Context, p *paymentd.Payment) {
+				a.sendReceipt(ctx, []*paymentd.Payment{p})
+			}),
+			wallet.WithOnTopUpCompleted(a.postWalletTopUp),
+		)
+	}
+	return a.walletService
+}
+
+func (a *app) AutopayService() autopayPort.Service {
+	if a.autopayService == nil {
+		a.autopayService = autopay.NewService(
+			storage.NewAutopayRepo(a.db),
+			a.paymentGateways,
+		)
+	}
+	return a.autopayService
+}
+
+func (a *app) AutopayScheduler() autopayPort.Scheduler {
+	if a.autopayScheduler != nil {
+		return a.autopayScheduler
+	}
+	cfg := a.cfg.Autopay
+	a.autopayScheduler = autopay.NewScheduler(
+		storage.NewAutopayRepo(a.db),
+		a.paymentGateways,
+		autopay.WithInterval(time.Minute*time.Duration(cfg.Interval)),
+		autopay.WithRetryInterval(time.Minute*time.Duration(cfg.RetryInterval)),
+		autopay.WithMaxFailures(cfg.MaxFailures),
+		autopay.WithFailureMailer(a.apartmentMailService()),
+		autopay.WithOnPaymentPaid(a.postPayments),
+		autopay.WithOnPaymentPaid(a.sendReceipt),
+	)
+	return a.autopayScheduler
+}
+
+func (a *app) EventDispatcher() eventPort.Dispatcher {
+	if a.eventDispatcher != nil {
+		return a.eventDispatcher
+	}
+	cfg := a.cfg.Outbox
+	d := event.NewDispatcher(
+		storage.NewOutboxRepo(a.db),
+		event.WithInterval(time.Second*time.Duration(cfg.Interval)),
+		event.WithMaxAttempts(cfg.MaxAttempts),
+		event.WithBackoff(time.Second*time.Duration(cfg.Backoff)),
+	)
+	d.Subscribe(eventd.InviteCreated, "invite-mailer", apartment.InviteMailer(a.apartmentMailService()))
+	post := ledger.Poster(a.LedgerService())
+	for _, t := range ledger.EventTypes {
+		d.Subscribe(t, "ledger", post)
+	}
+	for _, t := range webhookDomain.EventTypes {
+		d.Subscribe(t, "webhook", a.WebhookService().Enqueue)
+	}
+	for _, t := range notificationDomain.EventTypes {
+		d.Subscribe(t, "notification", a.NotificationService().Publish)
+	}
+	a.eventDispatcher = d
+	return a.eventDispatcher
+}
+
+func (a *app) WebhookService() webhookPort.Service {
+	if a.webhookService == nil {
+		a.webhookService = webhook.NewService(storage.NewWebhookRepo(a.db))
+	}
+	return a.webhookService
+}
+
+func (a *app) WebhookDeliverer() webhookPort.Deliverer {
+	if a.webhookDeliverer != nil {
+		return a.webhookDeliverer
+	}
+	cfg := a.cfg.Webhook
+	a.webhookDeliverer = webhook.NewDeliverer(
+		storage.NewWebhookRepo(a.db),
+		webhook.WithDeliveryInterval(time.Second*time.Duration(cfg.Interval)),
+		webhook.WithMaxAttempts(cfg.MaxAttempts),
+		webhook.WithBackoff(time.Second*time.Duration(cfg.Backoff)),
+		webhook.WithTimeout(time.Second*time.Duration(cfg.Timeout)),
+	)
+	return a.webhookDeliverer
+}
+
+func (a *app) NotificationService() notificationPort.Service {
+	if a.notificationService == nil {
+		a.notificationService = notification.NewService(
+			storage.NewNotificationRepo(a.db),
+			notification.NewHub(notification.WithReplaySize(a.cfg.Notification.ReplaySize)),
+		)
+	}
+	return a.notificationService
+}
+
+func (a *app) BillOverdueScanner() billPort.OverdueScanner {
+	if a.overdueScanner == nil {
+		a.overdueScanner = bill.NewOverdueScanner(
+			storage.NewBillOverdueRepo(a.db),
+			bill.WithOverdueInterval(time.Minute*time.Duration(a.cfg.Webhook.OverdueInterval)),
+		)
+	}
+	return a.overdueScanner
+}
+
+// applyWalletCredit pays a new bill from the wallets of its apartment.
+func (a *app) applyWalletCredit(ctx context.Context, b *billDomain.Bill) {
+	if err := a.WalletService().ApplyCredit(ctx, b.ID); err != nil {
+		appctx.Logger(ctx).Error("apply wallet credit", zap.Error(err))
+	}
+}
+
+func (a *app) LedgerService() ledgerPort.Service {
+	if a.ledgerService == nil {
+		a.ledgerService = ledger.NewService(storage.NewLedgerRepo(a.db))
+	}
+	return a.ledgerService
+}
+
+// postBillCharges posts a new bill to the ledger right away, so wallet
+// credit can pay it. An entry that fails to post here is posted by
+// ledger.Poster from the outbox.
+func (a *app) postBillCharges(ctx context.Context, b *billDomain.Bill) {
+	if err := a.LedgerService().PostBillCharges(ctx, b.ID); err != nil {
+		appctx.Logger(ctx).Error("post bill charges", zap.Error(err),
+			zap.String("billId", b.ID.String()))
+	}
+}
+
+// postPayment posts a paid payment or refund to the ledger right away. An
+// entry that fails to post here is posted by ledger.Poster from the outbox.
+func (a *app) postPayment(ctx context.Context, p *paymentd.Payment) {
+	if err := a.LedgerService().PostPayment(ctx, p); err != nil {
+		appctx.Logger(ctx).Error("post payment", zap.Error(err),
+			zap.String("paymentId", p.ID.String()))
+	}
+}
+
+func (a *app) postPayments(ctx context.Context, ps []*paymentd.Payment) {
+	for _, p := range ps {
+		a.postPayment(ctx, p)
+	}
+}
+
+// sendReceipt emails the payer one receipt for payments settled together.
+func (a *app) sendReceipt(ctx context.Context, ps []*paymentd.Payment) {
+	if len(ps) == 0 || ps[0].IsRefund() {
+		return
+	}
+	if err := a.PaymentService().SendReceipt(ctx, ps[0].ID); err != nil {
+		appctx.Logger(ctx).Error("send receipt", zap.Error(err),
+			zap.String("paymentId", ps[0].ID.String()))
+	}
+}
+
+// postWalletTopUp posts a completed top-up to the ledger right away. An
+// entry that fails to post here is posted by ledger.Poster from the outbox.
+func (a *app) postWalletTopUp(ctx context.Context, t *walletDomain.Transaction, w *walletDomain.Wallet) {
+	err := a.LedgerService().PostWalletTopUp(ctx, ledgerDomain.WalletTopUp{
+		Reference:   t.ID,
+		UserID:      w.UserID,
+		ApartmentID: w.ApartmentID,
+		Amount:      t.Amount,
+	})
+	if err != nil {
+		appctx.Logger(ctx).Error("post wallet top-up", zap.Error(err),
+			zap.String("walletTransactionId", t.ID.String()))
+	}
+}
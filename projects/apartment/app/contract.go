@@ -6,9 +6,17 @@ import (
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
 	apartment "github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/port"
+	auth "github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	autopay "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
 	bill "github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/port"
+	event "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	ledger "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	notification "github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
 	user "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
+	wallet "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	webhook "github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
 )
 
@@ -16,8 +24,20 @@ type App interface {
 	Config() config.Config
 	Logger() *logger.Logger
 	DB() *sql.DB
+	JWTKeys() *appjwt.KeySet
 	UserService(ctx context.Context) user.Service
+	AuthService() auth.Service
 	ApartmentService(ctx context.Context) apartment.Service
 	BillService() bill.Service
 	PaymentService() paymentp.Service
+	PaymentReconciler() paymentp.Reconciler
+	WalletService() wallet.Service
+	LedgerService() ledger.Service
+	AutopayService() autopay.Service
+	AutopayScheduler() autopay.Scheduler
+	EventDispatcher() event.Dispatcher
+	WebhookService() webhook.Service
+	WebhookDeliverer() webhook.Deliverer
+	BillOverdueScanner() bill.OverdueScanner
+	NotificationService() notification.Service
 }
@@ -0,0 +1,88 @@
+// Command ledger-check verifies the ledger against the bill, payment and
+// wallet rows it was posted from. It exits with status 1 if they disagree.
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/config"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/storage"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/postgres"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+var (
+	envfile = flag.String("env-file", "", "environment file path.")
+	fix     = flag.Bool("fix", false, "post the entries missing for bills, payments and top-ups first.")
+)
+
+func main() {
+	flag.Parse()
+
+	if v := os.Getenv("ENV_FILE"); len(v) > 0 {
+		*envfile = v
+	}
+	if len(*envfile) > 0 {
+		if err := godotenv.Load(*envfile); err != nil {
+			panic("failed to load env file: " + err.Error())
+		}
+	}
+
+	cfg := config.MustReadEnv()
+
+	log := logger.NewZapLogger(logger.ModeDevelopment)
+	defer log.Sync()
+
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+
+	db, err := postgres.NewPSQLConn(postgres.DBConnOptions{
+		User:    cfg.DB.User,
+		Pass:    cfg.DB.Password,
+		Host:    cfg.DB.Host,
+		Port:    cfg.DB.Port,
+		DBName:  cfg.DB.DBName,
+		Schema:  cfg.DB.Schema,
+		AppName: cfg.DB.AppName,
+	})
+	if err != nil {
+		log.Fatal("connect to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	svc := ledger.NewService(storage.NewLedgerRepo(db))
+	report, err := svc.Check(ctx, *fix)
+	if err != nil {
+		log.Fatal("ledger check", zap.Error(err))
+	}
+
+	if report.Posted > 0 {
+		log.Info("posted missing entries", zap.Int("count", report.Posted))
+	}
+	for _, id := range report.UnbalancedEntries {
+		log.Error("unbalanced entry", zap.String("entryId", id.String()))
+	}
+	for _, m := range report.BillMismatches {
+		log.Error("bill balance mismatch",
+			zap.String("userId", m.UserID.String()),
+			zap.String("billId", m.BillID.String()),
+			zap.Int64("expected", m.Expected),
+			zap.Int64("ledger", m.Actual))
+	}
+	for _, m := range report.WalletMismatches {
+		log.Error("wallet balance mismatch",
+			zap.String("walletId", m.WalletID.String()),
+			zap.Int64("expected", m.Expected),
+			zap.Int64("ledger", m.Actual))
+	}
+	if !report.OK() {
+		log.Sync()
+		os.Exit(1)
+	}
+	log.Info("ledger is consistent")
+}
@@ -39,6 +39,21 @@ func main() {
 	ctx := appctx.New(context.Background(), appctx.WithLogger(appLogger))
 
 	appContainer := app.MustNew(ctx, cfg)
+
+	// post the ledger entries of rows written before the ledger existed or
+	// whose posting failed, before balances are read from it
+	if posted, err := appContainer.LedgerService().PostMissing(ctx); err != nil {
+		appLogger.Error("post missing ledger entries", zap.Error(err), zap.Int("posted", posted))
+	} else if posted > 0 {
+		appLogger.Info("posted missing ledger entries", zap.Int("posted", posted))
+	}
+
+	go appContainer.PaymentReconciler().Run(ctx)
+	go appContainer.AutopayScheduler().Run(ctx)
+	go appContainer.EventDispatcher().Run(ctx)
+	go appContainer.WebhookDeliverer().Run(ctx)
+	go appContainer.BillOverdueScanner().Run(ctx)
+
 	appLogger.Info("Application started")
 	appLogger.Fatal("", zap.Error(handler.Run(appContainer)))
 }
@@ -1,14 +1,19 @@
 package config
 
 type Config struct {
-	AppMode AppModeType  `json:"appMode" env:"APP_MODE"`
-	DB      DBConfig     `json:"db"`
-	HTTP    HTTPConfig   `json:"http"`
-	Auth    AuthConfig   `json:"auth"`
-	SMTP    SMTPConfig   `json:"smtp"`
-	Minio   MinioConfig  `json:"minio"`
-	BaseURL string       `json:"baseURL" env:"BASE_URL"`
-	Smaila  SmailaConfig `json:"smaila"`
+	AppMode      AppModeType        `json:"appMode" env:"APP_MODE"`
+	DB           DBConfig           `json:"db"`
+	HTTP         HTTPConfig         `json:"http"`
+	Auth         AuthConfig         `json:"auth"`
+	SMTP         SMTPConfig         `json:"smtp"`
+	Minio        MinioConfig        `json:"minio"`
+	BaseURL      string             `json:"baseURL" env:"BASE_URL"`
+	Smaila       SmailaConfig       `json:"smaila"`
+	Payment      PaymentConfig      `json:"payment"`
+	Autopay      AutopayConfig      `json:"autopay"`
+	Outbox       OutboxConfig       `json:"outbox"`
+	Webhook      WebhookConfig      `json:"webhook"`
+	Notification NotificationConfig `json:"notification"`
 }
 
 type AppModeType string
@@ -30,12 +35,68 @@ type DBConfig struct {
 
 type HTTPConfig struct {
 	Port uint `json:"port" env:"HTTP_PORT"`
+	// TrustProxy takes the client address from the last X-Forwarded-For
+	// entry. Set it only behind a reverse proxy that appends one.
+	TrustProxy bool `json:"trustProxy" env:"HTTP_TRUST_PROXY"`
 }
 
 type AuthConfig struct {
-	JWTSecret     string `json:"jwtSecret" env:"AUTH_JWT_SECRET"`
+	// JWTSecret signs tokens with HS512 when no JWTSigningKey is set. With
+	// one, it only keeps accepting the tokens it signed until they expire.
+	JWTSecret string `json:"jwtSecret" env:"AUTH_JWT_SECRET"`
+	// JWTSigningKey is the file of the PEM private key, RSA or Ed25519,
+	// tokens are signed with. Its public key is served at
+	// /.well-known/jwks.json.
+	JWTSigningKey string `json:"jwtSigningKey" env:"AUTH_JWT_SIGNING_KEY"`
+	// JWTVerifyKeys are the files of PEM keys tokens are accepted from but
+	// not signed with: the next key, before it signs, and the previous one
+	// until its tokens expire.
+	JWTVerifyKeys []string `json:"jwtVerifyKeys" env:"AUTH_JWT_VERIFY_KEYS"`
+	// JWTIssuer and JWTAudience are set on tokens, and tokens without them
+	// are rejected.
+	JWTIssuer     string `json:"jwtIssuer" env:"AUTH_JWT_ISSUER"`
+	JWTAudience   string `json:"jwtAudience" env:"AUTH_JWT_AUDIENCE"`
 	AccessExpiry  int64  `json:"accessExpiry" env:"AUTH_ACCESS_EXPIRY"`
 	RefreshExpiry int64  `json:"refreshExpiry" env:"AUTH_REFRESH_EXPIRY"`
+	// ResetExpiry is how long, in minutes, a password reset link works.
+	ResetExpiry int64 `json:"resetExpiry" env:"AUTH_RESET_EXPIRY"`
+	// ResetURL is the page that asks for the new password; the reset token
+	// is appended as the token query parameter. Defaults to
+	// BASE_URL/reset-password.
+	ResetURL string `json:"resetURL" env:"AUTH_RESET_URL"`
+	// VerifyExpiry is how long, in minutes, an email verification link
+	// works.
+	VerifyExpiry int64 `json:"verifyExpiry" env:"AUTH_VERIFY_EXPIRY"`
+	// VerifyResendInterval is how long, in seconds, a user waits before
+	// another verification email is sent.
+	VerifyResendInterval int64 `json:"verifyResendInterval" env:"AUTH_VERIFY_RESEND_INTERVAL"`
+	// RequireVerifiedEmail blocks paying, inviting and creating apartments
+	// until the user verifies their email.
+	RequireVerifiedEmail bool `json:"requireVerifiedEmail" env:"AUTH_REQUIRE_VERIFIED_EMAIL"`
+	// MFAChallengeExpiry is how long, in seconds, a user has to enter their
+	// two-factor code after their password.
+	MFAChallengeExpiry int64 `json:"mfaChallengeExpiry" env:"AUTH_MFA_CHALLENGE_EXPIRY"`
+	// TOTPIssuer names the account in authenticator apps.
+	TOTPIssuer string `json:"totpIssuer" env:"AUTH_TOTP_ISSUER"`
+	// AttemptStore is where failed sign-ins are counted: memory for a
+	// single node, or database when several nodes serve sign-ins.
+	AttemptStore string `json:"attemptStore" env:"AUTH_ATTEMPT_STORE"`
+	// MaxLoginAttempts is how many failed sign-ins lock an account.
+	MaxLoginAttempts int `json:"maxLoginAttempts" env:"AUTH_MAX_LOGIN_ATTEMPTS"`
+	// MaxIPLoginAttempts is how many failed sign-ins, of any accounts, lock
+	// an IP address.
+	MaxIPLoginAttempts int `json:"maxIPLoginAttempts" env:"AUTH_MAX_IP_LOGIN_ATTEMPTS"`
+	// LoginLockout is how long, in minutes, a locked account or IP address
+	// can't sign in.
+	LoginLockout int64 `json:"loginLockout" env:"AUTH_LOGIN_LOCKOUT"`
+	// OIDCIssuer is the OpenID Connect provider users can sign in with,
+	// e.g. https://accounts.google.com. Empty turns it off.
+	OIDCIssuer       string `json:"oidcIssuer" env:"AUTH_OIDC_ISSUER"`
+	OIDCClientID     string `json:"oidcClientID" env:"AUTH_OIDC_CLIENT_ID"`
+	OIDCClientSecret string `json:"oidcClientSecret" env:"AUTH_OIDC_CLIENT_SECRET"`
+	// OIDCRedirectURL is registered at the provider. Defaults to
+	// BASE_URL/api/v1/auth/oidc/callback.
+	OIDCRedirectURL string `json:"oidcRedirectURL" env:"AUTH_OIDC_REDIRECT_URL"`
 }
 
 type SMTPConfig struct {
@@ -55,3 +116,92 @@ type MinioConfig struct {
 type SmailaConfig struct {
 	Endpoint string `json:"endpoint" env:"SMAILA_ENDPOINT"`
 }
+
+type PaymentConfig struct {
+	// IdempotencyKeyTTL is how long, in minutes, a payment idempotency key is kept.
+	IdempotencyKeyTTL int64 `json:"idempotencyKeyTTL" env:"PAYMENT_IDEMPOTENCY_KEY_TTL"`
+	// ReconcileInterval is how often, in minutes, pending payments are reconciled.
+	ReconcileInterval int64 `json:"reconcileInterval" env:"PAYMENT_RECONCILE_INTERVAL"`
+	// ReconcileThreshold is the age, in minutes, after which a pending payment
+	// is inquired from its gateway and expired if unpaid.
+	ReconcileThreshold int64 `json:"reconcileThreshold" env:"PAYMENT_RECONCILE_THRESHOLD"`
+	// MinAmount is the smallest partial payment accepted.
+	MinAmount int64 `json:"minAmount" env:"PAYMENT_MIN_AMOUNT"`
+	// AllocationRule splits a partial total debt payment between bills,
+	// "oldest-first" or "pro-rata".
+	AllocationRule string `json:"allocationRule" env:"PAYMENT_ALLOCATION_RULE"`
+	// Gateways are the payment gateways offered, only the mock gateway if
+	// empty. From the environment they are read as PAYMENT_GATEWAYS_0_NAME,
+	// PAYMENT_GATEWAYS_0_ENABLED and so on.
+	Gateways []GatewayConfig `json:"gateways" envPrefix:"PAYMENT_GATEWAYS"`
+	// GatewayFailureThreshold is how many transactions in a row may fail on
+	// a gateway before it is taken out of service.
+	GatewayFailureThreshold int `json:"gatewayFailureThreshold" env:"PAYMENT_GATEWAY_FAILURE_THRESHOLD"`
+	// GatewayCooldown is how long, in seconds, a failing gateway stays out
+	// of service.
+	GatewayCooldown int64 `json:"gatewayCooldown" env:"PAYMENT_GATEWAY_COOLDOWN"`
+}
+
+type GatewayConfig struct {
+	// Name is the gateway type, e.g. "mock-gateway".
+	Name        string `json:"name" env:"NAME"`
+	Enabled     bool   `json:"enabled" env:"ENABLED"`
+	DisplayName string `json:"displayName" env:"DISPLAY_NAME"`
+	// Priority orders the gateways, lower first.
+	Priority int `json:"priority" env:"PRIORITY"`
+	// MinAmount and MaxAmount bound one transaction, zero means no bound.
+	MinAmount int64 `json:"minAmount" env:"MIN_AMOUNT"`
+	MaxAmount int64 `json:"maxAmount" env:"MAX_AMOUNT"`
+	// BaseURL is the gateway endpoint, the app base URL for the mock gateway
+	// if empty.
+	BaseURL    string `json:"baseURL" env:"BASE_URL"`
+	MerchantID string `json:"merchantID" env:"MERCHANT_ID"`
+	APIKey     string `json:"apiKey" env:"API_KEY"`
+}
+
+type AutopayConfig struct {
+	// Interval is how often, in minutes, due bills are charged.
+	Interval int64 `json:"interval" env:"AUTOPAY_INTERVAL"`
+	// RetryInterval is how long, in minutes, to wait before charging an
+	// enrollment again after a failed charge.
+	RetryInterval int64 `json:"retryInterval" env:"AUTOPAY_RETRY_INTERVAL"`
+	// MaxFailures is how many charges may fail in a row before autopay is
+	// turned off for the enrollment.
+	MaxFailures int `json:"maxFailures" env:"AUTOPAY_MAX_FAILURES"`
+}
+
+type OutboxConfig struct {
+	// Interval is how often, in seconds, pending events are dispatched.
+	Interval int64 `json:"interval" env:"OUTBOX_INTERVAL"`
+	// MaxAttempts is how many times delivering an event may fail before
+	// it is given up on.
+	MaxAttempts int `json:"maxAttempts" env:"OUTBOX_MAX_ATTEMPTS"`
+	// Backoff is the delay, in seconds, before the first retry of an event.
+	// It doubles with every further attempt.
+	Backoff int64 `json:"backoff" env:"OUTBOX_BACKOFF"`
+}
+
+type WebhookConfig struct {
+	// Interval is how often, in seconds, pending deliveries are sent.
+	Interval int64 `json:"interval" env:"WEBHOOK_INTERVAL"`
+	// MaxAttempts is how many times a delivery may fail before it is given
+	// up on.
+	MaxAttempts int `json:"maxAttempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+	// Backoff is the delay, in seconds, before the first retry of a
+	// delivery. It doubles with every further attempt.
+	Backoff int64 `json:"backoff" env:"WEBHOOK_BACKOFF"`
+	// Timeout is how long, in seconds, an endpoint has to respond.
+	Timeout int64 `json:"timeout" env:"WEBHOOK_TIMEOUT"`
+	// OverdueInterval is how often, in minutes, bills are checked for
+	// being overdue.
+	OverdueInterval int64 `json:"overdueInterval" env:"WEBHOOK_OVERDUE_INTERVAL"`
+}
+
+type NotificationConfig struct {
+	// ReplaySize is how many notifications of a user are kept for clients
+	// reconnecting with Last-Event-ID.
+	ReplaySize int `json:"replaySize" env:"NOTIFICATION_REPLAY_SIZE"`
+	// Heartbeat is how often, in seconds, idle streams are pinged to keep
+	// proxies from closing them.
+	Heartbeat int64 `json:"heartbeat" env:"NOTIFICATION_HEARTBEAT"`
+}
@@ -19,8 +19,19 @@ DB_PASSWORD="postgres"
 DB_APP_NAME="apartment-api"
 
 AUTH_JWT_SECRET="I am the secret skyler"
+AUTH_JWT_SIGNING_KEY=""
+AUTH_JWT_VERIFY_KEYS=""
+AUTH_JWT_ISSUER=""
+AUTH_JWT_AUDIENCE=""
 AUTH_ACCESS_EXPIRY="1440"
 AUTH_REFRESH_EXPIRY="14400"
+AUTH_RESET_EXPIRY="30"
+AUTH_REQUIRE_VERIFIED_EMAIL="false"
+AUTH_TOTP_ISSUER="Apartment"
+AUTH_ATTEMPT_STORE="memory"
+AUTH_OIDC_ISSUER=""
+AUTH_OIDC_CLIENT_ID=""
+AUTH_OIDC_CLIENT_SECRET=""
 
 MINIO_ENDPOINT="apartment-minio:9000"
 MINIO_ACCESS_KEY="minioadmin"
@@ -28,6 +39,12 @@ MINIO_SECRET_KEY="minioadmin"
 
 SMAILA_ENDPOINT="http://apartment-smaila:1174"
 
+PAYMENT_IDEMPOTENCY_KEY_TTL="1440"
+PAYMENT_RECONCILE_INTERVAL="5"
+PAYMENT_RECONCILE_THRESHOLD="30"
+PAYMENT_MIN_AMOUNT="10000"
+PAYMENT_ALLOCATION_RULE="oldest-first"
+
 # smaila config
 SMAILA_HTTP_PORT="1174"
 SMTP_HOST="smtp.gmail.com"
@@ -88,9 +105,25 @@ if [[ "$MODE" == "interactive" ]]; then
     prompt_with_default "DB app name" "$DB_APP_NAME" DB_APP_NAME
 
     # Auth config
-    prompt_password "JWT secret (required)" AUTH_JWT_SECRET
+    prompt_with_default "JWT signing key PEM file (empty to sign with the secret)" "$AUTH_JWT_SIGNING_KEY" AUTH_JWT_SIGNING_KEY
+    if [ -z "$AUTH_JWT_SIGNING_KEY" ]; then
+        prompt_password "JWT secret (required)" AUTH_JWT_SECRET
+    else
+        prompt_with_default "Previous JWT key PEM files, comma separated" "$AUTH_JWT_VERIFY_KEYS" AUTH_JWT_VERIFY_KEYS
+    fi
+    prompt_with_default "JWT issuer (empty for none)" "$AUTH_JWT_ISSUER" AUTH_JWT_ISSUER
+    prompt_with_default "JWT audience (empty for none)" "$AUTH_JWT_AUDIENCE" AUTH_JWT_AUDIENCE
     prompt_with_default "Access expiry minutes" "$AUTH_ACCESS_EXPIRY" AUTH_ACCESS_EXPIRY
     prompt_with_default "Refresh expiry minutes" "$AUTH_REFRESH_EXPIRY" AUTH_REFRESH_EXPIRY
+    prompt_with_default "Password reset link expiry minutes" "$AUTH_RESET_EXPIRY" AUTH_RESET_EXPIRY
+    prompt_with_default "Require verified email (true/false)" "$AUTH_REQUIRE_VERIFIED_EMAIL" AUTH_REQUIRE_VERIFIED_EMAIL
+    prompt_with_default "Authenticator app issuer name" "$AUTH_TOTP_ISSUER" AUTH_TOTP_ISSUER
+    prompt_with_default "Failed sign-in store (memory/database)" "$AUTH_ATTEMPT_STORE" AUTH_ATTEMPT_STORE
+    prompt_with_default "OpenID Connect issuer, e.g. https://accounts.google.com (empty to disable)" "$AUTH_OIDC_ISSUER" AUTH_OIDC_ISSUER
+    if [ -n "$AUTH_OIDC_ISSUER" ]; then
+        prompt_with_default "OpenID Connect client ID" "$AUTH_OIDC_CLIENT_ID" AUTH_OIDC_CLIENT_ID
+        prompt_password "OpenID Connect client secret" AUTH_OIDC_CLIENT_SECRET
+    fi
 
     # Minio config
     prompt_with_default "Minio endpoint" "$MINIO_ENDPOINT" MINIO_ENDPOINT
@@ -152,8 +185,19 @@ DB_APP_NAME=${DB_APP_NAME}
 
 # auth config
 AUTH_JWT_SECRET=${AUTH_JWT_SECRET}
+AUTH_JWT_SIGNING_KEY=${AUTH_JWT_SIGNING_KEY}
+AUTH_JWT_VERIFY_KEYS=${AUTH_JWT_VERIFY_KEYS}
+AUTH_JWT_ISSUER=${AUTH_JWT_ISSUER}
+AUTH_JWT_AUDIENCE=${AUTH_JWT_AUDIENCE}
 AUTH_ACCESS_EXPIRY=${AUTH_ACCESS_EXPIRY}
 AUTH_REFRESH_EXPIRY=${AUTH_REFRESH_EXPIRY}
+AUTH_RESET_EXPIRY=${AUTH_RESET_EXPIRY}
+AUTH_REQUIRE_VERIFIED_EMAIL=${AUTH_REQUIRE_VERIFIED_EMAIL}
+AUTH_TOTP_ISSUER=${AUTH_TOTP_ISSUER}
+AUTH_ATTEMPT_STORE=${AUTH_ATTEMPT_STORE}
+AUTH_OIDC_ISSUER=${AUTH_OIDC_ISSUER}
+AUTH_OIDC_CLIENT_ID=${AUTH_OIDC_CLIENT_ID}
+AUTH_OIDC_CLIENT_SECRET=${AUTH_OIDC_CLIENT_SECRET}
 
 # minio config
 MINIO_ENDPOINT=${MINIO_ENDPOINT}
@@ -162,6 +206,13 @@ MINIO_SECRET_KEY=${MINIO_SECRET_KEY}
 
 # smaila config
 SMAILA_ENDPOINT=${SMAILA_ENDPOINT}
+
+# payment config
+PAYMENT_IDEMPOTENCY_KEY_TTL=${PAYMENT_IDEMPOTENCY_KEY_TTL}
+PAYMENT_RECONCILE_INTERVAL=${PAYMENT_RECONCILE_INTERVAL}
+PAYMENT_RECONCILE_THRESHOLD=${PAYMENT_RECONCILE_THRESHOLD}
+PAYMENT_MIN_AMOUNT=${PAYMENT_MIN_AMOUNT}
+PAYMENT_ALLOCATION_RULE=${PAYMENT_ALLOCATION_RULE}
 EOL
 
 echo ".env file created successfully."
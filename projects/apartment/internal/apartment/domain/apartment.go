@@ -41,6 +41,9 @@ type Invite struct {
 	Status    InviteStatus
 	Token     string
 	ExpiresAt time.Time
+	// AcceptURL is the link the invitee follows to accept, without the
+	// token.
+	AcceptURL string
 }
 
 type ApartmentMember struct {
@@ -54,8 +57,11 @@ type Apartment struct {
 	Address    string
 	UnitNumber int64
 	AdminID    common.ID
-	Members    []ApartmentMember
-	Bills      []billDomain.Bill
+	// RequireAdminMFA makes the admin sign in with two-factor
+	// authentication to manage the apartment.
+	RequireAdminMFA bool
+	Members         []ApartmentMember
+	Bills           []billDomain.Bill
 }
 
 func (a *Apartment) Validate() error {
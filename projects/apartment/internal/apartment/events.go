@@ -0,0 +1,64 @@
+package apartment
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	eventp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/template"
+)
+
+// InviteMailer returns an InviteCreated subscriber that emails the invite
+// link to the invitee.
+func InviteMailer(mail port.EmailSender) eventp.Handler {
+	return func(ctx context.Context, e eventd.Event) error {
+		var p eventd.InviteCreatedPayload
+		if err := e.Decode(&p); err != nil {
+			return err
+		}
+		msg, err := generateInviteMessage(&p)
+		if err != nil {
+			return fp.WrapErrors(ErrOnGenerateMessage, err)
+		}
+		if err = mail.Send([]string{p.Email}, msg); err != nil {
+			return fp.WrapErrors(ErrOnSendEmail, err)
+		}
+		return nil
+	}
+}
+
+func generateInviteMessage(
+	invite *eventd.InviteCreatedPayload,
+) (
+	*common.EmailMessage, error,
+) {
+	rsvpLink, err := url.Parse(invite.AcceptURL)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnParsURL, err)
+	}
+	rsvpLink.RawQuery = fmt.Sprintf("%s=%s", "token", invite.Token)
+
+	inviteData := template.InviteData{
+		Name:          strings.Split(invite.Email, "@")[0],
+		ApartmentName: "Apartment",
+		Message:       "Please use the following link to accept the invitation:",
+		RSVPLink:      rsvpLink.String(),
+		OrganizerName: "The ArCaptcha Team",
+	}
+	body, err := template.NewInvite(inviteData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.EmailMessage{
+		Subject: "apartment invite",
+		Body:    body,
+		IsHTML:  true,
+	}, nil
+}
@@ -0,0 +1,54 @@
+package apartment
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+)
+
+func inviteCreated(t *testing.T) (*eventd.Event, eventd.InviteCreatedPayload) {
+	acceptURL := getAcceptURL()
+	p := eventd.InviteCreatedPayload{
+		InviteID:    common.NewRandomID(),
+		ApartmentID: common.NewRandomID(),
+		Email:       "test@example.com",
+		Token:       common.NewRandomID().String(),
+		ExpiresAt:   time.Now().Add(7 * 24 * time.Hour),
+		AcceptURL:   acceptURL.String(),
+	}
+	e, err := eventd.New(eventd.InviteCreated, p.ApartmentID, p)
+	assert.NoError(t, err)
+	return e, p
+}
+
+func TestInviteMailer_SendsInvite(t *testing.T) {
+	email := new(MockEmail)
+	e, p := inviteCreated(t)
+
+	email.On("Send", []string{p.Email}, mock.MatchedBy(func(m *common.EmailMessage) bool {
+		return strings.Contains(string(m.Body), "token="+p.Token)
+	})).Return(nil)
+
+	err := InviteMailer(email)(ctx, *e)
+
+	assert.NoError(t, err)
+	email.AssertExpectations(t)
+}
+
+func TestInviteMailer_SendFails(t *testing.T) {
+	email := new(MockEmail)
+	e, p := inviteCreated(t)
+
+	email.On("Send", []string{p.Email}, mock.Anything).Return(errors.New("smtp down"))
+
+	err := InviteMailer(email)(ctx, *e)
+
+	assert.ErrorIs(t, err, ErrOnSendEmail)
+}
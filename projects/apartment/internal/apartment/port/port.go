@@ -19,6 +19,9 @@ type Service interface {
 	)
 	AcceptInvite(ctx context.Context, token string) error
 	Members(ctx context.Context, id common.ID) ([]domain.ApartmentMember, error)
+	// SetAdminMFAPolicy sets whether the admin must use two-factor
+	// authentication to manage the apartment.
+	SetAdminMFAPolicy(ctx context.Context, adminID, apartmentID common.ID, required bool) error
 }
 
 type Repo interface {
@@ -32,6 +35,7 @@ type Repo interface {
 		*domain.Invite, error,
 	)
 	AcceptInvite(ctx context.Context, token string) error
+	SetRequireAdminMFA(ctx context.Context, apartmentID common.ID, required bool) error
 }
 
 type EmailSender interface {
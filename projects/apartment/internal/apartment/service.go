@@ -3,9 +3,7 @@ package apartment
 import (
 	"context"
 	"errors"
-	"fmt"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/domain"
@@ -13,7 +11,6 @@ import (
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
-	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/template"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -32,17 +29,16 @@ var (
 	ErrOnParsURL         = errors.New("failed to parse url")
 	ErrOnGenerateMessage = errors.New("failed to generate message")
 	ErrInvalidEmail      = errors.New("invalid email")
+	ErrOnSetMFAPolicy    = errors.New("error on setting two-factor policy")
 )
 
 type service struct {
 	repo port.Repo
-	mail port.EmailSender
 }
 
-func NewService(r port.Repo, mail port.EmailSender) port.Service {
+func NewService(r port.Repo) port.Service {
 	return &service{
 		repo: r,
-		mail: mail,
 	}
 }
 
@@ -77,31 +73,25 @@ func (s *service) InviteMember(
 		return nil, fp.WrapErrors(ErrOnInviteMember, err)
 	}
 
+	if _, err := url.Parse(acceptURL); err != nil {
+		return nil, fp.WrapErrors(ErrOnInviteMember, ErrOnParsURL, err)
+	}
+
 	invite := &domain.Invite{
 		Email:     userEmail,
 		Status:    domain.InviteStatusPending,
 		Token:     uuid.NewString(),
 		ExpiresAt: time.Now().Add(time.Hour * 24 * 7),
+		AcceptURL: acceptURL,
 	}
 
+	// the invite email is sent by InviteMailer once the invite is stored
 	invite, err := s.repo.InviteMember(ctx, apartmentID, invite)
 	if err != nil {
 		log.Error("repo invite failed", zap.Error(err))
 		return nil, fp.WrapErrors(ErrOnInviteMember, err)
 	}
 
-	to := []string{invite.Email.String()}
-	msg, err := s.generateInviteMessage(invite, acceptURL)
-	if err != nil {
-		return nil, fp.WrapErrors(ErrOnInviteMember, err)
-	}
-
-	err = s.mail.Send(to, msg)
-	if err != nil {
-		log.Error("send email", zap.Error(err))
-		return nil, fp.WrapErrors(ErrOnInviteMember, ErrOnSendEmail, err)
-	}
-
 	return invite, nil
 }
 
@@ -121,36 +111,6 @@ func (s *service) validateApartmentAdmin(
 	return nil
 }
 
-func (s *service) generateInviteMessage(
-	invite *domain.Invite, acceptURL string,
-) (
-	*common.EmailMessage, error,
-) {
-	rsvpLink, err := url.Parse(acceptURL)
-	if err != nil {
-		return nil, fp.WrapErrors(ErrOnParsURL, err)
-	}
-	rsvpLink.RawQuery = fmt.Sprintf("%s=%s", "token", invite.Token)
-
-	inviteData := template.InviteData{
-		Name:          strings.Split(invite.Email.String(), "@")[0],
-		ApartmentName: "Apartment",
-		Message:       "Please use the following link to accept the invitation:",
-		RSVPLink:      rsvpLink.String(),
-		OrganizerName: "The ArCaptcha Team",
-	}
-	body, err := template.NewInvite(inviteData)
-	if err != nil {
-		return nil, err
-	}
-
-	return &common.EmailMessage{
-		Subject: "apartment invite",
-		Body:    body,
-		IsHTML:  true,
-	}, nil
-}
-
 func (s *service) AcceptInvite(ctx context.Context, token string) error {
 	if err := common.ValidateID(token); err != nil {
 		return fp.WrapErrors(ErrOnAcceptInvite, ErrInvalidToken, err)
@@ -162,6 +122,18 @@ func (s *service) AcceptInvite(ctx context.Context, token string) error {
 	return nil
 }
 
+func (s *service) SetAdminMFAPolicy(
+	ctx context.Context, adminID, apartmentID common.ID, required bool,
+) error {
+	if err := s.validateApartmentAdmin(ctx, apartmentID, adminID); err != nil {
+		return fp.WrapErrors(ErrOnSetMFAPolicy, err)
+	}
+	if err := s.repo.SetRequireAdminMFA(ctx, apartmentID, required); err != nil {
+		return fp.WrapErrors(ErrOnSetMFAPolicy, err)
+	}
+	return nil
+}
+
 func (s *service) Members(ctx context.Context, id common.ID) ([]domain.ApartmentMember, error) {
 	panic("unimplemented")
 }
@@ -80,8 +80,7 @@ func getAcceptURL() url.URL {
 
 func TestCreateApartment_Success(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	a := &domain.Apartment{
 		ID:      common.NewRandomID(),
@@ -101,8 +100,7 @@ func TestCreateApartment_Success(t *testing.T) {
 
 func TestInviteMember_Success(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	adminID := common.NewRandomID()
 	apartmentID := common.NewRandomID()
@@ -122,8 +120,9 @@ func TestInviteMember_Success(t *testing.T) {
 	}
 
 	repo.On("Get", ctx, &domain.ApartmentFilter{ID: apartmentID}).Return(apartmentObj, nil)
-	repo.On("InviteMember", ctx, apartmentID, mock.AnythingOfType("*domain.Invite")).Return(invite, nil)
-	email.On("Send", []string{userEmail.String()}, mock.Anything).Return(nil)
+	repo.On("InviteMember", ctx, apartmentID, mock.MatchedBy(func(i *domain.Invite) bool {
+		return i.AcceptURL == acceptURL.String()
+	})).Return(invite, nil)
 
 	result, err := svc.InviteMember(ctx, adminID, apartmentID, userEmail, acceptURL.String())
 
@@ -132,12 +131,10 @@ func TestInviteMember_Success(t *testing.T) {
 		assert.Equal(t, invite.Email, result.Email)
 	}
 	repo.AssertExpectations(t)
-	email.AssertExpectations(t)
 }
 func TestInviteMember_NotAdmin(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	adminID := common.NewRandomID()
 	apartmentID := common.NewRandomID()
@@ -160,8 +157,7 @@ func TestInviteMember_NotAdmin(t *testing.T) {
 
 func TestAcceptInvite_Success(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	token := common.NewRandomID().String()
 
@@ -174,8 +170,7 @@ func TestAcceptInvite_Success(t *testing.T) {
 }
 func TestAcceptInvite_InvalidToken(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	token := "k3jd4kj9e-kdh4iu-ejf4ioj4k"
 
@@ -186,8 +181,7 @@ func TestAcceptInvite_InvalidToken(t *testing.T) {
 
 func TestAcceptInvite_ExpiredToken(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	token := common.NewRandomID().String()
 
@@ -203,8 +197,7 @@ func TestAcceptInvite_ExpiredToken(t *testing.T) {
 
 func TestAcceptInvite_Unregistered(t *testing.T) {
 	repo := new(MockRepo)
-	email := new(MockEmail)
-	svc := NewService(repo, email)
+	svc := NewService(repo)
 
 	token := common.NewRandomID().String()
 
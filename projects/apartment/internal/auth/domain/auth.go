@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// RefreshToken is the stored state of an issued refresh token. Its ID is
+// the jti claim of the token.
+type RefreshToken struct {
+	ID        common.ID
+	CreatedAt time.Time
+	// FamilyID groups the tokens rotated from one sign-in. Reusing a
+	// rotated token revokes the whole family.
+	FamilyID  common.ID
+	UserID    common.ID
+	ExpiresAt time.Time
+	// UsedAt is set when the token is exchanged for a new one.
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// Tokens are the credentials handed to a signed-in user.
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// EmailVerification is the verification state of the email of a user.
+type EmailVerification struct {
+	UserID     common.ID
+	Email      common.Email
+	Name       string
+	VerifiedAt *time.Time
+	// SentAt is when the last verification email was sent.
+	SentAt *time.Time
+}
+
+// PasswordReset is an emailed password reset link. Only the hash of its
+// token is stored.
+type PasswordReset struct {
+	ID        common.ID
+	CreatedAt time.Time
+	UserID    common.ID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// TOTP is the authenticator app of a user. It is pending until the user
+// confirms it with a code; only a confirmed one is asked for on sign-in.
+type TOTP struct {
+	UserID      common.ID
+	CreatedAt   time.Time
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastStep is the time step of the last code used, so that a code
+	// can't be used twice.
+	LastStep int64
+	// FailedAttempts counts wrong codes since the last right one.
+	FailedAttempts int
+	LockedUntil    *time.Time
+}
+
+// TOTPEnrollment is what a user scans into their authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+	// QRCode is the URI as a PNG image.
+	QRCode []byte
+}
+
+// MFAChallenge is handed out on sign-in instead of tokens when the user has
+// two-factor authentication. It is exchanged for tokens with a code.
+type MFAChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// SignIn is the result of a sign-in: either tokens, or a challenge.
+type SignIn struct {
+	Tokens    *Tokens
+	Challenge *MFAChallenge
+}
+
+// LoginAttempts counts the failed sign-ins of an account or an IP address
+// since the last successful one or lockout.
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// OIDCIdentity is an account of a user at an OpenID Connect provider,
+// named by the issuer of the provider and the subject it gives the
+// account.
+type OIDCIdentity struct {
+	UserID    common.ID
+	CreatedAt time.Time
+	Issuer    string
+	Subject   string
+	// Email and the rest are what the provider says; the email is trusted
+	// only if verified.
+	Email         common.Email
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// Scope is what a personal access token may do.
+type Scope string
+
+const (
+	ScopeBillsRead     Scope = "bills:read"
+	ScopeBillsWrite    Scope = "bills:write"
+	ScopePaymentsRead  Scope = "payments:read"
+	ScopePaymentsWrite Scope = "payments:write"
+)
+
+var validScopes = map[Scope]struct{}{
+	ScopeBillsRead:     {},
+	ScopeBillsWrite:    {},
+	ScopePaymentsRead:  {},
+	ScopePaymentsWrite: {},
+}
+
+func (s Scope) IsValid() bool {
+	_, ok := validScopes[s]
+	return ok
+}
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+// PersonalToken is a long-lived token a user creates for scripts and
+// integrations. It acts as the user within its scopes, and only on its
+// apartment if it has one. Only the hash of the token is stored.
+type PersonalToken struct {
+	ID        common.ID
+	CreatedAt time.Time
+	UserID    common.ID
+	Name      string
+	TokenHash string
+	Scopes    []Scope
+	// ApartmentID restricts the token to one apartment.
+	ApartmentID *common.ID
+	// MFA is set when the token was created by a user who signed in with
+	// two-factor authentication, so it passes apartment policies that
+	// require it.
+	MFA        bool
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (t *PersonalToken) HasScope(s Scope) bool {
+	return slices.Contains(t.Scopes, s)
+}
@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/qrcode"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/totp"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnSignIn            = errors.New("error on sign in")
+	ErrOnVerifyMFA         = errors.New("error on verifying two-factor code")
+	ErrOnEnrollTOTP        = errors.New("error on enrolling authenticator")
+	ErrOnDisableTOTP       = errors.New("error on disabling two-factor authentication")
+	ErrOnRecoveryCodes     = errors.New("error on regenerating recovery codes")
+	ErrTOTPNotFound        = errors.New("two-factor authentication is not set up")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired sign-in challenge")
+	ErrMFALocked           = errors.New("too many wrong codes, try again later")
+	ErrMFARequired         = errors.New("an apartment you manage requires two-factor authentication")
+)
+
+const (
+	// RecoveryCodeCount is how many recovery codes a user has. Each works
+	// once in place of an authenticator code.
+	RecoveryCodeCount = 10
+	recoveryCodeLen   = 10
+	// wrong codes in a row before verification is locked for mfaLockout
+	maxMFAAttempts = 5
+	mfaLockout     = 15 * time.Minute
+	// pixels per QR module
+	qrScale = 6
+)
+
+func (s *service) SignIn(ctx context.Context, userID common.ID, email string) (*domain.SignIn, error) {
+	t, err := s.repo.TOTP(ctx, userID)
+	if err != nil && !errors.Is(err, ErrTOTPNotFound) {
+		return nil, fp.WrapErrors(ErrOnSignIn, err)
+	}
+	if err != nil || t.ConfirmedAt == nil {
+		tokens, err := s.Login(ctx, userID, email)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.SignIn{Tokens: tokens}, nil
+	}
+
+	now := s.now()
+	expiresAt := now.Add(s.challengeExp)
+	token, err := appjwt.CreateToken(s.keys, &appjwt.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		UserID:    userID.String(),
+		UserEMail: email,
+		Type:      appjwt.MFAChallengeToken,
+	})
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnSignIn, err)
+	}
+	return &domain.SignIn{
+		Challenge: &domain.MFAChallenge{Token: token, ExpiresAt: expiresAt},
+	}, nil
+}
+
+func (s *service) VerifyMFA(ctx context.Context, challenge, code string) (*domain.Tokens, error) {
+	log := appctx.Logger(ctx)
+
+	claims, err := appjwt.ParseTokenOfType(challenge, s.keys, appjwt.MFAChallengeToken)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnVerifyMFA, ErrInvalidMFAChallenge, err)
+	}
+	if common.ValidateID(claims.UserID) != nil {
+		return nil, fp.WrapErrors(ErrOnVerifyMFA, ErrInvalidMFAChallenge)
+	}
+	userID := common.IDFromText(claims.UserID)
+	if err = s.checkCode(ctx, userID, code); err != nil {
+		return nil, fp.WrapErrors(ErrOnVerifyMFA, err)
+	}
+
+	tokens, err := s.login(ctx, userID, claims.UserEMail, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("two-factor sign-in", zap.String("userId", userID.String()))
+	return tokens, nil
+}
+
+func (s *service) EnrollTOTP(
+	ctx context.Context, userID common.ID, email string,
+) (
+	*domain.TOTPEnrollment, error,
+) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	}
+	e := &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.totpIssuer, email, secret),
+	}
+	qr, err := qrcode.Encode([]byte(e.URI))
+	switch {
+	case errors.Is(err, qrcode.ErrTooLong):
+		// only for very long emails; the secret can still be typed in
+	case err != nil:
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	default:
+		if e.QRCode, err = qr.PNG(qrScale); err != nil {
+			return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+		}
+	}
+
+	err = s.repo.SavePendingTOTP(ctx, &domain.TOTP{
+		UserID:    userID,
+		CreatedAt: s.now(),
+		Secret:    secret,
+	})
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	}
+	return e, nil
+}
+
+func (s *service) ConfirmTOTP(ctx context.Context, userID common.ID, code string) ([]string, error) {
+	log := appctx.Logger(ctx)
+
+	t, err := s.repo.TOTP(ctx, userID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	}
+	if t.ConfirmedAt != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, ErrMFAAlreadyEnabled)
+	}
+	step, ok := totp.Validate(t.Secret, code, s.now())
+	if !ok {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, ErrInvalidMFACode)
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	}
+	if err = s.repo.ConfirmTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, fp.WrapErrors(ErrOnEnrollTOTP, err)
+	}
+	log.Info("two-factor authentication enabled", zap.String("userId", userID.String()))
+	return codes, nil
+}
+
+func (s *service) DisableTOTP(ctx context.Context, userID common.ID, code string) error {
+	log := appctx.Logger(ctx)
+
+	required, err := s.repo.MFARequired(ctx, userID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnDisableTOTP, err)
+	}
+	if required {
+		return fp.WrapErrors(ErrOnDisableTOTP, ErrMFARequired)
+	}
+	if err = s.checkCode(ctx, userID, code); err != nil {
+		return fp.WrapErrors(ErrOnDisableTOTP, err)
+	}
+	if err = s.repo.DeleteTOTP(ctx, userID); err != nil {
+		return fp.WrapErrors(ErrOnDisableTOTP, err)
+	}
+	log.Info("two-factor authentication disabled", zap.String("userId", userID.String()))
+	return nil
+}
+
+func (s *service) RegenerateRecoveryCodes(
+	ctx context.Context, userID common.ID, code string,
+) (
+	[]string, error,
+) {
+	if err := s.checkCode(ctx, userID, code); err != nil {
+		return nil, fp.WrapErrors(ErrOnRecoveryCodes, err)
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRecoveryCodes, err)
+	}
+	if err = s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fp.WrapErrors(ErrOnRecoveryCodes, err)
+	}
+	return codes, nil
+}
+
+func (s *service) MFARequired(ctx context.Context, userID common.ID) (bool, error) {
+	return s.repo.MFARequired(ctx, userID)
+}
+
+// checkCode checks code, from the authenticator app or a recovery code,
+// against the confirmed authenticator of the user. Wrong codes are counted
+// and lock verification after maxMFAAttempts.
+func (s *service) checkCode(ctx context.Context, userID common.ID, code string) error {
+	log := appctx.Logger(ctx)
+
+	t, err := s.repo.TOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if t.ConfirmedAt == nil {
+		return ErrTOTPNotFound
+	}
+	now := s.now()
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return ErrMFALocked
+	}
+
+	if step, ok := totp.Validate(t.Secret, code, now); ok {
+		err = s.repo.UseTOTPStep(ctx, userID, step)
+	} else if rc := normalizeRecoveryCode(code); len(rc) == recoveryCodeLen {
+		err = s.repo.UseRecoveryCode(ctx, userID, hashToken(rc))
+		if err == nil {
+			log.Info("recovery code used", zap.String("userId", userID.String()))
+		}
+	} else {
+		err = ErrInvalidMFACode
+	}
+	if !errors.Is(err, ErrInvalidMFACode) {
+		return err
+	}
+
+	log.Warn("wrong two-factor code", zap.String("userId", userID.String()))
+	if ferr := s.repo.RecordMFAFailure(ctx, userID, maxMFAAttempts, now.Add(mfaLockout)); ferr != nil {
+		return fp.WrapErrors(err, ferr)
+	}
+	return err
+}
+
+// newRecoveryCodes returns RecoveryCodeCount codes formatted as
+// "xxxxx-xxxxx", and their hashes.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for range RecoveryCodeCount {
+		b := make([]byte, 8)
+		if _, err = rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		c := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:recoveryCodeLen]
+		codes = append(codes, c[:5]+"-"+c[5:])
+		hashes = append(hashes, hashToken(c))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode drops the separator and case users may type.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockRepo) TOTP(ctx context.Context, userID common.ID) (*domain.TOTP, error) {
+	args := m.Called(ctx, userID)
+	res, _ := args.Get(0).(*domain.TOTP)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) SavePendingTOTP(ctx context.Context, t *domain.TOTP) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ConfirmTOTP(ctx context.Context, userID common.ID, step int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRepo) UseTOTPStep(ctx context.Context, userID common.ID, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockRepo) UseRecoveryCode(ctx context.Context, userID common.ID, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RecordMFAFailure(ctx context.Context, userID common.ID, maxAttempts int, lockedUntil time.Time) error {
+	args := m.Called(ctx, userID, maxAttempts, lockedUntil)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ReplaceRecoveryCodes(ctx context.Context, userID common.ID, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteTOTP(ctx context.Context, userID common.ID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MFARequired(ctx context.Context, userID common.ID) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+const totpSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func confirmedTOTP(userID common.ID) *domain.TOTP {
+	confirmed := time.Now().Add(-time.Hour)
+	return &domain.TOTP{UserID: userID, Secret: totpSecret, ConfirmedAt: &confirmed}
+}
+
+// challenge signs in a user with a confirmed authenticator.
+func challenge(t *testing.T, svc *service, repo *MockRepo, userID common.ID) string {
+	t.Helper()
+	repo.On("TOTP", ctx, userID).Return(confirmedTOTP(userID), nil)
+	si, err := svc.SignIn(ctx, userID, "user@example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, si.Tokens)
+	assert.NotNil(t, si.Challenge)
+	return si.Challenge.Token
+}
+
+func TestSignIn(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+
+	// without an authenticator, or with a pending one, tokens right away
+	repo.On("TOTP", ctx, userID).Return(nil, ErrTOTPNotFound).Once()
+	repo.On("CreateRefreshToken", ctx, mock.Anything).Return(nil)
+	si, err := svc.SignIn(ctx, userID, "user@example.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, si.Tokens)
+	assert.Nil(t, si.Challenge)
+
+	repo.On("TOTP", ctx, userID).Return(&domain.TOTP{UserID: userID, Secret: totpSecret}, nil).Once()
+	si, err = svc.SignIn(ctx, userID, "user@example.com")
+	assert.NoError(t, err)
+	assert.NotNil(t, si.Tokens)
+
+	// the challenge is not an access token
+	token := challenge(t, svc.(*service), repo, userID)
+	_, err = appjwt.ParseTokenOfType(token, keys, appjwt.AccessToken)
+	assert.ErrorIs(t, err, appjwt.ErrWrongTokenType)
+}
+
+func TestVerifyMFA(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	userID := common.NewRandomID()
+	token := challenge(t, svc, repo, userID)
+
+	now := time.Now()
+	code, _ := totp.Code(totpSecret, totp.Step(now))
+	repo.On("UseTOTPStep", ctx, userID, mock.Anything).Return(nil).Once()
+	repo.On("CreateRefreshToken", ctx, mock.Anything).Return(nil)
+
+	tokens, err := svc.VerifyMFA(ctx, token, code)
+	assert.NoError(t, err)
+	claims, err := appjwt.ParseTokenOfType(tokens.AccessToken, keys, appjwt.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, claims.MFA)
+	assert.Equal(t, userID.String(), claims.UserID)
+
+	// refreshed tokens keep the second factor
+	refreshClaims := mustParse(t, tokens.RefreshToken)
+	used := common.IDFromText(refreshClaims.ID)
+	repo.On("GetRefreshToken", ctx, used).Return(&domain.RefreshToken{
+		ID: used, FamilyID: common.IDFromText(refreshClaims.FamilyID), UserID: userID,
+	}, nil)
+	repo.On("RotateRefreshToken", ctx, used, mock.Anything).Return(nil)
+	refreshed, err := svc.Refresh(ctx, tokens.RefreshToken)
+	assert.NoError(t, err)
+	claims, err = appjwt.ParseTokenOfType(refreshed.AccessToken, keys, appjwt.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, claims.MFA)
+
+	// a plain sign-in does not have it
+	plain, _ := login(t, svc, repo, userID)
+	claims, _ = appjwt.ParseTokenOfType(plain.AccessToken, keys, appjwt.AccessToken)
+	assert.False(t, claims.MFA)
+}
+
+func mustParse(t *testing.T, token string) *appjwt.UserClaims {
+	t.Helper()
+	claims, err := appjwt.ParseToken(token, keys)
+	assert.NoError(t, err)
+	return claims
+}
+
+func TestVerifyMFA_WrongCode(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	userID := common.NewRandomID()
+	token := challenge(t, svc, repo, userID)
+
+	code, _ := totp.Code(totpSecret, totp.Step(now)-5)
+	repo.On("RecordMFAFailure", ctx, userID, maxMFAAttempts, now.Add(mfaLockout)).Return(nil).Once()
+	_, err := svc.VerifyMFA(ctx, token, code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	// a code used before counts as wrong
+	code, _ = totp.Code(totpSecret, totp.Step(now))
+	repo.On("UseTOTPStep", ctx, userID, totp.Step(now)).Return(ErrInvalidMFACode).Once()
+	repo.On("RecordMFAFailure", ctx, userID, maxMFAAttempts, now.Add(mfaLockout)).Return(nil).Once()
+	_, err = svc.VerifyMFA(ctx, token, code)
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	repo.AssertExpectations(t)
+}
+
+func TestVerifyMFA_Locked(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	userID := common.NewRandomID()
+	token, err := appjwt.CreateToken(keys, &appjwt.UserClaims{
+		UserID: userID.String(),
+		Type:   appjwt.MFAChallengeToken,
+	})
+	assert.NoError(t, err)
+
+	locked := confirmedTOTP(userID)
+	until := time.Now().Add(time.Minute)
+	locked.LockedUntil = &until
+	repo.On("TOTP", ctx, userID).Return(locked, nil)
+
+	code, _ := totp.Code(totpSecret, totp.Step(time.Now()))
+	_, err = svc.VerifyMFA(ctx, token, code)
+	assert.ErrorIs(t, err, ErrMFALocked)
+	repo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVerifyMFA_RecoveryCode(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	userID := common.NewRandomID()
+	token := challenge(t, svc, repo, userID)
+
+	repo.On("UseRecoveryCode", ctx, userID, hashToken("abcdefghij")).Return(nil).Once()
+	repo.On("CreateRefreshToken", ctx, mock.Anything).Return(nil)
+	_, err := svc.VerifyMFA(ctx, token, " ABCDE-fghij ")
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestVerifyMFA_InvalidChallenge(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, _ := login(t, svc, repo, common.NewRandomID())
+
+	_, err := svc.VerifyMFA(ctx, tokens.AccessToken, "123456")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+	_, err = svc.VerifyMFA(ctx, "", "123456")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys, WithTOTPIssuer("Acme"))
+	userID := common.NewRandomID()
+
+	var saved *domain.TOTP
+	repo.On("SavePendingTOTP", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.TOTP) }).
+		Return(nil).Once()
+
+	e, err := svc.EnrollTOTP(ctx, userID, "user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, saved.UserID)
+	assert.Equal(t, e.Secret, saved.Secret)
+	assert.Nil(t, saved.ConfirmedAt)
+	assert.True(t, strings.HasPrefix(e.URI, "otpauth://totp/Acme:user@example.com?"))
+	assert.Contains(t, e.URI, "secret="+e.Secret)
+
+	_, err = png.Decode(bytes.NewReader(e.QRCode))
+	assert.NoError(t, err)
+
+	repo.On("SavePendingTOTP", ctx, mock.Anything).Return(ErrMFAAlreadyEnabled).Once()
+	_, err = svc.EnrollTOTP(ctx, userID, "user@example.com")
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+	now := time.Now()
+
+	repo.On("TOTP", ctx, userID).Return(&domain.TOTP{UserID: userID, Secret: totpSecret}, nil)
+	_, err := svc.ConfirmTOTP(ctx, userID, "000000x")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	var hashes []string
+	repo.On("ConfirmTOTP", ctx, userID, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { hashes = args.Get(3).([]string) }).
+		Return(nil).Once()
+	code, _ := totp.Code(totpSecret, totp.Step(now))
+	codes, err := svc.ConfirmTOTP(ctx, userID, code)
+	assert.NoError(t, err)
+	assert.Len(t, codes, RecoveryCodeCount)
+	assert.Len(t, hashes, RecoveryCodeCount)
+	for i, c := range codes {
+		assert.Len(t, c, recoveryCodeLen+1)
+		assert.Equal(t, hashToken(normalizeRecoveryCode(c)), hashes[i])
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+	code, _ := totp.Code(totpSecret, totp.Step(time.Now()))
+
+	repo.On("MFARequired", ctx, userID).Return(true, nil).Once()
+	err := svc.DisableTOTP(ctx, userID, code)
+	assert.ErrorIs(t, err, ErrMFARequired)
+
+	repo.On("MFARequired", ctx, userID).Return(false, nil).Once()
+	repo.On("TOTP", ctx, userID).Return(confirmedTOTP(userID), nil)
+	repo.On("UseTOTPStep", ctx, userID, mock.Anything).Return(nil).Once()
+	repo.On("DeleteTOTP", ctx, userID).Return(nil).Once()
+	err = svc.DisableTOTP(ctx, userID, code)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnOIDCLogin           = errors.New("error on signing in with identity provider")
+	ErrOIDCDisabled          = errors.New("sign-in with an identity provider is not configured")
+	ErrInvalidOIDCState      = errors.New("invalid or expired sign-in state, start again")
+	ErrOIDCRejected          = errors.New("sign-in with the identity provider failed")
+	ErrOIDCEmailUnverified   = errors.New("the identity provider has not verified your email")
+	ErrOIDCAccountUnverified = errors.New("an account with your email exists, sign in with its password and verify the email first")
+	ErrIdentityNotFound      = errors.New("identity not linked to a user")
+)
+
+func (s *service) OIDCLogin(ctx context.Context) (authURL, stateToken string, err error) {
+	if s.oidc == nil {
+		return "", "", ErrOIDCDisabled
+	}
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = newToken(); err != nil {
+			return "", "", fp.WrapErrors(ErrOnOIDCLogin, err)
+		}
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	now := s.now()
+	stateToken, err = appjwt.CreateOIDCStateToken(s.keys, &appjwt.OIDCStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(OIDCStateExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+	})
+	if err != nil {
+		return "", "", fp.WrapErrors(ErrOnOIDCLogin, err)
+	}
+	authURL, err = s.oidc.AuthURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", "", fp.WrapErrors(ErrOnOIDCLogin, err)
+	}
+	return authURL, stateToken, nil
+}
+
+func (s *service) OIDCCallback(ctx context.Context, stateToken, state, code string) (*domain.SignIn, error) {
+	log := appctx.Logger(ctx)
+
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+	claims, err := appjwt.ParseOIDCStateToken(stateToken, s.keys)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnOIDCLogin, ErrInvalidOIDCState, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(claims.State)) != 1 {
+		return nil, fp.WrapErrors(ErrOnOIDCLogin, ErrInvalidOIDCState)
+	}
+	ident, err := s.oidc.Identity(ctx, code, claims.Verifier, claims.Nonce)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnOIDCLogin, ErrOIDCRejected, err)
+	}
+
+	userID, email, err := s.identityUser(ctx, ident)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnOIDCLogin, err)
+	}
+	log.Info("identity provider sign-in",
+		zap.String("userId", userID.String()),
+		zap.String("issuer", ident.Issuer),
+	)
+	return s.SignIn(ctx, userID, email.String())
+}
+
+// identityUser returns the user linked to ident. A new account at the
+// provider is linked to the user of its email, who must have verified it
+// so that whoever signed up with it is its owner, or else becomes a new
+// user.
+func (s *service) identityUser(ctx context.Context, ident *domain.OIDCIdentity) (common.ID, common.Email, error) {
+	log := appctx.Logger(ctx).With(
+		zap.String("issuer", ident.Issuer),
+		zap.String("subject", ident.Subject),
+	)
+
+	userID, email, err := s.repo.UserByIdentity(ctx, ident.Issuer, ident.Subject)
+	if !errors.Is(err, ErrIdentityNotFound) {
+		return userID, email, err
+	}
+	if !ident.EmailVerified {
+		return common.NilID, "", ErrOIDCEmailUnverified
+	}
+	if !ident.Email.IsValid() {
+		return common.NilID, "", ErrInvalidEmail
+	}
+	ident.CreatedAt = s.now()
+
+	userID, _, err = s.repo.UserByEmail(ctx, ident.Email)
+	switch {
+	case err == nil:
+		ev, err := s.repo.EmailVerification(ctx, userID)
+		if err != nil {
+			return common.NilID, "", err
+		}
+		if ev.VerifiedAt == nil {
+			return common.NilID, "", ErrOIDCAccountUnverified
+		}
+		ident.UserID = userID
+		if err = s.repo.LinkIdentity(ctx, ident); err != nil {
+			return common.NilID, "", err
+		}
+		log.Info("identity linked", zap.String("userId", userID.String()))
+
+	case errors.Is(err, ErrUserNotFound):
+		// a password nobody knows; the user can set one with a reset
+		password, err := newToken()
+		if err != nil {
+			return common.NilID, "", err
+		}
+		u := userDomain.NewUser(userDomain.NilID, ident.Email.String(), password, ident.FirstName, ident.LastName)
+		if err = u.HashPassword(); err != nil {
+			return common.NilID, "", err
+		}
+		if userID, err = s.repo.CreateIdentityUser(ctx, u, ident); err != nil {
+			return common.NilID, "", err
+		}
+		log.Info("user created from identity", zap.String("userId", userID.String()))
+
+	default:
+		return common.NilID, "", err
+	}
+	return userID, ident.Email, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/identity"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *MockRepo) UserByIdentity(ctx context.Context, issuer, subject string) (common.ID, common.Email, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Get(0).(common.ID), args.Get(1).(common.Email), args.Error(2)
+}
+
+func (m *MockRepo) LinkIdentity(ctx context.Context, i *domain.OIDCIdentity) error {
+	args := m.Called(ctx, i)
+	return args.Error(0)
+}
+
+func (m *MockRepo) CreateIdentityUser(ctx context.Context, u *userDomain.User, i *domain.OIDCIdentity) (common.ID, error) {
+	args := m.Called(ctx, u, i)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+var bob = oidctest.User{
+	Subject:       "42",
+	Email:         "bob@example.com",
+	EmailVerified: true,
+	GivenName:     "Bob",
+	FamilyName:    "Jones",
+}
+
+// oidcService returns a service signing in with a fake provider, for
+// users without two-factor authentication.
+func oidcService(t *testing.T) (*service, *MockRepo, *oidctest.Provider) {
+	t.Helper()
+	p := oidctest.NewProvider("apartment", "client-secret")
+	t.Cleanup(p.Close)
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       p.Issuer(),
+		ClientID:     "apartment",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	}, p.Client())
+
+	repo := new(MockRepo)
+	repo.On("TOTP", ctx, mock.Anything).Return(nil, ErrTOTPNotFound)
+	repo.On("CreateRefreshToken", ctx, mock.Anything).Return(nil)
+	svc := NewService(repo, keys, WithOIDCProvider(identity.NewOIDCProvider(client)))
+	return svc.(*service), repo, p
+}
+
+// oidcSignIn signs u in at the provider and comes back.
+func oidcSignIn(t *testing.T, svc *service, p *oidctest.Provider, u oidctest.User) (*domain.SignIn, error) {
+	t.Helper()
+	authURL, stateToken, err := svc.OIDCLogin(ctx)
+	require.NoError(t, err)
+	code, state, err := p.Authorize(authURL, u)
+	require.NoError(t, err)
+	return svc.OIDCCallback(ctx, stateToken, state, code)
+}
+
+func TestOIDC_Disabled(t *testing.T) {
+	svc := NewService(new(MockRepo), keys)
+	_, _, err := svc.OIDCLogin(ctx)
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+	_, err = svc.OIDCCallback(ctx, "token", "state", "code")
+	assert.ErrorIs(t, err, ErrOIDCDisabled)
+}
+
+func TestOIDC_LinkedIdentity(t *testing.T) {
+	svc, repo, p := oidcService(t)
+	userID := common.NewRandomID()
+	repo.On("UserByIdentity", ctx, p.Issuer(), "42").Return(userID, common.Email("bob@home.example"), nil)
+
+	si, err := oidcSignIn(t, svc, p, bob)
+	require.NoError(t, err)
+	assert.NotNil(t, si.Tokens)
+	repo.AssertNotCalled(t, "UserByEmail", mock.Anything, mock.Anything)
+}
+
+func TestOIDC_CreatesUser(t *testing.T) {
+	svc, repo, p := oidcService(t)
+	userID := common.NewRandomID()
+	repo.On("UserByIdentity", ctx, p.Issuer(), "42").Return(common.NilID, common.Email(""), ErrIdentityNotFound)
+	repo.On("UserByEmail", ctx, common.Email("bob@example.com")).Return(common.NilID, "", ErrUserNotFound)
+	repo.On("CreateIdentityUser", ctx,
+		mock.MatchedBy(func(u *userDomain.User) bool {
+			return u.Email == "bob@example.com" && u.FirstName == "Bob" && u.LastName == "Jones" && u.IsHashed()
+		}),
+		mock.MatchedBy(func(i *domain.OIDCIdentity) bool {
+			return i.Issuer == p.Issuer() && i.Subject == "42"
+		}),
+	).Return(userID, nil)
+
+	si, err := oidcSignIn(t, svc, p, bob)
+	require.NoError(t, err)
+	assert.NotNil(t, si.Tokens)
+	repo.AssertExpectations(t)
+}
+
+func TestOIDC_LinksVerifiedUser(t *testing.T) {
+	svc, repo, p := oidcService(t)
+	userID := common.NewRandomID()
+	verified := time.Now().Add(-time.Hour)
+	repo.On("UserByIdentity", ctx, p.Issuer(), "42").Return(common.NilID, common.Email(""), ErrIdentityNotFound)
+	repo.On("UserByEmail", ctx, common.Email("bob@example.com")).Return(userID, "Bob", nil)
+	repo.On("EmailVerification", ctx, userID).Return(&domain.EmailVerification{UserID: userID, VerifiedAt: &verified}, nil)
+	repo.On("LinkIdentity", ctx, mock.MatchedBy(func(i *domain.OIDCIdentity) bool {
+		return i.UserID == userID && i.Subject == "42"
+	})).Return(nil)
+
+	_, err := oidcSignIn(t, svc, p, bob)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestOIDC_RefusesUnverifiedUser(t *testing.T) {
+	// whoever signed up with the email without verifying it may not own it
+	svc, repo, p := oidcService(t)
+	userID := common.NewRandomID()
+	repo.On("UserByIdentity", ctx, p.Issuer(), "42").Return(common.NilID, common.Email(""), ErrIdentityNotFound)
+	repo.On("UserByEmail", ctx, common.Email("bob@example.com")).Return(userID, "Bob", nil)
+	repo.On("EmailVerification", ctx, userID).Return(&domain.EmailVerification{UserID: userID}, nil)
+
+	_, err := oidcSignIn(t, svc, p, bob)
+	assert.ErrorIs(t, err, ErrOIDCAccountUnverified)
+	repo.AssertNotCalled(t, "LinkIdentity", mock.Anything, mock.Anything)
+}
+
+func TestOIDC_RefusesUnverifiedEmail(t *testing.T) {
+	svc, repo, p := oidcService(t)
+	repo.On("UserByIdentity", ctx, p.Issuer(), "42").Return(common.NilID, common.Email(""), ErrIdentityNotFound)
+
+	u := bob
+	u.EmailVerified = false
+	_, err := oidcSignIn(t, svc, p, u)
+	assert.ErrorIs(t, err, ErrOIDCEmailUnverified)
+	repo.AssertNotCalled(t, "UserByEmail", mock.Anything, mock.Anything)
+}
+
+func TestOIDC_InvalidState(t *testing.T) {
+	svc, _, p := oidcService(t)
+	authURL, stateToken, err := svc.OIDCLogin(ctx)
+	require.NoError(t, err)
+	code, state, err := p.Authorize(authURL, bob)
+	require.NoError(t, err)
+
+	// a callback the browser didn't start, as in login CSRF
+	_, otherToken, err := svc.OIDCLogin(ctx)
+	require.NoError(t, err)
+	_, err = svc.OIDCCallback(ctx, otherToken, state, code)
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	_, err = svc.OIDCCallback(ctx, "not-a-token", state, code)
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+
+	// a code the provider didn't issue
+	_, err = svc.OIDCCallback(ctx, stateToken, state, "made-up-code")
+	assert.ErrorIs(t, err, ErrOIDCRejected)
+}
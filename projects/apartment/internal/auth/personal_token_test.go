@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (m *MockRepo) CreatePersonalToken(ctx context.Context, t *domain.PersonalToken) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockRepo) PersonalTokens(ctx context.Context, userID common.ID) ([]domain.PersonalToken, error) {
+	args := m.Called(ctx, userID)
+	ts, _ := args.Get(0).([]domain.PersonalToken)
+	return ts, args.Error(1)
+}
+
+func (m *MockRepo) PersonalTokenByHash(ctx context.Context, tokenHash string) (*domain.PersonalToken, error) {
+	args := m.Called(ctx, tokenHash)
+	t, _ := args.Get(0).(*domain.PersonalToken)
+	return t, args.Error(1)
+}
+
+func (m *MockRepo) DeletePersonalToken(ctx context.Context, userID, id common.ID) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) TouchPersonalToken(ctx context.Context, id common.ID, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+func TestCreatePersonalToken(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	userID := common.NewRandomID()
+	expiresAt := now.Add(30 * 24 * time.Hour)
+
+	var stored *domain.PersonalToken
+	repo.On("PersonalTokens", ctx, userID).Return(nil, nil)
+	repo.On("CreatePersonalToken", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.PersonalToken)
+	}).Return(nil)
+
+	token, err := svc.CreatePersonalToken(ctx, &domain.PersonalToken{
+		UserID:    userID,
+		Name:      "  treasurer script ",
+		Scopes:    []domain.Scope{domain.ScopePaymentsRead, domain.ScopeBillsWrite, domain.ScopePaymentsRead},
+		ExpiresAt: &expiresAt,
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, PersonalTokenPrefix))
+	require.NotNil(t, stored)
+	assert.Equal(t, "treasurer script", stored.Name)
+	assert.Equal(t, []domain.Scope{domain.ScopeBillsWrite, domain.ScopePaymentsRead}, stored.Scopes)
+	assert.Equal(t, now, stored.CreatedAt)
+	assert.NotEqual(t, common.NilID, stored.ID)
+	// only the hash is stored
+	assert.Equal(t, hashToken(token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, strings.TrimPrefix(token, PersonalTokenPrefix))
+}
+
+func TestCreatePersonalToken_Invalid(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	past := now.Add(-time.Minute)
+
+	for name, tc := range map[string]struct {
+		token domain.PersonalToken
+		err   error
+	}{
+		"no name":       {domain.PersonalToken{Scopes: []domain.Scope{domain.ScopeBillsRead}}, ErrInvalidTokenName},
+		"long name":     {domain.PersonalToken{Name: strings.Repeat("x", 101), Scopes: []domain.Scope{domain.ScopeBillsRead}}, ErrInvalidTokenName},
+		"no scopes":     {domain.PersonalToken{Name: "n"}, ErrInvalidTokenScopes},
+		"unknown scope": {domain.PersonalToken{Name: "n", Scopes: []domain.Scope{"admin"}}, ErrInvalidTokenScopes},
+		"expired":       {domain.PersonalToken{Name: "n", Scopes: []domain.Scope{domain.ScopeBillsRead}, ExpiresAt: &past}, ErrInvalidTokenExpiry},
+	} {
+		_, err := svc.CreatePersonalToken(ctx, &tc.token)
+		assert.ErrorIs(t, err, tc.err, name)
+	}
+	repo.AssertNotCalled(t, "CreatePersonalToken", mock.Anything, mock.Anything)
+}
+
+func TestCreatePersonalToken_TooMany(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+	repo.On("PersonalTokens", ctx, userID).Return(make([]domain.PersonalToken, MaxPersonalTokens), nil)
+
+	_, err := svc.CreatePersonalToken(ctx, &domain.PersonalToken{
+		UserID: userID, Name: "n", Scopes: []domain.Scope{domain.ScopeBillsRead},
+	})
+	assert.ErrorIs(t, err, ErrTooManyPersonalTokens)
+}
+
+func TestAuthenticatePersonalToken(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	token := PersonalTokenPrefix + "secret"
+	stored := &domain.PersonalToken{ID: common.NewRandomID(), UserID: common.NewRandomID()}
+
+	repo.On("PersonalTokenByHash", ctx, hashToken(token)).Return(stored, nil).Once()
+	repo.On("TouchPersonalToken", ctx, stored.ID, now).Return(nil).Once()
+	got, err := svc.AuthenticatePersonalToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, stored.UserID, got.UserID)
+	assert.Equal(t, &now, got.LastUsedAt)
+
+	// used a moment ago, the use isn't recorded again
+	recent := now.Add(-time.Second)
+	repo.On("PersonalTokenByHash", ctx, hashToken(token)).
+		Return(&domain.PersonalToken{ID: stored.ID, LastUsedAt: &recent}, nil).Once()
+	_, err = svc.AuthenticatePersonalToken(ctx, token)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "TouchPersonalToken", 1)
+}
+
+func TestAuthenticatePersonalToken_Rejects(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys).(*service)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	// JWTs aren't looked up
+	_, err := svc.AuthenticatePersonalToken(ctx, "eyJhbGciOiJIUzUxMiJ9.e30.sig")
+	assert.ErrorIs(t, err, ErrInvalidPersonalToken)
+
+	unknown := PersonalTokenPrefix + "unknown"
+	repo.On("PersonalTokenByHash", ctx, hashToken(unknown)).Return(nil, ErrPersonalTokenNotFound)
+	_, err = svc.AuthenticatePersonalToken(ctx, unknown)
+	assert.ErrorIs(t, err, ErrInvalidPersonalToken)
+
+	expired := PersonalTokenPrefix + "expired"
+	repo.On("PersonalTokenByHash", ctx, hashToken(expired)).
+		Return(&domain.PersonalToken{ID: common.NewRandomID(), ExpiresAt: &now}, nil)
+	_, err = svc.AuthenticatePersonalToken(ctx, expired)
+	assert.ErrorIs(t, err, ErrInvalidPersonalToken)
+
+	repo.AssertNotCalled(t, "TouchPersonalToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRevokePersonalToken(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID, id := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("DeletePersonalToken", ctx, userID, id).Return(nil).Once()
+	assert.NoError(t, svc.RevokePersonalToken(ctx, userID, id))
+
+	repo.On("DeletePersonalToken", ctx, userID, id).Return(ErrPersonalTokenNotFound).Once()
+	assert.ErrorIs(t, svc.RevokePersonalToken(ctx, userID, id), ErrPersonalTokenNotFound)
+}
@@ -0,0 +1,182 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+)
+
+type Service interface {
+	// Login starts a new token family for the user.
+	Login(ctx context.Context, userID common.ID, email string) (*domain.Tokens, error)
+	// Refresh exchanges a refresh token for new tokens of the same family.
+	// A refresh token can be used once; reusing one revokes its family.
+	Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	// Logout revokes the family of the refresh token.
+	Logout(ctx context.Context, refreshToken string) error
+	// LogoutAll revokes every refresh token of the user.
+	LogoutAll(ctx context.Context, userID common.ID) error
+	// ForgotPassword emails a reset link, resetURL with the token appended,
+	// to the user of email. It does not tell whether the user exists.
+	ForgotPassword(ctx context.Context, email common.Email, resetURL string) error
+	// ResetPassword sets the password of the user the reset token was sent
+	// to and revokes all their refresh tokens. A token can be used once.
+	ResetPassword(ctx context.Context, token, password string) error
+	// SendVerification emails a link, verifyURL with a signed token
+	// appended, that verifies the email of the user. It fails with
+	// ErrVerificationThrottled if the last one was sent too recently.
+	SendVerification(ctx context.Context, userID common.ID, verifyURL string) error
+	// VerifyEmail marks the email in the token verified, if it is still the
+	// email of the user.
+	VerifyEmail(ctx context.Context, token string) error
+	EmailVerified(ctx context.Context, userID common.ID) (bool, error)
+	// SignIn is Login for a user who may have two-factor authentication.
+	// If they have, it returns a challenge for VerifyMFA instead of tokens.
+	SignIn(ctx context.Context, userID common.ID, email string) (*domain.SignIn, error)
+	// VerifyMFA exchanges a sign-in challenge and an authenticator or
+	// recovery code for tokens.
+	VerifyMFA(ctx context.Context, challenge, code string) (*domain.Tokens, error)
+	// EnrollTOTP generates a secret for an authenticator app, replacing a
+	// pending one. It is not asked for until confirmed with ConfirmTOTP.
+	EnrollTOTP(ctx context.Context, userID common.ID, email string) (*domain.TOTPEnrollment, error)
+	// ConfirmTOTP enables two-factor authentication once code matches the
+	// pending secret. It returns the recovery codes of the user, which are
+	// not shown again.
+	ConfirmTOTP(ctx context.Context, userID common.ID, code string) ([]string, error)
+	// DisableTOTP turns two-factor authentication off, given a code. It
+	// fails with ErrMFARequired if an apartment of the user requires it.
+	DisableTOTP(ctx context.Context, userID common.ID, code string) error
+	// RegenerateRecoveryCodes replaces the recovery codes of the user,
+	// given a code.
+	RegenerateRecoveryCodes(ctx context.Context, userID common.ID, code string) ([]string, error)
+	// MFARequired tells whether the user administers an apartment that
+	// requires its admin to use two-factor authentication.
+	MFARequired(ctx context.Context, userID common.ID) (bool, error)
+	// CheckLogin fails with ErrLoginThrottled, and how long to wait, if
+	// email or ip failed to sign in too often lately. Unknown emails are
+	// throttled the same as known ones.
+	CheckLogin(ctx context.Context, email common.Email, ip string) (time.Duration, error)
+	// LoginFailed counts a wrong email or password against email and ip.
+	LoginFailed(ctx context.Context, email common.Email, ip string) error
+	// LoginSucceeded forgets the failed sign-ins of email.
+	LoginSucceeded(ctx context.Context, email common.Email, ip string) error
+	// OIDCLogin starts a sign-in with the OpenID Connect provider. It
+	// returns where to send the user, and a state token to keep until
+	// OIDCCallback. It fails with ErrOIDCDisabled without a provider.
+	OIDCLogin(ctx context.Context) (authURL, stateToken string, err error)
+	// OIDCCallback finishes the sign-in with the state and code the
+	// provider sent back. It signs in the user linked to the account at
+	// the provider, first linking the user of the same email, or creating
+	// one, if the provider verified the email.
+	OIDCCallback(ctx context.Context, stateToken, state, code string) (*domain.SignIn, error)
+	// CreatePersonalToken stores t for its user and returns the token,
+	// which is not shown again.
+	CreatePersonalToken(ctx context.Context, t *domain.PersonalToken) (string, error)
+	PersonalTokens(ctx context.Context, userID common.ID) ([]domain.PersonalToken, error)
+	// RevokePersonalToken deletes a token of the user, or fails with
+	// ErrPersonalTokenNotFound.
+	RevokePersonalToken(ctx context.Context, userID, id common.ID) error
+	// AuthenticatePersonalToken returns the unexpired personal access token
+	// of token and records its use, or fails with ErrInvalidPersonalToken.
+	AuthenticatePersonalToken(ctx context.Context, token string) (*domain.PersonalToken, error)
+}
+
+type Repo interface {
+	CreateRefreshToken(ctx context.Context, t *domain.RefreshToken) error
+	GetRefreshToken(ctx context.Context, id common.ID) (*domain.RefreshToken, error)
+	// RotateRefreshToken marks the token used and stores next in one
+	// transaction. It fails with ErrRefreshTokenReused if the token is no
+	// longer usable.
+	RotateRefreshToken(ctx context.Context, usedID common.ID, next *domain.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID common.ID) error
+	RevokeUserTokens(ctx context.Context, userID common.ID) error
+	// UserByEmail returns the user id and name of email, or
+	// ErrUserNotFound.
+	UserByEmail(ctx context.Context, email common.Email) (userID common.ID, name string, err error)
+	CreatePasswordReset(ctx context.Context, r *domain.PasswordReset) error
+	// ResetPassword uses the unexpired reset of tokenHash, stores the new
+	// password hash and revokes the refresh tokens of its user in one
+	// transaction. It fails with ErrInvalidResetToken if the token is
+	// unknown, used or expired.
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash []byte) (userID common.ID, err error)
+	// EmailVerification returns the email state of the user, or
+	// ErrUserNotFound.
+	EmailVerification(ctx context.Context, userID common.ID) (*domain.EmailVerification, error)
+	// ClaimVerificationSend records a verification email sent at now,
+	// unless one was sent after sentBefore, in which case it fails with
+	// ErrVerificationThrottled.
+	ClaimVerificationSend(ctx context.Context, userID common.ID, now, sentBefore time.Time) error
+	// MarkEmailVerified fails with ErrInvalidVerificationToken if email is
+	// no longer the email of the user.
+	MarkEmailVerified(ctx context.Context, userID common.ID, email common.Email) error
+	// TOTP returns the authenticator of the user, or ErrTOTPNotFound.
+	TOTP(ctx context.Context, userID common.ID) (*domain.TOTP, error)
+	// SavePendingTOTP stores t, replacing a pending one. It fails with
+	// ErrMFAAlreadyEnabled if the user has a confirmed one.
+	SavePendingTOTP(ctx context.Context, t *domain.TOTP) error
+	// ConfirmTOTP confirms the authenticator of the user with the code of
+	// step and replaces their recovery codes in one transaction.
+	ConfirmTOTP(ctx context.Context, userID common.ID, step int64, codeHashes []string) error
+	// UseTOTPStep records the code of step used and clears failed
+	// attempts. It fails with ErrInvalidMFACode if a code of step or a
+	// later one was used already.
+	UseTOTPStep(ctx context.Context, userID common.ID, step int64) error
+	// UseRecoveryCode marks the unused recovery code of codeHash used and
+	// clears failed attempts, or fails with ErrInvalidMFACode.
+	UseRecoveryCode(ctx context.Context, userID common.ID, codeHash string) error
+	// RecordMFAFailure counts a wrong code. Reaching maxAttempts locks
+	// verification until lockedUntil and starts counting again.
+	RecordMFAFailure(ctx context.Context, userID common.ID, maxAttempts int, lockedUntil time.Time) error
+	ReplaceRecoveryCodes(ctx context.Context, userID common.ID, codeHashes []string) error
+	// DeleteTOTP removes the authenticator and recovery codes of the user.
+	DeleteTOTP(ctx context.Context, userID common.ID) error
+	MFARequired(ctx context.Context, userID common.ID) (bool, error)
+	// UserByIdentity returns the user linked to the account at issuer,
+	// and their email, or ErrIdentityNotFound.
+	UserByIdentity(ctx context.Context, issuer, subject string) (userID common.ID, email common.Email, err error)
+	LinkIdentity(ctx context.Context, i *domain.OIDCIdentity) error
+	// CreateIdentityUser creates u, with a verified email, linked to i in
+	// one transaction and returns its id.
+	CreateIdentityUser(ctx context.Context, u *userDomain.User, i *domain.OIDCIdentity) (common.ID, error)
+	CreatePersonalToken(ctx context.Context, t *domain.PersonalToken) error
+	// PersonalTokens returns the tokens of the user, newest first.
+	PersonalTokens(ctx context.Context, userID common.ID) ([]domain.PersonalToken, error)
+	// PersonalTokenByHash returns the token of tokenHash, of a user who is
+	// not deleted, or ErrPersonalTokenNotFound.
+	PersonalTokenByHash(ctx context.Context, tokenHash string) (*domain.PersonalToken, error)
+	// DeletePersonalToken fails with ErrPersonalTokenNotFound if the user
+	// has no token id.
+	DeletePersonalToken(ctx context.Context, userID, id common.ID) error
+	TouchPersonalToken(ctx context.Context, id common.ID, usedAt time.Time) error
+}
+
+// AttemptStore keeps failed sign-ins by key, an account or an IP address.
+// It is shared by every node serving sign-ins.
+type AttemptStore interface {
+	// LoginAttempts returns the attempts of key, with no failures if there
+	// are none.
+	LoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error)
+	// AddLoginFailure counts a failure of key at now, forgetting failures
+	// older than windowStart, and returns the attempts.
+	AddLoginFailure(ctx context.Context, key string, now, windowStart time.Time) (*domain.LoginAttempts, error)
+	// LockLogin locks key until until and starts counting again.
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with.
+type OIDCProvider interface {
+	// AuthURL returns the provider page that signs the user in and sends
+	// them back with a code, given the state, nonce and PKCE challenge.
+	AuthURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	// Identity exchanges the code for an ID token, verifies it and its
+	// nonce, and returns the account it names.
+	Identity(ctx context.Context, code, codeVerifier, nonce string) (*domain.OIDCIdentity, error)
+}
+
+type EmailSender interface {
+	Send(to []string, msg *common.EmailMessage) error
+}
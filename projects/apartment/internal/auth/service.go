@@ -0,0 +1,588 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/template"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnLogin                  = errors.New("error on login")
+	ErrOnRefresh                = errors.New("error on refresh token")
+	ErrOnLogout                 = errors.New("error on logout")
+	ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+	ErrRefreshTokenNotFound     = errors.New("refresh token not found")
+	ErrRefreshTokenRevoked      = errors.New("refresh token revoked")
+	ErrRefreshTokenReused       = errors.New("refresh token already used, the session was revoked")
+	ErrOnForgotPassword         = errors.New("error on forgot password")
+	ErrOnResetPassword          = errors.New("error on reset password")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidEmail             = errors.New("invalid email address")
+	ErrOnSendVerification       = errors.New("error on sending email verification")
+	ErrOnVerifyEmail            = errors.New("error on verifying email")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrVerificationThrottled    = errors.New("verification email sent recently, try again later")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+const (
+	DefaultAccessExpiry  = 15 * time.Minute
+	DefaultRefreshExpiry = 7 * 24 * time.Hour
+	DefaultResetExpiry   = 30 * time.Minute
+	DefaultVerifyExpiry  = 24 * time.Hour
+	// DefaultResendInterval is how long a user waits before another
+	// verification email is sent.
+	DefaultResendInterval = time.Minute
+	// DefaultMFAChallengeExpiry is how long a user has to enter their
+	// code after their password.
+	DefaultMFAChallengeExpiry = 5 * time.Minute
+	// DefaultTOTPIssuer names the account in authenticator apps.
+	DefaultTOTPIssuer = "Apartment"
+	// DefaultMaxLoginAttempts is how many failed sign-ins lock an account
+	// for DefaultLoginLockout.
+	DefaultMaxLoginAttempts = 10
+	// DefaultMaxIPLoginAttempts is how many failed sign-ins, of any
+	// accounts, lock an IP address for DefaultLoginLockout.
+	DefaultMaxIPLoginAttempts = 50
+	DefaultLoginLockout       = 15 * time.Minute
+	// OIDCStateExpiry is how long a user has to sign in at the identity
+	// provider.
+	OIDCStateExpiry = 10 * time.Minute
+)
+
+type service struct {
+	repo           port.Repo
+	keys           *appjwt.KeySet
+	accessExpiry   time.Duration
+	refreshExpiry  time.Duration
+	resetExpiry    time.Duration
+	verifyExpiry   time.Duration
+	resendInterval time.Duration
+	challengeExp   time.Duration
+	totpIssuer     string
+	attempts       port.AttemptStore
+	accountLimit   loginLimit
+	ipLimit        loginLimit
+	loginLockout   time.Duration
+	oidc           port.OIDCProvider
+	mail           port.EmailSender
+	now            func() time.Time
+}
+
+type ServiceOpt func(*service)
+
+// WithAccessExpiry sets how long access tokens are valid.
+func WithAccessExpiry(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.accessExpiry = d
+		}
+	}
+}
+
+// WithRefreshExpiry sets how long refresh tokens are valid. Every refresh
+// issues a token valid for this long again.
+func WithRefreshExpiry(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.refreshExpiry = d
+		}
+	}
+}
+
+// WithResetExpiry sets how long a password reset link works.
+func WithResetExpiry(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.resetExpiry = d
+		}
+	}
+}
+
+// WithVerifyExpiry sets how long an email verification link works.
+func WithVerifyExpiry(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.verifyExpiry = d
+		}
+	}
+}
+
+// WithResendInterval sets how long a user waits before another
+// verification email is sent.
+func WithResendInterval(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.resendInterval = d
+		}
+	}
+}
+
+// WithMFAChallengeExpiry sets how long a sign-in challenge can be
+// exchanged for tokens.
+func WithMFAChallengeExpiry(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.challengeExp = d
+		}
+	}
+}
+
+// WithTOTPIssuer sets the issuer shown in authenticator apps.
+func WithTOTPIssuer(issuer string) ServiceOpt {
+	return func(s *service) {
+		if issuer != "" {
+			s.totpIssuer = issuer
+		}
+	}
+}
+
+// WithAttemptStore sets where failed sign-ins are counted. Without one,
+// sign-ins are not throttled.
+func WithAttemptStore(store port.AttemptStore) ServiceOpt {
+	return func(s *service) {
+		s.attempts = store
+	}
+}
+
+// WithMaxLoginAttempts sets how many failed sign-ins lock an account.
+func WithMaxLoginAttempts(n int) ServiceOpt {
+	return func(s *service) {
+		if n > 0 {
+			s.accountLimit.max = n
+		}
+	}
+}
+
+// WithMaxIPLoginAttempts sets how many failed sign-ins lock an IP address.
+func WithMaxIPLoginAttempts(n int) ServiceOpt {
+	return func(s *service) {
+		if n > 0 {
+			s.ipLimit.max = n
+		}
+	}
+}
+
+// WithLoginLockout sets how long a locked account or IP address can't sign
+// in, and how long failed sign-ins are remembered.
+func WithLoginLockout(d time.Duration) ServiceOpt {
+	return func(s *service) {
+		if d > 0 {
+			s.loginLockout = d
+		}
+	}
+}
+
+// WithOIDCProvider sets the OpenID Connect provider users can sign in
+// with. Without one, they can't.
+func WithOIDCProvider(p port.OIDCProvider) ServiceOpt {
+	return func(s *service) {
+		s.oidc = p
+	}
+}
+
+// WithMailer sets the sender of password reset and verification emails.
+// Without one, the emails are not sent.
+func WithMailer(m port.EmailSender) ServiceOpt {
+	return func(s *service) {
+		s.mail = m
+	}
+}
+
+func NewService(repo port.Repo, keys *appjwt.KeySet, opts ...ServiceOpt) port.Service {
+	s := &service{
+		repo:           repo,
+		keys:           keys,
+		accessExpiry:   DefaultAccessExpiry,
+		refreshExpiry:  DefaultRefreshExpiry,
+		resetExpiry:    DefaultResetExpiry,
+		verifyExpiry:   DefaultVerifyExpiry,
+		resendInterval: DefaultResendInterval,
+		challengeExp:   DefaultMFAChallengeExpiry,
+		totpIssuer:     DefaultTOTPIssuer,
+		accountLimit:   loginLimit{free: accountFreeAttempts, max: DefaultMaxLoginAttempts},
+		ipLimit:        loginLimit{free: ipFreeAttempts, max: DefaultMaxIPLoginAttempts},
+		loginLockout:   DefaultLoginLockout,
+		now:            time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Login(ctx context.Context, userID common.ID, email string) (*domain.Tokens, error) {
+	return s.login(ctx, userID, email, false)
+}
+
+func (s *service) login(ctx context.Context, userID common.ID, email string, mfa bool) (*domain.Tokens, error) {
+	rt := s.newRefreshToken(common.NewRandomID(), userID)
+	tokens, err := s.issue(rt, email, mfa)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnLogin, err)
+	}
+	if err = s.repo.CreateRefreshToken(ctx, rt); err != nil {
+		return nil, fp.WrapErrors(ErrOnLogin, err)
+	}
+	return tokens, nil
+}
+
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	log := appctx.Logger(ctx)
+
+	claims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefresh, err)
+	}
+	used, err := s.repo.GetRefreshToken(ctx, common.IDFromText(claims.ID))
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenNotFound) {
+			return nil, fp.WrapErrors(ErrOnRefresh, ErrInvalidRefreshToken)
+		}
+		return nil, fp.WrapErrors(ErrOnRefresh, err)
+	}
+	if used.RevokedAt != nil {
+		return nil, fp.WrapErrors(ErrOnRefresh, ErrRefreshTokenRevoked)
+	}
+	if used.UsedAt != nil {
+		return nil, s.revokeReused(ctx, used)
+	}
+
+	next := s.newRefreshToken(used.FamilyID, used.UserID)
+	tokens, err := s.issue(next, claims.UserEMail, claims.MFA)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefresh, err)
+	}
+	err = s.repo.RotateRefreshToken(ctx, used.ID, next)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// used concurrently
+		log.Warn("refresh token raced", zap.String("familyId", used.FamilyID.String()))
+		return nil, s.revokeReused(ctx, used)
+	}
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefresh, err)
+	}
+	return tokens, nil
+}
+
+// revokeReused revokes the family of a refresh token presented again after
+// it was rotated: either the client or an attacker holds a stolen copy.
+func (s *service) revokeReused(ctx context.Context, t *domain.RefreshToken) error {
+	log := appctx.Logger(ctx)
+
+	log.Warn("refresh token reuse detected", zap.String("userId", t.UserID.String()),
+		zap.String("familyId", t.FamilyID.String()))
+	if err := s.repo.RevokeFamily(ctx, t.FamilyID); err != nil {
+		return fp.WrapErrors(ErrOnRefresh, ErrRefreshTokenReused, err)
+	}
+	return fp.WrapErrors(ErrOnRefresh, ErrRefreshTokenReused)
+}
+
+func (s *service) Logout(ctx context.Context, refreshToken string) error {
+	claims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return fp.WrapErrors(ErrOnLogout, err)
+	}
+	if err = s.repo.RevokeFamily(ctx, common.IDFromText(claims.FamilyID)); err != nil {
+		return fp.WrapErrors(ErrOnLogout, err)
+	}
+	return nil
+}
+
+func (s *service) LogoutAll(ctx context.Context, userID common.ID) error {
+	if err := s.repo.RevokeUserTokens(ctx, userID); err != nil {
+		return fp.WrapErrors(ErrOnLogout, err)
+	}
+	return nil
+}
+
+func (s *service) ForgotPassword(ctx context.Context, email common.Email, resetURL string) error {
+	log := appctx.Logger(ctx)
+
+	if !email.IsValid() {
+		return fp.WrapErrors(ErrOnForgotPassword, ErrInvalidEmail)
+	}
+	userID, name, err := s.repo.UserByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		log.Info("password reset for unknown email")
+		return nil
+	}
+	if err != nil {
+		return fp.WrapErrors(ErrOnForgotPassword, err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return fp.WrapErrors(ErrOnForgotPassword, err)
+	}
+	now := s.now()
+	err = s.repo.CreatePasswordReset(ctx, &domain.PasswordReset{
+		ID:        common.NewRandomID(),
+		CreatedAt: now,
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.resetExpiry),
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnForgotPassword, err)
+	}
+
+	link, err := withToken(resetURL, token)
+	if err != nil {
+		return fp.WrapErrors(ErrOnForgotPassword, err)
+	}
+	body, err := template.NewPasswordReset(template.PasswordResetData{
+		Name:      displayName(name, email),
+		ResetLink: link,
+		ExpiresIn: formatDuration(s.resetExpiry),
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnForgotPassword, err)
+	}
+
+	// sent in the background so that known and unknown emails answer
+	// alike
+	go s.sendMail(context.WithoutCancel(ctx), email, &common.EmailMessage{
+		Subject: "reset your password",
+		Body:    body,
+		IsHTML:  true,
+	})
+	return nil
+}
+
+func (s *service) sendMail(ctx context.Context, to common.Email, msg *common.EmailMessage) {
+	log := appctx.Logger(ctx)
+
+	if s.mail == nil {
+		log.Warn("no mailer, email not sent", zap.String("subject", msg.Subject))
+		return
+	}
+	if err := s.mail.Send([]string{to.String()}, msg); err != nil {
+		log.Error("send email", zap.String("subject", msg.Subject), zap.Error(err))
+	}
+}
+
+func (s *service) ResetPassword(ctx context.Context, token, password string) error {
+	log := appctx.Logger(ctx)
+
+	u := userDomain.NewUser(userDomain.NilID, "", password, "", "")
+	if err := u.HashPassword(); err != nil {
+		return fp.WrapErrors(ErrOnResetPassword, err)
+	}
+	if token == "" {
+		return fp.WrapErrors(ErrOnResetPassword, ErrInvalidResetToken)
+	}
+	userID, err := s.repo.ResetPassword(ctx, hashToken(token), u.Password())
+	if err != nil {
+		return fp.WrapErrors(ErrOnResetPassword, err)
+	}
+	log.Info("password reset", zap.String("userId", userID.String()))
+	return nil
+}
+
+func (s *service) SendVerification(ctx context.Context, userID common.ID, verifyURL string) error {
+	ev, err := s.repo.EmailVerification(ctx, userID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendVerification, err)
+	}
+	if ev.VerifiedAt != nil {
+		return fp.WrapErrors(ErrOnSendVerification, ErrEmailAlreadyVerified)
+	}
+
+	now := s.now()
+	token, err := appjwt.CreateToken(s.keys, &appjwt.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.verifyExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		UserID:    ev.UserID.String(),
+		UserEMail: ev.Email.String(),
+		Type:      appjwt.EmailVerifyToken,
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendVerification, err)
+	}
+	link, err := withToken(verifyURL, token)
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendVerification, err)
+	}
+	body, err := template.NewEmailVerification(template.EmailVerificationData{
+		Name:       displayName(ev.Name, ev.Email),
+		VerifyLink: link,
+		ExpiresIn:  formatDuration(s.verifyExpiry),
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendVerification, err)
+	}
+
+	err = s.repo.ClaimVerificationSend(ctx, userID, now, now.Add(-s.resendInterval))
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendVerification, err)
+	}
+	go s.sendMail(context.WithoutCancel(ctx), ev.Email, &common.EmailMessage{
+		Subject: "verify your email",
+		Body:    body,
+		IsHTML:  true,
+	})
+	return nil
+}
+
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	log := appctx.Logger(ctx)
+
+	claims, err := appjwt.ParseTokenOfType(token, s.keys, appjwt.EmailVerifyToken)
+	if err != nil {
+		return fp.WrapErrors(ErrOnVerifyEmail, ErrInvalidVerificationToken, err)
+	}
+	if common.ValidateID(claims.UserID) != nil {
+		return fp.WrapErrors(ErrOnVerifyEmail, ErrInvalidVerificationToken)
+	}
+	userID := common.IDFromText(claims.UserID)
+	err = s.repo.MarkEmailVerified(ctx, userID, common.Email(claims.UserEMail))
+	if err != nil {
+		return fp.WrapErrors(ErrOnVerifyEmail, err)
+	}
+	log.Info("email verified", zap.String("userId", userID.String()))
+	return nil
+}
+
+func (s *service) EmailVerified(ctx context.Context, userID common.ID) (bool, error) {
+	ev, err := s.repo.EmailVerification(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return ev.VerifiedAt != nil, nil
+}
+
+// withToken returns rawURL with token added as the token query parameter.
+func withToken(rawURL, token string) (string, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+	return link.String(), nil
+}
+
+// displayName returns name, or the local part of email if name is empty.
+func displayName(name string, email common.Email) string {
+	if name != "" {
+		return name
+	}
+	return strings.Split(email.String(), "@")[0]
+}
+
+// newToken returns a random URL-safe token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken hashes random tokens for storage. They are long enough not to
+// need a slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatDuration writes d in whole hours or minutes, e.g. "30 minutes".
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	m := max(d/time.Minute, 1)
+	if m == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", m)
+}
+
+func (s *service) parseRefreshToken(token string) (*appjwt.UserClaims, error) {
+	claims, err := appjwt.ParseTokenOfType(token, s.keys, appjwt.RefreshToken)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrInvalidRefreshToken, err)
+	}
+	if common.ValidateID(claims.ID) != nil || common.ValidateID(claims.FamilyID) != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return claims, nil
+}
+
+func (s *service) newRefreshToken(familyID, userID common.ID) *domain.RefreshToken {
+	now := s.now()
+	return &domain.RefreshToken{
+		ID:        common.NewRandomID(),
+		CreatedAt: now,
+		FamilyID:  familyID,
+		UserID:    userID,
+		ExpiresAt: now.Add(s.refreshExpiry),
+	}
+}
+
+// issue signs an access token and the refresh token rt. mfa tells that
+// the user passed two-factor authentication.
+func (s *service) issue(rt *domain.RefreshToken, email string, mfa bool) (*domain.Tokens, error) {
+	now := s.now()
+	accessExpiresAt := now.Add(s.accessExpiry)
+	access, err := appjwt.CreateToken(s.keys, &appjwt.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(accessExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		UserID:    rt.UserID.String(),
+		UserEMail: email,
+		Type:      appjwt.AccessToken,
+		MFA:       mfa,
+	})
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := appjwt.CreateToken(s.keys, &appjwt.UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rt.ID.String(),
+			ExpiresAt: jwt.NewNumericDate(rt.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		UserID:    rt.UserID.String(),
+		UserEMail: email,
+		Type:      appjwt.RefreshToken,
+		FamilyID:  rt.FamilyID.String(),
+		MFA:       mfa,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Tokens{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: rt.ExpiresAt,
+	}, nil
+}
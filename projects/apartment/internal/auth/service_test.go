@@ -0,0 +1,404 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	appjwt "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/jwt"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+var keys = appjwt.NewSecretKeySet([]byte("secret"))
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) CreateRefreshToken(ctx context.Context, t *domain.RefreshToken) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetRefreshToken(ctx context.Context, id common.ID) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, id)
+	res, _ := args.Get(0).(*domain.RefreshToken)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) RotateRefreshToken(ctx context.Context, usedID common.ID, next *domain.RefreshToken) error {
+	args := m.Called(ctx, usedID, next)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeFamily(ctx context.Context, familyID common.ID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RevokeUserTokens(ctx context.Context, userID common.ID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) UserByEmail(ctx context.Context, email common.Email) (common.ID, string, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(common.ID), args.String(1), args.Error(2)
+}
+
+func (m *MockRepo) CreatePasswordReset(ctx context.Context, r *domain.PasswordReset) error {
+	args := m.Called(ctx, r)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ResetPassword(ctx context.Context, tokenHash string, passwordHash []byte) (common.ID, error) {
+	args := m.Called(ctx, tokenHash, passwordHash)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) EmailVerification(ctx context.Context, userID common.ID) (*domain.EmailVerification, error) {
+	args := m.Called(ctx, userID)
+	res, _ := args.Get(0).(*domain.EmailVerification)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) ClaimVerificationSend(ctx context.Context, userID common.ID, now, sentBefore time.Time) error {
+	args := m.Called(ctx, userID, now, sentBefore)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkEmailVerified(ctx context.Context, userID common.ID, email common.Email) error {
+	args := m.Called(ctx, userID, email)
+	return args.Error(0)
+}
+
+type MockEmailSender struct {
+	mock.Mock
+}
+
+func (m *MockEmailSender) Send(to []string, msg *common.EmailMessage) error {
+	args := m.Called(to, msg)
+	return args.Error(0)
+}
+
+// login signs in userID and returns the tokens with the stored refresh
+// token.
+func login(t *testing.T, svc port.Service, repo *MockRepo, userID common.ID) (*domain.Tokens, *domain.RefreshToken) {
+	var stored *domain.RefreshToken
+	repo.On("CreateRefreshToken", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.RefreshToken) }).
+		Return(nil).Once()
+	tokens, err := svc.Login(ctx, userID, "user@example.com")
+	assert.NoError(t, err)
+	return tokens, stored
+}
+
+// sentLink waits for an email on sent and returns the link in it that
+// starts with prefix.
+func sentLink(t *testing.T, sent <-chan *common.EmailMessage, prefix string) *url.URL {
+	t.Helper()
+	var msg *common.EmailMessage
+	select {
+	case msg = <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("email not sent")
+	}
+	body := string(msg.Body)
+	start := strings.Index(body, prefix)
+	if start < 0 {
+		t.Fatalf("no link %q in email", prefix)
+	}
+	end := start + strings.Index(body[start:], `"`)
+	link, err := url.Parse(strings.ReplaceAll(body[start:end], "&amp;", "&"))
+	assert.NoError(t, err)
+	return link
+}
+
+// ----------- Tests -------------
+
+func TestLogin_IssuesTypedTokens(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+
+	tokens, stored := login(t, svc, repo, userID)
+
+	access, err := appjwt.ParseTokenOfType(tokens.AccessToken, keys, appjwt.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, userID.String(), access.UserID)
+
+	refresh, err := appjwt.ParseTokenOfType(tokens.RefreshToken, keys, appjwt.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, stored.ID.String(), refresh.ID)
+	assert.Equal(t, stored.FamilyID.String(), refresh.FamilyID)
+	assert.Equal(t, userID, stored.UserID)
+
+	_, err = appjwt.ParseTokenOfType(tokens.AccessToken, keys, appjwt.RefreshToken)
+	assert.ErrorIs(t, err, appjwt.ErrWrongTokenType)
+}
+
+func TestRefresh_Rotates(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, stored := login(t, svc, repo, common.NewRandomID())
+
+	repo.On("GetRefreshToken", ctx, stored.ID).Return(stored, nil)
+	repo.On("RotateRefreshToken", ctx, stored.ID, mock.MatchedBy(func(next *domain.RefreshToken) bool {
+		return next.ID != stored.ID && next.FamilyID == stored.FamilyID && next.UserID == stored.UserID
+	})).Return(nil)
+
+	next, err := svc.Refresh(ctx, tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, next.RefreshToken)
+	repo.AssertExpectations(t)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, stored := login(t, svc, repo, common.NewRandomID())
+
+	usedAt := time.Now()
+	stored.UsedAt = &usedAt
+	repo.On("GetRefreshToken", ctx, stored.ID).Return(stored, nil)
+	repo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+
+	_, err := svc.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefresh_ConcurrentUseRevokesFamily(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, stored := login(t, svc, repo, common.NewRandomID())
+
+	repo.On("GetRefreshToken", ctx, stored.ID).Return(stored, nil)
+	repo.On("RotateRefreshToken", ctx, stored.ID, mock.Anything).Return(ErrRefreshTokenReused)
+	repo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+
+	_, err := svc.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	repo.AssertExpectations(t)
+}
+
+func TestRefresh_Revoked(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, stored := login(t, svc, repo, common.NewRandomID())
+
+	revokedAt := time.Now()
+	stored.RevokedAt = &revokedAt
+	repo.On("GetRefreshToken", ctx, stored.ID).Return(stored, nil)
+
+	_, err := svc.Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenRevoked)
+}
+
+func TestRefresh_RejectsAccessToken(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, _ := login(t, svc, repo, common.NewRandomID())
+
+	_, err := svc.Refresh(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	repo.AssertNotCalled(t, "GetRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestRefresh_RejectsOtherSecret(t *testing.T) {
+	repo := new(MockRepo)
+	tokens, _ := login(t, NewService(repo, appjwt.NewSecretKeySet([]byte("other"))), repo, common.NewRandomID())
+
+	_, err := NewService(repo, keys).Refresh(ctx, tokens.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestLogout_RevokesFamily(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, stored := login(t, svc, repo, common.NewRandomID())
+
+	repo.On("RevokeFamily", ctx, stored.FamilyID).Return(nil)
+
+	assert.NoError(t, svc.Logout(ctx, tokens.RefreshToken))
+	repo.AssertExpectations(t)
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	repo := new(MockRepo)
+	mail := new(MockEmailSender)
+	svc := NewService(repo, keys, WithMailer(mail))
+
+	repo.On("UserByEmail", ctx, common.Email("nobody@example.com")).
+		Return(common.NilID, "", ErrUserNotFound)
+
+	err := svc.ForgotPassword(ctx, "nobody@example.com", "https://example.com/reset")
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "CreatePasswordReset", mock.Anything, mock.Anything)
+	mail.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestForgotPassword_InvalidEmail(t *testing.T) {
+	svc := NewService(new(MockRepo), keys)
+
+	err := svc.ForgotPassword(ctx, "not-an-email", "https://example.com/reset")
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+}
+
+func TestForgotPassword_MailsHashedToken(t *testing.T) {
+	repo := new(MockRepo)
+	mail := new(MockEmailSender)
+	svc := NewService(repo, keys, WithMailer(mail), WithResetExpiry(time.Hour))
+	userID := common.NewRandomID()
+
+	var stored *domain.PasswordReset
+	repo.On("UserByEmail", ctx, common.Email("user@example.com")).Return(userID, "Alex", nil)
+	repo.On("CreatePasswordReset", ctx, mock.Anything).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.PasswordReset) }).
+		Return(nil)
+	sent := make(chan *common.EmailMessage, 1)
+	mail.On("Send", []string{"user@example.com"}, mock.Anything).
+		Run(func(args mock.Arguments) { sent <- args.Get(1).(*common.EmailMessage) }).
+		Return(nil)
+
+	err := svc.ForgotPassword(ctx, "user@example.com", "https://example.com/reset?lang=en")
+	assert.NoError(t, err)
+
+	link := sentLink(t, sent, "https://example.com/reset?")
+	token := link.Query().Get("token")
+
+	assert.Equal(t, "en", link.Query().Get("lang"))
+	assert.NotEmpty(t, token)
+	assert.Equal(t, userID, stored.UserID)
+	assert.Equal(t, hashToken(token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, token)
+	assert.Equal(t, time.Hour, stored.ExpiresAt.Sub(stored.CreatedAt))
+}
+
+func TestResetPassword(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+
+	var hash []byte
+	repo.On("ResetPassword", ctx, hashToken("token"), mock.Anything).
+		Run(func(args mock.Arguments) { hash = args.Get(2).([]byte) }).
+		Return(userID, nil)
+
+	assert.NoError(t, svc.ResetPassword(ctx, "token", "new-password"))
+
+	u := &userDomain.User{}
+	u.SetPassword(hash)
+	assert.True(t, u.IsHashed())
+	assert.NoError(t, u.ComparePassword([]byte("new-password")))
+}
+
+func TestResetPassword_ValidatesPassword(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+
+	err := svc.ResetPassword(ctx, "token", "short")
+	assert.ErrorIs(t, err, userDomain.ErrUserShortPassword)
+	repo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_InvalidToken(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+
+	repo.On("ResetPassword", ctx, hashToken("used"), mock.Anything).
+		Return(common.NilID, ErrInvalidResetToken)
+
+	err := svc.ResetPassword(ctx, "used", "new-password")
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestSendVerification_Roundtrip(t *testing.T) {
+	repo := new(MockRepo)
+	mail := new(MockEmailSender)
+	now := time.Now()
+	svc := NewService(repo, keys, WithMailer(mail), WithResendInterval(2*time.Minute))
+	svc.(*service).now = func() time.Time { return now }
+	userID := common.NewRandomID()
+
+	repo.On("EmailVerification", ctx, userID).Return(&domain.EmailVerification{
+		UserID: userID,
+		Email:  "user@example.com",
+	}, nil)
+	repo.On("ClaimVerificationSend", ctx, userID, now, now.Add(-2*time.Minute)).Return(nil)
+	sent := make(chan *common.EmailMessage, 1)
+	mail.On("Send", []string{"user@example.com"}, mock.Anything).
+		Run(func(args mock.Arguments) { sent <- args.Get(1).(*common.EmailMessage) }).
+		Return(nil)
+
+	err := svc.SendVerification(ctx, userID, "https://example.com/verify")
+	assert.NoError(t, err)
+
+	link := sentLink(t, sent, "https://example.com/verify?")
+	repo.On("MarkEmailVerified", ctx, userID, common.Email("user@example.com")).Return(nil)
+	assert.NoError(t, svc.VerifyEmail(ctx, link.Query().Get("token")))
+	repo.AssertExpectations(t)
+}
+
+func TestSendVerification_AlreadyVerified(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	userID := common.NewRandomID()
+	verifiedAt := time.Now()
+
+	repo.On("EmailVerification", ctx, userID).Return(&domain.EmailVerification{
+		UserID:     userID,
+		Email:      "user@example.com",
+		VerifiedAt: &verifiedAt,
+	}, nil)
+
+	err := svc.SendVerification(ctx, userID, "https://example.com/verify")
+	assert.ErrorIs(t, err, ErrEmailAlreadyVerified)
+	repo.AssertNotCalled(t, "ClaimVerificationSend", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSendVerification_Throttled(t *testing.T) {
+	repo := new(MockRepo)
+	mail := new(MockEmailSender)
+	svc := NewService(repo, keys, WithMailer(mail))
+	userID := common.NewRandomID()
+
+	repo.On("EmailVerification", ctx, userID).Return(&domain.EmailVerification{
+		UserID: userID,
+		Email:  "user@example.com",
+	}, nil)
+	repo.On("ClaimVerificationSend", ctx, userID, mock.Anything, mock.Anything).
+		Return(ErrVerificationThrottled)
+
+	err := svc.SendVerification(ctx, userID, "https://example.com/verify")
+	assert.ErrorIs(t, err, ErrVerificationThrottled)
+	mail.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestVerifyEmail_RejectsOtherTokens(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, keys)
+	tokens, _ := login(t, svc, repo, common.NewRandomID())
+
+	err := svc.VerifyEmail(ctx, tokens.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	repo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnCheckLogin   = errors.New("error on checking sign-in attempts")
+	ErrOnRecordLogin  = errors.New("error on recording sign-in attempt")
+	ErrLoginThrottled = errors.New("too many failed sign-ins, try again later")
+)
+
+const (
+	// failed sign-ins before each further one has to wait
+	accountFreeAttempts = 3
+	ipFreeAttempts      = 10
+	// the wait doubles with every failure up to maxLoginDelay
+	maxLoginDelay = 30 * time.Second
+)
+
+// loginLimit throttles the failed sign-ins of one key: after free
+// failures each one waits progressively longer, and max of them lock the
+// key.
+type loginLimit struct {
+	free int
+	max  int
+}
+
+// wait returns how long a of key has to wait before it may try again.
+func (l loginLimit) wait(a *domain.LoginAttempts, now time.Time) time.Duration {
+	if a.LockedUntil != nil && now.Before(*a.LockedUntil) {
+		return a.LockedUntil.Sub(now)
+	}
+	if a.Failures < l.free {
+		return 0
+	}
+	delay := maxLoginDelay
+	if n := a.Failures - l.free; n < 5 {
+		delay = min(time.Second<<n, maxLoginDelay)
+	}
+	return max(a.LastFailureAt.Add(delay).Sub(now), 0)
+}
+
+type loginKey struct {
+	key   string
+	limit loginLimit
+}
+
+// loginKeys returns the keys a sign-in of email from ip counts against.
+func (s *service) loginKeys(email common.Email, ip string) []loginKey {
+	keys := []loginKey{{
+		key:   "account:" + strings.ToLower(strings.TrimSpace(email.String())),
+		limit: s.accountLimit,
+	}}
+	if ip != "" {
+		keys = append(keys, loginKey{key: "ip:" + ip, limit: s.ipLimit})
+	}
+	return keys
+}
+
+func (s *service) CheckLogin(ctx context.Context, email common.Email, ip string) (time.Duration, error) {
+	if s.attempts == nil {
+		return 0, nil
+	}
+	now := s.now()
+	var wait time.Duration
+	for _, k := range s.loginKeys(email, ip) {
+		a, err := s.attempts.LoginAttempts(ctx, k.key)
+		if err != nil {
+			return 0, fp.WrapErrors(ErrOnCheckLogin, err)
+		}
+		wait = max(wait, k.limit.wait(a, now))
+	}
+	if wait > 0 {
+		appctx.Logger(ctx).Warn("throttled sign-in",
+			zap.String("email", email.String()),
+			zap.String("ip", ip),
+			zap.Duration("wait", wait),
+		)
+		return wait, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+func (s *service) LoginFailed(ctx context.Context, email common.Email, ip string) error {
+	log := appctx.Logger(ctx).With(zap.String("email", email.String()), zap.String("ip", ip))
+
+	log.Warn("failed sign-in")
+	if s.attempts == nil {
+		return nil
+	}
+	now := s.now()
+	for _, k := range s.loginKeys(email, ip) {
+		a, err := s.attempts.AddLoginFailure(ctx, k.key, now, now.Add(-s.loginLockout))
+		if err != nil {
+			return fp.WrapErrors(ErrOnRecordLogin, err)
+		}
+		if a.Failures < k.limit.max {
+			continue
+		}
+		if err = s.attempts.LockLogin(ctx, k.key, now.Add(s.loginLockout)); err != nil {
+			return fp.WrapErrors(ErrOnRecordLogin, err)
+		}
+		log.Warn("sign-in locked",
+			zap.String("key", k.key),
+			zap.Int("failures", a.Failures),
+			zap.Duration("lockout", s.loginLockout),
+		)
+	}
+	return nil
+}
+
+func (s *service) LoginSucceeded(ctx context.Context, email common.Email, ip string) error {
+	appctx.Logger(ctx).Info("sign-in", zap.String("email", email.String()), zap.String("ip", ip))
+	if s.attempts == nil {
+		return nil
+	}
+	// the IP address is left alone, one right password shouldn't clear
+	// guesses at other accounts
+	if err := s.attempts.ResetLoginAttempts(ctx, s.loginKeys(email, "")[0].key); err != nil {
+		return fp.WrapErrors(ErrOnRecordLogin, err)
+	}
+	return nil
+}
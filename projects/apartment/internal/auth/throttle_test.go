@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAttemptStore keeps attempts in a map, like the memory store of the
+// storage adapter.
+type fakeAttemptStore map[string]domain.LoginAttempts
+
+func (f fakeAttemptStore) LoginAttempts(_ context.Context, key string) (*domain.LoginAttempts, error) {
+	a := f[key]
+	a.Key = key
+	return &a, nil
+}
+
+func (f fakeAttemptStore) AddLoginFailure(
+	_ context.Context, key string, now, windowStart time.Time,
+) (
+	*domain.LoginAttempts, error,
+) {
+	a := f[key]
+	if a.LastFailureAt.Before(windowStart) {
+		a.Failures = 0
+	}
+	a.Key = key
+	a.Failures++
+	a.LastFailureAt = now
+	f[key] = a
+	return &a, nil
+}
+
+func (f fakeAttemptStore) LockLogin(_ context.Context, key string, until time.Time) error {
+	a := f[key]
+	a.Failures = 0
+	a.LockedUntil = &until
+	f[key] = a
+	return nil
+}
+
+func (f fakeAttemptStore) ResetLoginAttempts(_ context.Context, key string) error {
+	delete(f, key)
+	return nil
+}
+
+func throttledService(store fakeAttemptStore, now *time.Time) *service {
+	svc := NewService(new(MockRepo), keys,
+		WithAttemptStore(store),
+		WithMaxLoginAttempts(5),
+		WithMaxIPLoginAttempts(8),
+		WithLoginLockout(10*time.Minute),
+	).(*service)
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func TestCheckLogin_ProgressiveDelay(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	svc := throttledService(fakeAttemptStore{}, &now)
+
+	for range accountFreeAttempts {
+		_, err := svc.CheckLogin(ctx, "user@example.com", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", "10.0.0.1"))
+	}
+
+	// the next attempt waits a second, the one after two
+	wait, err := svc.CheckLogin(ctx, "user@example.com", "10.0.0.2")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	assert.Equal(t, time.Second, wait)
+	now = now.Add(time.Second)
+	_, err = svc.CheckLogin(ctx, "user@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+	assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", "10.0.0.2"))
+	wait, err = svc.CheckLogin(ctx, "USER@example.com ", "10.0.0.3")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	assert.Equal(t, 2*time.Second, wait)
+
+	// other accounts are not throttled
+	_, err = svc.CheckLogin(ctx, "other@example.com", "10.0.0.3")
+	assert.NoError(t, err)
+}
+
+func TestLoginFailed_LocksAccount(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	store := fakeAttemptStore{}
+	svc := throttledService(store, &now)
+
+	for range 5 {
+		now = now.Add(time.Minute)
+		assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", ""))
+	}
+	wait, err := svc.CheckLogin(ctx, "user@example.com", "")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	assert.Equal(t, 10*time.Minute, wait)
+
+	// once the lock is over, a success clears the failures
+	now = now.Add(10 * time.Minute)
+	_, err = svc.CheckLogin(ctx, "user@example.com", "")
+	assert.NoError(t, err)
+	assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", ""))
+	assert.NoError(t, svc.LoginSucceeded(ctx, "user@example.com", ""))
+	assert.NotContains(t, store, "account:user@example.com")
+}
+
+func TestLoginFailed_ForgetsOldFailures(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	store := fakeAttemptStore{}
+	svc := throttledService(store, &now)
+
+	for range 4 {
+		assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", ""))
+	}
+	now = now.Add(11 * time.Minute)
+	assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", ""))
+	assert.Equal(t, 1, store["account:user@example.com"].Failures)
+	_, err := svc.CheckLogin(ctx, "user@example.com", "")
+	assert.NoError(t, err)
+}
+
+func TestLoginFailed_LocksIP(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	store := fakeAttemptStore{}
+	svc := throttledService(store, &now)
+
+	// spraying many accounts from one address
+	for _, email := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		now = now.Add(time.Minute)
+		assert.NoError(t, svc.LoginFailed(ctx, common.Email("user-"+email+"@example.com"), "10.0.0.1"))
+	}
+	wait, err := svc.CheckLogin(ctx, "new@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	assert.Equal(t, 10*time.Minute, wait)
+
+	// a right password doesn't unlock the address
+	assert.NoError(t, svc.LoginSucceeded(ctx, "user-a@example.com", "10.0.0.1"))
+	_, err = svc.CheckLogin(ctx, "new@example.com", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrLoginThrottled)
+	_, err = svc.CheckLogin(ctx, "new@example.com", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestCheckLogin_WithoutStore(t *testing.T) {
+	svc := NewService(new(MockRepo), keys)
+	for range 20 {
+		assert.NoError(t, svc.LoginFailed(ctx, "user@example.com", "10.0.0.1"))
+	}
+	_, err := svc.CheckLogin(ctx, "user@example.com", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginLimit_Wait(t *testing.T) {
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	l := loginLimit{free: 3, max: 100}
+	for failures, want := range map[int]time.Duration{
+		2: 0, 3: time.Second, 4: 2 * time.Second, 7: 16 * time.Second, 8: maxLoginDelay, 60: maxLoginDelay,
+	} {
+		a := &domain.LoginAttempts{Failures: failures, LastFailureAt: now}
+		assert.Equal(t, want, l.wait(a, now), "failures %d", failures)
+	}
+}
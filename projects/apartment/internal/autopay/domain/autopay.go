@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+// MaxDaysBeforeDue bounds how early before its due date a bill is charged.
+const MaxDaysBeforeDue = 30
+
+var ErrInvalidDaysBeforeDue = errors.New("days before due must be between 0 and 30")
+
+type EnrollmentStatus string
+
+const (
+	// EnrollmentPending waits for the payer to save a payment method at
+	// the gateway.
+	EnrollmentPending EnrollmentStatus = "pending"
+	// EnrollmentActive charges new bills from the saved payment method.
+	EnrollmentActive EnrollmentStatus = "active"
+	// EnrollmentCancelled was turned off by its user.
+	EnrollmentCancelled EnrollmentStatus = "cancelled"
+	// EnrollmentSuspended was turned off after too many failed charges.
+	EnrollmentSuspended EnrollmentStatus = "suspended"
+)
+
+func (s EnrollmentStatus) String() string {
+	return string(s)
+}
+
+// Enrollment makes the bills of an apartment be paid automatically for one
+// of its members.
+type Enrollment struct {
+	ID          common.ID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      common.ID
+	ApartmentID common.ID
+	Gateway     paymentd.GatewayType
+	// Token is the gateway reference of the saved payment method.
+	Token    string
+	CardMask string
+	// DaysBeforeDue is how many days before its due date a bill is
+	// charged, zero charges on the due date.
+	DaysBeforeDue int
+	Status        EnrollmentStatus
+	// Failures counts the charges failed in a row.
+	Failures     int
+	LastError    string
+	LastFailedAt *time.Time
+	// ActivatedAt is when the payment method was saved. Only bills issued
+	// after it are charged.
+	ActivatedAt *time.Time
+}
+
+func (e *Enrollment) Validate() error {
+	if e.DaysBeforeDue < 0 || e.DaysBeforeDue > MaxDaysBeforeDue {
+		return ErrInvalidDaysBeforeDue
+	}
+	return nil
+}
+
+// Charge is a bill share due to be paid by autopay.
+type Charge struct {
+	Enrollment Enrollment
+	BillID     common.ID
+	BillName   string
+	DueDate    time.Time
+	Amount     int64
+}
@@ -0,0 +1,55 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+type Service interface {
+	// Enroll starts saving a payment method at the gateway. The enrollment
+	// is active once the gateway calls back.
+	Enroll(ctx context.Context, e *domain.Enrollment, callbackURL string, metadata map[string]string) (*domain.Enrollment, *paymentd.RedirectGateway, error)
+	HandleEnrollCallback(ctx context.Context, gateway paymentd.GatewayType, data map[string][]string) error
+	Enrollments(ctx context.Context, userID common.ID) ([]domain.Enrollment, error)
+	Cancel(ctx context.Context, userID, enrollmentID common.ID) error
+}
+
+// Scheduler charges the bills due for autopay.
+type Scheduler interface {
+	Run(ctx context.Context)
+	ChargeDue(ctx context.Context) error
+}
+
+type Repo interface {
+	IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error)
+	// Enroll stores a pending enrollment, replacing an inactive one of the
+	// same user and apartment. It returns ErrAlreadyEnrolled if there is an
+	// active one.
+	Enroll(ctx context.Context, e *domain.Enrollment) (*domain.Enrollment, error)
+	// Activate saves the payment method of a pending enrollment. It returns
+	// ErrEnrollmentSettled if the enrollment is not pending.
+	Activate(ctx context.Context, id common.ID, m *paymentd.SavedMethod) (*domain.Enrollment, error)
+	GetEnrollment(ctx context.Context, id common.ID) (*domain.Enrollment, error)
+	Enrollments(ctx context.Context, userID common.ID) ([]domain.Enrollment, error)
+	SetStatus(ctx context.Context, id common.ID, s domain.EnrollmentStatus) error
+	// DueCharges returns the unpaid shares of the bills of active
+	// enrollments whose charge date has come, leaving out enrollments that
+	// failed since retryAfter and bills with a payment in progress.
+	DueCharges(ctx context.Context, now, retryAfter time.Time) ([]domain.Charge, error)
+	CreatePayment(ctx context.Context, p *paymentd.Payment) (*paymentd.Payment, error)
+	// ChargeSucceeded marks p paid and clears the failures of the
+	// enrollment.
+	ChargeSucceeded(ctx context.Context, enrollmentID common.ID, p *paymentd.Payment) (*paymentd.Payment, error)
+	// ChargeFailed marks the payment failed, if any, and counts a failure
+	// of the enrollment, suspending it at maxFailures.
+	ChargeFailed(ctx context.Context, enrollmentID, paymentID common.ID, reason string, maxFailures int) (*domain.Enrollment, error)
+	UserEmail(ctx context.Context, userID common.ID) (string, error)
+}
+
+type EmailSender interface {
+	Send(to []string, msg *common.EmailMessage) error
+}
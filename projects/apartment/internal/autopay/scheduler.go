@@ -0,0 +1,229 @@
+package autopay
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/template"
+	"go.uber.org/zap"
+)
+
+var ErrOnChargeDue = errors.New("error on charge autopay bills")
+
+const (
+	DefaultInterval      = time.Hour
+	DefaultRetryInterval = 24 * time.Hour
+	DefaultMaxFailures   = 3
+)
+
+type scheduler struct {
+	repo          port.Repo
+	gateways      map[paymentd.GatewayType]paymentp.Gateway
+	interval      time.Duration
+	retryInterval time.Duration
+	maxFailures   int
+	mail          port.EmailSender
+	onPaid        []func(context.Context, []*paymentd.Payment)
+	now           func() time.Time
+}
+
+type SchedulerOpt func(*scheduler)
+
+// WithInterval sets how often due bills are looked for.
+func WithInterval(d time.Duration) SchedulerOpt {
+	return func(s *scheduler) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithRetryInterval sets how long to wait before charging an enrollment
+// again after a failed charge.
+func WithRetryInterval(d time.Duration) SchedulerOpt {
+	return func(s *scheduler) {
+		if d > 0 {
+			s.retryInterval = d
+		}
+	}
+}
+
+// WithMaxFailures sets after how many failed charges in a row an
+// enrollment is suspended.
+func WithMaxFailures(n int) SchedulerOpt {
+	return func(s *scheduler) {
+		if n > 0 {
+			s.maxFailures = n
+		}
+	}
+}
+
+// WithFailureMailer makes the scheduler email the payer about failed
+// charges through m.
+func WithFailureMailer(m port.EmailSender) SchedulerOpt {
+	return func(s *scheduler) {
+		s.mail = m
+	}
+}
+
+// WithOnPaymentPaid registers fn to run after a bill is charged.
+func WithOnPaymentPaid(fn func(context.Context, []*paymentd.Payment)) SchedulerOpt {
+	return func(s *scheduler) {
+		s.onPaid = append(s.onPaid, fn)
+	}
+}
+
+func NewScheduler(
+	repo port.Repo,
+	gws map[paymentd.GatewayType]paymentp.Gateway,
+	opts ...SchedulerOpt,
+) port.Scheduler {
+	s := &scheduler{
+		repo:          repo,
+		gateways:      gws,
+		interval:      DefaultInterval,
+		retryInterval: DefaultRetryInterval,
+		maxFailures:   DefaultMaxFailures,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run charges due bills every interval until ctx is done.
+func (s *scheduler) Run(ctx context.Context) {
+	log := appctx.Logger(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ChargeDue(ctx); err != nil {
+			log.Error("autopay scheduler", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ChargeDue charges every bill share whose charge date has come to the
+// saved payment method of its enrollment. After a failure the other bills
+// of the enrollment wait for the next retry.
+func (s *scheduler) ChargeDue(ctx context.Context) error {
+	log := appctx.Logger(ctx)
+
+	now := s.now()
+	charges, err := s.repo.DueCharges(ctx, now, now.Add(-s.retryInterval))
+	if err != nil {
+		return fp.WrapErrors(ErrOnChargeDue, err)
+	}
+	failed := make(map[common.ID]bool)
+	for _, c := range charges {
+		if failed[c.Enrollment.ID] {
+			continue
+		}
+		if err := s.charge(ctx, c); err != nil {
+			failed[c.Enrollment.ID] = true
+			log.Error("autopay charge", zap.Error(err),
+				zap.String("enrollmentId", c.Enrollment.ID.String()),
+				zap.String("billId", c.BillID.String()))
+		}
+	}
+	return nil
+}
+
+func (s *scheduler) charge(ctx context.Context, c domain.Charge) error {
+	e := c.Enrollment
+	gateway, err := recurringGateway(s.gateways, e.Gateway)
+	if err != nil {
+		return s.fail(ctx, c, common.NilID, err)
+	}
+	p, err := s.repo.CreatePayment(ctx, &paymentd.Payment{
+		BillID:  c.BillID,
+		PayerID: e.UserID,
+		Amount:  c.Amount,
+		Status:  paymentd.PaymentPending,
+		Gateway: e.Gateway.String(),
+	})
+	if err != nil {
+		return err
+	}
+	transactionID, err := gateway.ChargeToken(ctx, e.Token, paymentd.Transaction{
+		PaymentIDs: []common.ID{p.ID},
+		Amount:     c.Amount,
+		PayerID:    e.UserID,
+		Bills:      []paymentd.BillWithAmount{{BillID: c.BillID, Amount: c.Amount}},
+	})
+	if err != nil {
+		return s.fail(ctx, c, p.ID, err)
+	}
+	p.TransactionID = transactionID
+	p.PaidAt = s.now().UTC()
+	p, err = s.repo.ChargeSucceeded(ctx, e.ID, p)
+	if err != nil {
+		return err
+	}
+	for _, fn := range s.onPaid {
+		fn(ctx, []*paymentd.Payment{p})
+	}
+	return nil
+}
+
+// fail records a failed charge and tells the payer about it. It returns
+// cause.
+func (s *scheduler) fail(ctx context.Context, c domain.Charge, paymentID common.ID, cause error) error {
+	log := appctx.Logger(ctx)
+
+	e, err := s.repo.ChargeFailed(ctx, c.Enrollment.ID, paymentID, cause.Error(), s.maxFailures)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	if e.Status == domain.EnrollmentSuspended {
+		log.Warn("autopay enrollment suspended", zap.String("enrollmentId", e.ID.String()),
+			zap.Int("failures", e.Failures))
+	}
+	if err = s.notifyFailure(ctx, c, e, cause); err != nil {
+		log.Error("autopay failure email", zap.Error(err),
+			zap.String("enrollmentId", e.ID.String()))
+	}
+	return cause
+}
+
+func (s *scheduler) notifyFailure(ctx context.Context, c domain.Charge, e *domain.Enrollment, cause error) error {
+	if s.mail == nil {
+		return nil
+	}
+	to, err := s.repo.UserEmail(ctx, e.UserID)
+	if err != nil {
+		return err
+	}
+	body, err := template.NewAutopayFailed(template.AutopayFailedData{
+		BillName:  c.BillName,
+		DueDate:   c.DueDate.Format(time.DateOnly),
+		Amount:    strconv.FormatInt(c.Amount, 10),
+		CardMask:  e.CardMask,
+		Reason:    cause.Error(),
+		Suspended: e.Status == domain.EnrollmentSuspended,
+	})
+	if err != nil {
+		return err
+	}
+	return s.mail.Send([]string{to}, &common.EmailMessage{
+		Subject: "Autopay payment failed",
+		Body:    body,
+		IsHTML:  true,
+	})
+}
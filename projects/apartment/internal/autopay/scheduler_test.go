@@ -0,0 +1,139 @@
+package autopay
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestScheduler(repo *MockRepo, gw paymentp.Gateway, now time.Time, opts ...SchedulerOpt) *scheduler {
+	s := NewScheduler(repo, map[paymentd.GatewayType]paymentp.Gateway{
+		paymentd.MockGateway: gw,
+	}, opts...).(*scheduler)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func testCharge(e domain.Enrollment, amount int64) domain.Charge {
+	return domain.Charge{
+		Enrollment: e,
+		BillID:     common.NewRandomID(),
+		BillName:   "water",
+		DueDate:    time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+		Amount:     amount,
+	}
+}
+
+func TestChargeDue_Success(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+
+	var paid []*paymentd.Payment
+	s := newTestScheduler(repo, gw, now, WithOnPaymentPaid(func(_ context.Context, ps []*paymentd.Payment) {
+		paid = append(paid, ps...)
+	}))
+
+	e := domain.Enrollment{
+		ID: common.NewRandomID(), UserID: common.NewRandomID(),
+		Gateway: paymentd.MockGateway, Token: "tok", Status: domain.EnrollmentActive,
+	}
+	c := testCharge(e, 700)
+	p := &paymentd.Payment{ID: common.NewRandomID(), BillID: c.BillID, PayerID: e.UserID, Amount: 700}
+
+	repo.On("DueCharges", ctx, now, now.Add(-DefaultRetryInterval)).Return([]domain.Charge{c}, nil)
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *paymentd.Payment) bool {
+		return p.BillID == c.BillID && p.Amount == 700 && p.Status == paymentd.PaymentPending
+	})).Return(p, nil)
+	gw.On("ChargeToken", ctx, "tok", mock.MatchedBy(func(tx paymentd.Transaction) bool {
+		return tx.Amount == 700 && len(tx.PaymentIDs) == 1 && tx.PaymentIDs[0] == p.ID
+	})).Return("tx-1", nil)
+	repo.On("ChargeSucceeded", ctx, e.ID, mock.MatchedBy(func(p *paymentd.Payment) bool {
+		return p.TransactionID == "tx-1"
+	})).Return(p, nil)
+
+	assert.NoError(t, s.ChargeDue(ctx))
+	assert.Equal(t, []*paymentd.Payment{p}, paid)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestChargeDue_FailureSuspendsAndMails(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	mailer := new(MockMailer)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(repo, gw, now, WithMaxFailures(2), WithFailureMailer(mailer))
+
+	e := domain.Enrollment{
+		ID: common.NewRandomID(), UserID: common.NewRandomID(),
+		Gateway: paymentd.MockGateway, Token: "tok", Status: domain.EnrollmentActive, Failures: 1,
+	}
+	c := testCharge(e, 700)
+	paymentID := common.NewRandomID()
+	declined := errors.New("payment declined")
+	suspended := e
+	suspended.Failures = 2
+	suspended.Status = domain.EnrollmentSuspended
+
+	repo.On("DueCharges", ctx, now, now.Add(-DefaultRetryInterval)).Return([]domain.Charge{c}, nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&paymentd.Payment{ID: paymentID}, nil)
+	gw.On("ChargeToken", ctx, "tok", mock.Anything).Return("", declined)
+	repo.On("ChargeFailed", ctx, e.ID, paymentID, declined.Error(), 2).Return(&suspended, nil)
+	repo.On("UserEmail", ctx, e.UserID).Return("payer@example.com", nil)
+	mailer.On("Send", []string{"payer@example.com"}, mock.MatchedBy(func(m *common.EmailMessage) bool {
+		return m.IsHTML && len(m.Body) > 0
+	})).Return(nil)
+
+	assert.NoError(t, s.ChargeDue(ctx))
+	repo.AssertNotCalled(t, "ChargeSucceeded", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestChargeDue_SkipsEnrollmentAfterFailure(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(repo, gw, now)
+
+	e := domain.Enrollment{
+		ID: common.NewRandomID(), UserID: common.NewRandomID(),
+		Gateway: paymentd.MockGateway, Token: "tok", Status: domain.EnrollmentActive,
+	}
+	first, second := testCharge(e, 700), testCharge(e, 300)
+	paymentID := common.NewRandomID()
+
+	repo.On("DueCharges", ctx, now, now.Add(-DefaultRetryInterval)).Return([]domain.Charge{first, second}, nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&paymentd.Payment{ID: paymentID}, nil).Once()
+	gw.On("ChargeToken", ctx, "tok", mock.Anything).Return("", errors.New("gateway timeout")).Once()
+	repo.On("ChargeFailed", ctx, e.ID, paymentID, "gateway timeout", DefaultMaxFailures).Return(&e, nil)
+
+	assert.NoError(t, s.ChargeDue(ctx))
+	repo.AssertNumberOfCalls(t, "CreatePayment", 1)
+	gw.AssertNumberOfCalls(t, "ChargeToken", 1)
+}
+
+func TestChargeDue_RecurringUnsupported(t *testing.T) {
+	repo := new(MockRepo)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	s := newTestScheduler(repo, &MockPlainGateway{}, now)
+
+	e := domain.Enrollment{ID: common.NewRandomID(), Gateway: paymentd.MockGateway}
+	c := testCharge(e, 700)
+
+	repo.On("DueCharges", ctx, now, now.Add(-DefaultRetryInterval)).Return([]domain.Charge{c}, nil)
+	repo.On("ChargeFailed", ctx, e.ID, common.NilID, ErrRecurringUnsupported.Error(), DefaultMaxFailures).
+		Return(&e, nil)
+
+	assert.NoError(t, s.ChargeDue(ctx))
+	repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,199 @@
+package autopay
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+const (
+	GatewayKey      = "gateway"
+	EnrollmentIDKey = "autopay-enrollment-id"
+)
+
+var (
+	ErrOnEnroll             = errors.New("error on autopay enroll")
+	ErrOnCallback           = errors.New("error on handle autopay callback")
+	ErrOnGetEnrollments     = errors.New("error on get autopay enrollments")
+	ErrOnCancel             = errors.New("error on cancel autopay")
+	ErrUnknownGateway       = errors.New("unknown gateway")
+	ErrRecurringUnsupported = errors.New("gateway does not support recurring payments")
+	ErrNotMember            = errors.New("user is not a member of the apartment")
+	ErrAlreadyEnrolled      = errors.New("autopay is already active for the apartment")
+	ErrEnrollmentNotFound   = errors.New("autopay enrollment not found")
+	ErrEnrollmentSettled    = errors.New("autopay enrollment is not pending")
+	ErrInvalidCallback      = errors.New("invalid callback")
+)
+
+type service struct {
+	repo     port.Repo
+	gateways map[paymentd.GatewayType]paymentp.Gateway
+}
+
+func NewService(
+	repo port.Repo,
+	gws map[paymentd.GatewayType]paymentp.Gateway,
+) port.Service {
+	return &service{repo: repo, gateways: gws}
+}
+
+// recurringGateway returns the gateway gt if it can charge saved payment
+// methods.
+func recurringGateway(
+	gws map[paymentd.GatewayType]paymentp.Gateway,
+	gt paymentd.GatewayType,
+) (
+	paymentp.RecurringGateway, error,
+) {
+	gateway, ok := gws[gt]
+	if !ok {
+		return nil, ErrUnknownGateway
+	}
+	recurring, ok := gateway.(paymentp.RecurringGateway)
+	if !ok {
+		return nil, ErrRecurringUnsupported
+	}
+	return recurring, nil
+}
+
+func (s *service) Enroll(
+	ctx context.Context,
+	e *domain.Enrollment,
+	callbackURL string,
+	metadata map[string]string,
+) (
+	*domain.Enrollment, *paymentd.RedirectGateway, error,
+) {
+	e, redirect, err := s.enroll(ctx, e, callbackURL, metadata)
+	if err != nil {
+		return nil, nil, fp.WrapErrors(ErrOnEnroll, err)
+	}
+	return e, redirect, nil
+}
+
+func (s *service) enroll(
+	ctx context.Context,
+	e *domain.Enrollment,
+	callbackURL string,
+	metadata map[string]string,
+) (
+	*domain.Enrollment, *paymentd.RedirectGateway, error,
+) {
+	if err := e.Validate(); err != nil {
+		return nil, nil, err
+	}
+	gateway, err := recurringGateway(s.gateways, e.Gateway)
+	if err != nil {
+		return nil, nil, err
+	}
+	ok, err := s.repo.IsApartmentMember(ctx, e.UserID, e.ApartmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrNotMember
+	}
+	e, err = s.repo.Enroll(ctx, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	callbackURL, err = callbackURLWithEnrollment(callbackURL, e.Gateway, e.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	redirect, err := gateway.Tokenize(ctx, paymentd.TokenRequest{
+		PayerID:     e.UserID,
+		CallbackURL: callbackURL,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	redirect.Gateway = e.Gateway
+	return e, redirect, nil
+}
+
+func callbackURLWithEnrollment(
+	callbackURL string,
+	gt paymentd.GatewayType,
+	id common.ID,
+) (
+	string, error,
+) {
+	cURL, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	query, err := url.ParseQuery(cURL.RawQuery)
+	if err != nil {
+		return "", err
+	}
+	query.Set(GatewayKey, gt.String())
+	query.Set(EnrollmentIDKey, id.String())
+	cURL.RawQuery = query.Encode()
+	return cURL.String(), nil
+}
+
+// HandleEnrollCallback activates an enrollment with the payment method the
+// gateway saved.
+func (s *service) HandleEnrollCallback(
+	ctx context.Context,
+	gt paymentd.GatewayType,
+	data map[string][]string,
+) error {
+	log := appctx.Logger(ctx)
+
+	gateway, err := recurringGateway(s.gateways, gt)
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, err)
+	}
+	ids := data[EnrollmentIDKey]
+	if len(ids) != 1 || common.ValidateID(ids[0]) != nil {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback)
+	}
+	method, err := gateway.VerifyToken(ctx, data)
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback, err)
+	}
+	_, err = s.repo.Activate(ctx, common.IDFromText(ids[0]), method)
+	if errors.Is(err, ErrEnrollmentSettled) {
+		log.Warn("autopay enrollment already settled", zap.String("enrollmentId", ids[0]))
+		return nil
+	}
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, err)
+	}
+	return nil
+}
+
+func (s *service) Enrollments(ctx context.Context, userID common.ID) ([]domain.Enrollment, error) {
+	es, err := s.repo.Enrollments(ctx, userID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetEnrollments, err)
+	}
+	return es, nil
+}
+
+// Cancel turns off an enrollment of the user.
+func (s *service) Cancel(ctx context.Context, userID, enrollmentID common.ID) error {
+	e, err := s.repo.GetEnrollment(ctx, enrollmentID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnCancel, err)
+	}
+	if e.UserID != userID {
+		return fp.WrapErrors(ErrOnCancel, ErrEnrollmentNotFound)
+	}
+	if err = s.repo.SetStatus(ctx, e.ID, domain.EnrollmentCancelled); err != nil {
+		return fp.WrapErrors(ErrOnCancel, err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package autopay
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error) {
+	args := m.Called(ctx, userID, apartmentID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) Enroll(ctx context.Context, e *domain.Enrollment) (*domain.Enrollment, error) {
+	args := m.Called(ctx, e)
+	res, _ := args.Get(0).(*domain.Enrollment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) Activate(ctx context.Context, id common.ID, sm *paymentd.SavedMethod) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id, sm)
+	res, _ := args.Get(0).(*domain.Enrollment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) GetEnrollment(ctx context.Context, id common.ID) (*domain.Enrollment, error) {
+	args := m.Called(ctx, id)
+	res, _ := args.Get(0).(*domain.Enrollment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) SetStatus(ctx context.Context, id common.ID, s domain.EnrollmentStatus) error {
+	args := m.Called(ctx, id, s)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DueCharges(ctx context.Context, now, retryAfter time.Time) ([]domain.Charge, error) {
+	args := m.Called(ctx, now, retryAfter)
+	res, _ := args.Get(0).([]domain.Charge)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) CreatePayment(ctx context.Context, p *paymentd.Payment) (*paymentd.Payment, error) {
+	args := m.Called(ctx, p)
+	res, _ := args.Get(0).(*paymentd.Payment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) ChargeSucceeded(ctx context.Context, enrollmentID common.ID, p *paymentd.Payment) (*paymentd.Payment, error) {
+	args := m.Called(ctx, enrollmentID, p)
+	res, _ := args.Get(0).(*paymentd.Payment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) ChargeFailed(
+	ctx context.Context, enrollmentID, paymentID common.ID, reason string, maxFailures int,
+) (
+	*domain.Enrollment, error,
+) {
+	args := m.Called(ctx, enrollmentID, paymentID, reason, maxFailures)
+	res, _ := args.Get(0).(*domain.Enrollment)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) UserEmail(ctx context.Context, userID common.ID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+type MockGateway struct {
+	mock.Mock
+	paymentp.Gateway
+}
+
+func (m *MockGateway) Tokenize(ctx context.Context, r paymentd.TokenRequest) (*paymentd.RedirectGateway, error) {
+	args := m.Called(ctx, r)
+	res, _ := args.Get(0).(*paymentd.RedirectGateway)
+	return res, args.Error(1)
+}
+
+func (m *MockGateway) VerifyToken(ctx context.Context, data map[string][]string) (*paymentd.SavedMethod, error) {
+	args := m.Called(ctx, data)
+	res, _ := args.Get(0).(*paymentd.SavedMethod)
+	return res, args.Error(1)
+}
+
+func (m *MockGateway) ChargeToken(ctx context.Context, token string, tx paymentd.Transaction) (string, error) {
+	args := m.Called(ctx, token, tx)
+	return args.String(0), args.Error(1)
+}
+
+// MockPlainGateway cannot charge saved payment methods.
+type MockPlainGateway struct {
+	paymentp.Gateway
+}
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(to []string, msg *common.EmailMessage) error {
+	args := m.Called(to, msg)
+	return args.Error(0)
+}
+
+func newTestService(repo *MockRepo, gw paymentp.Gateway) port.Service {
+	return NewService(repo, map[paymentd.GatewayType]paymentp.Gateway{
+		paymentd.MockGateway: gw,
+	})
+}
+
+const callbackURL = "http://127.0.0.1:8080/api/v1/autopay/callback"
+
+// ----------- Tests -------------
+
+func TestEnroll_Success(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, aptID := common.NewRandomID(), common.NewRandomID()
+	e := &domain.Enrollment{
+		UserID: userID, ApartmentID: aptID, Gateway: paymentd.MockGateway, DaysBeforeDue: 3,
+	}
+	saved := *e
+	saved.ID = common.NewRandomID()
+	saved.Status = domain.EnrollmentPending
+	redirect := &paymentd.RedirectGateway{Method: "POST", URL: "http://gateway/tokenize"}
+
+	repo.On("IsApartmentMember", ctx, userID, aptID).Return(true, nil)
+	repo.On("Enroll", ctx, e).Return(&saved, nil)
+	gw.On("Tokenize", ctx, mock.MatchedBy(func(r paymentd.TokenRequest) bool {
+		u, err := url.Parse(r.CallbackURL)
+		return err == nil && r.PayerID == userID &&
+			u.Query().Get(EnrollmentIDKey) == saved.ID.String() &&
+			u.Query().Get(GatewayKey) == string(paymentd.MockGateway)
+	})).Return(redirect, nil)
+
+	res, rd, err := svc.Enroll(ctx, e, callbackURL, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &saved, res)
+	assert.Equal(t, paymentd.GatewayType(paymentd.MockGateway), rd.Gateway)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestEnroll_InvalidDaysBeforeDue(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, new(MockGateway))
+
+	_, _, err := svc.Enroll(ctx, &domain.Enrollment{
+		Gateway: paymentd.MockGateway, DaysBeforeDue: domain.MaxDaysBeforeDue + 1,
+	}, callbackURL, nil)
+
+	assert.ErrorIs(t, err, domain.ErrInvalidDaysBeforeDue)
+	repo.AssertNotCalled(t, "Enroll", mock.Anything, mock.Anything)
+}
+
+func TestEnroll_RecurringUnsupported(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, &MockPlainGateway{})
+
+	_, _, err := svc.Enroll(ctx, &domain.Enrollment{Gateway: paymentd.MockGateway}, callbackURL, nil)
+
+	assert.ErrorIs(t, err, ErrRecurringUnsupported)
+}
+
+func TestEnroll_NotMember(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, new(MockGateway))
+
+	userID, aptID := common.NewRandomID(), common.NewRandomID()
+	repo.On("IsApartmentMember", ctx, userID, aptID).Return(false, nil)
+
+	_, _, err := svc.Enroll(ctx, &domain.Enrollment{
+		UserID: userID, ApartmentID: aptID, Gateway: paymentd.MockGateway,
+	}, callbackURL, nil)
+
+	assert.ErrorIs(t, err, ErrNotMember)
+	repo.AssertNotCalled(t, "Enroll", mock.Anything, mock.Anything)
+}
+
+func TestHandleEnrollCallback_Activates(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	id := common.NewRandomID()
+	data := map[string][]string{EnrollmentIDKey: {id.String()}, "cardToken": {"tok"}}
+	method := &paymentd.SavedMethod{Token: "tok", Mask: "6037-****-****-1234"}
+
+	gw.On("VerifyToken", ctx, data).Return(method, nil)
+	repo.On("Activate", ctx, id, method).Return(&domain.Enrollment{ID: id}, nil)
+
+	err := svc.HandleEnrollCallback(ctx, paymentd.MockGateway, data)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestHandleEnrollCallback_AlreadySettled(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	id := common.NewRandomID()
+	data := map[string][]string{EnrollmentIDKey: {id.String()}}
+	method := &paymentd.SavedMethod{Token: "tok"}
+
+	gw.On("VerifyToken", ctx, data).Return(method, nil)
+	repo.On("Activate", ctx, id, method).Return(nil, ErrEnrollmentSettled)
+
+	assert.NoError(t, svc.HandleEnrollCallback(ctx, paymentd.MockGateway, data))
+}
+
+func TestHandleEnrollCallback_InvalidToken(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	data := map[string][]string{EnrollmentIDKey: {common.NewRandomID().String()}}
+	gw.On("VerifyToken", ctx, data).Return(nil, errors.New("unknown card token"))
+
+	err := svc.HandleEnrollCallback(ctx, paymentd.MockGateway, data)
+
+	assert.ErrorIs(t, err, ErrInvalidCallback)
+	repo.AssertNotCalled(t, "Activate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancel_OtherUser(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, new(MockGateway))
+
+	id := common.NewRandomID()
+	repo.On("GetEnrollment", ctx, id).Return(&domain.Enrollment{ID: id, UserID: common.NewRandomID()}, nil)
+
+	err := svc.Cancel(ctx, common.NewRandomID(), id)
+
+	assert.ErrorIs(t, err, ErrEnrollmentNotFound)
+	repo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancel_Success(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, new(MockGateway))
+
+	userID, id := common.NewRandomID(), common.NewRandomID()
+	repo.On("GetEnrollment", ctx, id).Return(&domain.Enrollment{ID: id, UserID: userID}, nil)
+	repo.On("SetStatus", ctx, id, domain.EnrollmentCancelled).Return(nil)
+
+	assert.NoError(t, svc.Cancel(ctx, userID, id))
+	repo.AssertExpectations(t)
+}
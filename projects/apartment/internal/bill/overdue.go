@@ -0,0 +1,81 @@
+package bill
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var ErrOnScanOverdue = errors.New("error on scan overdue bills")
+
+const DefaultOverdueInterval = time.Hour
+
+type overdueScanner struct {
+	repo     port.OverdueRepo
+	interval time.Duration
+	now      func() time.Time
+}
+
+type OverdueScannerOpt func(*overdueScanner)
+
+// WithOverdueInterval sets how often overdue bills are looked for.
+func WithOverdueInterval(d time.Duration) OverdueScannerOpt {
+	return func(s *overdueScanner) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+func NewOverdueScanner(repo port.OverdueRepo, opts ...OverdueScannerOpt) port.OverdueScanner {
+	s := &overdueScanner{
+		repo:     repo,
+		interval: DefaultOverdueInterval,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run scans for overdue bills every interval until ctx is done.
+func (s *overdueScanner) Run(ctx context.Context) {
+	log := appctx.Logger(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scan(ctx); err != nil {
+			log.Error("bill overdue scanner", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan flags the bills whose due date has passed with a balance due. The
+// BillOverdue events are recorded with the flag and delivered by the event
+// dispatcher.
+func (s *overdueScanner) Scan(ctx context.Context) error {
+	log := appctx.Logger(ctx)
+
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	bills, err := s.repo.MarkOverdue(ctx, today)
+	if err != nil {
+		return fp.WrapErrors(ErrOnScanOverdue, err)
+	}
+	if len(bills) > 0 {
+		log.Info("bills overdue", zap.Int("count", len(bills)))
+	}
+	return nil
+}
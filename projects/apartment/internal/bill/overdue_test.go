@@ -0,0 +1,43 @@
+package bill
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOverdueRepo struct {
+	mock.Mock
+}
+
+func (m *MockOverdueRepo) MarkOverdue(ctx context.Context, today time.Time) ([]domain.Bill, error) {
+	args := m.Called(ctx, today)
+	res, _ := args.Get(0).([]domain.Bill)
+	return res, args.Error(1)
+}
+
+func TestOverdueScan_TruncatesToDate(t *testing.T) {
+	repo := new(MockOverdueRepo)
+	s := NewOverdueScanner(repo).(*overdueScanner)
+	s.now = func() time.Time { return time.Date(2025, 8, 8, 17, 30, 0, 0, time.UTC) }
+
+	repo.On("MarkOverdue", ctx, time.Date(2025, 8, 8, 0, 0, 0, 0, time.UTC)).
+		Return([]domain.Bill{{Name: "water"}}, nil)
+
+	assert.NoError(t, s.Scan(ctx))
+	repo.AssertExpectations(t)
+}
+
+func TestOverdueScan_RepoError(t *testing.T) {
+	repo := new(MockOverdueRepo)
+	s := NewOverdueScanner(repo)
+
+	repo.On("MarkOverdue", ctx, mock.Anything).Return(nil, errors.New("db down"))
+
+	assert.ErrorIs(t, s.Scan(ctx), ErrOnScanOverdue)
+}
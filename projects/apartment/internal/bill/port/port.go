@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
@@ -22,6 +23,20 @@ type Repo interface {
 	GetUserTotalDebt(ctx context.Context, userID common.ID) (int, error)
 }
 
+// OverdueRepo flags bills that passed their due date unpaid.
+type OverdueRepo interface {
+	// MarkOverdue flags the bills due before today that still have a
+	// balance due, and records a BillOverdue event for each. A bill is
+	// flagged once.
+	MarkOverdue(ctx context.Context, today time.Time) ([]domain.Bill, error)
+}
+
+type OverdueScanner interface {
+	// Run scans for overdue bills periodically until ctx is done.
+	Run(ctx context.Context)
+	Scan(ctx context.Context) error
+}
+
 type ObjectStorage interface {
 	FPut(ctx context.Context, key, filename string) error
 	FGet(ctx context.Context, key, filename string) error
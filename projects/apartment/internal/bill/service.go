@@ -24,12 +24,26 @@ var (
 )
 
 type service struct {
-	repo port.Repo
-	strg port.ObjectStorage
+	repo      port.Repo
+	strg      port.ObjectStorage
+	onCreated []func(context.Context, *domain.Bill)
 }
 
-func NewService(r port.Repo, s port.ObjectStorage) port.Service {
-	return &service{repo: r, strg: s}
+type ServiceOpt func(*service)
+
+// WithOnBillCreated registers fn to run after a bill is added.
+func WithOnBillCreated(fn func(context.Context, *domain.Bill)) ServiceOpt {
+	return func(s *service) {
+		s.onCreated = append(s.onCreated, fn)
+	}
+}
+
+func NewService(r port.Repo, s port.ObjectStorage, opts ...ServiceOpt) port.Service {
+	svc := &service{repo: r, strg: s}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 func (s *service) AddBill(ctx context.Context, bill *domain.Bill) (*domain.Bill, error) {
@@ -47,7 +61,14 @@ func (s *service) AddBill(ctx context.Context, bill *domain.Bill) (*domain.Bill,
 			return nil, fp.WrapErrors(ErrOnAddBill, err)
 		}
 	}
-	return s.repo.Create(ctx, bill)
+	bill, err := s.repo.Create(ctx, bill)
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range s.onCreated {
+		fn(ctx, bill)
+	}
+	return bill, nil
 }
 
 func (s *service) GetBill(ctx context.Context, f *domain.BillFilter) (*domain.Bill, error) {
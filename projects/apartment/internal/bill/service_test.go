@@ -115,6 +115,25 @@ func TestAddBill_SuccessWithImage(t *testing.T) {
 	storage.AssertExpectations(t)
 }
 
+func TestAddBill_RunsOnCreatedHooks(t *testing.T) {
+	repo := new(MockRepo)
+	storage := new(MockStorage)
+	var created *domain.Bill
+	svc := NewService(repo, storage, WithOnBillCreated(func(_ context.Context, b *domain.Bill) {
+		created = b
+	}))
+
+	bill := createValidBill()
+	bill.HasImage = false
+
+	repo.On("Create", ctx, bill).Return(bill, nil)
+
+	_, err := svc.AddBill(ctx, bill)
+
+	assert.NoError(t, err)
+	assert.Equal(t, bill, created)
+}
+
 func TestAddBill_ValidationError(t *testing.T) {
 	repo := new(MockRepo)
 	storage := new(MockStorage)
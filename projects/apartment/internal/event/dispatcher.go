@@ -0,0 +1,208 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var ErrOnDispatch = errors.New("error on dispatch events")
+
+const (
+	DefaultInterval    = 5 * time.Second
+	DefaultMaxAttempts = 10
+	DefaultBackoff     = 10 * time.Second
+	DefaultBatchSize   = 100
+	// maxBackoff caps the delay between two deliveries of an event.
+	maxBackoff = time.Hour
+	// claimLease is how long claimed events are held for delivery, after
+	// it they are claimed again if the dispatcher stopped mid-way.
+	claimLease = 5 * time.Minute
+)
+
+type dispatcher struct {
+	repo        port.Repo
+	interval    time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	batchSize   int
+	now         func() time.Time
+
+	mu   sync.RWMutex
+	subs map[domain.Type][]subscriber
+}
+
+type subscriber struct {
+	name string
+	h    port.Handler
+}
+
+type DispatcherOpt func(*dispatcher)
+
+// WithInterval sets how often the outbox is polled.
+func WithInterval(interval time.Duration) DispatcherOpt {
+	return func(d *dispatcher) {
+		if interval > 0 {
+			d.interval = interval
+		}
+	}
+}
+
+// WithMaxAttempts sets after how many failed deliveries an event is given
+// up on.
+func WithMaxAttempts(n int) DispatcherOpt {
+	return func(d *dispatcher) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry of an event. It
+// doubles with every further attempt.
+func WithBackoff(b time.Duration) DispatcherOpt {
+	return func(d *dispatcher) {
+		if b > 0 {
+			d.backoff = b
+		}
+	}
+}
+
+func NewDispatcher(repo port.Repo, opts ...DispatcherOpt) port.Dispatcher {
+	d := &dispatcher{
+		repo:        repo,
+		interval:    DefaultInterval,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		batchSize:   DefaultBatchSize,
+		now:         time.Now,
+		subs:        make(map[domain.Type][]subscriber),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *dispatcher) Subscribe(t domain.Type, name string, h port.Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subs[t] = append(d.subs[t], subscriber{name: name, h: h})
+}
+
+// Run dispatches pending events every interval until ctx is done.
+func (d *dispatcher) Run(ctx context.Context) {
+	log := appctx.Logger(ctx)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Dispatch(ctx); err != nil {
+			log.Error("event dispatcher", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch delivers the events due now to their subscribers. A failed
+// event is retried with exponential backoff, and marked failed after
+// maxAttempts deliveries. A retry skips the subscribers that already
+// handled the event.
+func (d *dispatcher) Dispatch(ctx context.Context) error {
+	log := appctx.Logger(ctx)
+
+	now := d.now()
+	events, err := d.repo.Claim(ctx, now, now.Add(claimLease), d.batchSize)
+	if err != nil {
+		return fp.WrapErrors(ErrOnDispatch, err)
+	}
+	for _, e := range events {
+		if err := d.deliver(ctx, e); err != nil {
+			if err := d.fail(ctx, e, err); err != nil {
+				log.Error("mark event failed", zap.Error(err),
+					zap.String("eventId", e.ID.String()))
+			}
+			continue
+		}
+		if err := d.repo.MarkDispatched(ctx, e.ID); err != nil {
+			log.Error("mark event dispatched", zap.Error(err),
+				zap.String("eventId", e.ID.String()))
+		}
+	}
+	return nil
+}
+
+func (d *dispatcher) deliver(ctx context.Context, e domain.Event) error {
+	log := appctx.Logger(ctx)
+
+	d.mu.RLock()
+	subs := d.subs[e.Type]
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if slices.Contains(e.Delivered, sub.name) {
+			continue
+		}
+		if err := handle(ctx, sub.h, e); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// unrecorded, the subscriber gets the event again if another fails
+		if err := d.repo.MarkDelivered(ctx, e.ID, sub.name); err != nil {
+			log.Error("mark event delivered", zap.Error(err),
+				zap.String("eventId", e.ID.String()), zap.String("subscriber", sub.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handle runs h, turning a panic into an error.
+func handle(ctx context.Context, h port.Handler, e domain.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler panic: %v", r)
+		}
+	}()
+	return h(ctx, e)
+}
+
+func (d *dispatcher) fail(ctx context.Context, e domain.Event, cause error) error {
+	log := appctx.Logger(ctx)
+
+	attempts := e.Attempts + 1
+	if attempts >= d.maxAttempts {
+		log.Error("event delivery given up", zap.Error(cause),
+			zap.String("eventId", e.ID.String()), zap.String("type", e.Type.String()),
+			zap.Int("attempts", attempts))
+		return d.repo.MarkFailed(ctx, e.ID, cause.Error(), nil)
+	}
+	log.Warn("event delivery failed", zap.Error(cause),
+		zap.String("eventId", e.ID.String()), zap.String("type", e.Type.String()),
+		zap.Int("attempts", attempts))
+	retryAt := d.now().Add(d.retryDelay(attempts))
+	return d.repo.MarkFailed(ctx, e.ID, cause.Error(), &retryAt)
+}
+
+// retryDelay returns the delay after the given number of failed
+// deliveries.
+func (d *dispatcher) retryDelay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,176 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Event, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	res, _ := args.Get(0).([]domain.Event)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) MarkDelivered(ctx context.Context, id common.ID, subscriber string) error {
+	args := m.Called(ctx, id, subscriber)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkDispatched(ctx context.Context, id common.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkFailed(ctx context.Context, id common.ID, reason string, retryAt *time.Time) error {
+	args := m.Called(ctx, id, reason, retryAt)
+	return args.Error(0)
+}
+
+func newTestDispatcher(repo *MockRepo, now time.Time, opts ...DispatcherOpt) *dispatcher {
+	d := NewDispatcher(repo, opts...).(*dispatcher)
+	d.now = func() time.Time { return now }
+	return d
+}
+
+func testEvent(t *testing.T, typ domain.Type, attempts int) domain.Event {
+	e, err := domain.New(typ, common.NewRandomID(), map[string]string{"k": "v"})
+	assert.NoError(t, err)
+	e.ID = common.NewRandomID()
+	e.Attempts = attempts
+	return *e
+}
+
+// ----------- Tests -------------
+
+func TestDispatch_DeliversToSubscribers(t *testing.T) {
+	repo := new(MockRepo)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, now)
+
+	billEvent := testEvent(t, domain.BillCreated, 0)
+	joinEvent := testEvent(t, domain.MemberJoined, 0)
+
+	var got []domain.Type
+	d.Subscribe(domain.BillCreated, "first", func(_ context.Context, e domain.Event) error {
+		got = append(got, e.Type)
+		return nil
+	})
+	d.Subscribe(domain.BillCreated, "second", func(_ context.Context, e domain.Event) error {
+		got = append(got, e.Type)
+		return nil
+	})
+
+	repo.On("Claim", ctx, now, now.Add(claimLease), DefaultBatchSize).
+		Return([]domain.Event{billEvent, joinEvent}, nil)
+	repo.On("MarkDelivered", ctx, billEvent.ID, "first").Return(nil)
+	repo.On("MarkDelivered", ctx, billEvent.ID, "second").Return(nil)
+	repo.On("MarkDispatched", ctx, billEvent.ID).Return(nil)
+	// no subscribers, nothing to wait for
+	repo.On("MarkDispatched", ctx, joinEvent.ID).Return(nil)
+
+	assert.NoError(t, d.Dispatch(ctx))
+	assert.Equal(t, []domain.Type{domain.BillCreated, domain.BillCreated}, got)
+	repo.AssertExpectations(t)
+}
+
+func TestDispatch_RetriesWithBackoff(t *testing.T) {
+	repo := new(MockRepo)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, now, WithBackoff(time.Second))
+
+	e := testEvent(t, domain.InviteCreated, 2)
+	d.Subscribe(domain.InviteCreated, "mailer", func(context.Context, domain.Event) error {
+		return errors.New("smtp down")
+	})
+
+	retryAt := now.Add(4 * time.Second)
+	repo.On("Claim", ctx, now, now.Add(claimLease), DefaultBatchSize).Return([]domain.Event{e}, nil)
+	repo.On("MarkFailed", ctx, e.ID, "smtp down", &retryAt).Return(nil)
+
+	assert.NoError(t, d.Dispatch(ctx))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "MarkDispatched", mock.Anything, mock.Anything)
+}
+
+func TestDispatch_GivesUpAfterMaxAttempts(t *testing.T) {
+	repo := new(MockRepo)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, now, WithMaxAttempts(3))
+
+	e := testEvent(t, domain.InviteCreated, 2)
+	d.Subscribe(domain.InviteCreated, "mailer", func(context.Context, domain.Event) error {
+		panic("boom")
+	})
+
+	repo.On("Claim", ctx, now, now.Add(claimLease), DefaultBatchSize).Return([]domain.Event{e}, nil)
+	repo.On("MarkFailed", ctx, e.ID, "event handler panic: boom", (*time.Time)(nil)).Return(nil)
+
+	assert.NoError(t, d.Dispatch(ctx))
+	repo.AssertExpectations(t)
+}
+
+func TestDispatch_RetriesOnlyFailedSubscribers(t *testing.T) {
+	repo := new(MockRepo)
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	d := newTestDispatcher(repo, now, WithBackoff(time.Second))
+
+	e := testEvent(t, domain.PaymentSucceeded, 0)
+	var ledgerRuns, webhookRuns int
+	webhookErr := errors.New("queue down")
+	d.Subscribe(domain.PaymentSucceeded, "ledger", func(context.Context, domain.Event) error {
+		ledgerRuns++
+		return nil
+	})
+	d.Subscribe(domain.PaymentSucceeded, "webhook", func(context.Context, domain.Event) error {
+		webhookRuns++
+		return webhookErr
+	})
+
+	retryAt := now.Add(time.Second)
+	repo.On("Claim", ctx, now, now.Add(claimLease), DefaultBatchSize).Return([]domain.Event{e}, nil).Once()
+	repo.On("MarkDelivered", ctx, e.ID, "ledger").Return(nil).Once()
+	repo.On("MarkFailed", ctx, e.ID, "queue down", &retryAt).Return(nil).Once()
+	assert.NoError(t, d.Dispatch(ctx))
+
+	// the retry claims the event with the delivery to the ledger recorded
+	e.Attempts, e.Delivered = 1, []string{"ledger"}
+	webhookErr = nil
+	repo.On("Claim", ctx, now, now.Add(claimLease), DefaultBatchSize).Return([]domain.Event{e}, nil).Once()
+	repo.On("MarkDelivered", ctx, e.ID, "webhook").Return(nil).Once()
+	repo.On("MarkDispatched", ctx, e.ID).Return(nil).Once()
+	assert.NoError(t, d.Dispatch(ctx))
+
+	assert.Equal(t, 1, ledgerRuns)
+	assert.Equal(t, 2, webhookRuns)
+	repo.AssertExpectations(t)
+}
+
+func TestRetryDelay_Capped(t *testing.T) {
+	d := newTestDispatcher(new(MockRepo), time.Now(), WithBackoff(time.Minute))
+
+	assert.Equal(t, time.Minute, d.retryDelay(1))
+	assert.Equal(t, 8*time.Minute, d.retryDelay(4))
+	assert.Equal(t, maxBackoff, d.retryDelay(20))
+}
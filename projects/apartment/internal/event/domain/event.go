@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+type Type string
+
+const (
+	BillCreated      Type = "bill.created"
+	BillOverdue      Type = "bill.overdue"
+	PaymentSucceeded Type = "payment.succeeded"
+	// PaymentRefunded is a refund that was paid back to the payer.
+	PaymentRefunded Type = "payment.refunded"
+	// WalletToppedUp is a wallet top-up the gateway captured.
+	WalletToppedUp Type = "wallet.topped_up"
+	InviteCreated  Type = "invite.created"
+	MemberJoined   Type = "member.joined"
+	// AnnouncementPosted is an admin message to the apartment members.
+	AnnouncementPosted Type = "announcement.posted"
+)
+
+func (t Type) String() string {
+	return string(t)
+}
+
+type Status string
+
+const (
+	// StatusPending waits in the outbox to be delivered.
+	StatusPending Status = "pending"
+	// StatusDispatched was delivered to every subscriber.
+	StatusDispatched Status = "dispatched"
+	// StatusFailed ran out of delivery attempts.
+	StatusFailed Status = "failed"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// Event is a state change recorded in the outbox in the same transaction as
+// the change itself, and delivered to the subscribers afterwards.
+type Event struct {
+	ID        common.ID
+	CreatedAt time.Time
+	Type      Type
+	// AggregateID is the ID of the changed entity, e.g. the bill.
+	AggregateID common.ID
+	Payload     json.RawMessage
+	Status      Status
+	// Attempts counts the failed deliveries.
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	DispatchedAt  *time.Time
+	// Delivered names the subscribers that already handled the event, they
+	// are skipped when it is retried.
+	Delivered []string
+}
+
+// New returns a pending event with payload encoded as JSON.
+func New(t Type, aggregateID common.ID, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Type:        t,
+		AggregateID: aggregateID,
+		Payload:     data,
+		Status:      StatusPending,
+	}, nil
+}
+
+// Decode decodes the payload of e into v.
+func (e *Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+type BillCreatedPayload struct {
+	BillID      common.ID `json:"billId"`
+	ApartmentID common.ID `json:"apartmentId"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Amount      int64     `json:"amount"`
+	DueDate     time.Time `json:"dueDate"`
+}
+
+type BillOverduePayload struct {
+	BillID      common.ID `json:"billId"`
+	ApartmentID common.ID `json:"apartmentId"`
+	Name        string    `json:"name"`
+	DueDate     time.Time `json:"dueDate"`
+	// BalanceDue is the part of the bill the members haven't paid.
+	BalanceDue int64 `json:"balanceDue"`
+}
+
+type PaymentSucceededPayload struct {
+	PaymentID     common.ID `json:"paymentId"`
+	BillID        common.ID `json:"billId"`
+	PayerID       common.ID `json:"payerId"`
+	Amount        int64     `json:"amount"`
+	Gateway       string    `json:"gateway"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	PaidAt        time.Time `json:"paidAt"`
+}
+
+type PaymentRefundedPayload struct {
+	PaymentID common.ID `json:"paymentId"`
+	// RefundOf is the refunded payment.
+	RefundOf common.ID `json:"refundOf"`
+	BillID   common.ID `json:"billId"`
+	PayerID  common.ID `json:"payerId"`
+	// Amount is negative, like the amount of the refund payment.
+	Amount        int64     `json:"amount"`
+	Gateway       string    `json:"gateway"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	PaidAt        time.Time `json:"paidAt"`
+}
+
+type WalletToppedUpPayload struct {
+	// WalletTransactionID is the completed top-up transaction.
+	WalletTransactionID common.ID `json:"walletTransactionId"`
+	WalletID            common.ID `json:"walletId"`
+	UserID              common.ID `json:"userId"`
+	ApartmentID         common.ID `json:"apartmentId"`
+	Amount              int64     `json:"amount"`
+}
+
+type InviteCreatedPayload struct {
+	InviteID    common.ID `json:"inviteId"`
+	ApartmentID common.ID `json:"apartmentId"`
+	Email       string    `json:"email"`
+	Token       string    `json:"token"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	AcceptURL   string    `json:"acceptUrl"`
+}
+
+type MemberJoinedPayload struct {
+	ApartmentID common.ID `json:"apartmentId"`
+	UserID      common.ID `json:"userId"`
+	Email       string    `json:"email"`
+}
+
+type AnnouncementPostedPayload struct {
+	ApartmentID common.ID `json:"apartmentId"`
+	AuthorID    common.ID `json:"authorId"`
+	Title       string    `json:"title"`
+	Message     string    `json:"message"`
+}
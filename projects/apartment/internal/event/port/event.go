@@ -0,0 +1,38 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+)
+
+// Handler handles one event. A failed event is retried only for the
+// handlers that failed, but events are delivered at least once, so a
+// handler may still see the same event again, e.g. if the dispatcher
+// stopped before recording its delivery.
+type Handler func(ctx context.Context, e domain.Event) error
+
+type Dispatcher interface {
+	// Subscribe makes h receive the events of type t. The name records
+	// the deliveries of h, so it must be unique per type and stay the
+	// same across restarts.
+	Subscribe(t domain.Type, name string, h Handler)
+	// Run dispatches pending events periodically until ctx is done.
+	Run(ctx context.Context)
+	// Dispatch delivers the events due now.
+	Dispatch(ctx context.Context) error
+}
+
+type Repo interface {
+	// Claim returns up to limit pending events due by now, oldest first,
+	// and holds them until leaseUntil so other dispatchers skip them.
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Event, error)
+	// MarkDelivered records that the subscriber handled the event.
+	MarkDelivered(ctx context.Context, id common.ID, subscriber string) error
+	MarkDispatched(ctx context.Context, id common.ID) error
+	// MarkFailed records a failed delivery. The event is retried at
+	// retryAt, or given up on if retryAt is nil.
+	MarkFailed(ctx context.Context, id common.ID, reason string, retryAt *time.Time) error
+}
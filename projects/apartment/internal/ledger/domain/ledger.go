@@ -0,0 +1,201 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+var (
+	ErrUnbalancedEntry    = errors.New("journal entry does not balance")
+	ErrEmptyEntry         = errors.New("journal entry needs at least two lines")
+	ErrZeroLine           = errors.New("journal line amount can not be zero")
+	ErrInvalidEntryType   = errors.New("invalid journal entry type")
+	ErrMissingReference   = errors.New("journal entry reference is required")
+	ErrMissingApartmentID = errors.New("journal entry apartment id is required")
+)
+
+// AccountKind is the role of an account. Receivable and wallet accounts
+// belong to a member of an apartment, income and cash to the apartment.
+type AccountKind string
+
+const (
+	// AccountReceivable is what a member owes the apartment.
+	AccountReceivable AccountKind = "receivable"
+	// AccountWallet is the prepaid credit the apartment holds for a member.
+	AccountWallet AccountKind = "wallet"
+	// AccountIncome is what the apartment has charged its members.
+	AccountIncome AccountKind = "income"
+	// AccountCash is the money the apartment has collected.
+	AccountCash AccountKind = "cash"
+)
+
+func (k AccountKind) String() string {
+	return string(k)
+}
+
+type Account struct {
+	Kind        AccountKind
+	ApartmentID common.ID
+	// UserID is NilID for apartment accounts.
+	UserID common.ID
+}
+
+func MemberAccount(kind AccountKind, apartmentID, userID common.ID) Account {
+	return Account{Kind: kind, ApartmentID: apartmentID, UserID: userID}
+}
+
+func ApartmentAccount(kind AccountKind, apartmentID common.ID) Account {
+	return Account{Kind: kind, ApartmentID: apartmentID}
+}
+
+// Code identifies the account uniquely.
+func (a Account) Code() string {
+	if a.UserID == common.NilID {
+		return fmt.Sprintf("%s:%s", a.Kind, a.ApartmentID)
+	}
+	return fmt.Sprintf("%s:%s:%s", a.Kind, a.ApartmentID, a.UserID)
+}
+
+type EntryType string
+
+const (
+	EntryBillCharge    EntryType = "bill-charge"
+	EntryPayment       EntryType = "payment"
+	EntryRefund        EntryType = "refund"
+	EntryWalletTopUp   EntryType = "wallet-top-up"
+	EntryWalletPayment EntryType = "wallet-payment"
+	EntryFee           EntryType = "fee"
+	EntryAdjustment    EntryType = "adjustment"
+)
+
+var validEntryTypes = map[EntryType]struct{}{
+	EntryBillCharge:    {},
+	EntryPayment:       {},
+	EntryRefund:        {},
+	EntryWalletTopUp:   {},
+	EntryWalletPayment: {},
+	EntryFee:           {},
+	EntryAdjustment:    {},
+}
+
+func (t EntryType) String() string {
+	return string(t)
+}
+
+func (t EntryType) IsValid() bool {
+	_, ok := validEntryTypes[t]
+	return ok
+}
+
+// Line moves Amount into an account. Positive amounts are debits and
+// negative amounts are credits.
+type Line struct {
+	Account Account
+	Amount  int64
+}
+
+// Entry is an immutable journal entry. Reference is the ID of the source
+// row, an entry is posted at most once per type and reference.
+type Entry struct {
+	ID          common.ID
+	CreatedAt   time.Time
+	Type        EntryType
+	Reference   string
+	ApartmentID common.ID
+	BillID      *common.ID
+	Description string
+	Lines       []Line
+}
+
+func (e *Entry) Validate() error {
+	if !e.Type.IsValid() {
+		return ErrInvalidEntryType
+	}
+	if e.Reference == "" {
+		return ErrMissingReference
+	}
+	if e.ApartmentID == common.NilID {
+		return ErrMissingApartmentID
+	}
+	if len(e.Lines) < 2 {
+		return ErrEmptyEntry
+	}
+	var sum int64
+	for _, l := range e.Lines {
+		if l.Amount == 0 {
+			return ErrZeroLine
+		}
+		sum += l.Amount
+	}
+	if sum != 0 {
+		return ErrUnbalancedEntry
+	}
+	return nil
+}
+
+// Transfer returns the lines that move amount from one account to another.
+func Transfer(from, to Account, amount int64) []Line {
+	return []Line{
+		{Account: to, Amount: amount},
+		{Account: from, Amount: -amount},
+	}
+}
+
+// MemberShare is a member's part of a bill.
+type MemberShare struct {
+	UserID common.ID
+	Amount int64
+}
+
+// WalletTopUp is credit a member bought for their wallet.
+type WalletTopUp struct {
+	// Reference is the ID of the wallet transaction.
+	Reference   common.ID
+	UserID      common.ID
+	ApartmentID common.ID
+	Amount      int64
+}
+
+// Adjustment is a fee or correction an apartment admin charges, or with a
+// negative amount credits, to a member on a bill.
+type Adjustment struct {
+	Type        EntryType
+	BillID      common.ID
+	UserID      common.ID
+	Amount      int64
+	Description string
+}
+
+// BillMismatch is a member's bill balance that differs between the ledger
+// and the bill and payment rows.
+type BillMismatch struct {
+	UserID   common.ID
+	BillID   common.ID
+	Expected int64
+	Actual   int64
+}
+
+// WalletMismatch is a wallet balance that differs from its ledger account.
+type WalletMismatch struct {
+	WalletID common.ID
+	Expected int64
+	Actual   int64
+}
+
+// Report is the result of a ledger consistency check.
+type Report struct {
+	// Posted is the number of entries posted for source rows that had none.
+	Posted            int
+	UnbalancedEntries []common.ID
+	BillMismatches    []BillMismatch
+	WalletMismatches  []WalletMismatch
+}
+
+func (r *Report) OK() bool {
+	return len(r.UnbalancedEntries) == 0 &&
+		len(r.BillMismatches) == 0 &&
+		len(r.WalletMismatches) == 0
+}
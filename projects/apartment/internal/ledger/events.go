@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	eventp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+// EventTypes are the events Poster posts to the ledger.
+var EventTypes = []eventd.Type{
+	eventd.BillCreated,
+	eventd.PaymentSucceeded,
+	eventd.PaymentRefunded,
+	eventd.WalletToppedUp,
+}
+
+// Poster returns a subscriber that posts the entry of a bill, payment,
+// refund or wallet top-up. The outbox retries it until the entry is posted,
+// and posting an entry twice is a no-op.
+func Poster(s port.Service) eventp.Handler {
+	return func(ctx context.Context, e eventd.Event) error {
+		switch e.Type {
+		case eventd.BillCreated:
+			var p eventd.BillCreatedPayload
+			if err := e.Decode(&p); err != nil {
+				return err
+			}
+			err := s.PostBillCharges(ctx, p.BillID)
+			if errors.Is(err, ErrNoMembersToCharge) {
+				// nobody to charge, retrying won't change that
+				return nil
+			}
+			return err
+		case eventd.PaymentSucceeded:
+			var p eventd.PaymentSucceededPayload
+			if err := e.Decode(&p); err != nil {
+				return err
+			}
+			return s.PostPayment(ctx, &paymentd.Payment{
+				ID:            p.PaymentID,
+				BillID:        p.BillID,
+				PayerID:       p.PayerID,
+				Amount:        p.Amount,
+				PaidAt:        p.PaidAt,
+				Status:        paymentd.PaymentPaid,
+				Gateway:       p.Gateway,
+				TransactionID: p.TransactionID,
+				Description:   p.Description,
+			})
+		case eventd.PaymentRefunded:
+			var p eventd.PaymentRefundedPayload
+			if err := e.Decode(&p); err != nil {
+				return err
+			}
+			return s.PostPayment(ctx, &paymentd.Payment{
+				ID:            p.PaymentID,
+				BillID:        p.BillID,
+				PayerID:       p.PayerID,
+				Amount:        p.Amount,
+				PaidAt:        p.PaidAt,
+				Status:        paymentd.PaymentPaid,
+				Gateway:       p.Gateway,
+				TransactionID: p.TransactionID,
+				RefundOf:      &p.RefundOf,
+				Description:   p.Description,
+			})
+		case eventd.WalletToppedUp:
+			var p eventd.WalletToppedUpPayload
+			if err := e.Decode(&p); err != nil {
+				return err
+			}
+			return s.PostWalletTopUp(ctx, domain.WalletTopUp{
+				Reference:   p.WalletTransactionID,
+				UserID:      p.UserID,
+				ApartmentID: p.ApartmentID,
+				Amount:      p.Amount,
+			})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+func newEvent(t *testing.T, typ eventd.Type, payload any) eventd.Event {
+	e, err := eventd.New(typ, common.NewRandomID(), payload)
+	assert.NoError(t, err)
+	return *e
+}
+
+func TestPoster_PostsRefund(t *testing.T) {
+	repo := new(MockRepo)
+	apartmentID, payerID := common.NewRandomID(), common.NewRandomID()
+	p := eventd.PaymentRefundedPayload{
+		PaymentID: common.NewRandomID(),
+		RefundOf:  common.NewRandomID(),
+		BillID:    common.NewRandomID(),
+		PayerID:   payerID,
+		Amount:    -1000,
+		Gateway:   paymentd.MockGateway,
+		PaidAt:    time.Now(),
+	}
+	repo.On("BillApartment", ctx, p.BillID).Return(apartmentID, common.NewRandomID(), nil)
+	var e *domain.Entry
+	postedEntry(repo, &e)
+
+	err := Poster(NewService(repo))(ctx, newEvent(t, eventd.PaymentRefunded, p))
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EntryRefund, e.Type)
+	assert.Equal(t, p.PaymentID.String(), e.Reference)
+	assert.Equal(t, map[string]int64{
+		domain.ApartmentAccount(domain.AccountCash, apartmentID).Code():             -1000,
+		domain.MemberAccount(domain.AccountReceivable, apartmentID, payerID).Code(): 1000,
+	}, balances(e))
+}
+
+func TestPoster_PostsWalletTopUp(t *testing.T) {
+	repo := new(MockRepo)
+	p := eventd.WalletToppedUpPayload{
+		WalletTransactionID: common.NewRandomID(),
+		WalletID:            common.NewRandomID(),
+		UserID:              common.NewRandomID(),
+		ApartmentID:         common.NewRandomID(),
+		Amount:              2000,
+	}
+	var e *domain.Entry
+	postedEntry(repo, &e)
+
+	err := Poster(NewService(repo))(ctx, newEvent(t, eventd.WalletToppedUp, p))
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EntryWalletTopUp, e.Type)
+	assert.Equal(t, p.WalletTransactionID.String(), e.Reference)
+}
+
+func TestPoster_BillWithoutMembers(t *testing.T) {
+	repo := new(MockRepo)
+	p := eventd.BillCreatedPayload{BillID: common.NewRandomID(), Amount: 1000}
+	repo.On("BillCharge", ctx, p.BillID).Return(common.NewRandomID(), nil, nil)
+
+	err := Poster(NewService(repo))(ctx, newEvent(t, eventd.BillCreated, p))
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+}
+
+func TestPoster_PostFailsIsRetried(t *testing.T) {
+	repo := new(MockRepo)
+	p := eventd.PaymentSucceededPayload{
+		PaymentID: common.NewRandomID(),
+		BillID:    common.NewRandomID(),
+		PayerID:   common.NewRandomID(),
+		Amount:    3000,
+		Gateway:   paymentd.MockGateway,
+	}
+	repo.On("BillApartment", ctx, p.BillID).Return(common.NewRandomID(), common.NewRandomID(), nil)
+	repo.On("Post", ctx, mock.Anything).Return(false, errors.New("db down"))
+
+	err := Poster(NewService(repo))(ctx, newEvent(t, eventd.PaymentSucceeded, p))
+
+	assert.ErrorIs(t, err, ErrOnPostPayment)
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+type Service interface {
+	// PostBillCharges charges each member their share of a bill.
+	PostBillCharges(ctx context.Context, billID common.ID) error
+	// PostPayment records a paid payment, refund or wallet payment.
+	PostPayment(ctx context.Context, p *paymentd.Payment) error
+	PostWalletTopUp(ctx context.Context, t domain.WalletTopUp) error
+	PostAdjustment(ctx context.Context, adminID common.ID, a domain.Adjustment) (*domain.Entry, error)
+	// PostMissing posts the entries missing for source rows and returns
+	// how many it posted.
+	PostMissing(ctx context.Context) (int, error)
+	// Check verifies the ledger against the bill, payment and wallet rows.
+	// With fix, entries missing for source rows are posted first.
+	Check(ctx context.Context, fix bool) (*domain.Report, error)
+}
+
+type Repo interface {
+	// Post stores e and its lines. It returns false if an entry with the
+	// same type and reference was already posted.
+	Post(ctx context.Context, e *domain.Entry) (bool, error)
+	// BillCharge returns the apartment of a bill and the share of each
+	// member who had joined when the bill was issued.
+	BillCharge(ctx context.Context, billID common.ID) (common.ID, []domain.MemberShare, error)
+	// BillApartment returns the apartment of a bill and its admin.
+	BillApartment(ctx context.Context, billID common.ID) (apartmentID, adminID common.ID, err error)
+	IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error)
+
+	UnpostedBills(ctx context.Context) ([]common.ID, error)
+	UnpostedPayments(ctx context.Context) ([]*paymentd.Payment, error)
+	UnpostedTopUps(ctx context.Context) ([]domain.WalletTopUp, error)
+	UnbalancedEntries(ctx context.Context) ([]common.ID, error)
+	BillMismatches(ctx context.Context) ([]domain.BillMismatch, error)
+	WalletMismatches(ctx context.Context) ([]domain.WalletMismatch, error)
+}
@@ -0,0 +1,256 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnPostBillCharges  = errors.New("error on post bill charges")
+	ErrOnPostPayment      = errors.New("error on post payment")
+	ErrOnPostWalletTopUp  = errors.New("error on post wallet top-up")
+	ErrOnPostAdjustment   = errors.New("error on post adjustment")
+	ErrOnCheck            = errors.New("error on ledger check")
+	ErrBillNotFound       = errors.New("bill not found")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrNotMember          = errors.New("user is not a member of the apartment")
+	ErrInvalidAdjustment  = errors.New("invalid adjustment")
+	ErrPaymentNotSettled  = errors.New("payment is not paid")
+	ErrNoMembersToCharge  = errors.New("bill has no members to charge")
+	ErrInvalidWalletTopUp = errors.New("invalid wallet top-up")
+)
+
+type service struct {
+	repo port.Repo
+}
+
+func NewService(repo port.Repo) port.Service {
+	return &service{repo: repo}
+}
+
+func (s *service) post(ctx context.Context, e *domain.Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	posted, err := s.repo.Post(ctx, e)
+	if err != nil {
+		return err
+	}
+	if !posted {
+		appctx.Logger(ctx).Debug("journal entry already posted",
+			zap.String("type", e.Type.String()), zap.String("reference", e.Reference))
+	}
+	return nil
+}
+
+func (s *service) PostBillCharges(ctx context.Context, billID common.ID) error {
+	apartmentID, shares, err := s.repo.BillCharge(ctx, billID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnPostBillCharges, err)
+	}
+	e := &domain.Entry{
+		Type:        domain.EntryBillCharge,
+		Reference:   billID.String(),
+		ApartmentID: apartmentID,
+		BillID:      &billID,
+	}
+	var total int64
+	for _, share := range shares {
+		if share.Amount == 0 {
+			continue
+		}
+		e.Lines = append(e.Lines, domain.Line{
+			Account: domain.MemberAccount(domain.AccountReceivable, apartmentID, share.UserID),
+			Amount:  share.Amount,
+		})
+		total += share.Amount
+	}
+	if total == 0 {
+		return fp.WrapErrors(ErrOnPostBillCharges, ErrNoMembersToCharge)
+	}
+	e.Lines = append(e.Lines, domain.Line{
+		Account: domain.ApartmentAccount(domain.AccountIncome, apartmentID),
+		Amount:  -total,
+	})
+	if err = s.post(ctx, e); err != nil {
+		return fp.WrapErrors(ErrOnPostBillCharges, err)
+	}
+	return nil
+}
+
+// PostPayment moves a paid amount off the payer's receivable, into the
+// apartment's cash or, for wallet payments, out of the payer's wallet.
+// Refunds carry a negative amount and move it back.
+func (s *service) PostPayment(ctx context.Context, p *paymentd.Payment) error {
+	if p.Status != paymentd.PaymentPaid {
+		return fp.WrapErrors(ErrOnPostPayment, ErrPaymentNotSettled)
+	}
+	apartmentID, _, err := s.repo.BillApartment(ctx, p.BillID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnPostPayment, err)
+	}
+	receivable := domain.MemberAccount(domain.AccountReceivable, apartmentID, p.PayerID)
+	e := &domain.Entry{
+		Type:        domain.EntryPayment,
+		Reference:   p.ID.String(),
+		ApartmentID: apartmentID,
+		BillID:      &p.BillID,
+		Description: p.Description,
+		Lines: domain.Transfer(receivable,
+			domain.ApartmentAccount(domain.AccountCash, apartmentID), p.Amount),
+	}
+	switch {
+	case p.IsRefund():
+		e.Type = domain.EntryRefund
+	case p.Gateway == paymentd.WalletGateway:
+		e.Type = domain.EntryWalletPayment
+		e.Lines = domain.Transfer(receivable,
+			domain.MemberAccount(domain.AccountWallet, apartmentID, p.PayerID), p.Amount)
+	}
+	if err = s.post(ctx, e); err != nil {
+		return fp.WrapErrors(ErrOnPostPayment, err)
+	}
+	return nil
+}
+
+// PostWalletTopUp records the cash a member paid for wallet credit.
+func (s *service) PostWalletTopUp(ctx context.Context, t domain.WalletTopUp) error {
+	if t.Amount <= 0 {
+		return fp.WrapErrors(ErrOnPostWalletTopUp, ErrInvalidWalletTopUp)
+	}
+	e := &domain.Entry{
+		Type:        domain.EntryWalletTopUp,
+		Reference:   t.Reference.String(),
+		ApartmentID: t.ApartmentID,
+		Lines: domain.Transfer(
+			domain.MemberAccount(domain.AccountWallet, t.ApartmentID, t.UserID),
+			domain.ApartmentAccount(domain.AccountCash, t.ApartmentID),
+			t.Amount,
+		),
+	}
+	if err := s.post(ctx, e); err != nil {
+		return fp.WrapErrors(ErrOnPostWalletTopUp, err)
+	}
+	return nil
+}
+
+// PostAdjustment charges a fee, or corrects a member's balance on a bill.
+// Only the apartment admin can post adjustments.
+func (s *service) PostAdjustment(
+	ctx context.Context,
+	adminID common.ID,
+	a domain.Adjustment,
+) (
+	*domain.Entry, error,
+) {
+	if (a.Type != domain.EntryFee && a.Type != domain.EntryAdjustment) ||
+		a.Amount == 0 || (a.Type == domain.EntryFee && a.Amount < 0) {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, ErrInvalidAdjustment)
+	}
+	apartmentID, apartmentAdmin, err := s.repo.BillApartment(ctx, a.BillID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, err)
+	}
+	if apartmentAdmin != adminID {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, ErrPermissionDenied)
+	}
+	member, err := s.repo.IsApartmentMember(ctx, a.UserID, apartmentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, err)
+	}
+	if !member {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, ErrNotMember)
+	}
+	e := &domain.Entry{
+		Type:        a.Type,
+		Reference:   common.NewRandomID().String(),
+		ApartmentID: apartmentID,
+		BillID:      &a.BillID,
+		Description: a.Description,
+		Lines: domain.Transfer(
+			domain.ApartmentAccount(domain.AccountIncome, apartmentID),
+			domain.MemberAccount(domain.AccountReceivable, apartmentID, a.UserID),
+			a.Amount,
+		),
+	}
+	if err = s.post(ctx, e); err != nil {
+		return nil, fp.WrapErrors(ErrOnPostAdjustment, err)
+	}
+	return e, nil
+}
+
+func (s *service) Check(ctx context.Context, fix bool) (*domain.Report, error) {
+	report := &domain.Report{}
+	if fix {
+		posted, err := s.PostMissing(ctx)
+		report.Posted = posted
+		if err != nil {
+			return report, fp.WrapErrors(ErrOnCheck, err)
+		}
+	}
+
+	var err error
+	if report.UnbalancedEntries, err = s.repo.UnbalancedEntries(ctx); err != nil {
+		return report, fp.WrapErrors(ErrOnCheck, err)
+	}
+	if report.BillMismatches, err = s.repo.BillMismatches(ctx); err != nil {
+		return report, fp.WrapErrors(ErrOnCheck, err)
+	}
+	if report.WalletMismatches, err = s.repo.WalletMismatches(ctx); err != nil {
+		return report, fp.WrapErrors(ErrOnCheck, err)
+	}
+	return report, nil
+}
+
+// PostMissing posts the entries of source rows that have none, e.g. rows
+// written before the ledger existed or whose posting failed.
+func (s *service) PostMissing(ctx context.Context) (int, error) {
+	log := appctx.Logger(ctx)
+	posted := 0
+
+	bills, err := s.repo.UnpostedBills(ctx)
+	if err != nil {
+		return posted, err
+	}
+	for _, id := range bills {
+		if err := s.PostBillCharges(ctx, id); err != nil {
+			log.Warn("post missing bill charges", zap.Error(err), zap.String("billId", id.String()))
+			continue
+		}
+		posted++
+	}
+
+	payments, err := s.repo.UnpostedPayments(ctx)
+	if err != nil {
+		return posted, err
+	}
+	for _, p := range payments {
+		if err := s.PostPayment(ctx, p); err != nil {
+			log.Warn("post missing payment", zap.Error(err), zap.String("paymentId", p.ID.String()))
+			continue
+		}
+		posted++
+	}
+
+	topUps, err := s.repo.UnpostedTopUps(ctx)
+	if err != nil {
+		return posted, err
+	}
+	for _, t := range topUps {
+		if err := s.PostWalletTopUp(ctx, t); err != nil {
+			log.Warn("post missing wallet top-up", zap.Error(err),
+				zap.String("walletTransactionId", t.Reference.String()))
+			continue
+		}
+		posted++
+	}
+	return posted, nil
+}
@@ -0,0 +1,295 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) Post(ctx context.Context, e *domain.Entry) (bool, error) {
+	args := m.Called(ctx, e)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) BillCharge(ctx context.Context, billID common.ID) (common.ID, []domain.MemberShare, error) {
+	args := m.Called(ctx, billID)
+	shares, _ := args.Get(1).([]domain.MemberShare)
+	return args.Get(0).(common.ID), shares, args.Error(2)
+}
+
+func (m *MockRepo) BillApartment(ctx context.Context, billID common.ID) (common.ID, common.ID, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).(common.ID), args.Get(1).(common.ID), args.Error(2)
+}
+
+func (m *MockRepo) IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error) {
+	args := m.Called(ctx, userID, apartmentID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) UnpostedBills(ctx context.Context) ([]common.ID, error) {
+	args := m.Called(ctx)
+	ids, _ := args.Get(0).([]common.ID)
+	return ids, args.Error(1)
+}
+
+func (m *MockRepo) UnpostedPayments(ctx context.Context) ([]*paymentd.Payment, error) {
+	args := m.Called(ctx)
+	ps, _ := args.Get(0).([]*paymentd.Payment)
+	return ps, args.Error(1)
+}
+
+func (m *MockRepo) UnpostedTopUps(ctx context.Context) ([]domain.WalletTopUp, error) {
+	args := m.Called(ctx)
+	ts, _ := args.Get(0).([]domain.WalletTopUp)
+	return ts, args.Error(1)
+}
+
+func (m *MockRepo) UnbalancedEntries(ctx context.Context) ([]common.ID, error) {
+	args := m.Called(ctx)
+	ids, _ := args.Get(0).([]common.ID)
+	return ids, args.Error(1)
+}
+
+func (m *MockRepo) BillMismatches(ctx context.Context) ([]domain.BillMismatch, error) {
+	args := m.Called(ctx)
+	ms, _ := args.Get(0).([]domain.BillMismatch)
+	return ms, args.Error(1)
+}
+
+func (m *MockRepo) WalletMismatches(ctx context.Context) ([]domain.WalletMismatch, error) {
+	args := m.Called(ctx)
+	ms, _ := args.Get(0).([]domain.WalletMismatch)
+	return ms, args.Error(1)
+}
+
+// ----------- Helpers -------------
+
+// postedEntry makes repo.Post store the posted entry in *e.
+func postedEntry(repo *MockRepo, e **domain.Entry) {
+	repo.On("Post", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		*e = args.Get(1).(*domain.Entry)
+	}).Return(true, nil)
+}
+
+func balances(e *domain.Entry) map[string]int64 {
+	b := make(map[string]int64)
+	for _, l := range e.Lines {
+		b[l.Account.Code()] += l.Amount
+	}
+	return b
+}
+
+// ----------- Tests -------------
+
+func TestPostBillCharges(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	billID, apartmentID := common.NewRandomID(), common.NewRandomID()
+	alice, bob := common.NewRandomID(), common.NewRandomID()
+	repo.On("BillCharge", ctx, billID).Return(apartmentID, []domain.MemberShare{
+		{UserID: alice, Amount: 5000},
+		{UserID: bob, Amount: 5000},
+	}, nil)
+	var e *domain.Entry
+	postedEntry(repo, &e)
+
+	err := svc.PostBillCharges(ctx, billID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.EntryBillCharge, e.Type)
+	assert.Equal(t, billID.String(), e.Reference)
+	assert.Equal(t, map[string]int64{
+		domain.MemberAccount(domain.AccountReceivable, apartmentID, alice).Code(): 5000,
+		domain.MemberAccount(domain.AccountReceivable, apartmentID, bob).Code():   5000,
+		domain.ApartmentAccount(domain.AccountIncome, apartmentID).Code():         -10000,
+	}, balances(e))
+}
+
+func TestPostBillCharges_NoMembers(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	billID := common.NewRandomID()
+	repo.On("BillCharge", ctx, billID).Return(common.NewRandomID(), nil, nil)
+
+	err := svc.PostBillCharges(ctx, billID)
+
+	assert.True(t, errors.Is(err, ErrNoMembersToCharge))
+	repo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+}
+
+func TestPostPayment(t *testing.T) {
+	apartmentID, payerID := common.NewRandomID(), common.NewRandomID()
+	receivable := domain.MemberAccount(domain.AccountReceivable, apartmentID, payerID).Code()
+	cash := domain.ApartmentAccount(domain.AccountCash, apartmentID).Code()
+	wallet := domain.MemberAccount(domain.AccountWallet, apartmentID, payerID).Code()
+	refundOf := common.NewRandomID()
+
+	cases := map[string]struct {
+		payment  paymentd.Payment
+		typ      domain.EntryType
+		balances map[string]int64
+	}{
+		"gateway payment": {
+			payment:  paymentd.Payment{Amount: 3000, Gateway: paymentd.MockGateway},
+			typ:      domain.EntryPayment,
+			balances: map[string]int64{cash: 3000, receivable: -3000},
+		},
+		"wallet payment": {
+			payment:  paymentd.Payment{Amount: 3000, Gateway: paymentd.WalletGateway},
+			typ:      domain.EntryWalletPayment,
+			balances: map[string]int64{wallet: 3000, receivable: -3000},
+		},
+		"refund": {
+			payment:  paymentd.Payment{Amount: -1000, Gateway: paymentd.OfflineGateway, RefundOf: &refundOf},
+			typ:      domain.EntryRefund,
+			balances: map[string]int64{cash: -1000, receivable: 1000},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := NewService(repo)
+
+			p := c.payment
+			p.ID, p.BillID, p.PayerID, p.Status = common.NewRandomID(), common.NewRandomID(), payerID, paymentd.PaymentPaid
+			repo.On("BillApartment", ctx, p.BillID).Return(apartmentID, common.NewRandomID(), nil)
+			var e *domain.Entry
+			postedEntry(repo, &e)
+
+			err := svc.PostPayment(ctx, &p)
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.typ, e.Type)
+			assert.Equal(t, p.ID.String(), e.Reference)
+			assert.Equal(t, c.balances, balances(e))
+		})
+	}
+}
+
+func TestPostPayment_NotPaid(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	err := svc.PostPayment(ctx, &paymentd.Payment{Amount: 3000, Status: paymentd.PaymentPending})
+
+	assert.True(t, errors.Is(err, ErrPaymentNotSettled))
+	repo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+}
+
+func TestPostAdjustment(t *testing.T) {
+	billID, apartmentID := common.NewRandomID(), common.NewRandomID()
+	adminID, userID := common.NewRandomID(), common.NewRandomID()
+
+	cases := map[string]struct {
+		adminID common.ID
+		member  bool
+		adj     domain.Adjustment
+		err     error
+	}{
+		"fee":            {adminID: adminID, member: true, adj: domain.Adjustment{Type: domain.EntryFee, Amount: 500}},
+		"credit":         {adminID: adminID, member: true, adj: domain.Adjustment{Type: domain.EntryAdjustment, Amount: -500}},
+		"negative fee":   {adminID: adminID, member: true, adj: domain.Adjustment{Type: domain.EntryFee, Amount: -500}, err: ErrInvalidAdjustment},
+		"other type":     {adminID: adminID, member: true, adj: domain.Adjustment{Type: domain.EntryPayment, Amount: 500}, err: ErrInvalidAdjustment},
+		"not admin":      {adminID: common.NewRandomID(), member: true, adj: domain.Adjustment{Type: domain.EntryFee, Amount: 500}, err: ErrPermissionDenied},
+		"not the member": {adminID: adminID, adj: domain.Adjustment{Type: domain.EntryFee, Amount: 500}, err: ErrNotMember},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := NewService(repo)
+
+			adj := c.adj
+			adj.BillID, adj.UserID = billID, userID
+			repo.On("BillApartment", ctx, billID).Return(apartmentID, adminID, nil)
+			repo.On("IsApartmentMember", ctx, userID, apartmentID).Return(c.member, nil)
+			var posted *domain.Entry
+			postedEntry(repo, &posted)
+
+			e, err := svc.PostAdjustment(ctx, c.adminID, adj)
+
+			if c.err != nil {
+				assert.True(t, errors.Is(err, c.err))
+				repo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, posted, e)
+			assert.Equal(t, map[string]int64{
+				domain.MemberAccount(domain.AccountReceivable, apartmentID, userID).Code(): adj.Amount,
+				domain.ApartmentAccount(domain.AccountIncome, apartmentID).Code():          -adj.Amount,
+			}, balances(e))
+		})
+	}
+}
+
+func TestCheck_FixPostsMissingEntries(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	apartmentID, userID := common.NewRandomID(), common.NewRandomID()
+	billID := common.NewRandomID()
+	p := &paymentd.Payment{
+		ID: common.NewRandomID(), BillID: billID, PayerID: userID,
+		Amount: 1000, Status: paymentd.PaymentPaid, Gateway: paymentd.MockGateway,
+	}
+	repo.On("UnpostedBills", ctx).Return([]common.ID{billID}, nil)
+	repo.On("BillCharge", ctx, billID).Return(apartmentID, []domain.MemberShare{{UserID: userID, Amount: 1000}}, nil)
+	repo.On("UnpostedPayments", ctx).Return([]*paymentd.Payment{p}, nil)
+	repo.On("BillApartment", ctx, billID).Return(apartmentID, common.NewRandomID(), nil)
+	repo.On("UnpostedTopUps", ctx).Return([]domain.WalletTopUp{
+		{Reference: common.NewRandomID(), UserID: userID, ApartmentID: apartmentID, Amount: 2000},
+	}, nil)
+	repo.On("Post", ctx, mock.Anything).Return(true, nil)
+	repo.On("UnbalancedEntries", ctx).Return(nil, nil)
+	repo.On("BillMismatches", ctx).Return(nil, nil)
+	repo.On("WalletMismatches", ctx).Return([]domain.WalletMismatch{
+		{WalletID: common.NewRandomID(), Expected: 2000, Actual: 0},
+	}, nil)
+
+	report, err := svc.Check(ctx, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Posted)
+	assert.False(t, report.OK())
+	repo.AssertNumberOfCalls(t, "Post", 3)
+}
+
+func TestCheck_WithoutFixOnlyVerifies(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	repo.On("UnbalancedEntries", ctx).Return(nil, nil)
+	repo.On("BillMismatches", ctx).Return(nil, nil)
+	repo.On("WalletMismatches", ctx).Return(nil, nil)
+
+	report, err := svc.Check(ctx, false)
+
+	assert.NoError(t, err)
+	assert.True(t, report.OK())
+	repo.AssertNotCalled(t, "UnpostedBills", mock.Anything)
+	repo.AssertNotCalled(t, "Post", mock.Anything, mock.Anything)
+}
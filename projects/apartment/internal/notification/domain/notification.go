@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+)
+
+var (
+	ErrEmptyTitle   = errors.New("announcement title is required")
+	ErrEmptyMessage = errors.New("announcement message is required")
+)
+
+// EventTypes are the events pushed to the members of an apartment.
+var EventTypes = []eventd.Type{
+	eventd.BillCreated,
+	eventd.PaymentSucceeded,
+	eventd.MemberJoined,
+	eventd.AnnouncementPosted,
+}
+
+// Notification is an event pushed to a connected user.
+type Notification struct {
+	// ID of the event, sent as the stream event id so clients resume after
+	// it with Last-Event-ID.
+	ID          common.ID
+	Type        eventd.Type
+	ApartmentID common.ID
+	CreatedAt   time.Time
+	Data        json.RawMessage
+}
+
+// Announcement is a message of an apartment admin to its members.
+type Announcement struct {
+	ApartmentID common.ID
+	AuthorID    common.ID
+	Title       string
+	Message     string
+}
+
+func (a *Announcement) Validate() error {
+	a.Title = strings.TrimSpace(a.Title)
+	a.Message = strings.TrimSpace(a.Message)
+	if a.Title == "" {
+		return ErrEmptyTitle
+	}
+	if a.Message == "" {
+		return ErrEmptyMessage
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package notification
+
+import (
+	"sync"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+)
+
+const (
+	DefaultReplaySize = 50
+	DefaultBufferSize = 16
+)
+
+// Hub fans notifications out to the connections of each user in this
+// process. It keeps the latest notifications of every user so a client
+// reconnecting with the ID of the last one it saw gets the ones it missed.
+type Hub struct {
+	replaySize int
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[common.ID]map[*subscription]struct{}
+	recent map[common.ID][]domain.Notification
+}
+
+type subscription struct {
+	ch chan domain.Notification
+}
+
+type HubOpt func(*Hub)
+
+// WithReplaySize sets how many notifications of a user are kept for
+// reconnecting clients.
+func WithReplaySize(n int) HubOpt {
+	return func(h *Hub) {
+		if n > 0 {
+			h.replaySize = n
+		}
+	}
+}
+
+// WithBufferSize sets how many notifications a connection may fall behind
+// before it is dropped.
+func WithBufferSize(n int) HubOpt {
+	return func(h *Hub) {
+		if n > 0 {
+			h.bufferSize = n
+		}
+	}
+}
+
+func NewHub(opts ...HubOpt) *Hub {
+	h := &Hub{
+		replaySize: DefaultReplaySize,
+		bufferSize: DefaultBufferSize,
+		subs:       make(map[common.ID]map[*subscription]struct{}),
+		recent:     make(map[common.ID][]domain.Notification),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Subscribe registers a connection of the user. It returns the
+// notifications kept after lastEventID; all of them if lastEventID is
+// unknown, none if it is nil.
+func (h *Hub) Subscribe(userID, lastEventID common.ID) (
+	<-chan domain.Notification, []domain.Notification, func(),
+) {
+	s := &subscription{ch: make(chan domain.Notification, h.bufferSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*subscription]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+	replay := h.missed(userID, lastEventID)
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(userID, s)
+	}
+	return s.ch, replay, cancel
+}
+
+func (h *Hub) missed(userID, lastEventID common.ID) []domain.Notification {
+	if lastEventID == common.NilID {
+		return nil
+	}
+	recent := h.recent[userID]
+	for i := range recent {
+		if recent[i].ID == lastEventID {
+			return append([]domain.Notification(nil), recent[i+1:]...)
+		}
+	}
+	return append([]domain.Notification(nil), recent...)
+}
+
+// Publish sends n to every connection of the users. Connections that fell
+// bufferSize notifications behind are closed.
+func (h *Hub) Publish(userIDs []common.ID, n domain.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, userID := range userIDs {
+		recent := append(h.recent[userID], n)
+		if len(recent) > h.replaySize {
+			recent = recent[len(recent)-h.replaySize:]
+		}
+		h.recent[userID] = recent
+
+		for s := range h.subs[userID] {
+			select {
+			case s.ch <- n:
+			default:
+				h.remove(userID, s)
+			}
+		}
+	}
+}
+
+// remove closes s once; h.mu must be held.
+func (h *Hub) remove(userID common.ID, s *subscription) {
+	subs := h.subs[userID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	close(s.ch)
+	if len(subs) == 0 {
+		delete(h.subs, userID)
+	}
+}
@@ -0,0 +1,72 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func testNotification() domain.Notification {
+	return domain.Notification{ID: common.NewRandomID(), Type: eventd.BillCreated}
+}
+
+func TestHub_FansOutPerUser(t *testing.T) {
+	h := NewHub()
+	alice, bob := common.NewRandomID(), common.NewRandomID()
+
+	phone, _, cancelPhone := h.Subscribe(alice, common.NilID)
+	defer cancelPhone()
+	laptop, _, cancelLaptop := h.Subscribe(alice, common.NilID)
+	defer cancelLaptop()
+	other, _, cancelOther := h.Subscribe(bob, common.NilID)
+	defer cancelOther()
+
+	n := testNotification()
+	h.Publish([]common.ID{alice}, n)
+
+	assert.Equal(t, n, <-phone)
+	assert.Equal(t, n, <-laptop)
+	assert.Empty(t, other)
+}
+
+func TestHub_ReplaysAfterLastEventID(t *testing.T) {
+	h := NewHub(WithReplaySize(3))
+	user := common.NewRandomID()
+
+	ns := make([]domain.Notification, 4)
+	for i := range ns {
+		ns[i] = testNotification()
+		h.Publish([]common.ID{user}, ns[i])
+	}
+
+	_, replay, cancel := h.Subscribe(user, ns[1].ID)
+	cancel()
+	assert.Equal(t, ns[2:], replay)
+
+	// the first notification was dropped, so replay everything kept
+	_, replay, cancel = h.Subscribe(user, ns[0].ID)
+	cancel()
+	assert.Equal(t, ns[1:], replay)
+
+	_, replay, cancel = h.Subscribe(user, common.NilID)
+	cancel()
+	assert.Empty(t, replay)
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	h := NewHub(WithBufferSize(1))
+	user := common.NewRandomID()
+
+	ch, _, cancel := h.Subscribe(user, common.NilID)
+	h.Publish([]common.ID{user}, testNotification())
+	h.Publish([]common.ID{user}, testNotification())
+
+	<-ch
+	_, ok := <-ch
+	assert.False(t, ok, "slow subscriber should be closed")
+	// cancelling after the hub closed it is safe
+	cancel()
+}
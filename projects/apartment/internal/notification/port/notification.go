@@ -0,0 +1,32 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+)
+
+type Service interface {
+	// Announce posts an announcement to the members of the apartment.
+	Announce(ctx context.Context, adminID common.ID, a *domain.Announcement) error
+	// Subscribe streams the notifications of the user until cancel is
+	// called. replay holds the notifications missed after lastEventID, a
+	// nil lastEventID replays nothing. The channel is closed if the user
+	// falls too far behind; the client should reconnect.
+	Subscribe(ctx context.Context, userID, lastEventID common.ID) (
+		notifications <-chan domain.Notification, replay []domain.Notification, cancel func(),
+	)
+	// Publish is an event subscriber that pushes e to the users it concerns.
+	Publish(ctx context.Context, e eventd.Event) error
+}
+
+type Repo interface {
+	ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error)
+	BillApartment(ctx context.Context, billID common.ID) (common.ID, error)
+	// ApartmentMembers returns the IDs of the members of the apartment.
+	ApartmentMembers(ctx context.Context, apartmentID common.ID) ([]common.ID, error)
+	// PostAnnouncement records an AnnouncementPosted event.
+	PostAnnouncement(ctx context.Context, a *domain.Announcement) error
+}
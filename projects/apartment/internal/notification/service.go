@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+)
+
+var (
+	ErrOnAnnounce        = errors.New("error on post announcement")
+	ErrOnPublish         = errors.New("error on publish notification")
+	ErrNotAdmin          = errors.New("only the apartment admin can post announcements")
+	ErrApartmentNotFound = errors.New("apartment not found")
+)
+
+type service struct {
+	repo port.Repo
+	hub  *Hub
+}
+
+func NewService(repo port.Repo, hub *Hub) port.Service {
+	return &service{repo: repo, hub: hub}
+}
+
+func (s *service) Announce(ctx context.Context, adminID common.ID, a *domain.Announcement) error {
+	if err := a.Validate(); err != nil {
+		return fp.WrapErrors(ErrOnAnnounce, err)
+	}
+	id, err := s.repo.ApartmentAdmin(ctx, a.ApartmentID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnAnnounce, err)
+	}
+	if id != adminID {
+		return fp.WrapErrors(ErrOnAnnounce, ErrNotAdmin)
+	}
+	a.AuthorID = adminID
+	if err = s.repo.PostAnnouncement(ctx, a); err != nil {
+		return fp.WrapErrors(ErrOnAnnounce, err)
+	}
+	return nil
+}
+
+func (s *service) Subscribe(
+	ctx context.Context, userID, lastEventID common.ID,
+) (
+	<-chan domain.Notification, []domain.Notification, func(),
+) {
+	return s.hub.Subscribe(userID, lastEventID)
+}
+
+// Publish pushes e to the admin and members of its apartment. Payments
+// only reach their payer and the admin.
+func (s *service) Publish(ctx context.Context, e eventd.Event) error {
+	if !slices.Contains(domain.EventTypes, e.Type) {
+		return nil
+	}
+	var ref struct {
+		ApartmentID common.ID `json:"apartmentId"`
+		BillID      common.ID `json:"billId"`
+		PayerID     common.ID `json:"payerId"`
+	}
+	if err := e.Decode(&ref); err != nil {
+		return fp.WrapErrors(ErrOnPublish, err)
+	}
+	apartmentID := ref.ApartmentID
+	if apartmentID == common.NilID {
+		id, err := s.repo.BillApartment(ctx, ref.BillID)
+		if err != nil {
+			return fp.WrapErrors(ErrOnPublish, err)
+		}
+		apartmentID = id
+	}
+	recipients, err := s.recipients(ctx, e.Type, apartmentID, ref.PayerID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnPublish, err)
+	}
+	s.hub.Publish(recipients, domain.Notification{
+		ID:          e.ID,
+		Type:        e.Type,
+		ApartmentID: apartmentID,
+		CreatedAt:   e.CreatedAt,
+		Data:        e.Payload,
+	})
+	return nil
+}
+
+func (s *service) recipients(
+	ctx context.Context, t eventd.Type, apartmentID, payerID common.ID,
+) (
+	[]common.ID, error,
+) {
+	adminID, err := s.repo.ApartmentAdmin(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if t == eventd.PaymentSucceeded {
+		if payerID == adminID {
+			return []common.ID{adminID}, nil
+		}
+		return []common.ID{payerID, adminID}, nil
+	}
+	members, err := s.repo.ApartmentMembers(ctx, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(members, adminID) {
+		members = append(members, adminID)
+	}
+	return members, nil
+}
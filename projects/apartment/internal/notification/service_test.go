@@ -0,0 +1,154 @@
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error) {
+	args := m.Called(ctx, apartmentID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) BillApartment(ctx context.Context, billID common.ID) (common.ID, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) ApartmentMembers(ctx context.Context, apartmentID common.ID) ([]common.ID, error) {
+	args := m.Called(ctx, apartmentID)
+	res, _ := args.Get(0).([]common.ID)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) PostAnnouncement(ctx context.Context, a *domain.Announcement) error {
+	args := m.Called(ctx, a)
+	return args.Error(0)
+}
+
+func testEvent(t *testing.T, typ eventd.Type, payload any) eventd.Event {
+	e, err := eventd.New(typ, common.NewRandomID(), payload)
+	assert.NoError(t, err)
+	e.ID = common.NewRandomID()
+	return *e
+}
+
+// ----------- Tests -------------
+
+func TestPublish_BillCreatedReachesMembersAndAdmin(t *testing.T) {
+	repo := new(MockRepo)
+	hub := NewHub()
+	svc := NewService(repo, hub)
+
+	apartmentID, adminID, member := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+	e := testEvent(t, eventd.BillCreated, &eventd.BillCreatedPayload{ApartmentID: apartmentID})
+
+	adminCh, _, cancelAdmin := hub.Subscribe(adminID, common.NilID)
+	defer cancelAdmin()
+	memberCh, _, cancelMember := hub.Subscribe(member, common.NilID)
+	defer cancelMember()
+
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+	repo.On("ApartmentMembers", ctx, apartmentID).Return([]common.ID{member}, nil)
+
+	assert.NoError(t, svc.Publish(ctx, e))
+	for _, ch := range []<-chan domain.Notification{adminCh, memberCh} {
+		n := <-ch
+		assert.Equal(t, e.ID, n.ID)
+		assert.Equal(t, apartmentID, n.ApartmentID)
+		assert.Equal(t, eventd.BillCreated, n.Type)
+	}
+}
+
+func TestPublish_PaymentReachesPayerAndAdminOnly(t *testing.T) {
+	repo := new(MockRepo)
+	hub := NewHub()
+	svc := NewService(repo, hub)
+
+	apartmentID, billID := common.NewRandomID(), common.NewRandomID()
+	adminID, payer, other := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+	e := testEvent(t, eventd.PaymentSucceeded, &eventd.PaymentSucceededPayload{
+		BillID:  billID,
+		PayerID: payer,
+	})
+
+	payerCh, _, cancelPayer := hub.Subscribe(payer, common.NilID)
+	defer cancelPayer()
+	otherCh, _, cancelOther := hub.Subscribe(other, common.NilID)
+	defer cancelOther()
+
+	repo.On("BillApartment", ctx, billID).Return(apartmentID, nil)
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+
+	assert.NoError(t, svc.Publish(ctx, e))
+	assert.Equal(t, e.ID, (<-payerCh).ID)
+	assert.Empty(t, otherCh)
+	repo.AssertNotCalled(t, "ApartmentMembers", mock.Anything, mock.Anything)
+}
+
+func TestPublish_IgnoresOtherEvents(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, NewHub())
+
+	e := testEvent(t, eventd.InviteCreated, &eventd.InviteCreatedPayload{ApartmentID: common.NewRandomID()})
+
+	assert.NoError(t, svc.Publish(ctx, e))
+	repo.AssertNotCalled(t, "ApartmentAdmin", mock.Anything, mock.Anything)
+}
+
+func TestAnnounce(t *testing.T) {
+	adminID, apartmentID := common.NewRandomID(), common.NewRandomID()
+
+	t.Run("admin", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewService(repo, NewHub())
+		a := &domain.Announcement{ApartmentID: apartmentID, Title: " Water cut ", Message: "Tomorrow 9-12"}
+
+		repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+		repo.On("PostAnnouncement", ctx, mock.MatchedBy(func(a *domain.Announcement) bool {
+			return a.AuthorID == adminID && a.Title == "Water cut"
+		})).Return(nil)
+
+		assert.NoError(t, svc.Announce(ctx, adminID, a))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("not admin", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewService(repo, NewHub())
+		a := &domain.Announcement{ApartmentID: apartmentID, Title: "Party", Message: "Roof, 8pm"}
+
+		repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+
+		assert.ErrorIs(t, svc.Announce(ctx, common.NewRandomID(), a), ErrNotAdmin)
+		repo.AssertNotCalled(t, "PostAnnouncement", mock.Anything, mock.Anything)
+	})
+
+	t.Run("empty message", func(t *testing.T) {
+		svc := NewService(new(MockRepo), NewHub())
+		a := &domain.Announcement{ApartmentID: apartmentID, Title: "Party", Message: "  "}
+
+		assert.ErrorIs(t, svc.Announce(ctx, adminID, a), domain.ErrEmptyMessage)
+	})
+}
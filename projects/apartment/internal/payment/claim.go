@@ -0,0 +1,200 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnSubmitClaim        = errors.New("error on submit payment claim")
+	ErrOnReviewClaim        = errors.New("error on review payment claim")
+	ErrOnGetClaims          = errors.New("error on get payment claims")
+	ErrOnGetClaimProof      = errors.New("error on get payment claim proof")
+	ErrClaimNotFound        = errors.New("payment claim not found")
+	ErrClaimReviewed        = errors.New("payment claim is already reviewed")
+	ErrInvalidClaim         = errors.New("invalid payment claim")
+	ErrClaimExceedsBalance  = errors.New("claimed amount exceeds the balance due")
+	ErrProofStorageDisabled = errors.New("proof storage is not configured")
+)
+
+// SubmitClaim stores the proof of an offline payment and records a pending
+// claim for the apartment admin to review.
+func (s *service) SubmitClaim(
+	ctx context.Context, c *domain.PaymentClaim,
+) (
+	*domain.PaymentClaim, error,
+) {
+	log := appctx.Logger(ctx)
+
+	if s.proofs == nil {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, ErrProofStorageDisabled)
+	}
+	c.Reference = strings.TrimSpace(c.Reference)
+	if c.Amount <= 0 || c.Reference == "" || c.PaidAt.IsZero() ||
+		c.PaidAt.After(time.Now()) || c.Proof == nil || c.Proof.Path == "" {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, ErrInvalidClaim)
+	}
+
+	balanceDue, err := s.repo.UserBillBalanceDue(ctx, c.PayerID, c.BillID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, err)
+	}
+	if balanceDue <= 0 {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, ErrNoBalanceDue)
+	}
+	if _, err = s.paymentAmount(c.Amount, balanceDue); err != nil {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, err)
+	}
+
+	c.ProofID = common.NewRandomID()
+	if err = s.proofs.FPut(ctx, c.ProofID.String(), c.Proof.Path); err != nil {
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, err)
+	}
+	c.Status = domain.ClaimPending
+	claim, err := s.repo.CreateClaim(ctx, c)
+	if err != nil {
+		if delErr := s.proofs.Del(ctx, c.ProofID.String()); delErr != nil {
+			log.Error("delete orphan claim proof", zap.Error(delErr),
+				zap.String("proofId", c.ProofID.String()))
+		}
+		return nil, fp.WrapErrors(ErrOnSubmitClaim, err)
+	}
+	return claim, nil
+}
+
+// ReviewClaim approves or rejects a pending claim. An approved claim is
+// recorded as a paid offline payment of the member, unless it is more than
+// the member still owes.
+func (s *service) ReviewClaim(
+	ctx context.Context, r domain.ClaimReview,
+) (
+	*domain.PaymentClaim, error,
+) {
+	c, err := s.repo.GetClaim(ctx, r.ClaimID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnReviewClaim, err)
+	}
+	adminID, err := s.repo.BillApartmentAdmin(ctx, c.BillID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnReviewClaim, err)
+	}
+	if adminID != r.AdminID {
+		return nil, fp.WrapErrors(ErrOnReviewClaim, ErrPermissionDenied)
+	}
+	if c.Status != domain.ClaimPending {
+		return nil, fp.WrapErrors(ErrOnReviewClaim, ErrClaimReviewed)
+	}
+
+	now := time.Now().UTC()
+	c.ReviewedBy = &r.AdminID
+	c.ReviewedAt = &now
+
+	if !r.Approve {
+		c.Status = domain.ClaimRejected
+		c.RejectReason = r.Reason
+		if err = s.repo.RejectClaim(ctx, c); err != nil {
+			return nil, fp.WrapErrors(ErrOnReviewClaim, err)
+		}
+		return c, nil
+	}
+
+	c.Status = domain.ClaimApproved
+	p, err := s.repo.ApproveClaim(ctx, c, c.Payment())
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnReviewClaim, err)
+	}
+	c.PaymentID = &p.ID
+	for _, fn := range s.onPaid {
+		fn(ctx, []*domain.Payment{p})
+	}
+	return c, nil
+}
+
+// UserClaims returns the claims userID submitted, newest first.
+func (s *service) UserClaims(
+	ctx context.Context, userID common.ID, status domain.ClaimStatus,
+) (
+	[]domain.PaymentClaim, error,
+) {
+	if status != "" && !status.IsValid() {
+		return nil, fp.WrapErrors(ErrOnGetClaims, domain.ErrInvalidFilter)
+	}
+	cs, err := s.repo.Claims(ctx, domain.ClaimFilter{PayerID: userID, Status: status})
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetClaims, err)
+	}
+	return cs, nil
+}
+
+// ApartmentClaims returns the claims on the bills of an apartment, newest
+// first. Only the apartment admin can see them.
+func (s *service) ApartmentClaims(
+	ctx context.Context,
+	adminID, apartmentID common.ID,
+	status domain.ClaimStatus,
+) (
+	[]domain.PaymentClaim, error,
+) {
+	if status != "" && !status.IsValid() {
+		return nil, fp.WrapErrors(ErrOnGetClaims, domain.ErrInvalidFilter)
+	}
+	admin, err := s.repo.ApartmentAdmin(ctx, apartmentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetClaims, err)
+	}
+	if admin != adminID {
+		return nil, fp.WrapErrors(ErrOnGetClaims, ErrPermissionDenied)
+	}
+	cs, err := s.repo.Claims(ctx, domain.ClaimFilter{ApartmentID: apartmentID, Status: status})
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetClaims, err)
+	}
+	return cs, nil
+}
+
+// ClaimProof downloads the proof of a claim for its payer or the admin of
+// the bill's apartment.
+func (s *service) ClaimProof(
+	ctx context.Context, userID, claimID common.ID,
+) (
+	*domain.Proof, error,
+) {
+	if s.proofs == nil {
+		return nil, fp.WrapErrors(ErrOnGetClaimProof, ErrProofStorageDisabled)
+	}
+	c, err := s.repo.GetClaim(ctx, claimID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetClaimProof, err)
+	}
+	if c.PayerID != userID {
+		adminID, err := s.repo.BillApartmentAdmin(ctx, c.BillID)
+		if err != nil {
+			return nil, fp.WrapErrors(ErrOnGetClaimProof, err)
+		}
+		if adminID != userID {
+			return nil, fp.WrapErrors(ErrOnGetClaimProof, ErrPermissionDenied)
+		}
+	}
+
+	path := filepath.Join(os.TempDir(), c.ProofID.String())
+	if err = s.proofs.FGet(ctx, c.ProofID.String(), path); err != nil {
+		return nil, fp.WrapErrors(ErrOnGetClaimProof, err)
+	}
+	proof := &domain.Proof{Path: path}
+	if c.Proof != nil {
+		proof.Name = c.Proof.Name
+		proof.Type = c.Proof.Type
+		proof.Size = c.Proof.Size
+	}
+	return proof, nil
+}
@@ -0,0 +1,278 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockRepo) CreateClaim(ctx context.Context, c *domain.PaymentClaim) (*domain.PaymentClaim, error) {
+	args := m.Called(ctx, c)
+	claim, _ := args.Get(0).(*domain.PaymentClaim)
+	return claim, args.Error(1)
+}
+
+func (m *MockRepo) GetClaim(ctx context.Context, id common.ID) (*domain.PaymentClaim, error) {
+	args := m.Called(ctx, id)
+	claim, _ := args.Get(0).(*domain.PaymentClaim)
+	return claim, args.Error(1)
+}
+
+func (m *MockRepo) Claims(ctx context.Context, f domain.ClaimFilter) ([]domain.PaymentClaim, error) {
+	args := m.Called(ctx, f)
+	cs, _ := args.Get(0).([]domain.PaymentClaim)
+	return cs, args.Error(1)
+}
+
+func (m *MockRepo) ApproveClaim(
+	ctx context.Context, c *domain.PaymentClaim, p *domain.Payment,
+) (
+	*domain.Payment, error,
+) {
+	args := m.Called(ctx, c, p)
+	pay, _ := args.Get(0).(*domain.Payment)
+	return pay, args.Error(1)
+}
+
+func (m *MockRepo) RejectClaim(ctx context.Context, c *domain.PaymentClaim) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+type MockObjectStorage struct {
+	mock.Mock
+}
+
+func (m *MockObjectStorage) FPut(ctx context.Context, key, filename string) error {
+	return m.Called(ctx, key, filename).Error(0)
+}
+
+func (m *MockObjectStorage) FGet(ctx context.Context, key, filename string) error {
+	return m.Called(ctx, key, filename).Error(0)
+}
+
+func (m *MockObjectStorage) Del(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func newClaim(amount int64) *domain.PaymentClaim {
+	return &domain.PaymentClaim{
+		BillID:    common.NewRandomID(),
+		PayerID:   common.NewRandomID(),
+		Amount:    amount,
+		PaidAt:    time.Now().Add(-time.Hour),
+		Reference: " 123456 ",
+		Proof:     &domain.Proof{Name: "receipt.png", Path: "/tmp/receipt.png", Type: "image/png"},
+	}
+}
+
+func pendingClaim(amount int64) *domain.PaymentClaim {
+	c := newClaim(amount)
+	c.ID = common.NewRandomID()
+	c.ProofID = common.NewRandomID()
+	c.Reference = "123456"
+	c.Status = domain.ClaimPending
+	return c
+}
+
+func TestSubmitClaim_StoresProofAndPendingClaim(t *testing.T) {
+	repo, proofs := new(MockRepo), new(MockObjectStorage)
+	svc := newReceiptTestService(repo, WithProofStorage(proofs))
+	c := newClaim(3000)
+
+	repo.On("UserBillBalanceDue", ctx, c.PayerID, c.BillID).Return(int64(5000), nil)
+	proofs.On("FPut", ctx, mock.Anything, c.Proof.Path).Return(nil)
+	repo.On("CreateClaim", ctx, mock.MatchedBy(func(c *domain.PaymentClaim) bool {
+		return c.Status == domain.ClaimPending && c.Reference == "123456" && c.ProofID != common.NilID
+	})).Return(c, nil)
+
+	claim, err := svc.SubmitClaim(ctx, c)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimPending, claim.Status)
+	proofs.AssertCalled(t, "FPut", ctx, claim.ProofID.String(), c.Proof.Path)
+	repo.AssertExpectations(t)
+}
+
+func TestSubmitClaim_Invalid(t *testing.T) {
+	cases := map[string]struct {
+		edit func(*domain.PaymentClaim)
+		due  int64
+		err  error
+	}{
+		"no reference":    {edit: func(c *domain.PaymentClaim) { c.Reference = " " }, err: ErrInvalidClaim},
+		"no proof":        {edit: func(c *domain.PaymentClaim) { c.Proof = nil }, err: ErrInvalidClaim},
+		"future date":     {edit: func(c *domain.PaymentClaim) { c.PaidAt = time.Now().Add(time.Hour) }, err: ErrInvalidClaim},
+		"zero amount":     {edit: func(c *domain.PaymentClaim) { c.Amount = 0 }, err: ErrInvalidClaim},
+		"exceeds balance": {edit: func(c *domain.PaymentClaim) {}, due: 1000, err: ErrInvalidAmount},
+		"no balance due":  {edit: func(c *domain.PaymentClaim) {}, due: 0, err: ErrNoBalanceDue},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo, proofs := new(MockRepo), new(MockObjectStorage)
+			svc := newReceiptTestService(repo, WithProofStorage(proofs))
+			c := newClaim(3000)
+			tc.edit(c)
+
+			repo.On("UserBillBalanceDue", ctx, c.PayerID, c.BillID).Return(tc.due, nil)
+
+			_, err := svc.SubmitClaim(ctx, c)
+			assert.True(t, errors.Is(err, tc.err), err)
+			proofs.AssertNotCalled(t, "FPut", mock.Anything, mock.Anything, mock.Anything)
+			repo.AssertNotCalled(t, "CreateClaim", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSubmitClaim_WithoutProofStorage(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+
+	_, err := svc.SubmitClaim(ctx, newClaim(3000))
+	assert.True(t, errors.Is(err, ErrProofStorageDisabled))
+}
+
+func TestSubmitClaim_FailureDeletesProof(t *testing.T) {
+	repo, proofs := new(MockRepo), new(MockObjectStorage)
+	svc := newReceiptTestService(repo, WithProofStorage(proofs))
+	c := newClaim(3000)
+
+	repo.On("UserBillBalanceDue", ctx, c.PayerID, c.BillID).Return(int64(3000), nil)
+	proofs.On("FPut", ctx, mock.Anything, c.Proof.Path).Return(nil)
+	repo.On("CreateClaim", ctx, c).Return(nil, errors.New("db down"))
+	proofs.On("Del", ctx, mock.Anything).Return(nil)
+
+	_, err := svc.SubmitClaim(ctx, c)
+	assert.Error(t, err)
+	proofs.AssertCalled(t, "Del", ctx, c.ProofID.String())
+}
+
+func TestReviewClaim_ApproveCreatesOfflinePayment(t *testing.T) {
+	repo := new(MockRepo)
+	var paid []*domain.Payment
+	svc := newReceiptTestService(repo, WithOnPaymentPaid(func(_ context.Context, ps []*domain.Payment) {
+		paid = append(paid, ps...)
+	}))
+	c := pendingClaim(3000)
+	adminID := common.NewRandomID()
+
+	repo.On("GetClaim", ctx, c.ID).Return(c, nil)
+	repo.On("BillApartmentAdmin", ctx, c.BillID).Return(adminID, nil)
+	repo.On("ApproveClaim", ctx, c, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Status == domain.PaymentPaid && p.Gateway == domain.OfflineGateway &&
+			p.Amount == 3000 && p.PayerID == c.PayerID && p.TransactionID == "123456"
+	})).Return(&domain.Payment{ID: common.NewRandomID(), Amount: 3000}, nil)
+
+	claim, err := svc.ReviewClaim(ctx, domain.ClaimReview{ClaimID: c.ID, AdminID: adminID, Approve: true})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimApproved, claim.Status)
+	assert.Equal(t, adminID, *claim.ReviewedBy)
+	assert.NotNil(t, claim.PaymentID)
+	assert.Len(t, paid, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestReviewClaim_Reject(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+	c := pendingClaim(3000)
+	adminID := common.NewRandomID()
+
+	repo.On("GetClaim", ctx, c.ID).Return(c, nil)
+	repo.On("BillApartmentAdmin", ctx, c.BillID).Return(adminID, nil)
+	repo.On("RejectClaim", ctx, mock.MatchedBy(func(c *domain.PaymentClaim) bool {
+		return c.Status == domain.ClaimRejected && c.RejectReason == "no such transfer"
+	})).Return(nil)
+
+	claim, err := svc.ReviewClaim(ctx, domain.ClaimReview{
+		ClaimID: c.ID, AdminID: adminID, Reason: "no such transfer",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimRejected, claim.Status)
+	assert.Nil(t, claim.PaymentID)
+	repo.AssertNotCalled(t, "ApproveClaim", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReviewClaim_Refused(t *testing.T) {
+	adminID := common.NewRandomID()
+	cases := map[string]struct {
+		adminID common.ID
+		status  domain.ClaimStatus
+		err     error
+	}{
+		"not admin": {adminID: common.NewRandomID(), status: domain.ClaimPending, err: ErrPermissionDenied},
+		"reviewed":  {adminID: adminID, status: domain.ClaimRejected, err: ErrClaimReviewed},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := newReceiptTestService(repo)
+			c := pendingClaim(3000)
+			c.Status = tc.status
+
+			repo.On("GetClaim", ctx, c.ID).Return(c, nil)
+			repo.On("BillApartmentAdmin", ctx, c.BillID).Return(adminID, nil)
+
+			_, err := svc.ReviewClaim(ctx, domain.ClaimReview{ClaimID: c.ID, AdminID: tc.adminID, Approve: true})
+			assert.True(t, errors.Is(err, tc.err), err)
+			repo.AssertNotCalled(t, "ApproveClaim", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReviewClaim_ExceedsBalance(t *testing.T) {
+	repo := new(MockRepo)
+	var paid []*domain.Payment
+	svc := newReceiptTestService(repo, WithOnPaymentPaid(func(_ context.Context, ps []*domain.Payment) {
+		paid = append(paid, ps...)
+	}))
+	c := pendingClaim(3000)
+	adminID := common.NewRandomID()
+
+	// the member paid online after submitting the claim
+	repo.On("GetClaim", ctx, c.ID).Return(c, nil)
+	repo.On("BillApartmentAdmin", ctx, c.BillID).Return(adminID, nil)
+	repo.On("ApproveClaim", ctx, c, mock.Anything).Return(nil, ErrClaimExceedsBalance)
+
+	claim, err := svc.ReviewClaim(ctx, domain.ClaimReview{ClaimID: c.ID, AdminID: adminID, Approve: true})
+	assert.Nil(t, claim)
+	assert.True(t, errors.Is(err, ErrClaimExceedsBalance), err)
+	assert.Empty(t, paid)
+}
+
+func TestClaimProof_Access(t *testing.T) {
+	c := pendingClaim(3000)
+	adminID := common.NewRandomID()
+	cases := map[string]struct {
+		userID common.ID
+		err    error
+	}{
+		"payer":    {userID: c.PayerID},
+		"admin":    {userID: adminID},
+		"stranger": {userID: common.NewRandomID(), err: ErrPermissionDenied},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo, proofs := new(MockRepo), new(MockObjectStorage)
+			svc := newReceiptTestService(repo, WithProofStorage(proofs))
+
+			repo.On("GetClaim", ctx, c.ID).Return(c, nil)
+			repo.On("BillApartmentAdmin", ctx, c.BillID).Return(adminID, nil)
+			proofs.On("FGet", ctx, c.ProofID.String(), mock.Anything).Return(nil)
+
+			proof, err := svc.ClaimProof(ctx, tc.userID, c.ID)
+			if tc.err != nil {
+				assert.True(t, errors.Is(err, tc.err))
+				proofs.AssertNotCalled(t, "FGet", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", proof.Type)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"sort"
+)
+
+// AllocationRule decides how a partial payment of several bills is split
+// between them.
+type AllocationRule string
+
+const (
+	// AllocateOldestFirst settles the bills with the earliest due date first.
+	AllocateOldestFirst AllocationRule = "oldest-first"
+	// AllocateProRata splits the amount in proportion to each bill's balance.
+	AllocateProRata AllocationRule = "pro-rata"
+)
+
+func (r AllocationRule) IsValid() bool {
+	return r == AllocateOldestFirst || r == AllocateProRata
+}
+
+func (r AllocationRule) String() string {
+	return string(r)
+}
+
+// Allocate splits amount over bills according to the rule. No bill gets more
+// than its balance and bills with nothing allocated are left out. bills is
+// not modified.
+func (r AllocationRule) Allocate(bills []BillWithAmount, amount int64) []BillWithAmount {
+	sorted := make([]BillWithAmount, len(bills))
+	copy(sorted, bills)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DueDate.Before(sorted[j].DueDate)
+	})
+
+	shares := make([]int64, len(sorted))
+	remaining := amount
+	if r == AllocateProRata {
+		var total int64
+		for _, b := range sorted {
+			total += b.Amount
+		}
+		if total > 0 && amount < total {
+			for i, b := range sorted {
+				shares[i] = amount * b.Amount / total
+				remaining -= shares[i]
+			}
+		}
+	}
+	// what is left, all of it for oldest-first and the rounding remainder
+	// for pro-rata, goes to the oldest bills
+	for i, b := range sorted {
+		if remaining <= 0 {
+			break
+		}
+		add := min(b.Amount-shares[i], remaining)
+		shares[i] += add
+		remaining -= add
+	}
+
+	allocated := make([]BillWithAmount, 0, len(sorted))
+	for i, b := range sorted {
+		if shares[i] > 0 {
+			b.Amount = shares[i]
+			allocated = append(allocated, b)
+		}
+	}
+	return allocated
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// ClaimStatus is the review state of an offline payment claim.
+type ClaimStatus string
+
+const (
+	ClaimPending  ClaimStatus = "pending"
+	ClaimApproved ClaimStatus = "approved"
+	ClaimRejected ClaimStatus = "rejected"
+)
+
+func (cs ClaimStatus) String() string {
+	return string(cs)
+}
+
+var validClaimStatuses = map[ClaimStatus]struct{}{
+	ClaimPending:  {},
+	ClaimApproved: {},
+	ClaimRejected: {},
+}
+
+func (cs ClaimStatus) IsValid() bool {
+	_, ok := validClaimStatuses[cs]
+	return ok
+}
+
+// Proof is the uploaded image of a transfer receipt.
+type Proof struct {
+	Name string
+	Path string
+	Type string
+	Size int64
+}
+
+// PaymentClaim is a member's statement that they paid a bill outside of any
+// gateway, e.g. by card-to-card transfer to the apartment admin. An approved
+// claim becomes a paid offline payment.
+type PaymentClaim struct {
+	ID        common.ID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	BillID  common.ID
+	PayerID common.ID
+	Amount  int64
+	// PaidAt is when the member says the money was transferred.
+	PaidAt time.Time
+	// Reference is the bank or card transfer reference number.
+	Reference string
+	ProofID   common.ID
+	Proof     *Proof
+
+	Status       ClaimStatus
+	ReviewedBy   *common.ID
+	ReviewedAt   *time.Time
+	RejectReason string
+	// PaymentID is set once the claim is approved.
+	PaymentID *common.ID
+}
+
+// Payment returns the paid offline payment an approved c becomes.
+func (c *PaymentClaim) Payment() *Payment {
+	return &Payment{
+		BillID:        c.BillID,
+		PayerID:       c.PayerID,
+		Amount:        c.Amount,
+		PaidAt:        c.PaidAt,
+		Status:        PaymentPaid,
+		Gateway:       OfflineGateway,
+		TransactionID: c.Reference,
+		CallbackData:  CallbackData{"claimId": c.ID.String()},
+	}
+}
+
+// ClaimReview is an admin's decision on a pending claim.
+type ClaimReview struct {
+	ClaimID common.ID
+	AdminID common.ID
+	Approve bool
+	Reason  string
+}
+
+// ClaimFilter selects claims. Zero fields match every claim.
+type ClaimFilter struct {
+	ApartmentID common.ID
+	PayerID     common.ID
+	Status      ClaimStatus
+}
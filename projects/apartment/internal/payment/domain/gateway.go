@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"sort"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// GatewayInfo describes a payment gateway to the users choosing one.
+type GatewayInfo struct {
+	Type        GatewayType
+	DisplayName string
+	// Priority orders the gateways, lower first. Failover also picks the
+	// next gateway by priority.
+	Priority int
+	// MinAmount and MaxAmount bound the amount of one transaction, zero
+	// means no bound.
+	MinAmount int64
+	MaxAmount int64
+}
+
+// Accepts reports whether a transaction of amount is within the limits of
+// the gateway.
+func (g GatewayInfo) Accepts(amount int64) bool {
+	if g.MinAmount > 0 && amount < g.MinAmount {
+		return false
+	}
+	if g.MaxAmount > 0 && amount > g.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// SortGateways orders gateways by priority, then by type.
+func SortGateways(gateways []GatewayInfo) {
+	sort.SliceStable(gateways, func(i, j int) bool {
+		if gateways[i].Priority != gateways[j].Priority {
+			return gateways[i].Priority < gateways[j].Priority
+		}
+		return gateways[i].Type < gateways[j].Type
+	})
+}
+
+// TokenRequest asks a gateway to save a payment method of the payer for
+// later charges. The gateway calls back CallbackURL when it is saved.
+type TokenRequest struct {
+	PayerID     common.ID
+	CallbackURL string
+	Metadata    map[string]string
+}
+
+// SavedMethod is a payment method saved at a gateway.
+type SavedMethod struct {
+	// Token is the gateway reference used to charge the method.
+	Token string
+	// Mask shows the method to its owner, e.g. 6037-****-****-1234.
+	Mask string
+}
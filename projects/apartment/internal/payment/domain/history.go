@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var ErrInvalidFilter = errors.New("invalid payment filter")
+
+// PaymentFilter selects payments for a history page. Zero fields match
+// every payment. From is inclusive and To exclusive.
+type PaymentFilter struct {
+	PayerID     common.ID
+	ApartmentID common.ID
+	BillID      common.ID
+	Status      PaymentStatus
+	Gateway     GatewayType
+	From        time.Time
+	To          time.Time
+	// Page starts at 1.
+	Page     int
+	PageSize int
+}
+
+// Normalize validates f and fills in the default page.
+func (f *PaymentFilter) Normalize() error {
+	if f.Status != "" && !f.Status.IsValid() {
+		return ErrInvalidFilter
+	}
+	if f.Gateway != "" && !f.Gateway.IsValid() {
+		return ErrInvalidFilter
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return ErrInvalidFilter
+	}
+	if f.Page < 0 || f.PageSize < 0 || f.PageSize > MaxPageSize {
+		return ErrInvalidFilter
+	}
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.PageSize == 0 {
+		f.PageSize = DefaultPageSize
+	}
+	return nil
+}
+
+func (f *PaymentFilter) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// PaymentRecord is a payment with the names needed to show it in a history.
+type PaymentRecord struct {
+	Payment
+	BillName      string
+	ApartmentID   common.ID
+	ApartmentName string
+	PayerName     string
+	PayerEmail    string
+}
+
+type PaymentPage struct {
+	Records  []PaymentRecord
+	Total    int
+	Page     int
+	PageSize int
+}
+
+// MemberBalance is what a member still owes on a bill.
+type MemberBalance struct {
+	UserID     common.ID
+	Name       string
+	Email      string
+	BalanceDue int64
+}
+
+// ApartmentPaymentPage is an admin's view of an apartment's payments. When
+// the filter selects a bill, Unpaid lists the members who still owe on it.
+type ApartmentPaymentPage struct {
+	PaymentPage
+	Unpaid []MemberBalance
+}
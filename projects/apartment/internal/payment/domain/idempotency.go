@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// IdempotencyKey stores the fingerprint of a payment initiation request and
+// the gateway redirect it produced, so a retried request with the same key
+// can be answered without starting a new transaction.
+type IdempotencyKey struct {
+	Key         string
+	UserID      common.ID
+	Fingerprint string
+	Response    *RedirectGateway
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// IsCompleted reports whether the original request finished and its response
+// was stored.
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.Response != nil
+}
+
+// Fingerprint returns a stable hash of the given request parts.
+func Fingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,11 +1,14 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 )
 
+var ErrTransactionNotFound = errors.New("transaction not found")
+
 // PaymentStatus represents the current status of a payment
 type PaymentStatus string
 
@@ -14,6 +17,7 @@ const (
 	PaymentPaid      PaymentStatus = "paid"
 	PaymentFailed    PaymentStatus = "failed"
 	PaymentCancelled PaymentStatus = "cancelled"
+	PaymentExpired   PaymentStatus = "expired"
 )
 
 func (ps PaymentStatus) String() string {
@@ -25,6 +29,7 @@ var validPaymentStatuses = map[PaymentStatus]struct{}{
 	PaymentPaid:      {},
 	PaymentFailed:    {},
 	PaymentCancelled: {},
+	PaymentExpired:   {},
 }
 
 func (ps PaymentStatus) IsValid() bool {
@@ -49,11 +54,21 @@ type Payment struct {
 	Gateway       string        `json:"gateway"`
 	TransactionID string        `json:"transactionId,omitempty"`
 	CallbackData  CallbackData  `json:"callbackData,omitempty"` // Use map for parsed JSONB
+
+	// RefundOf is set on refund entries and points to the refunded payment.
+	// Refund entries carry a negative Amount.
+	RefundOf    *common.ID `json:"refundOf,omitempty"`
+	Description string     `json:"description,omitempty"`
+}
+
+func (p *Payment) IsRefund() bool {
+	return p.RefundOf != nil
 }
 
 type BillWithAmount struct {
-	BillID common.ID
-	Amount int64
+	BillID  common.ID
+	Amount  int64
+	DueDate time.Time
 }
 
 type GatewayType string
@@ -62,10 +77,19 @@ func (g GatewayType) String() string {
 	return string(g)
 }
 
-const MockGateway = "mock-gateway"
+const (
+	MockGateway = "mock-gateway"
+	// OfflineGateway records money moved outside of any gateway, e.g. an
+	// approved card-to-card payment claim or a refund paid back in cash.
+	OfflineGateway = "offline"
+	// WalletGateway records payments made from a resident's wallet credit.
+	WalletGateway = "wallet"
+)
 
 var validGateways = map[GatewayType]struct{}{
-	MockGateway: {},
+	MockGateway:    {},
+	OfflineGateway: {},
+	WalletGateway:  {},
 }
 
 func (g GatewayType) IsValid() bool {
@@ -86,4 +110,31 @@ type RedirectGateway struct {
 	Method string
 	URL    string
 	Body   map[string]any
+	// TransactionID is the gateway reference used to inquire the
+	// transaction later. Empty if the gateway has none.
+	TransactionID string
+	// Gateway is the gateway the transaction was created on, which differs
+	// from the requested one after a failover.
+	Gateway GatewayType
+}
+
+// Capture is what a gateway verified of a transaction on callback.
+type Capture struct {
+	// TransactionID is the gateway reference of the transaction. Empty if
+	// the callback didn't name one.
+	TransactionID string
+	// Amount captured, zero if the gateway didn't report it.
+	Amount int64
+}
+
+// Refund describes an admin request to give back part or all of a payment.
+type Refund struct {
+	PaymentID common.ID
+	AdminID   common.ID
+	// Amount to refund, zero means whatever is left of the payment.
+	Amount int64
+	Reason string
+	// Offline records a refund made outside the gateway instead of
+	// asking the gateway to refund.
+	Offline bool
 }
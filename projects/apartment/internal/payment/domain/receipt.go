@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// ReceiptItem is a bill covered by a receipt.
+type ReceiptItem struct {
+	PaymentID        common.ID
+	BillID           common.ID
+	BillName         string
+	ApartmentID      common.ID
+	ApartmentName    string
+	ApartmentAdminID common.ID
+	Amount           int64
+}
+
+// Receipt covers the payments settled by one gateway transaction.
+type Receipt struct {
+	Number        string
+	PaidAt        time.Time
+	PayerID       common.ID
+	PayerName     string
+	PayerEmail    string
+	Gateway       string
+	TransactionID string
+	Items         []ReceiptItem
+}
+
+// ReceiptNumber derives the receipt number from the first payment it
+// covers, so every copy of a receipt has the same number.
+func ReceiptNumber(paidAt time.Time, paymentID common.ID) string {
+	id := strings.ReplaceAll(paymentID.String(), "-", "")
+	return fmt.Sprintf("RC-%s-%s", paidAt.UTC().Format("20060102"), strings.ToUpper(id[:8]))
+}
+
+func (r *Receipt) Total() int64 {
+	var total int64
+	for _, item := range r.Items {
+		total += item.Amount
+	}
+	return total
+}
+
+// VisibleTo returns the part of the receipt userID may see: all of it for
+// the payer and the bills of their apartments for apartment admins. ok is
+// false if userID may see nothing.
+func (r *Receipt) VisibleTo(userID common.ID) (_ *Receipt, ok bool) {
+	if userID == r.PayerID {
+		return r, true
+	}
+	visible := *r
+	visible.Items = nil
+	for _, item := range r.Items {
+		if item.ApartmentAdminID == userID {
+			visible.Items = append(visible.Items, item)
+		}
+	}
+	return &visible, len(visible.Items) > 0
+}
+
+type ReceiptFormat string
+
+const (
+	ReceiptHTML ReceiptFormat = "html"
+	ReceiptPDF  ReceiptFormat = "pdf"
+)
+
+func (f ReceiptFormat) IsValid() bool {
+	return f == ReceiptHTML || f == ReceiptPDF
+}
+
+// ReceiptFile is a rendered receipt.
+type ReceiptFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
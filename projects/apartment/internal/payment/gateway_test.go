@@ -0,0 +1,147 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const otherGateway domain.GatewayType = "other-gateway"
+
+func newFailoverTestService(repo *MockRepo, primary, backup *MockGateway, opts ...ServiceOpt) *service {
+	opts = append([]ServiceOpt{WithGatewayInfo(
+		domain.GatewayInfo{Type: domain.MockGateway, DisplayName: "Mock", Priority: 1},
+		domain.GatewayInfo{Type: otherGateway, DisplayName: "Other", Priority: 2},
+	)}, opts...)
+	return NewService(repo, map[domain.GatewayType]port.Gateway{
+		domain.MockGateway: primary,
+		otherGateway:       backup,
+	}, opts...).(*service)
+}
+
+func gatewayTypes(infos []domain.GatewayInfo) []domain.GatewayType {
+	types := make([]domain.GatewayType, 0, len(infos))
+	for _, info := range infos {
+		types = append(types, info.Type)
+	}
+	return types
+}
+
+func TestSupportedGateways_OrderedByPriority(t *testing.T) {
+	svc := NewService(new(MockRepo), map[domain.GatewayType]port.Gateway{
+		"c-gateway":        new(MockGateway),
+		"b-gateway":        new(MockGateway),
+		domain.MockGateway: new(MockGateway),
+	}, WithGatewayInfo(
+		domain.GatewayInfo{Type: "c-gateway", DisplayName: "C", Priority: 1},
+		domain.GatewayInfo{Type: "b-gateway", DisplayName: "B", Priority: 1},
+		domain.GatewayInfo{Type: domain.MockGateway, DisplayName: "Mock", Priority: 2},
+	))
+
+	got := svc.SupportedGateways()
+
+	assert.Equal(t, []domain.GatewayType{"b-gateway", "c-gateway", domain.MockGateway}, gatewayTypes(got))
+	assert.Equal(t, "B", got[0].DisplayName)
+}
+
+func TestPayBill_CircuitOpensAndFailsOver(t *testing.T) {
+	repo := new(MockRepo)
+	primary, backup := new(MockGateway), new(MockGateway)
+	svc := newFailoverTestService(repo, primary, backup, WithCircuitBreaker(2, time.Minute))
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+	gatewayErr := errors.New("gateway down")
+
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+	primary.On("CreateTransaction", ctx, mock.Anything).Return(nil, gatewayErr).Twice()
+	backup.On("CreateTransaction", ctx, mock.Anything).Return(&domain.RedirectGateway{}, nil).Once()
+
+	for range 2 {
+		_, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+		assert.ErrorIs(t, err, gatewayErr)
+	}
+	assert.Equal(t, []domain.GatewayType{otherGateway}, gatewayTypes(svc.SupportedGateways()))
+
+	redirect, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, otherGateway, redirect.Gateway)
+	repo.AssertCalled(t, "CreatePayment", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Gateway == otherGateway.String()
+	}))
+	primary.AssertExpectations(t)
+	backup.AssertExpectations(t)
+}
+
+func TestPayBill_CircuitClosesAfterCooldown(t *testing.T) {
+	repo := new(MockRepo)
+	primary, backup := new(MockGateway), new(MockGateway)
+	svc := newFailoverTestService(repo, primary, backup, WithCircuitBreaker(1, time.Minute))
+	now := time.Now()
+	svc.breakers[domain.MockGateway].now = func() time.Time { return now }
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+	primary.On("CreateTransaction", ctx, mock.Anything).Return(nil, errors.New("gateway down")).Once()
+
+	_, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+	assert.Error(t, err)
+	assert.False(t, svc.breakers[domain.MockGateway].Available())
+
+	now = now.Add(time.Minute)
+	primary.On("CreateTransaction", ctx, mock.Anything).Return(&domain.RedirectGateway{}, nil).Once()
+
+	redirect, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.GatewayType(domain.MockGateway), redirect.Gateway)
+	backup.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestPayBill_AmountOutsideGatewayLimits(t *testing.T) {
+	cases := map[string]struct {
+		balanceDue int64
+		want       domain.GatewayType
+		err        error
+	}{
+		"within primary":  {balanceDue: 100, want: domain.MockGateway},
+		"fails over":      {balanceDue: 1000, want: otherGateway},
+		"none accepts it": {balanceDue: 10000, err: ErrGatewayUnavailable},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			primary, backup := new(MockGateway), new(MockGateway)
+			svc := newFailoverTestService(repo, primary, backup, WithGatewayInfo(
+				domain.GatewayInfo{Type: domain.MockGateway, Priority: 1, MaxAmount: 500},
+				domain.GatewayInfo{Type: otherGateway, Priority: 2, MinAmount: 500, MaxAmount: 5000},
+			))
+
+			userID, billID := common.NewRandomID(), common.NewRandomID()
+
+			repo.On("UserBillBalanceDue", ctx, userID, billID).Return(c.balanceDue, nil)
+			repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+			primary.On("CreateTransaction", ctx, mock.Anything).Return(&domain.RedirectGateway{}, nil)
+			backup.On("CreateTransaction", ctx, mock.Anything).Return(&domain.RedirectGateway{}, nil)
+
+			redirect, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+
+			if c.err != nil {
+				assert.ErrorIs(t, err, c.err)
+				repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, redirect.Gateway)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package payment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+)
+
+var (
+	ErrOnPaymentHistory  = errors.New("error on get payment history")
+	ErrApartmentNotFound = errors.New("apartment not found")
+)
+
+// UserPayments returns a page of the payments userID made.
+func (s *service) UserPayments(
+	ctx context.Context,
+	userID common.ID,
+	f domain.PaymentFilter,
+) (
+	*domain.PaymentPage, error,
+) {
+	f.PayerID = userID
+	if err := f.Normalize(); err != nil {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+	}
+	page, err := s.repo.Payments(ctx, f)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+	}
+	return page, nil
+}
+
+// ApartmentPayments returns a page of the payments made for the bills of
+// an apartment. Only the apartment admin can see them.
+func (s *service) ApartmentPayments(
+	ctx context.Context,
+	adminID, apartmentID common.ID,
+	f domain.PaymentFilter,
+) (
+	*domain.ApartmentPaymentPage, error,
+) {
+	f.ApartmentID = apartmentID
+	if err := f.Normalize(); err != nil {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+	}
+	admin, err := s.repo.ApartmentAdmin(ctx, apartmentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+	}
+	if admin != adminID {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, ErrPermissionDenied)
+	}
+
+	page, err := s.repo.Payments(ctx, f)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+	}
+	result := &domain.ApartmentPaymentPage{PaymentPage: *page}
+	if f.BillID != common.NilID {
+		result.Unpaid, err = s.repo.UnpaidMembers(ctx, apartmentID, f.BillID)
+		if err != nil {
+			return nil, fp.WrapErrors(ErrOnPaymentHistory, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockRepo) Payments(ctx context.Context, f domain.PaymentFilter) (*domain.PaymentPage, error) {
+	args := m.Called(ctx, f)
+	p, _ := args.Get(0).(*domain.PaymentPage)
+	return p, args.Error(1)
+}
+
+func (m *MockRepo) ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error) {
+	args := m.Called(ctx, apartmentID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) UnpaidMembers(ctx context.Context, apartmentID, billID common.ID) ([]domain.MemberBalance, error) {
+	args := m.Called(ctx, apartmentID, billID)
+	b, _ := args.Get(0).([]domain.MemberBalance)
+	return b, args.Error(1)
+}
+
+func TestUserPayments_OnlyOwnPayments(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+	userID := common.NewRandomID()
+
+	repo.On("Payments", ctx, mock.MatchedBy(func(f domain.PaymentFilter) bool {
+		return f.PayerID == userID && f.Page == 1 && f.PageSize == domain.DefaultPageSize
+	})).Return(&domain.PaymentPage{Total: 1, Page: 1, PageSize: domain.DefaultPageSize}, nil)
+
+	// A payer set by the caller is replaced by the user.
+	page, err := svc.UserPayments(ctx, userID, domain.PaymentFilter{PayerID: common.NewRandomID()})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+	repo.AssertExpectations(t)
+}
+
+func TestUserPayments_InvalidFilter(t *testing.T) {
+	cases := map[string]domain.PaymentFilter{
+		"status":    {Status: "unknown"},
+		"gateway":   {Gateway: "unknown"},
+		"page size": {PageSize: domain.MaxPageSize + 1},
+		"page":      {Page: -1},
+	}
+	for name, f := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := newReceiptTestService(repo)
+
+			_, err := svc.UserPayments(ctx, common.NewRandomID(), f)
+			assert.True(t, errors.Is(err, domain.ErrInvalidFilter))
+			repo.AssertNotCalled(t, "Payments", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestApartmentPayments_NotAdmin(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+	apartmentID := common.NewRandomID()
+
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(common.NewRandomID(), nil)
+
+	_, err := svc.ApartmentPayments(ctx, common.NewRandomID(), apartmentID, domain.PaymentFilter{})
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+	repo.AssertNotCalled(t, "Payments", mock.Anything, mock.Anything)
+}
+
+func TestApartmentPayments_UnpaidMembersForBill(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+	adminID, apartmentID, billID := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+	unpaid := []domain.MemberBalance{{UserID: common.NewRandomID(), Name: "Sam", BalanceDue: 2500}}
+
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+	repo.On("Payments", ctx, mock.MatchedBy(func(f domain.PaymentFilter) bool {
+		return f.ApartmentID == apartmentID && f.BillID == billID
+	})).Return(&domain.PaymentPage{Page: 1, PageSize: domain.DefaultPageSize}, nil)
+	repo.On("UnpaidMembers", ctx, apartmentID, billID).Return(unpaid, nil)
+
+	page, err := svc.ApartmentPayments(ctx, adminID, apartmentID, domain.PaymentFilter{BillID: billID})
+	assert.NoError(t, err)
+	assert.Equal(t, unpaid, page.Unpaid)
+	repo.AssertExpectations(t)
+}
+
+func TestApartmentPayments_WithoutBillSkipsUnpaid(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+	adminID, apartmentID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+	repo.On("Payments", ctx, mock.Anything).Return(&domain.PaymentPage{}, nil)
+
+	page, err := svc.ApartmentPayments(ctx, adminID, apartmentID, domain.PaymentFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Unpaid)
+	repo.AssertNotCalled(t, "UnpaidMembers", mock.Anything, mock.Anything, mock.Anything)
+}
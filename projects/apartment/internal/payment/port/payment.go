@@ -2,27 +2,141 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 )
 
 type Service interface {
-	PayBill(ctx context.Context, gateway domain.GatewayType, billID, userID common.ID, callBackURL string) (*domain.RedirectGateway, error)
-	PayTotalDebt(ctx context.Context, gateway domain.GatewayType, userID common.ID, callBackURL string) (*domain.RedirectGateway, error)
+	// PayBill pays amount of the user's share of a bill, zero pays all of it.
+	PayBill(ctx context.Context, gateway domain.GatewayType, userID, billID common.ID, amount int64, callBackURL, idempotencyKey string) (*domain.RedirectGateway, error)
+	// PayTotalDebt pays amount of the user's debt on billIDs, or on all bills
+	// if billIDs is empty. Zero pays all of it.
+	PayTotalDebt(ctx context.Context, gateway domain.GatewayType, userID common.ID, billIDs []common.ID, amount int64, callBackURL, idempotencyKey string) (*domain.RedirectGateway, error)
 	HandleCallback(ctx context.Context, gateway domain.GatewayType, data map[string][]string) error
-	SupportedGateways() []string
+	// SupportedGateways returns the gateways currently available, ordered
+	// by priority.
+	SupportedGateways() []domain.GatewayInfo
+	Refund(ctx context.Context, r domain.Refund) (*domain.Payment, error)
+	// Receipt renders the receipt of a paid payment for its payer or the
+	// admins of the apartments it paid bills of.
+	Receipt(ctx context.Context, userID, paymentID common.ID, format domain.ReceiptFormat) (*domain.ReceiptFile, error)
+	// SendReceipt emails the receipt of a paid payment to its payer.
+	SendReceipt(ctx context.Context, paymentID common.ID) error
+	UserPayments(ctx context.Context, userID common.ID, f domain.PaymentFilter) (*domain.PaymentPage, error)
+	ApartmentPayments(ctx context.Context, adminID, apartmentID common.ID, f domain.PaymentFilter) (*domain.ApartmentPaymentPage, error)
+	// SubmitClaim records a member's offline payment for the apartment
+	// admin to approve or reject.
+	SubmitClaim(ctx context.Context, c *domain.PaymentClaim) (*domain.PaymentClaim, error)
+	ReviewClaim(ctx context.Context, r domain.ClaimReview) (*domain.PaymentClaim, error)
+	UserClaims(ctx context.Context, userID common.ID, status domain.ClaimStatus) ([]domain.PaymentClaim, error)
+	ApartmentClaims(ctx context.Context, adminID, apartmentID common.ID, status domain.ClaimStatus) ([]domain.PaymentClaim, error)
+	ClaimProof(ctx context.Context, userID, claimID common.ID) (*domain.Proof, error)
 }
 
 type Repo interface {
 	CreatePayment(ctx context.Context, p *domain.Payment) (*domain.Payment, error)
 	BatchCreatePayment(ctx context.Context, ps []*domain.Payment) ([]*domain.Payment, error)
 	UpdateStatus(ctx context.Context, paymentID []common.ID, s domain.PaymentStatus) error
+	// SettlePending moves the given payments, but not refunds, to s if they
+	// are still pending and returns how many rows changed.
+	SettlePending(ctx context.Context, paymentIDs []common.ID, s domain.PaymentStatus) (int64, error)
+	// SettleCaptured moves the given payments to paid if they are pending,
+	// or expired because the capture was confirmed after the reconciler
+	// gave up on them. The payments must all have been started on the
+	// captured transaction of gateway gt, add up to its amount and not be
+	// refunds, or ErrCaptureMismatch is returned. It returns how many rows
+	// changed and which of them were expired.
+	SettleCaptured(ctx context.Context, paymentIDs []common.ID, gt domain.GatewayType, c domain.Capture) (int64, []common.ID, error)
+	SetTransactionID(ctx context.Context, paymentIDs []common.ID, transactionID string) error
+	// PendingPayments returns the pending payments, but not refunds,
+	// created before createdBefore.
+	PendingPayments(ctx context.Context, createdBefore time.Time) ([]*domain.Payment, error)
+	GetPayment(ctx context.Context, id common.ID) (*domain.Payment, error)
+	// ReserveRefund records refund of the paid payment it refunds, if as
+	// much is left to refund, counting pending refunds. The payment is
+	// locked meanwhile, so concurrent refunds can't exceed it. A zero
+	// amount refunds whatever is left. It returns ErrNotRefundable or
+	// ErrInvalidRefundAmount otherwise.
+	ReserveRefund(ctx context.Context, refund *domain.Payment) (*domain.Payment, error)
+	// SettleRefund moves a pending refund to s, recording the gateway
+	// reference of the refund. A paid refund is recorded in the outbox.
+	SettleRefund(ctx context.Context, id common.ID, transactionID string, s domain.PaymentStatus) error
+	BillApartmentAdmin(ctx context.Context, billID common.ID) (common.ID, error)
+	// Receipt returns the receipt of p, covering the paid payments settled
+	// by the same gateway transaction.
+	Receipt(ctx context.Context, p *domain.Payment) (*domain.Receipt, error)
+	Payments(ctx context.Context, f domain.PaymentFilter) (*domain.PaymentPage, error)
+	ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error)
+	// UnpaidMembers returns the members of an apartment who still owe on
+	// one of its bills.
+	UnpaidMembers(ctx context.Context, apartmentID, billID common.ID) ([]domain.MemberBalance, error)
+	CreateClaim(ctx context.Context, c *domain.PaymentClaim) (*domain.PaymentClaim, error)
+	GetClaim(ctx context.Context, id common.ID) (*domain.PaymentClaim, error)
+	Claims(ctx context.Context, f domain.ClaimFilter) ([]domain.PaymentClaim, error)
+	// ApproveClaim creates p and marks c approved in one transaction, unless
+	// c was reviewed in the meantime or p is more than the payer owes on
+	// the bill. The bill is locked while the balance is checked.
+	ApproveClaim(ctx context.Context, c *domain.PaymentClaim, p *domain.Payment) (*domain.Payment, error)
+	// RejectClaim marks c rejected unless it was reviewed in the meantime.
+	RejectClaim(ctx context.Context, c *domain.PaymentClaim) error
 	UserBillBalanceDue(ctx context.Context, userId, billId common.ID) (int64, error)
 	UserBillsBalanceDue(ctx context.Context, userId common.ID) ([]domain.BillWithAmount, error)
+	// ReserveIdempotencyKey stores k unless an unexpired key with the same
+	// user and value already exists. It returns the stored key and whether
+	// it was newly reserved.
+	ReserveIdempotencyKey(ctx context.Context, k *domain.IdempotencyKey) (*domain.IdempotencyKey, bool, error)
+	SaveIdempotencyResponse(ctx context.Context, k *domain.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID common.ID, key string) error
 }
 
 type Gateway interface {
 	CreateTransaction(ctx context.Context, tx domain.Transaction) (*domain.RedirectGateway, error)
-	VerifyTransaction(ctx context.Context, data map[string][]string) error
+	// VerifyTransaction checks a callback with the gateway and returns
+	// what was captured. It returns an error unless the payment succeeded.
+	VerifyTransaction(ctx context.Context, data map[string][]string) (*domain.Capture, error)
+}
+
+// Inquirer is implemented by gateways that can report the state of a
+// transaction without waiting for a callback.
+type Inquirer interface {
+	InquireTransaction(ctx context.Context, transactionID string) (domain.PaymentStatus, error)
+}
+
+// Refunder is implemented by gateways that can give money back for a
+// settled transaction. It returns the gateway reference of the refund.
+type Refunder interface {
+	RefundTransaction(ctx context.Context, transactionID string, amount int64) (string, error)
+}
+
+// RecurringGateway is implemented by gateways that can save a payment
+// method and charge it later without the payer, e.g. for autopay.
+type RecurringGateway interface {
+	Gateway
+	// Tokenize starts saving a payment method. The payer is redirected to
+	// the gateway, which calls back the request's callback URL.
+	Tokenize(ctx context.Context, r domain.TokenRequest) (*domain.RedirectGateway, error)
+	// VerifyToken checks a tokenization callback and returns the saved
+	// method.
+	VerifyToken(ctx context.Context, data map[string][]string) (*domain.SavedMethod, error)
+	// ChargeToken charges tx to a saved method and returns the gateway
+	// transaction ID. It returns an error unless the charge was captured.
+	ChargeToken(ctx context.Context, token string, tx domain.Transaction) (string, error)
+}
+
+type ObjectStorage interface {
+	FPut(ctx context.Context, key, filename string) error
+	FGet(ctx context.Context, key, filename string) error
+	Del(ctx context.Context, key string) error
+}
+
+type EmailSender interface {
+	Send(to []string, msg *common.EmailMessage) error
+}
+
+// Reconciler settles payments whose callback never arrived.
+type Reconciler interface {
+	Run(ctx context.Context)
+	Reconcile(ctx context.Context) error
 }
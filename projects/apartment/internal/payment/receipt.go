@@ -0,0 +1,116 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/template"
+)
+
+var (
+	ErrOnReceipt             = errors.New("error on get receipt")
+	ErrOnSendReceipt         = errors.New("error on send receipt")
+	ErrReceiptNotAvailable   = errors.New("receipts are only issued for paid payments")
+	ErrInvalidReceiptFormat  = errors.New("invalid receipt format")
+	ErrReceiptMailerDisabled = errors.New("receipt mailer is not configured")
+)
+
+func (s *service) Receipt(
+	ctx context.Context,
+	userID, paymentID common.ID,
+	format domain.ReceiptFormat,
+) (
+	*domain.ReceiptFile, error,
+) {
+	if !format.IsValid() {
+		return nil, fp.WrapErrors(ErrOnReceipt, ErrInvalidReceiptFormat)
+	}
+	r, err := s.receipt(ctx, paymentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnReceipt, err)
+	}
+	r, ok := r.VisibleTo(userID)
+	if !ok {
+		return nil, fp.WrapErrors(ErrOnReceipt, ErrPermissionDenied)
+	}
+
+	file := &domain.ReceiptFile{Name: r.Number + "." + string(format)}
+	switch format {
+	case domain.ReceiptPDF:
+		file.ContentType = "application/pdf"
+		file.Content = template.NewReceiptPDF(receiptData(r))
+	default:
+		file.ContentType = "text/html; charset=utf-8"
+		file.Content, err = template.NewReceipt(receiptData(r))
+		if err != nil {
+			return nil, fp.WrapErrors(ErrOnReceipt, err)
+		}
+	}
+	return file, nil
+}
+
+func (s *service) SendReceipt(ctx context.Context, paymentID common.ID) error {
+	if s.mail == nil {
+		return fp.WrapErrors(ErrOnSendReceipt, ErrReceiptMailerDisabled)
+	}
+	r, err := s.receipt(ctx, paymentID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendReceipt, err)
+	}
+	body, err := template.NewReceipt(receiptData(r))
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendReceipt, err)
+	}
+	err = s.mail.Send([]string{r.PayerEmail}, &common.EmailMessage{
+		Subject: "Payment receipt " + r.Number,
+		Body:    body,
+		IsHTML:  true,
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnSendReceipt, err)
+	}
+	return nil
+}
+
+func (s *service) receipt(ctx context.Context, paymentID common.ID) (*domain.Receipt, error) {
+	p, err := s.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.PaymentPaid || p.IsRefund() {
+		return nil, ErrReceiptNotAvailable
+	}
+	r, err := s.repo.Receipt(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Items) == 0 {
+		return nil, ErrReceiptNotAvailable
+	}
+	r.Number = domain.ReceiptNumber(r.PaidAt, r.Items[0].PaymentID)
+	return r, nil
+}
+
+func receiptData(r *domain.Receipt) template.ReceiptData {
+	data := template.ReceiptData{
+		Number:        r.Number,
+		PaidAt:        r.PaidAt.UTC().Format("2006-01-02 15:04 MST"),
+		PayerName:     r.PayerName,
+		PayerEmail:    r.PayerEmail,
+		Gateway:       r.Gateway,
+		TransactionID: r.TransactionID,
+		Total:         strconv.FormatInt(r.Total(), 10),
+	}
+	for _, item := range r.Items {
+		data.Items = append(data.Items, template.ReceiptItemData{
+			ApartmentName: item.ApartmentName,
+			BillName:      item.BillName,
+			Amount:        strconv.FormatInt(item.Amount, 10),
+		})
+	}
+	return data
+}
@@ -0,0 +1,143 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockRepo) Receipt(ctx context.Context, p *domain.Payment) (*domain.Receipt, error) {
+	args := m.Called(ctx, p)
+	r, _ := args.Get(0).(*domain.Receipt)
+	return r, args.Error(1)
+}
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(to []string, msg *common.EmailMessage) error {
+	args := m.Called(to, msg)
+	return args.Error(0)
+}
+
+func newReceiptTestService(repo *MockRepo, opts ...ServiceOpt) port.Service {
+	return NewService(repo, map[domain.GatewayType]port.Gateway{}, opts...)
+}
+
+// twoApartmentReceipt returns a receipt of p that paid bills in two
+// apartments with different admins.
+func twoApartmentReceipt(p *domain.Payment, admin1, admin2 common.ID) *domain.Receipt {
+	return &domain.Receipt{
+		PaidAt:        time.Date(2025, 10, 19, 10, 0, 0, 0, time.UTC),
+		PayerID:       p.PayerID,
+		PayerName:     "Alex",
+		PayerEmail:    "alex@example.com",
+		Gateway:       p.Gateway,
+		TransactionID: p.TransactionID,
+		Items: []domain.ReceiptItem{
+			{PaymentID: p.ID, BillName: "Water", ApartmentName: "Blue Tower", ApartmentAdminID: admin1, Amount: 3000},
+			{PaymentID: common.NewRandomID(), BillName: "Gas", ApartmentName: "Red Tower", ApartmentAdminID: admin2, Amount: 2000},
+		},
+	}
+}
+
+func TestReceipt_Access(t *testing.T) {
+	p := paidPayment(3000)
+	admin1, admin2 := common.NewRandomID(), common.NewRandomID()
+
+	cases := map[string]struct {
+		userID   common.ID
+		err      error
+		contains []string
+		excludes []string
+	}{
+		"payer":    {userID: p.PayerID, contains: []string{"Blue Tower", "Red Tower", "5000"}},
+		"admin":    {userID: admin1, contains: []string{"Blue Tower", "3000"}, excludes: []string{"Red Tower"}},
+		"stranger": {userID: common.NewRandomID(), err: ErrPermissionDenied},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := newReceiptTestService(repo)
+			repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+			repo.On("Receipt", ctx, p).Return(twoApartmentReceipt(p, admin1, admin2), nil)
+
+			file, err := svc.Receipt(ctx, c.userID, p.ID, domain.ReceiptHTML)
+
+			if c.err != nil {
+				assert.True(t, errors.Is(err, c.err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "text/html; charset=utf-8", file.ContentType)
+			assert.Contains(t, string(file.Content), domain.ReceiptNumber(time.Date(2025, 10, 19, 0, 0, 0, 0, time.UTC), p.ID))
+			for _, s := range c.contains {
+				assert.Contains(t, string(file.Content), s)
+			}
+			for _, s := range c.excludes {
+				assert.NotContains(t, string(file.Content), s)
+			}
+		})
+	}
+}
+
+func TestReceipt_PDF(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newReceiptTestService(repo)
+
+	p := paidPayment(3000)
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("Receipt", ctx, p).Return(twoApartmentReceipt(p, common.NewRandomID(), common.NewRandomID()), nil)
+
+	file, err := svc.Receipt(ctx, p.PayerID, p.ID, domain.ReceiptPDF)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", file.ContentType)
+	assert.True(t, bytes.HasPrefix(file.Content, []byte("%PDF-")))
+}
+
+func TestReceipt_NotAvailable(t *testing.T) {
+	pending := paidPayment(3000)
+	pending.Status = domain.PaymentPending
+	refundOf := common.NewRandomID()
+	refund := paidPayment(-1000)
+	refund.RefundOf = &refundOf
+
+	for name, p := range map[string]*domain.Payment{"pending": pending, "refund": refund} {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := newReceiptTestService(repo)
+			repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+
+			_, err := svc.Receipt(ctx, p.PayerID, p.ID, domain.ReceiptHTML)
+
+			assert.True(t, errors.Is(err, ErrReceiptNotAvailable))
+			repo.AssertNotCalled(t, "Receipt", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSendReceipt(t *testing.T) {
+	repo := new(MockRepo)
+	mailer := new(MockMailer)
+	svc := newReceiptTestService(repo, WithReceiptMailer(mailer))
+
+	p := paidPayment(3000)
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("Receipt", ctx, p).Return(twoApartmentReceipt(p, common.NewRandomID(), common.NewRandomID()), nil)
+	mailer.On("Send", []string{"alex@example.com"}, mock.MatchedBy(func(msg *common.EmailMessage) bool {
+		return msg.IsHTML && bytes.Contains(msg.Body, []byte("Red Tower"))
+	})).Return(nil)
+
+	assert.NoError(t, svc.SendReceipt(ctx, p.ID))
+	mailer.AssertExpectations(t)
+}
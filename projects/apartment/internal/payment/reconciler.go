@@ -0,0 +1,180 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var ErrOnReconcile = errors.New("error on reconcile pending payments")
+
+const (
+	DefaultReconcileInterval  = 5 * time.Minute
+	DefaultReconcileThreshold = 30 * time.Minute
+)
+
+type reconciler struct {
+	repo      port.Repo
+	gateways  map[domain.GatewayType]port.Gateway
+	interval  time.Duration
+	threshold time.Duration
+	onPaid    []func(context.Context, []*domain.Payment)
+}
+
+type ReconcilerOpt func(*reconciler)
+
+// WithReconcileInterval sets how often pending payments are checked.
+func WithReconcileInterval(d time.Duration) ReconcilerOpt {
+	return func(r *reconciler) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// WithReconcileThreshold sets how old a pending payment must be before it
+// is inquired and, if still unpaid, expired.
+func WithReconcileThreshold(d time.Duration) ReconcilerOpt {
+	return func(r *reconciler) {
+		if d > 0 {
+			r.threshold = d
+		}
+	}
+}
+
+// WithReconcilerOnPaid registers fn to run after a pending payment is
+// settled as paid.
+func WithReconcilerOnPaid(fn func(context.Context, []*domain.Payment)) ReconcilerOpt {
+	return func(r *reconciler) {
+		r.onPaid = append(r.onPaid, fn)
+	}
+}
+
+func NewReconciler(
+	repo port.Repo,
+	gws map[domain.GatewayType]port.Gateway,
+	opts ...ReconcilerOpt,
+) port.Reconciler {
+	r := &reconciler{
+		repo:      repo,
+		gateways:  gws,
+		interval:  DefaultReconcileInterval,
+		threshold: DefaultReconcileThreshold,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run reconciles pending payments every interval until ctx is done.
+func (r *reconciler) Run(ctx context.Context) {
+	log := appctx.Logger(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Reconcile(ctx); err != nil {
+			log.Error("payment reconciler", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile inquires every payment that has been pending longer than the
+// threshold and settles it with the status reported by its gateway.
+// Payments the gateway can't confirm are expired.
+func (r *reconciler) Reconcile(ctx context.Context) error {
+	log := appctx.Logger(ctx)
+
+	payments, err := r.repo.PendingPayments(ctx, time.Now().Add(-r.threshold))
+	if err != nil {
+		return fp.WrapErrors(ErrOnReconcile, err)
+	}
+
+	// payments of one PayTotalDebt share a transaction, inquire it once
+	groups := make(map[string][]*domain.Payment)
+	var keys []string
+	for _, p := range payments {
+		key := p.Gateway + "/" + p.TransactionID
+		if p.TransactionID == "" {
+			key = p.ID.String()
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		status, ok := r.inquire(ctx, group[0])
+		if !ok {
+			continue
+		}
+		ids := fp.Mapper(group, func(p *domain.Payment) common.ID { return p.ID })
+		n, err := r.repo.SettlePending(ctx, ids, status)
+		if err != nil {
+			log.Error("settle pending payments", zap.Error(err),
+				zap.String("transactionId", group[0].TransactionID))
+			continue
+		}
+		log.Info("reconciled pending payments",
+			zap.String("gateway", group[0].Gateway),
+			zap.String("transactionId", group[0].TransactionID),
+			zap.String("status", status.String()),
+			zap.Int64("settled", n),
+		)
+		if n > 0 && status == domain.PaymentPaid {
+			runOnPaid(ctx, r.repo, r.onPaid, ids)
+		}
+	}
+	return nil
+}
+
+// inquire returns the final status for p. A transaction the gateway
+// doesn't know about, or can't be inquired at all, is expired. ok is false
+// if the gateway couldn't be reached and p should be retried later.
+func (r *reconciler) inquire(
+	ctx context.Context, p *domain.Payment,
+) (
+	status domain.PaymentStatus, ok bool,
+) {
+	log := appctx.Logger(ctx)
+
+	gateway, found := r.gateways[domain.GatewayType(p.Gateway)]
+	if !found || p.TransactionID == "" {
+		return domain.PaymentExpired, true
+	}
+	inquirer, canInquire := gateway.(port.Inquirer)
+	if !canInquire {
+		return domain.PaymentExpired, true
+	}
+	status, err := inquirer.InquireTransaction(ctx, p.TransactionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionNotFound) {
+			return domain.PaymentExpired, true
+		}
+		log.Warn("inquire transaction", zap.Error(err),
+			zap.String("gateway", p.Gateway),
+			zap.String("transactionId", p.TransactionID))
+		return "", false
+	}
+	switch status {
+	case domain.PaymentPaid, domain.PaymentFailed, domain.PaymentCancelled:
+		return status, true
+	default:
+		return domain.PaymentExpired, true
+	}
+}
@@ -0,0 +1,132 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestReconciler(repo *MockRepo, gw port.Gateway) port.Reconciler {
+	return NewReconciler(repo, map[domain.GatewayType]port.Gateway{
+		domain.MockGateway: gw,
+	})
+}
+
+func pendingPayment(transactionID string) *domain.Payment {
+	return &domain.Payment{
+		ID:            common.NewRandomID(),
+		Status:        domain.PaymentPending,
+		Gateway:       domain.MockGateway,
+		TransactionID: transactionID,
+	}
+}
+
+func TestReconcile_SettlesPaidTransactionOnce(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockInquirerGateway)
+	rec := newTestReconciler(repo, gw)
+
+	p1, p2 := pendingPayment("tx-1"), pendingPayment("tx-1")
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p1, p2}, nil)
+	gw.On("InquireTransaction", ctx, "tx-1").Return(domain.PaymentStatus(domain.PaymentPaid), nil).Once()
+	repo.On("SettlePending", ctx, []common.ID{p1.ID, p2.ID}, domain.PaymentStatus(domain.PaymentPaid)).
+		Return(int64(2), nil)
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestReconcile_RunsOnPaidHooks(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockInquirerGateway)
+
+	var paid []common.ID
+	rec := NewReconciler(repo, map[domain.GatewayType]port.Gateway{domain.MockGateway: gw},
+		WithReconcilerOnPaid(func(_ context.Context, ps []*domain.Payment) {
+			for _, p := range ps {
+				paid = append(paid, p.ID)
+			}
+		}))
+
+	p := pendingPayment("tx-1")
+	settled := *p
+	settled.Status = domain.PaymentPaid
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p}, nil)
+	gw.On("InquireTransaction", ctx, "tx-1").Return(domain.PaymentStatus(domain.PaymentPaid), nil)
+	repo.On("SettlePending", ctx, []common.ID{p.ID}, domain.PaymentStatus(domain.PaymentPaid)).
+		Return(int64(1), nil)
+	repo.On("GetPayment", ctx, p.ID).Return(&settled, nil)
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	assert.Equal(t, []common.ID{p.ID}, paid)
+}
+
+func TestReconcile_ExpiresUnknownTransaction(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockInquirerGateway)
+	rec := newTestReconciler(repo, gw)
+
+	p := pendingPayment("tx-1")
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p}, nil)
+	gw.On("InquireTransaction", ctx, "tx-1").Return(domain.PaymentStatus(""), domain.ErrTransactionNotFound)
+	repo.On("SettlePending", ctx, []common.ID{p.ID}, domain.PaymentStatus(domain.PaymentExpired)).
+		Return(int64(1), nil)
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	repo.AssertExpectations(t)
+}
+
+func TestReconcile_ExpiresStillPendingTransaction(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockInquirerGateway)
+	rec := newTestReconciler(repo, gw)
+
+	p := pendingPayment("tx-1")
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p}, nil)
+	gw.On("InquireTransaction", ctx, "tx-1").Return(domain.PaymentStatus(domain.PaymentPending), nil)
+	repo.On("SettlePending", ctx, []common.ID{p.ID}, domain.PaymentStatus(domain.PaymentExpired)).
+		Return(int64(1), nil)
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	repo.AssertExpectations(t)
+}
+
+func TestReconcile_KeepsPendingWhenGatewayUnreachable(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockInquirerGateway)
+	rec := newTestReconciler(repo, gw)
+
+	p := pendingPayment("tx-1")
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p}, nil)
+	gw.On("InquireTransaction", ctx, "tx-1").Return(domain.PaymentStatus(""), errors.New("connection refused"))
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	repo.AssertNotCalled(t, "SettlePending", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReconcile_ExpiresWithoutInquirySupport(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	rec := newTestReconciler(repo, gw)
+
+	p := pendingPayment("tx-1")
+
+	repo.On("PendingPayments", ctx, mock.Anything).Return([]*domain.Payment{p}, nil)
+	repo.On("SettlePending", ctx, []common.ID{p.ID}, domain.PaymentStatus(domain.PaymentExpired)).
+		Return(int64(1), nil)
+
+	assert.NoError(t, rec.Reconcile(ctx))
+	repo.AssertExpectations(t)
+}
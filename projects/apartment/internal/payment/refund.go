@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOnRefund            = errors.New("error on refund")
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrNotRefundable       = errors.New("payment is not refundable")
+	ErrInvalidRefundAmount = errors.New("invalid refund amount")
+	ErrRefundNotSupported  = errors.New("gateway does not support refunds, record an offline refund instead")
+)
+
+// Refund gives back part or all of a paid payment. The refund is recorded
+// as a negative payment entry linked to the original, so it is reflected in
+// the payer's balance due. A gateway refund is reserved as pending before
+// the gateway is asked, so concurrent refunds can't give back more than
+// was paid.
+func (s *service) Refund(ctx context.Context, r domain.Refund) (*domain.Payment, error) {
+	log := appctx.Logger(ctx)
+
+	p, err := s.repo.GetPayment(ctx, r.PaymentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefund, err)
+	}
+	if p.Status != domain.PaymentPaid || p.IsRefund() {
+		return nil, fp.WrapErrors(ErrOnRefund, ErrNotRefundable)
+	}
+
+	adminID, err := s.repo.BillApartmentAdmin(ctx, p.BillID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefund, err)
+	}
+	if adminID != r.AdminID {
+		return nil, fp.WrapErrors(ErrOnRefund, ErrPermissionDenied)
+	}
+	if r.Amount < 0 || r.Amount > p.Amount {
+		return nil, fp.WrapErrors(ErrOnRefund, ErrInvalidRefundAmount)
+	}
+
+	refundOf := p.ID
+	refund := &domain.Payment{
+		BillID:      p.BillID,
+		PayerID:     p.PayerID,
+		Amount:      -r.Amount,
+		PaidAt:      time.Now().UTC(),
+		Status:      domain.PaymentPaid,
+		Gateway:     domain.OfflineGateway,
+		RefundOf:    &refundOf,
+		Description: r.Reason,
+	}
+	var refunder port.Refunder
+	if !r.Offline {
+		if refunder, err = s.refunder(p); err != nil {
+			return nil, fp.WrapErrors(ErrOnRefund, err)
+		}
+		refund.Gateway = p.Gateway
+		refund.Status = domain.PaymentPending
+	}
+	refund, err = s.repo.ReserveRefund(ctx, refund)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRefund, err)
+	}
+
+	if refunder != nil {
+		transactionID, err := refunder.RefundTransaction(ctx, p.TransactionID, -refund.Amount)
+		if err != nil {
+			// free the reserved amount for another try
+			if sErr := s.repo.SettleRefund(ctx, refund.ID, "", domain.PaymentFailed); sErr != nil {
+				log.Error("release failed refund", zap.Error(sErr),
+					zap.String("refundId", refund.ID.String()))
+			}
+			return nil, fp.WrapErrors(ErrOnRefund, err)
+		}
+		refund.TransactionID = transactionID
+		refund.Status = domain.PaymentPaid
+		if err = s.repo.SettleRefund(ctx, refund.ID, transactionID, domain.PaymentPaid); err != nil {
+			// the gateway has already given the money back, the refund
+			// stays pending and keeps its amount reserved
+			log.Error("refund not recorded", zap.Error(err),
+				zap.String("paymentId", p.ID.String()),
+				zap.String("refundId", refund.ID.String()),
+				zap.String("refundTransactionId", transactionID),
+				zap.Int64("amount", -refund.Amount))
+			return nil, fp.WrapErrors(ErrOnRefund, err)
+		}
+	}
+	for _, fn := range s.onPaid {
+		fn(ctx, []*domain.Payment{refund})
+	}
+	return refund, nil
+}
+
+// refunder returns the gateway that refunds p.
+func (s *service) refunder(p *domain.Payment) (port.Refunder, error) {
+	gateway, err := s.Gateway(domain.GatewayType(p.Gateway))
+	if err != nil {
+		return nil, err
+	}
+	refunder, ok := gateway.(port.Refunder)
+	if !ok || p.TransactionID == "" {
+		return nil, ErrRefundNotSupported
+	}
+	return refunder, nil
+}
@@ -0,0 +1,214 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func (m *MockRepo) GetPayment(ctx context.Context, id common.ID) (*domain.Payment, error) {
+	args := m.Called(ctx, id)
+	p, _ := args.Get(0).(*domain.Payment)
+	return p, args.Error(1)
+}
+
+func (m *MockRepo) ReserveRefund(ctx context.Context, refund *domain.Payment) (*domain.Payment, error) {
+	args := m.Called(ctx, refund)
+	r, _ := args.Get(0).(*domain.Payment)
+	return r, args.Error(1)
+}
+
+func (m *MockRepo) SettleRefund(
+	ctx context.Context, id common.ID, transactionID string, s domain.PaymentStatus,
+) error {
+	args := m.Called(ctx, id, transactionID, s)
+	return args.Error(0)
+}
+
+func (m *MockRepo) BillApartmentAdmin(ctx context.Context, billID common.ID) (common.ID, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+type MockRefunderGateway struct {
+	MockGateway
+}
+
+func (m *MockRefunderGateway) RefundTransaction(
+	ctx context.Context, transactionID string, amount int64,
+) (
+	string, error,
+) {
+	args := m.Called(ctx, transactionID, amount)
+	return args.String(0), args.Error(1)
+}
+
+func newRefundTestService(repo *MockRepo, gw port.Gateway) port.Service {
+	return NewService(repo, map[domain.GatewayType]port.Gateway{
+		domain.MockGateway: gw,
+	})
+}
+
+func paidPayment(amount int64) *domain.Payment {
+	return &domain.Payment{
+		ID:            common.NewRandomID(),
+		BillID:        common.NewRandomID(),
+		PayerID:       common.NewRandomID(),
+		Amount:        amount,
+		Status:        domain.PaymentPaid,
+		Gateway:       domain.MockGateway,
+		TransactionID: "tx-1",
+	}
+}
+
+func TestRefund_FullRefundThroughGateway(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockRefunderGateway)
+	svc := newRefundTestService(repo, gw)
+
+	adminID := common.NewRandomID()
+	p := paidPayment(100)
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(adminID, nil)
+	// 30 was refunded before, the rest is reserved before asking the
+	// gateway
+	refundID := common.NewRandomID()
+	repo.On("ReserveRefund", ctx, mock.MatchedBy(func(r *domain.Payment) bool {
+		return r.Amount == 0 && *r.RefundOf == p.ID && r.PayerID == p.PayerID &&
+			r.Status == domain.PaymentPending
+	})).Return(&domain.Payment{ID: refundID, Amount: -70, Status: domain.PaymentPending}, nil)
+	gw.On("RefundTransaction", ctx, "tx-1", int64(70)).Return("refund-1", nil)
+	repo.On("SettleRefund", ctx, refundID, "refund-1", domain.PaymentPaid).Return(nil)
+
+	refund, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID, AdminID: adminID, Reason: "duplicate"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-70), refund.Amount)
+	assert.Equal(t, domain.PaymentPaid, refund.Status)
+	assert.Equal(t, "refund-1", refund.TransactionID)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestRefund_OfflineRefund(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newRefundTestService(repo, gw)
+
+	adminID := common.NewRandomID()
+	p := paidPayment(100)
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(adminID, nil)
+	repo.On("ReserveRefund", ctx, mock.MatchedBy(func(r *domain.Payment) bool {
+		return r.Amount == -40 && r.Gateway == domain.OfflineGateway && r.Description == "cash" &&
+			r.Status == domain.PaymentPaid
+	})).Return(&domain.Payment{ID: common.NewRandomID(), Amount: -40}, nil)
+
+	_, err := svc.Refund(ctx, domain.Refund{
+		PaymentID: p.ID, AdminID: adminID, Amount: 40, Reason: "cash", Offline: true,
+	})
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestRefund_GatewayWithoutRefundSupport(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newRefundTestService(repo, gw)
+
+	adminID := common.NewRandomID()
+	p := paidPayment(100)
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(adminID, nil)
+
+	_, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID, AdminID: adminID})
+
+	assert.True(t, errors.Is(err, ErrRefundNotSupported))
+	repo.AssertNotCalled(t, "ReserveRefund", mock.Anything, mock.Anything)
+}
+
+func TestRefund_AmountExceedsRemaining(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockRefunderGateway)
+	svc := newRefundTestService(repo, gw)
+
+	adminID := common.NewRandomID()
+	p := paidPayment(100)
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(adminID, nil)
+	// 80 already refunded or reserved by a concurrent refund
+	repo.On("ReserveRefund", ctx, mock.Anything).Return(nil, ErrInvalidRefundAmount)
+
+	_, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID, AdminID: adminID, Amount: 30})
+
+	assert.True(t, errors.Is(err, ErrInvalidRefundAmount))
+	gw.AssertNotCalled(t, "RefundTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRefund_GatewayFailsReleasesReservation(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockRefunderGateway)
+	svc := newRefundTestService(repo, gw)
+
+	adminID := common.NewRandomID()
+	p := paidPayment(100)
+	refundID := common.NewRandomID()
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(adminID, nil)
+	repo.On("ReserveRefund", ctx, mock.Anything).
+		Return(&domain.Payment{ID: refundID, Amount: -50, Status: domain.PaymentPending}, nil)
+	gw.On("RefundTransaction", ctx, "tx-1", int64(50)).Return("", errors.New("gateway down"))
+	repo.On("SettleRefund", ctx, refundID, "", domain.PaymentFailed).Return(nil)
+
+	_, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID, AdminID: adminID, Amount: 50})
+
+	assert.Error(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestRefund_NotAdmin(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockRefunderGateway)
+	svc := newRefundTestService(repo, gw)
+
+	p := paidPayment(100)
+
+	repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+	repo.On("BillApartmentAdmin", ctx, p.BillID).Return(common.NewRandomID(), nil)
+
+	_, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID, AdminID: common.NewRandomID()})
+
+	assert.True(t, errors.Is(err, ErrPermissionDenied))
+}
+
+func TestRefund_NotRefundable(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockRefunderGateway)
+	svc := newRefundTestService(repo, gw)
+
+	pending := paidPayment(100)
+	pending.Status = domain.PaymentPending
+	refundOf := common.NewRandomID()
+	refund := paidPayment(-50)
+	refund.RefundOf = &refundOf
+
+	for _, p := range []*domain.Payment{pending, refund} {
+		repo.On("GetPayment", ctx, p.ID).Return(p, nil)
+
+		_, err := svc.Refund(ctx, domain.Refund{PaymentID: p.ID})
+
+		assert.True(t, errors.Is(err, ErrNotRefundable))
+	}
+}
@@ -4,16 +4,25 @@ import (
 	"context"
 	"errors"
 	"net/url"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
 )
 
 const (
 	PaymentIDsKey = "payment-ids"
 	GatewayKey    = "gateway"
+	// MetadataKey holds a map[string]string in the request context that is
+	// passed to the gateway with every transaction, e.g. to pick a mock
+	// gateway scenario.
+	MetadataKey appctx.CtxKey = "PaymentMetadata"
 )
 
 var (
@@ -23,24 +32,147 @@ var (
 	ErrOnPayTotalDebt  = errors.New("error on pay total debt")
 	ErrOnCallback      = errors.New("error on handle callbackI")
 	ErrInvalidCallback = errors.New("invalid callback")
+	ErrCaptureMismatch = errors.New("callback doesn't match the payments")
 	ErrInvalidStatus   = errors.New("invalid status")
+	ErrInvalidAmount   = errors.New("invalid payment amount")
+
+	ErrGatewayUnavailable = errors.New("no payment gateway available")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+const (
+	DefaultIdempotencyKeyTTL = 24 * time.Hour
+	DefaultAllocationRule    = domain.AllocateOldestFirst
 )
 
 type service struct {
-	repo     port.Repo
-	gateways map[domain.GatewayType]port.Gateway
+	repo           port.Repo
+	gateways       map[domain.GatewayType]port.Gateway
+	gatewayInfo    map[domain.GatewayType]domain.GatewayInfo
+	breakers       map[domain.GatewayType]*breaker
+	breakerLimit   int
+	breakerWait    time.Duration
+	idempotencyTTL time.Duration
+	minAmount      int64
+	allocationRule domain.AllocationRule
+	onPaid         []func(context.Context, []*domain.Payment)
+	mail           port.EmailSender
+	proofs         port.ObjectStorage
 }
 
-func NewService(repo port.Repo, gws map[domain.GatewayType]port.Gateway) port.Service {
-	return &service{
-		repo:     repo,
-		gateways: gws,
+type ServiceOpt func(*service)
+
+// WithIdempotencyKeyTTL sets how long an idempotency key is remembered.
+func WithIdempotencyKeyTTL(ttl time.Duration) ServiceOpt {
+	return func(s *service) {
+		if ttl > 0 {
+			s.idempotencyTTL = ttl
+		}
 	}
 }
 
+// WithMinPaymentAmount sets the smallest amount a partial payment may have.
+// A balance smaller than that can still be paid off in full.
+func WithMinPaymentAmount(amount int64) ServiceOpt {
+	return func(s *service) {
+		if amount > 0 {
+			s.minAmount = amount
+		}
+	}
+}
+
+// WithAllocationRule sets how a partial total debt payment is split between
+// bills. Unknown rules are ignored.
+func WithAllocationRule(rule domain.AllocationRule) ServiceOpt {
+	return func(s *service) {
+		if rule.IsValid() {
+			s.allocationRule = rule
+		}
+	}
+}
+
+// WithOnPaymentPaid registers fn to run after payments or a refund are
+// paid. fn gets the payments settled together, e.g. by one transaction.
+func WithOnPaymentPaid(fn func(context.Context, []*domain.Payment)) ServiceOpt {
+	return func(s *service) {
+		s.onPaid = append(s.onPaid, fn)
+	}
+}
+
+// WithReceiptMailer makes the service email receipts through m.
+func WithReceiptMailer(m port.EmailSender) ServiceOpt {
+	return func(s *service) {
+		s.mail = m
+	}
+}
+
+// WithProofStorage makes the service accept offline payment claims and
+// store their proofs in st.
+func WithProofStorage(st port.ObjectStorage) ServiceOpt {
+	return func(s *service) {
+		s.proofs = st
+	}
+}
+
+// WithGatewayInfo sets the display name, priority and amount limits of
+// gateways. Gateways without info are shown by their type, with priority
+// zero and no amount limits.
+func WithGatewayInfo(infos ...domain.GatewayInfo) ServiceOpt {
+	return func(s *service) {
+		for _, info := range infos {
+			s.gatewayInfo[info.Type] = info
+		}
+	}
+}
+
+// WithCircuitBreaker makes a gateway unavailable for cooldown after
+// threshold transactions in a row failed to be created on it.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ServiceOpt {
+	return func(s *service) {
+		if threshold > 0 {
+			s.breakerLimit = threshold
+		}
+		if cooldown > 0 {
+			s.breakerWait = cooldown
+		}
+	}
+}
+
+func NewService(
+	repo port.Repo,
+	gws map[domain.GatewayType]port.Gateway,
+	opts ...ServiceOpt,
+) port.Service {
+	s := &service{
+		repo:           repo,
+		gateways:       gws,
+		gatewayInfo:    make(map[domain.GatewayType]domain.GatewayInfo),
+		breakers:       make(map[domain.GatewayType]*breaker, len(gws)),
+		breakerLimit:   DefaultBreakerThreshold,
+		breakerWait:    DefaultBreakerCooldown,
+		idempotencyTTL: DefaultIdempotencyKeyTTL,
+		allocationRule: DefaultAllocationRule,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for gt := range gws {
+		s.breakers[gt] = newBreaker(s.breakerLimit, s.breakerWait)
+		if _, ok := s.gatewayInfo[gt]; !ok {
+			s.gatewayInfo[gt] = domain.GatewayInfo{
+				Type:        gt,
+				DisplayName: gt.String(),
+			}
+		}
+	}
+	return s
+}
+
 func (s *service) Gateway(gt domain.GatewayType) (port.Gateway, error) {
 	gateway, ok := s.gateways[gt]
-	if !gt.IsValid() || !ok {
+	if !ok {
 		return nil, ErrUnknownGateway
 	}
 	return gateway, nil
@@ -50,92 +182,157 @@ func (s *service) PayBill(
 	ctx context.Context,
 	gt domain.GatewayType,
 	userID, billID common.ID,
-	callBackURL string,
+	amount int64,
+	callBackURL, idempotencyKey string,
 ) (
-	redirect *domain.RedirectGateway, err error,
+	*domain.RedirectGateway, error,
 ) {
-	gateway, err := s.Gateway(gt)
+	parts := []string{"pay-bill", gt.String(), billID.String(),
+		strconv.FormatInt(amount, 10), callBackURL}
+	fingerprint := domain.Fingerprint(append(parts, metadataParts(ctx)...)...)
+	redirect, err := s.idempotent(ctx, userID, idempotencyKey, fingerprint,
+		func() (*domain.RedirectGateway, error) {
+			return s.payBill(ctx, gt, userID, billID, amount, callBackURL)
+		})
 	if err != nil {
 		return nil, fp.WrapErrors(ErrOnPayBill, err)
 	}
+	return redirect, nil
+}
+
+func (s *service) payBill(
+	ctx context.Context,
+	gt domain.GatewayType,
+	userID, billID common.ID,
+	amount int64,
+	callBackURL string,
+) (
+	redirect *domain.RedirectGateway, err error,
+) {
+	if _, err = s.Gateway(gt); err != nil {
+		return nil, err
+	}
 	balanceDue, err := s.repo.UserBillBalanceDue(ctx, userID, billID)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayBill, err)
+		return nil, err
 	}
 	if balanceDue <= 0 {
-		return nil, fp.WrapErrors(ErrOnPayBill, ErrNoBalanceDue)
+		return nil, ErrNoBalanceDue
+	}
+	amount, err = s.paymentAmount(amount, balanceDue)
+	if err != nil {
+		return nil, err
+	}
+	gt, err = s.selectGateway(ctx, gt, amount)
+	if err != nil {
+		return nil, err
 	}
 	p := &domain.Payment{
 		BillID:  billID,
 		PayerID: userID,
-		Amount:  balanceDue,
+		Amount:  amount,
 		ID:      userID,
 		Status:  domain.PaymentPending,
 		Gateway: gt.String(),
 	}
 	p, err = s.repo.CreatePayment(ctx, p)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayBill, err)
+		return nil, err
 	}
 	callBackURL, err = CallbackURLWithPaymentIDs(callBackURL, gt, p.ID)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayBill, err)
+		return nil, err
 	}
 	tx := domain.Transaction{
 		PaymentIDs: []common.ID{p.ID},
-		Amount:     balanceDue,
+		Amount:     amount,
 		PayerID:    userID,
 		Bills: []domain.BillWithAmount{{
 			BillID: billID,
-			Amount: balanceDue,
+			Amount: amount,
 		}},
 		CallbackURL: callBackURL,
 	}
-	redirect, err = gateway.CreateTransaction(ctx, tx)
+	return s.createTransaction(ctx, gt, tx)
+}
+
+func (s *service) PayTotalDebt(
+	ctx context.Context,
+	gt domain.GatewayType,
+	userID common.ID,
+	billIDs []common.ID,
+	amount int64,
+	callBackURL, idempotencyKey string,
+) (
+	*domain.RedirectGateway, error,
+) {
+	parts := []string{"pay-total-debt", gt.String(), strconv.FormatInt(amount, 10), callBackURL}
+	for _, id := range billIDs {
+		parts = append(parts, id.String())
+	}
+	fingerprint := domain.Fingerprint(append(parts, metadataParts(ctx)...)...)
+	redirect, err := s.idempotent(ctx, userID, idempotencyKey, fingerprint,
+		func() (*domain.RedirectGateway, error) {
+			return s.payTotalDebt(ctx, gt, userID, billIDs, amount, callBackURL)
+		})
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayBill, err)
+		return nil, fp.WrapErrors(ErrOnPayTotalDebt, err)
 	}
-	return
+	return redirect, nil
 }
 
-func (s *service) PayTotalDebt(
+func (s *service) payTotalDebt(
 	ctx context.Context,
 	gt domain.GatewayType,
 	userID common.ID,
+	billIDs []common.ID,
+	amount int64,
 	callBackURL string,
 ) (
 	redirect *domain.RedirectGateway, err error,
 ) {
-	gateway, err := s.Gateway(gt)
-	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayTotalDebt, err)
+	if _, err = s.Gateway(gt); err != nil {
+		return nil, err
 	}
 	balanceDues, err := s.repo.UserBillsBalanceDue(ctx, userID)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayTotalDebt, err)
+		return nil, err
 	}
-	if len(balanceDues) == 0 {
-		return nil, fp.WrapErrors(ErrOnPayTotalDebt, ErrNoBalanceDue)
+	balanceDues, err = selectBills(balanceDues, billIDs)
+	if err != nil {
+		return nil, err
 	}
-	var totalAmount int64
-	var payments []*domain.Payment
+	var totalDue int64
 	for _, bDue := range balanceDues {
-		if bDue.Amount <= 0 {
-			continue
-		}
+		totalDue += bDue.Amount
+	}
+	if totalDue <= 0 {
+		return nil, ErrNoBalanceDue
+	}
+	amount, err = s.paymentAmount(amount, totalDue)
+	if err != nil {
+		return nil, err
+	}
+	gt, err = s.selectGateway(ctx, gt, amount)
+	if err != nil {
+		return nil, err
+	}
+	bills := s.allocationRule.Allocate(balanceDues, amount)
+
+	var payments []*domain.Payment
+	for _, b := range bills {
 		p := &domain.Payment{
 			PayerID: userID,
-			BillID:  bDue.BillID,
-			Amount:  bDue.Amount,
+			BillID:  b.BillID,
+			Amount:  b.Amount,
 			Status:  domain.PaymentPending,
 			Gateway: gt.String(),
 		}
 		payments = append(payments, p)
-		totalAmount += bDue.Amount
 	}
 	payments, err = s.repo.BatchCreatePayment(ctx, payments)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayTotalDebt, err)
+		return nil, err
 	}
 	paymentIDs := []common.ID{}
 	for i := range payments {
@@ -143,20 +340,140 @@ func (s *service) PayTotalDebt(
 	}
 	callBackURL, err = CallbackURLWithPaymentIDs(callBackURL, gt, paymentIDs...)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayBill, err)
+		return nil, err
 	}
 	tx := domain.Transaction{
 		PaymentIDs:  paymentIDs,
-		Amount:      totalAmount,
+		Amount:      amount,
 		PayerID:     userID,
-		Bills:       balanceDues,
+		Bills:       bills,
 		CallbackURL: callBackURL,
 	}
-	redirect, err = gateway.CreateTransaction(ctx, tx)
+	return s.createTransaction(ctx, gt, tx)
+}
+
+// paymentAmount returns the amount to charge for a balance. Zero means the
+// whole balance. Otherwise amount must not exceed the balance and must be at
+// least the minimum, unless it pays the balance off.
+func (s *service) paymentAmount(amount, balanceDue int64) (int64, error) {
+	switch {
+	case amount == 0 || amount == balanceDue:
+		return balanceDue, nil
+	case amount < 0 || amount > balanceDue || amount < s.minAmount:
+		return 0, ErrInvalidAmount
+	}
+	return amount, nil
+}
+
+// selectBills keeps the bills in billIDs, or all bills with a balance if
+// billIDs is empty. Every selected bill must have a balance due.
+func selectBills(
+	balanceDues []domain.BillWithAmount,
+	billIDs []common.ID,
+) (
+	[]domain.BillWithAmount, error,
+) {
+	due := make([]domain.BillWithAmount, 0, len(balanceDues))
+	for _, b := range balanceDues {
+		if b.Amount > 0 {
+			due = append(due, b)
+		}
+	}
+	if len(billIDs) == 0 {
+		return due, nil
+	}
+	byID := make(map[common.ID]domain.BillWithAmount, len(due))
+	for _, b := range due {
+		byID[b.BillID] = b
+	}
+	selected := make([]domain.BillWithAmount, 0, len(billIDs))
+	seen := make(map[common.ID]struct{}, len(billIDs))
+	for _, id := range billIDs {
+		b, ok := byID[id]
+		if !ok {
+			return nil, ErrNoBalanceDue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		selected = append(selected, b)
+	}
+	return selected, nil
+}
+
+// selectGateway returns gt if it is available and accepts amount, or else
+// fails over to the first gateway by priority that does.
+func (s *service) selectGateway(
+	ctx context.Context,
+	gt domain.GatewayType,
+	amount int64,
+) (
+	domain.GatewayType, error,
+) {
+	if s.available(gt, amount) {
+		return gt, nil
+	}
+	for _, info := range s.orderedGateways() {
+		if info.Type != gt && s.available(info.Type, amount) {
+			appctx.Logger(ctx).Warn("payment gateway failover",
+				zap.String("from", gt.String()), zap.String("to", info.Type.String()))
+			return info.Type, nil
+		}
+	}
+	return "", ErrGatewayUnavailable
+}
+
+func (s *service) available(gt domain.GatewayType, amount int64) bool {
+	b, ok := s.breakers[gt]
+	return ok && b.Available() && s.gatewayInfo[gt].Accepts(amount)
+}
+
+func (s *service) orderedGateways() []domain.GatewayInfo {
+	infos := make([]domain.GatewayInfo, 0, len(s.gateways))
+	for gt := range s.gateways {
+		infos = append(infos, s.gatewayInfo[gt])
+	}
+	domain.SortGateways(infos)
+	return infos
+}
+
+// createTransaction starts tx on the gateway gt, records the outcome in its
+// circuit breaker and records the gateway reference on its payments so they
+// can be reconciled later.
+func (s *service) createTransaction(
+	ctx context.Context,
+	gt domain.GatewayType,
+	tx domain.Transaction,
+) (
+	*domain.RedirectGateway, error,
+) {
+	gateway, err := s.Gateway(gt)
 	if err != nil {
-		return nil, fp.WrapErrors(ErrOnPayTotalDebt, err)
+		return nil, err
+	}
+	if md, ok := ctx.Value(MetadataKey).(map[string]string); ok {
+		if tx.Metadata == nil {
+			tx.Metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			tx.Metadata[k] = v
+		}
+	}
+	redirect, err := gateway.CreateTransaction(ctx, tx)
+	if err != nil {
+		s.breakers[gt].Failure()
+		return nil, err
+	}
+	s.breakers[gt].Success()
+	redirect.Gateway = gt
+	if redirect.TransactionID != "" {
+		err = s.repo.SetTransactionID(ctx, tx.PaymentIDs, redirect.TransactionID)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return
+	return redirect, nil
 }
 
 func CallbackURLWithPaymentIDs(
@@ -182,6 +499,68 @@ func CallbackURLWithPaymentIDs(
 	return cURL.String(), nil
 }
 
+// metadataParts returns the request metadata as sorted key=value parts of a
+// fingerprint, so a key reused with other metadata is rejected.
+func metadataParts(ctx context.Context) []string {
+	md, _ := ctx.Value(MetadataKey).(map[string]string)
+	parts := make([]string, 0, len(md))
+	for k, v := range md {
+		parts = append(parts, k+"="+v)
+	}
+	slices.Sort(parts)
+	return parts
+}
+
+// idempotent runs fn at most once per user and idempotency key. A retried
+// request with the same key and fingerprint gets the stored redirect back.
+// An empty key disables the check.
+func (s *service) idempotent(
+	ctx context.Context,
+	userID common.ID,
+	key, fingerprint string,
+	fn func() (*domain.RedirectGateway, error),
+) (
+	*domain.RedirectGateway, error,
+) {
+	if key == "" {
+		return fn()
+	}
+	now := time.Now().UTC()
+	k, reserved, err := s.repo.ReserveIdempotencyKey(ctx, &domain.IdempotencyKey{
+		Key:         key,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.idempotencyTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		switch {
+		case k.Fingerprint != fingerprint:
+			return nil, ErrIdempotencyKeyReused
+		case !k.IsCompleted():
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return k.Response, nil
+	}
+
+	redirect, err := fn()
+	if err != nil {
+		// release the key so the client can retry after a failure
+		if delErr := s.repo.DeleteIdempotencyKey(ctx, userID, key); delErr != nil {
+			return nil, fp.WrapErrors(err, delErr)
+		}
+		return nil, err
+	}
+	k.Response = redirect
+	if err = s.repo.SaveIdempotencyResponse(ctx, k); err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
 func (s *service) HandleCallback(
 	ctx context.Context,
 	gt domain.GatewayType,
@@ -191,25 +570,140 @@ func (s *service) HandleCallback(
 	if err != nil {
 		return fp.WrapErrors(ErrOnCallback, err)
 	}
-	err = gateway.VerifyTransaction(ctx, data)
+	capture, err := gateway.VerifyTransaction(ctx, data)
 	if err != nil {
 		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback, err)
 	}
+	// the payments are named by the caller, so only the ones started on
+	// the captured transaction, captured in full, are settled
+	if capture.TransactionID == "" {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback)
+	}
 	paymentIDs := []common.ID{}
 	for _, id := range data[PaymentIDsKey] {
+		if common.ValidateID(id) != nil {
+			return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback)
+		}
 		paymentIDs = append(paymentIDs, common.IDFromText(id))
 	}
-	err = s.repo.UpdateStatus(ctx, paymentIDs, domain.PaymentPaid)
+	if err = s.checkCapture(ctx, gt, capture, paymentIDs); err != nil {
+		return s.refuseCapture(ctx, gt, capture, data, err)
+	}
+	// a repeated callback finds the payments already settled
+	n, expired, err := s.repo.SettleCaptured(ctx, paymentIDs, gt, *capture)
+	if errors.Is(err, ErrCaptureMismatch) {
+		return s.refuseCapture(ctx, gt, capture, data, err)
+	}
 	if err != nil {
 		return fp.WrapErrors(ErrOnCallback, err)
 	}
+	if len(expired) > 0 {
+		// the bill may have been paid again meanwhile, and need a refund
+		appctx.Logger(ctx).Error("captured payment had expired, settled it as paid",
+			zap.String("gateway", gt.String()),
+			zap.Strings("paymentIds", fp.Mapper(expired, common.ID.String)))
+	}
+	if n > 0 {
+		runOnPaid(ctx, s.repo, s.onPaid, paymentIDs)
+	}
+	return nil
+}
+
+// refuseCapture logs a callback whose capture doesn't match its payments
+// and returns the error for it.
+func (s *service) refuseCapture(
+	ctx context.Context,
+	gt domain.GatewayType,
+	capture *domain.Capture,
+	data map[string][]string,
+	err error,
+) error {
+	if errors.Is(err, ErrCaptureMismatch) {
+		appctx.Logger(ctx).Warn("payment callback mismatch",
+			zap.String("gateway", gt.String()),
+			zap.String("transactionId", capture.TransactionID),
+			zap.Int64("capturedAmount", capture.Amount),
+			zap.Strings("paymentIds", data[PaymentIDsKey]),
+		)
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback, err)
+	}
+	return fp.WrapErrors(ErrOnCallback, err)
+}
+
+// checkCapture returns ErrCaptureMismatch unless the payments were all
+// started on the captured transaction of gt and add up to its amount.
+// SettleCaptured checks it again with the payments locked.
+func (s *service) checkCapture(
+	ctx context.Context,
+	gt domain.GatewayType,
+	capture *domain.Capture,
+	paymentIDs []common.ID,
+) error {
+	if len(paymentIDs) == 0 {
+		return ErrCaptureMismatch
+	}
+	seen := make(map[common.ID]struct{}, len(paymentIDs))
+	var total int64
+	for _, id := range paymentIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		p, err := s.repo.GetPayment(ctx, id)
+		if errors.Is(err, ErrPaymentNotFound) {
+			return ErrCaptureMismatch
+		}
+		if err != nil {
+			return err
+		}
+		if p.IsRefund() || p.Gateway != gt.String() || p.TransactionID != capture.TransactionID {
+			return ErrCaptureMismatch
+		}
+		total += p.Amount
+	}
+	if total != capture.Amount {
+		return ErrCaptureMismatch
+	}
 	return nil
 }
 
-func (s *service) SupportedGateways() []string {
-	result := make([]string, 0)
-	for key := range s.gateways {
-		result = append(result, key.String())
+// runOnPaid runs the paid hooks for the payments. The payments are already
+// paid, so a payment that can't be read is only logged.
+func runOnPaid(
+	ctx context.Context,
+	repo port.Repo,
+	hooks []func(context.Context, []*domain.Payment),
+	paymentIDs []common.ID,
+) {
+	if len(hooks) == 0 {
+		return
+	}
+	log := appctx.Logger(ctx)
+	var payments []*domain.Payment
+	for _, id := range paymentIDs {
+		p, err := repo.GetPayment(ctx, id)
+		if err != nil {
+			log.Error("run payment paid hooks", zap.Error(err), zap.String("paymentId", id.String()))
+			continue
+		}
+		payments = append(payments, p)
+	}
+	if len(payments) == 0 {
+		return
+	}
+	for _, fn := range hooks {
+		fn(ctx, payments)
+	}
+}
+
+// SupportedGateways returns the gateways currently available, ordered by
+// priority.
+func (s *service) SupportedGateways() []domain.GatewayInfo {
+	result := make([]domain.GatewayInfo, 0, len(s.gateways))
+	for _, info := range s.orderedGateways() {
+		if s.breakers[info.Type].Available() {
+			result = append(result, info)
+		}
 	}
 	return result
 }
@@ -0,0 +1,515 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) CreatePayment(ctx context.Context, p *domain.Payment) (*domain.Payment, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(*domain.Payment), args.Error(1)
+}
+
+func (m *MockRepo) UserBillBalanceDue(ctx context.Context, userID, billID common.ID) (int64, error) {
+	args := m.Called(ctx, userID, billID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) UserBillsBalanceDue(ctx context.Context, userID common.ID) ([]domain.BillWithAmount, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.BillWithAmount), args.Error(1)
+}
+
+func (m *MockRepo) BatchCreatePayment(ctx context.Context, ps []*domain.Payment) ([]*domain.Payment, error) {
+	args := m.Called(ctx, ps)
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockRepo) ReserveIdempotencyKey(
+	ctx context.Context, k *domain.IdempotencyKey,
+) (
+	*domain.IdempotencyKey, bool, error,
+) {
+	args := m.Called(ctx, k)
+	key, _ := args.Get(0).(*domain.IdempotencyKey)
+	return key, args.Bool(1), args.Error(2)
+}
+
+func (m *MockRepo) SaveIdempotencyResponse(ctx context.Context, k *domain.IdempotencyKey) error {
+	args := m.Called(ctx, k)
+	return args.Error(0)
+}
+
+func (m *MockRepo) DeleteIdempotencyKey(ctx context.Context, userID common.ID, key string) error {
+	args := m.Called(ctx, userID, key)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SetTransactionID(ctx context.Context, ids []common.ID, transactionID string) error {
+	args := m.Called(ctx, ids, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) PendingPayments(ctx context.Context, createdBefore time.Time) ([]*domain.Payment, error) {
+	args := m.Called(ctx, createdBefore)
+	return args.Get(0).([]*domain.Payment), args.Error(1)
+}
+
+func (m *MockRepo) SettlePending(ctx context.Context, ids []common.ID, s domain.PaymentStatus) (int64, error) {
+	args := m.Called(ctx, ids, s)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) SettleCaptured(
+	ctx context.Context, ids []common.ID, gt domain.GatewayType, c domain.Capture,
+) (
+	int64, []common.ID, error,
+) {
+	args := m.Called(ctx, ids, gt, c)
+	expired, _ := args.Get(1).([]common.ID)
+	return args.Get(0).(int64), expired, args.Error(2)
+}
+
+type MockGateway struct {
+	mock.Mock
+	port.Gateway
+}
+
+func (m *MockGateway) CreateTransaction(
+	ctx context.Context, tx domain.Transaction,
+) (
+	*domain.RedirectGateway, error,
+) {
+	args := m.Called(ctx, tx)
+	redirect, _ := args.Get(0).(*domain.RedirectGateway)
+	return redirect, args.Error(1)
+}
+
+func (m *MockGateway) VerifyTransaction(
+	ctx context.Context, data map[string][]string,
+) (
+	*domain.Capture, error,
+) {
+	args := m.Called(ctx, data)
+	capture, _ := args.Get(0).(*domain.Capture)
+	return capture, args.Error(1)
+}
+
+type MockInquirerGateway struct {
+	MockGateway
+}
+
+func (m *MockInquirerGateway) InquireTransaction(
+	ctx context.Context, transactionID string,
+) (
+	domain.PaymentStatus, error,
+) {
+	args := m.Called(ctx, transactionID)
+	return args.Get(0).(domain.PaymentStatus), args.Error(1)
+}
+
+func newTestService(repo *MockRepo, gw *MockGateway, opts ...ServiceOpt) port.Service {
+	return NewService(repo, map[domain.GatewayType]port.Gateway{
+		domain.MockGateway: gw,
+	}, opts...)
+}
+
+const callbackURL = "http://127.0.0.1:8080/api/v1/payment/callback"
+
+// ----------- Tests -------------
+
+func TestPayBill_WithoutIdempotencyKey(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+	redirect := &domain.RedirectGateway{Method: "POST", URL: "http://gateway/pay"}
+
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+	gw.On("CreateTransaction", ctx, mock.Anything).Return(redirect, nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, result)
+	repo.AssertNotCalled(t, "ReserveIdempotencyKey", mock.Anything, mock.Anything)
+}
+
+func TestPayBill_NewIdempotencyKey(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+	redirect := &domain.RedirectGateway{Method: "POST", URL: "http://gateway/pay"}
+
+	repo.On("ReserveIdempotencyKey", ctx, mock.Anything).
+		Return(&domain.IdempotencyKey{Key: "key-1", UserID: userID}, true, nil)
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+	gw.On("CreateTransaction", ctx, mock.Anything).Return(redirect, nil)
+	repo.On("SaveIdempotencyResponse", ctx, mock.MatchedBy(func(k *domain.IdempotencyKey) bool {
+		return k.Response == redirect
+	})).Return(nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, result)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestPayBill_ReplayedIdempotencyKey(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+	redirect := &domain.RedirectGateway{Method: "POST", URL: "http://gateway/pay"}
+	fingerprint := domain.Fingerprint("pay-bill", domain.MockGateway, billID.String(), "0", callbackURL)
+
+	repo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(&domain.IdempotencyKey{
+		Key:         "key-1",
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		Response:    redirect,
+	}, false, nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, result)
+	repo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+	gw.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestPayBill_ReusedIdempotencyKey(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(&domain.IdempotencyKey{
+		Key:         "key-1",
+		UserID:      userID,
+		Fingerprint: domain.Fingerprint("pay-total-debt", domain.MockGateway, callbackURL),
+		Response:    &domain.RedirectGateway{},
+	}, false, nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrIdempotencyKeyReused))
+}
+
+func TestPayBill_IdempotencyKeyReusedWithOtherMetadata(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+	mdCtx := appctx.New(context.Background(), appctx.WithLogger(log))
+	appctx.SetValue(mdCtx, MetadataKey, map[string]string{"scenario": "failure"})
+
+	// the key was first used without metadata
+	repo.On("ReserveIdempotencyKey", mdCtx, mock.Anything).Return(&domain.IdempotencyKey{
+		Key:         "key-1",
+		UserID:      userID,
+		Fingerprint: domain.Fingerprint("pay-bill", domain.MockGateway, billID.String(), "0", callbackURL),
+		Response:    &domain.RedirectGateway{},
+	}, false, nil)
+
+	result, err := svc.PayBill(mdCtx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrIdempotencyKeyReused))
+}
+
+func TestPayBill_IdempotencyKeyInProgress(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("ReserveIdempotencyKey", ctx, mock.Anything).Return(&domain.IdempotencyKey{
+		Key:         "key-1",
+		UserID:      userID,
+		Fingerprint: domain.Fingerprint("pay-bill", domain.MockGateway, billID.String(), "0", callbackURL),
+	}, false, nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrIdempotencyKeyInProgress))
+}
+
+func TestPayBill_FailureReleasesIdempotencyKey(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("ReserveIdempotencyKey", ctx, mock.Anything).
+		Return(&domain.IdempotencyKey{Key: "key-1", UserID: userID}, true, nil)
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(0), nil)
+	repo.On("DeleteIdempotencyKey", ctx, userID, "key-1").Return(nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "key-1")
+
+	assert.Nil(t, result)
+	assert.True(t, errors.Is(err, ErrNoBalanceDue))
+	repo.AssertExpectations(t)
+}
+
+func TestPayBill_StoresTransactionID(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, billID, paymentID := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+	redirect := &domain.RedirectGateway{Method: "POST", TransactionID: "tx-1"}
+
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: paymentID}, nil)
+	gw.On("CreateTransaction", ctx, mock.Anything).Return(redirect, nil)
+	repo.On("SetTransactionID", ctx, []common.ID{paymentID}, "tx-1").Return(nil)
+
+	result, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 0, callbackURL, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, result)
+	repo.AssertExpectations(t)
+}
+
+func TestPayBill_PartialAmount(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw, WithMinPaymentAmount(10))
+
+	userID, billID := common.NewRandomID(), common.NewRandomID()
+
+	repo.On("UserBillBalanceDue", ctx, userID, billID).Return(int64(100), nil)
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *domain.Payment) bool {
+		return p.Amount == 40
+	})).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+	gw.On("CreateTransaction", ctx, mock.MatchedBy(func(tx domain.Transaction) bool {
+		return tx.Amount == 40 && tx.Bills[0].Amount == 40
+	})).Return(&domain.RedirectGateway{}, nil)
+
+	_, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, 40, callbackURL, "")
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestPayBill_InvalidAmount(t *testing.T) {
+	cases := map[string]struct {
+		amount, balanceDue int64
+		valid              bool
+	}{
+		"below minimum":         {amount: 5, balanceDue: 100},
+		"above balance due":     {amount: 101, balanceDue: 100},
+		"negative":              {amount: -1, balanceDue: 100},
+		"pays off small remain": {amount: 5, balanceDue: 5, valid: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			gw := new(MockGateway)
+			svc := newTestService(repo, gw, WithMinPaymentAmount(10))
+
+			userID, billID := common.NewRandomID(), common.NewRandomID()
+
+			repo.On("UserBillBalanceDue", ctx, userID, billID).Return(c.balanceDue, nil)
+			repo.On("CreatePayment", ctx, mock.Anything).Return(&domain.Payment{ID: common.NewRandomID()}, nil)
+			gw.On("CreateTransaction", ctx, mock.Anything).Return(&domain.RedirectGateway{}, nil)
+
+			_, err := svc.PayBill(ctx, domain.MockGateway, userID, billID, c.amount, callbackURL, "")
+
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidAmount))
+			}
+		})
+	}
+}
+
+func payTotalDebtAllocation(
+	t *testing.T,
+	rule domain.AllocationRule,
+	dues []domain.BillWithAmount,
+	billIDs []common.ID,
+	amount int64,
+) map[common.ID]int64 {
+	t.Helper()
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw, WithAllocationRule(rule))
+
+	userID := common.NewRandomID()
+	allocated := map[common.ID]int64{}
+
+	repo.On("UserBillsBalanceDue", ctx, userID).Return(dues, nil)
+	repo.On("BatchCreatePayment", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		for _, p := range args.Get(1).([]*domain.Payment) {
+			allocated[p.BillID] = p.Amount
+		}
+	}).Return([]*domain.Payment{}, nil)
+	var txAmount int64
+	gw.On("CreateTransaction", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		txAmount = args.Get(1).(domain.Transaction).Amount
+	}).Return(&domain.RedirectGateway{}, nil)
+
+	_, err := svc.PayTotalDebt(ctx, domain.MockGateway, userID, billIDs, amount, callbackURL, "")
+
+	assert.NoError(t, err)
+	var total int64
+	for _, a := range allocated {
+		total += a
+	}
+	assert.Equal(t, total, txAmount)
+	return allocated
+}
+
+func TestPayTotalDebt_Allocation(t *testing.T) {
+	now := time.Now()
+	older, old, recent := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+	dues := []domain.BillWithAmount{
+		{BillID: recent, Amount: 300, DueDate: now},
+		{BillID: older, Amount: 100, DueDate: now.AddDate(0, -2, 0)},
+		{BillID: old, Amount: 200, DueDate: now.AddDate(0, -1, 0)},
+	}
+
+	t.Run("oldest first", func(t *testing.T) {
+		allocated := payTotalDebtAllocation(t, domain.AllocateOldestFirst, dues, nil, 250)
+		assert.Equal(t, map[common.ID]int64{older: 100, old: 150}, allocated)
+	})
+	t.Run("pro rata", func(t *testing.T) {
+		allocated := payTotalDebtAllocation(t, domain.AllocateProRata, dues, nil, 301)
+		assert.Equal(t, map[common.ID]int64{older: 51, old: 100, recent: 150}, allocated)
+	})
+	t.Run("selected bills", func(t *testing.T) {
+		allocated := payTotalDebtAllocation(t, domain.AllocateOldestFirst, dues, []common.ID{recent, old}, 0)
+		assert.Equal(t, map[common.ID]int64{old: 200, recent: 300}, allocated)
+	})
+}
+
+func TestPayTotalDebt_SelectedBillWithoutBalance(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID := common.NewRandomID()
+	repo.On("UserBillsBalanceDue", ctx, userID).Return([]domain.BillWithAmount{
+		{BillID: common.NewRandomID(), Amount: 100},
+	}, nil)
+
+	_, err := svc.PayTotalDebt(ctx, domain.MockGateway, userID,
+		[]common.ID{common.NewRandomID()}, 0, callbackURL, "")
+
+	assert.True(t, errors.Is(err, ErrNoBalanceDue))
+	repo.AssertNotCalled(t, "BatchCreatePayment", mock.Anything, mock.Anything)
+}
+
+func callbackData(paymentIDs ...common.ID) map[string][]string {
+	return map[string][]string{
+		PaymentIDsKey:   fp.Mapper(paymentIDs, common.ID.String),
+		"token":         {"token"},
+		"transactionId": {"tx-1"},
+	}
+}
+
+func TestHandleCallback_SettlesCapturedPayments(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	p1 := &domain.Payment{ID: common.NewRandomID(), Amount: 3000, Gateway: domain.MockGateway, TransactionID: "tx-1"}
+	p2 := &domain.Payment{ID: common.NewRandomID(), Amount: 2000, Gateway: domain.MockGateway, TransactionID: "tx-1"}
+	data := callbackData(p1.ID, p2.ID)
+	capture := domain.Capture{TransactionID: "tx-1", Amount: 5000}
+	gw.On("VerifyTransaction", ctx, data).Return(&capture, nil)
+	repo.On("GetPayment", ctx, p1.ID).Return(p1, nil)
+	repo.On("GetPayment", ctx, p2.ID).Return(p2, nil)
+	repo.On("SettleCaptured", ctx, []common.ID{p1.ID, p2.ID}, domain.GatewayType(domain.MockGateway), capture).
+		Return(int64(2), nil, nil)
+
+	err := svc.HandleCallback(ctx, domain.MockGateway, data)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestHandleCallback_CaptureMismatch(t *testing.T) {
+	own := func() *domain.Payment {
+		return &domain.Payment{ID: common.NewRandomID(), Amount: 5000, Gateway: domain.MockGateway, TransactionID: "tx-1"}
+	}
+	cases := map[string]struct {
+		payment *domain.Payment
+		capture *domain.Capture
+	}{
+		"foreign transaction": {
+			payment: &domain.Payment{ID: common.NewRandomID(), Amount: 5000, Gateway: domain.MockGateway, TransactionID: "tx-2"},
+			capture: &domain.Capture{TransactionID: "tx-1", Amount: 5000},
+		},
+		"missing transaction id": {
+			payment: own(),
+			capture: &domain.Capture{Amount: 5000},
+		},
+		"wrong amount": {
+			payment: own(),
+			capture: &domain.Capture{TransactionID: "tx-1", Amount: 2500},
+		},
+		"other gateway": {
+			payment: &domain.Payment{ID: common.NewRandomID(), Amount: 5000, Gateway: "other", TransactionID: "tx-1"},
+			capture: &domain.Capture{TransactionID: "tx-1", Amount: 5000},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			gw := new(MockGateway)
+			svc := newTestService(repo, gw)
+
+			data := callbackData(c.payment.ID)
+			gw.On("VerifyTransaction", ctx, data).Return(c.capture, nil)
+			repo.On("GetPayment", ctx, c.payment.ID).Return(c.payment, nil)
+
+			err := svc.HandleCallback(ctx, domain.MockGateway, data)
+
+			assert.ErrorIs(t, err, ErrInvalidCallback)
+			repo.AssertNotCalled(t, "SettleCaptured", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+// Export is the personal data of a user, handed to them on request.
+type Export struct {
+	ExportedAt  time.Time
+	Profile     *User
+	Memberships []Membership
+	BillShares  []BillShare
+	Payments    []Payment
+}
+
+// Membership is an apartment the user belongs to or belonged to.
+type Membership struct {
+	ApartmentID common.ID
+	Name        string
+	Address     string
+	UnitNumber  int64
+	IsAdmin     bool
+	JoinedAt    time.Time
+	LeftAt      *time.Time
+}
+
+// BillShare is the part of a bill charged to the user.
+type BillShare struct {
+	BillID     common.ID
+	BillName   string
+	BillAmount int64
+	Share      int64
+	Paid       int64
+	BalanceDue int64
+}
+
+type Payment struct {
+	ID          common.ID
+	CreatedAt   time.Time
+	BillID      common.ID
+	Amount      int64
+	Status      string
+	Gateway     string
+	PaidAt      *time.Time
+	RefundOf    *common.ID
+	Description string
+}
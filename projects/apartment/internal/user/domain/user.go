@@ -2,7 +2,10 @@ package domain
 
 import (
 	"errors"
+	"net/url"
+	"regexp"
 	"slices"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/google/uuid"
@@ -13,6 +16,15 @@ var (
 	ErrInvalidEmail      = errors.New("invalid email address")
 	ErrUserShortPassword = errors.New("password must be at least 8 characters long")
 	ErrUserLongPassword  = errors.New("password must be at most 72 characters long")
+	ErrInvalidPhone      = errors.New("invalid phone number")
+	ErrInvalidLocale     = errors.New("invalid locale")
+	ErrInvalidAvatarURL  = errors.New("avatar must be an http or https URL")
+	ErrLongName          = errors.New("name must be at most 100 characters long")
+)
+
+var (
+	phoneRegex  = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
 )
 
 type (
@@ -28,6 +40,12 @@ type User struct {
 	isHashed  bool
 	FirstName string
 	LastName  string
+	Phone     string
+	// Locale is a BCP 47 language tag, e.g. "fa-IR".
+	Locale    string
+	AvatarURL string
+	// EmailVerifiedAt is nil until the user follows the verification link.
+	EmailVerifiedAt *time.Time
 }
 
 func NewUser(id UserID, email, pass, firstName, lastName string) *User {
@@ -95,6 +113,64 @@ func (u *User) Validate() error {
 	return nil
 }
 
+// ChangeEmail sets the email of the user, which then needs to be verified
+// again.
+func (u *User) ChangeEmail(email common.Email) error {
+	if !email.IsValid() {
+		return ErrInvalidEmail
+	}
+	if email != u.Email {
+		u.Email = email
+		u.EmailVerifiedAt = nil
+	}
+	return nil
+}
+
+// ProfileUpdate holds the fields a user edits on their profile. Nil fields
+// are left unchanged and empty strings clear them.
+type ProfileUpdate struct {
+	FirstName *string
+	LastName  *string
+	Phone     *string
+	Locale    *string
+	AvatarURL *string
+}
+
+func (p *ProfileUpdate) Validate() error {
+	for _, name := range []*string{p.FirstName, p.LastName} {
+		if name != nil && len([]rune(*name)) > 100 {
+			return ErrLongName
+		}
+	}
+	if p.Phone != nil && *p.Phone != "" && !phoneRegex.MatchString(*p.Phone) {
+		return ErrInvalidPhone
+	}
+	if p.Locale != nil && *p.Locale != "" && !localeRegex.MatchString(*p.Locale) {
+		return ErrInvalidLocale
+	}
+	if p.AvatarURL != nil && *p.AvatarURL != "" {
+		u, err := url.Parse(*p.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidAvatarURL
+		}
+	}
+	return nil
+}
+
+// ApplyProfile copies the set fields of p to the user.
+func (u *User) ApplyProfile(p *ProfileUpdate) {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&u.FirstName, p.FirstName)
+	set(&u.LastName, p.LastName)
+	set(&u.Phone, p.Phone)
+	set(&u.Locale, p.Locale)
+	set(&u.AvatarURL, p.AvatarURL)
+}
+
 type UserFilter struct {
 	ID    UserID
 	Email common.Email
@@ -10,4 +10,13 @@ type Repo interface {
 	Create(context.Context, *domain.User) (*domain.User, error)
 	Get(context.Context, *domain.UserFilter) (*domain.User, error)
 	Delete(context.Context, *domain.UserFilter) error
+	// Update stores the email, password and profile of the user and bumps
+	// its updated_at. It fails with ErrEmailTaken if another user has the
+	// email.
+	Update(context.Context, *domain.User) (*domain.User, error)
+	// DeleteAccount soft-deletes the user and scrubs their personal data,
+	// keeping their payments and ledger entries. It fails with
+	// ErrOwnsApartments or ErrHasDebt.
+	DeleteAccount(ctx context.Context, userID domain.UserID) error
+	Export(ctx context.Context, userID domain.UserID) (*domain.Export, error)
 }
@@ -3,6 +3,7 @@ package port
 import (
 	"context"
 
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
 )
 
@@ -10,4 +11,20 @@ type Service interface {
 	Create(context.Context, *domain.User) (*domain.User, error)
 	Get(context.Context, *domain.UserFilter) (*domain.User, error)
 	Delete(context.Context, *domain.UserFilter) error
+	// Authenticate returns the user of email if password is theirs. It
+	// fails with ErrInvalidCredentials whether the email is unknown or the
+	// password wrong, taking about as long either way.
+	Authenticate(ctx context.Context, email common.Email, password string) (*domain.User, error)
+	// UpdateProfile applies p to the profile of the user.
+	UpdateProfile(ctx context.Context, userID domain.UserID, p *domain.ProfileUpdate) (*domain.User, error)
+	// ChangePassword sets a new password after checking the current one.
+	ChangePassword(ctx context.Context, userID domain.UserID, current, next string) error
+	// ChangeEmail sets a new, unverified email after checking the password.
+	ChangeEmail(ctx context.Context, userID domain.UserID, email common.Email, password string) (*domain.User, error)
+	// DeleteAccount deletes the account of the user after checking the
+	// password. Users who administer apartments or owe money can't delete
+	// their account.
+	DeleteAccount(ctx context.Context, userID domain.UserID, password string) error
+	// Export returns all the personal data of the user.
+	Export(ctx context.Context, userID domain.UserID) (*domain.Export, error)
 }
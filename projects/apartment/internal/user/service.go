@@ -4,20 +4,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
 )
 
 var (
-	ErrUserOnValidate     = errors.New("user validation failed")
-	ErrUserOnCreate       = errors.New("user Creation failed")
-	ErrInvalidOrNilFilter = errors.New("invalid or empty filter")
-	ErrUserOnGet          = errors.New("user retrieve failed")
-	ErrUserOnDelete       = errors.New("error on deleting failed")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrUserOnValidate      = errors.New("user validation failed")
+	ErrUserOnCreate        = errors.New("user Creation failed")
+	ErrInvalidOrNilFilter  = errors.New("invalid or empty filter")
+	ErrUserOnGet           = errors.New("user retrieve failed")
+	ErrUserOnDelete        = errors.New("error on deleting failed")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUserOnUpdate        = errors.New("user update failed")
+	ErrWrongPassword       = errors.New("wrong password")
+	ErrEmailTaken          = errors.New("email already in use")
+	ErrUserOnDeleteAccount = errors.New("account deletion failed")
+	ErrUserOnExport        = errors.New("user data export failed")
+	ErrOwnsApartments      = errors.New("transfer or delete your apartments before deleting your account")
+	ErrHasDebt             = errors.New("pay your debt before deleting your account")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
 )
 
+// dummyUser is checked against passwords given for unknown emails, so that
+// they take as long as wrong passwords of known ones.
+var dummyUser = sync.OnceValue(func() *domain.User {
+	u := domain.NewUser(domain.NilID, "", "not anyone's password", "", "")
+	_ = u.HashPassword()
+	return u
+})
+
 type service struct {
 	repo port.Repo
 }
@@ -61,3 +80,109 @@ func (s *service) Delete(ctx context.Context, filter *domain.UserFilter) error {
 	}
 	return nil
 }
+
+func (s *service) Authenticate(
+	ctx context.Context, email common.Email, password string,
+) (
+	*domain.User, error,
+) {
+	u, err := s.repo.Get(ctx, &domain.UserFilter{Email: email})
+	if errors.Is(err, ErrUserNotFound) {
+		_ = dummyUser().ComparePassword([]byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnGet, err)
+	}
+	if !u.IsHashed() {
+		// deleted accounts have no password
+		_ = dummyUser().ComparePassword([]byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err = u.ComparePassword([]byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+func (s *service) UpdateProfile(
+	ctx context.Context, userID domain.UserID, p *domain.ProfileUpdate,
+) (
+	*domain.User, error,
+) {
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnValidate, err)
+	}
+	u, err := s.repo.Get(ctx, &domain.UserFilter{ID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	u.ApplyProfile(p)
+	if u, err = s.repo.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	return u, nil
+}
+
+func (s *service) ChangePassword(ctx context.Context, userID domain.UserID, current, next string) error {
+	u, err := s.repo.Get(ctx, &domain.UserFilter{ID: userID})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	if err = u.ComparePassword([]byte(current)); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnUpdate, ErrWrongPassword)
+	}
+	n := domain.NewUser(userID, "", next, "", "")
+	if err = n.HashPassword(); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnValidate, err)
+	}
+	u.SetPassword(n.Password())
+	if _, err = s.repo.Update(ctx, u); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	return nil
+}
+
+func (s *service) ChangeEmail(
+	ctx context.Context, userID domain.UserID, email common.Email, password string,
+) (
+	*domain.User, error,
+) {
+	u, err := s.repo.Get(ctx, &domain.UserFilter{ID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	if err = u.ComparePassword([]byte(password)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnUpdate, ErrWrongPassword)
+	}
+	if err = u.ChangeEmail(email); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnValidate, err)
+	}
+	if u, err = s.repo.Update(ctx, u); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnUpdate, err)
+	}
+	return u, nil
+}
+
+func (s *service) DeleteAccount(ctx context.Context, userID domain.UserID, password string) error {
+	u, err := s.repo.Get(ctx, &domain.UserFilter{ID: userID})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnDeleteAccount, err)
+	}
+	if err = u.ComparePassword([]byte(password)); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnDeleteAccount, ErrWrongPassword)
+	}
+	if err = s.repo.DeleteAccount(ctx, userID); err != nil {
+		return fmt.Errorf("%w: %w", ErrUserOnDeleteAccount, err)
+	}
+	return nil
+}
+
+func (s *service) Export(ctx context.Context, userID domain.UserID) (*domain.Export, error) {
+	e, err := s.repo.Export(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUserOnExport, err)
+	}
+	e.ExportedAt = time.Now()
+	return e, nil
+}
@@ -3,7 +3,9 @@ package user
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
@@ -12,6 +14,7 @@ import (
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -39,6 +42,32 @@ func (m *MockRepo) Delete(ctx context.Context, f *domain.UserFilter) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) Update(ctx context.Context, u *domain.User) (*domain.User, error) {
+	args := m.Called(ctx, u)
+	res, _ := args.Get(0).(*domain.User)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) DeleteAccount(ctx context.Context, userID domain.UserID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) Export(ctx context.Context, userID domain.UserID) (*domain.Export, error) {
+	args := m.Called(ctx, userID)
+	res, _ := args.Get(0).(*domain.Export)
+	return res, args.Error(1)
+}
+
+// storedUser returns a user as the repo returns it, with a hashed password.
+func storedUser(t *testing.T, password string) *domain.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.NoError(t, err)
+	u := domain.NewUser(common.NewRandomID(), "test@gmail.com", "", "", "")
+	u.SetPassword(hash)
+	return u
+}
+
 func TestCreate_Success(t *testing.T) {
 	repo := new(MockRepo)
 	svc := NewService(repo)
@@ -201,3 +230,203 @@ func TestDelete_RepoError(t *testing.T) {
 	assert.Contains(t, err.Error(), "error on deleting failed")
 	repo.AssertExpectations(t)
 }
+
+func TestUpdateProfile(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+	u.LastName = "Doe"
+
+	firstName, phone, empty := "Jane", "+989121234567", ""
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+	repo.On("Update", ctx, u).Return(u, nil)
+
+	res, err := svc.UpdateProfile(ctx, u.ID, &domain.ProfileUpdate{
+		FirstName: &firstName,
+		LastName:  &empty,
+		Phone:     &phone,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", res.FirstName)
+	assert.Equal(t, "", res.LastName)
+	assert.Equal(t, phone, res.Phone)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateProfile_Validation(t *testing.T) {
+	bad := func(s string) *string { return &s }
+	testData := []struct {
+		update *domain.ProfileUpdate
+		err    error
+	}{
+		{&domain.ProfileUpdate{Phone: bad("12ab")}, domain.ErrInvalidPhone},
+		{&domain.ProfileUpdate{Locale: bad("english please")}, domain.ErrInvalidLocale},
+		{&domain.ProfileUpdate{AvatarURL: bad("javascript:alert(1)")}, domain.ErrInvalidAvatarURL},
+		{&domain.ProfileUpdate{FirstName: bad(strings.Repeat("a", 101))}, domain.ErrLongName},
+	}
+
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	for _, test := range testData {
+		_, err := svc.UpdateProfile(ctx, common.NewRandomID(), test.update)
+		assert.ErrorIs(t, err, ErrUserOnValidate)
+		assert.ErrorIs(t, err, test.err)
+	}
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticate(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{Email: u.Email}).Return(u, nil)
+
+	got, err := svc.Authenticate(ctx, u.Email, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	_, err = svc.Authenticate(ctx, u.Email, "wrong-password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthenticate_UnknownEmail(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	email := common.Email("nobody@gmail.com")
+
+	repo.On("Get", ctx, &domain.UserFilter{Email: email}).Return((*domain.User)(nil), ErrUserNotFound)
+
+	// the same error as a wrong password
+	_, err := svc.Authenticate(ctx, email, "password")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestChangePassword(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+	repo.On("Update", ctx, u).Return(u, nil)
+
+	assert.NoError(t, svc.ChangePassword(ctx, u.ID, "password", "new-password"))
+	assert.NoError(t, u.ComparePassword([]byte("new-password")))
+	repo.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrent(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+
+	err := svc.ChangePassword(ctx, u.ID, "wrong-password", "new-password")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_TooShort(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+
+	err := svc.ChangePassword(ctx, u.ID, "password", "short")
+	assert.ErrorIs(t, err, domain.ErrUserShortPassword)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestChangeEmail_ClearsVerification(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+	verifiedAt := time.Now()
+	u.EmailVerifiedAt = &verifiedAt
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+	repo.On("Update", ctx, u).Return(u, nil)
+
+	res, err := svc.ChangeEmail(ctx, u.ID, "new@gmail.com", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, common.Email("new@gmail.com"), res.Email)
+	assert.Nil(t, res.EmailVerifiedAt)
+}
+
+func TestChangeEmail_Errors(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+
+	_, err := svc.ChangeEmail(ctx, u.ID, "new@gmail.com", "wrong-password")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	_, err = svc.ChangeEmail(ctx, u.ID, "not-an-email", "password")
+	assert.ErrorIs(t, err, domain.ErrInvalidEmail)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	repo.On("Update", ctx, u).Return(nil, ErrEmailTaken)
+	_, err = svc.ChangeEmail(ctx, u.ID, "taken@gmail.com", "password")
+	assert.ErrorIs(t, err, ErrEmailTaken)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+	repo.On("DeleteAccount", ctx, u.ID).Return(nil)
+
+	assert.NoError(t, svc.DeleteAccount(ctx, u.ID, "password"))
+	repo.AssertExpectations(t)
+}
+
+func TestDeleteAccount_WrongPassword(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+
+	err := svc.DeleteAccount(ctx, u.ID, "wrong-password")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	repo.AssertNotCalled(t, "DeleteAccount", mock.Anything, mock.Anything)
+}
+
+func TestDeleteAccount_Blocked(t *testing.T) {
+	for _, blocker := range []error{ErrOwnsApartments, ErrHasDebt} {
+		repo := new(MockRepo)
+		svc := NewService(repo)
+		u := storedUser(t, "password")
+
+		repo.On("Get", ctx, &domain.UserFilter{ID: u.ID}).Return(u, nil)
+		repo.On("DeleteAccount", ctx, u.ID).Return(blocker)
+
+		err := svc.DeleteAccount(ctx, u.ID, "password")
+		assert.ErrorIs(t, err, ErrUserOnDeleteAccount)
+		assert.ErrorIs(t, err, blocker)
+	}
+}
+
+func TestExport(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+	u := storedUser(t, "password")
+
+	repo.On("Export", ctx, u.ID).Return(&domain.Export{
+		Profile:  u,
+		Payments: []domain.Payment{{ID: common.NewRandomID(), Amount: 100}},
+	}, nil)
+
+	e, err := svc.Export(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, u, e.Profile)
+	assert.Len(t, e.Payments, 1)
+	assert.WithinDuration(t, time.Now(), e.ExportedAt, time.Second)
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+)
+
+var (
+	ErrInvalidTransactionType   = errors.New("invalid wallet transaction type")
+	ErrInvalidTransactionStatus = errors.New("invalid wallet transaction status")
+)
+
+// Wallet holds the prepaid credit of a user in an apartment.
+type Wallet struct {
+	ID          common.ID `json:"id"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	UserID      common.ID `json:"userId"`
+	ApartmentID common.ID `json:"apartmentId"`
+	Balance     int64     `json:"balance"`
+}
+
+type TransactionType string
+
+const (
+	// TransactionTopUp adds credit paid through a gateway.
+	TransactionTopUp TransactionType = "top-up"
+	// TransactionBillPayment spends credit on a bill.
+	TransactionBillPayment TransactionType = "bill-payment"
+)
+
+func (t TransactionType) String() string {
+	return string(t)
+}
+
+func (t TransactionType) IsValid() bool {
+	return t == TransactionTopUp || t == TransactionBillPayment
+}
+
+type TransactionStatus string
+
+const (
+	TransactionPending   TransactionStatus = "pending"
+	TransactionCompleted TransactionStatus = "completed"
+	TransactionFailed    TransactionStatus = "failed"
+)
+
+func (s TransactionStatus) String() string {
+	return string(s)
+}
+
+func (s TransactionStatus) IsValid() bool {
+	switch s {
+	case TransactionPending, TransactionCompleted, TransactionFailed:
+		return true
+	}
+	return false
+}
+
+// Transaction is an entry in the wallet history. Amount is positive for
+// credit and negative for spending.
+type Transaction struct {
+	ID            common.ID         `json:"id"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	WalletID      common.ID         `json:"walletId"`
+	Type          TransactionType   `json:"type"`
+	Amount        int64             `json:"amount"`
+	Status        TransactionStatus `json:"status"`
+	Gateway       string            `json:"gateway,omitempty"`
+	TransactionID string            `json:"transactionId,omitempty"`
+	// PaymentID and BillID are set on bill payments.
+	PaymentID   *common.ID `json:"paymentId,omitempty"`
+	BillID      *common.ID `json:"billId,omitempty"`
+	Description string     `json:"description,omitempty"`
+}
@@ -0,0 +1,46 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+)
+
+type Service interface {
+	Get(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error)
+	// TopUp starts a gateway payment that credits the wallet once verified.
+	TopUp(ctx context.Context, gateway paymentd.GatewayType, userID, apartmentID common.ID, amount int64, callbackURL string) (*paymentd.RedirectGateway, error)
+	HandleTopUpCallback(ctx context.Context, gateway paymentd.GatewayType, data map[string][]string) error
+	// PayBill pays amount of the user's share of a bill from the wallet,
+	// zero pays as much as the balance allows.
+	PayBill(ctx context.Context, userID, billID common.ID, amount int64) (*paymentd.Payment, error)
+	// ApplyCredit pays a new bill from the wallets of the apartment members.
+	ApplyCredit(ctx context.Context, billID common.ID) error
+	Transactions(ctx context.Context, userID, apartmentID common.ID) ([]domain.Transaction, error)
+}
+
+type Repo interface {
+	IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error)
+	GetOrCreate(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error)
+	Get(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error)
+	// BillWallets returns the wallets with credit of the members of the
+	// bill's apartment.
+	BillWallets(ctx context.Context, billID common.ID) ([]*domain.Wallet, error)
+	BillApartment(ctx context.Context, billID common.ID) (common.ID, error)
+	UserBillBalanceDue(ctx context.Context, userID, billID common.ID) (int64, error)
+
+	CreateTransaction(ctx context.Context, t *domain.Transaction) (*domain.Transaction, error)
+	SetGatewayTransactionID(ctx context.Context, id common.ID, transactionID string) error
+	// CompleteTopUp marks a pending top-up completed and credits its
+	// wallet, if it was created with the gateway transaction transactionID
+	// and amount is what it asked for. It returns ErrTopUpSettled if the
+	// top-up was not pending and ErrTopUpMismatch if the transaction or
+	// amount differ. A completed top-up is recorded in the outbox.
+	CompleteTopUp(ctx context.Context, id common.ID, transactionID string, amount int64) (*domain.Transaction, *domain.Wallet, error)
+	// PayBill debits the wallet and records a paid payment for the bill in
+	// one transaction.
+	PayBill(ctx context.Context, w *domain.Wallet, p *paymentd.Payment) (*paymentd.Payment, error)
+	Transactions(ctx context.Context, walletID common.ID) ([]domain.Transaction, error)
+}
@@ -0,0 +1,369 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+const (
+	GatewayKey       = "gateway"
+	TransactionIDKey = "wallet-transaction-id"
+)
+
+var (
+	ErrOnGetWallet        = errors.New("error on get wallet")
+	ErrOnTopUp            = errors.New("error on wallet top-up")
+	ErrOnCallback         = errors.New("error on handle wallet callback")
+	ErrOnPayBill          = errors.New("error on pay bill from wallet")
+	ErrOnApplyCredit      = errors.New("error on apply wallet credit")
+	ErrOnTransactions     = errors.New("error on get wallet transactions")
+	ErrWalletNotFound     = errors.New("wallet not found")
+	ErrBillNotFound       = errors.New("bill not found")
+	ErrNotMember          = errors.New("user is not a member of the apartment")
+	ErrUnknownGateway     = errors.New("unknown gateway")
+	ErrInvalidCallback    = errors.New("invalid callback")
+	ErrInvalidAmount      = errors.New("invalid amount")
+	ErrInsufficientCredit = errors.New("insufficient wallet credit")
+	ErrNoBalanceDue       = errors.New("no balance due")
+	ErrTopUpSettled       = errors.New("wallet top-up already settled")
+	ErrTopUpMismatch      = errors.New("callback doesn't match the wallet top-up")
+)
+
+type service struct {
+	repo     port.Repo
+	gateways map[paymentd.GatewayType]paymentp.Gateway
+	onPaid   []func(context.Context, *paymentd.Payment)
+	onTopUp  []func(context.Context, *domain.Transaction, *domain.Wallet)
+}
+
+type ServiceOpt func(*service)
+
+// WithOnPaymentPaid registers fn to run after a bill is paid from a wallet.
+func WithOnPaymentPaid(fn func(context.Context, *paymentd.Payment)) ServiceOpt {
+	return func(s *service) {
+		s.onPaid = append(s.onPaid, fn)
+	}
+}
+
+// WithOnTopUpCompleted registers fn to run after a top-up is credited.
+func WithOnTopUpCompleted(fn func(context.Context, *domain.Transaction, *domain.Wallet)) ServiceOpt {
+	return func(s *service) {
+		s.onTopUp = append(s.onTopUp, fn)
+	}
+}
+
+func NewService(
+	repo port.Repo,
+	gws map[paymentd.GatewayType]paymentp.Gateway,
+	opts ...ServiceOpt,
+) port.Service {
+	s := &service{repo: repo, gateways: gws}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) gateway(gt paymentd.GatewayType) (paymentp.Gateway, error) {
+	gateway, ok := s.gateways[gt]
+	if !gt.IsValid() || !ok {
+		return nil, ErrUnknownGateway
+	}
+	return gateway, nil
+}
+
+func (s *service) checkMember(ctx context.Context, userID, apartmentID common.ID) error {
+	ok, err := s.repo.IsApartmentMember(ctx, userID, apartmentID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// Get returns the user's wallet in the apartment. A user who never topped
+// up gets an empty wallet.
+func (s *service) Get(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error) {
+	if err := s.checkMember(ctx, userID, apartmentID); err != nil {
+		return nil, fp.WrapErrors(ErrOnGetWallet, err)
+	}
+	w, err := s.repo.Get(ctx, userID, apartmentID)
+	if errors.Is(err, ErrWalletNotFound) {
+		return &domain.Wallet{UserID: userID, ApartmentID: apartmentID}, nil
+	}
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetWallet, err)
+	}
+	return w, nil
+}
+
+func (s *service) TopUp(
+	ctx context.Context,
+	gt paymentd.GatewayType,
+	userID, apartmentID common.ID,
+	amount int64,
+	callbackURL string,
+) (
+	*paymentd.RedirectGateway, error,
+) {
+	redirect, err := s.topUp(ctx, gt, userID, apartmentID, amount, callbackURL)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnTopUp, err)
+	}
+	return redirect, nil
+}
+
+func (s *service) topUp(
+	ctx context.Context,
+	gt paymentd.GatewayType,
+	userID, apartmentID common.ID,
+	amount int64,
+	callbackURL string,
+) (
+	*paymentd.RedirectGateway, error,
+) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	gateway, err := s.gateway(gt)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkMember(ctx, userID, apartmentID); err != nil {
+		return nil, err
+	}
+	w, err := s.repo.GetOrCreate(ctx, userID, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.repo.CreateTransaction(ctx, &domain.Transaction{
+		WalletID: w.ID,
+		Type:     domain.TransactionTopUp,
+		Amount:   amount,
+		Status:   domain.TransactionPending,
+		Gateway:  gt.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	callbackURL, err = callbackURLWithTransaction(callbackURL, gt, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	redirect, err := gateway.CreateTransaction(ctx, paymentd.Transaction{
+		Amount:      amount,
+		PayerID:     userID,
+		CallbackURL: callbackURL,
+		Metadata:    map[string]string{TransactionIDKey: t.ID.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if redirect.TransactionID != "" {
+		err = s.repo.SetGatewayTransactionID(ctx, t.ID, redirect.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return redirect, nil
+}
+
+func callbackURLWithTransaction(
+	callbackURL string,
+	gt paymentd.GatewayType,
+	id common.ID,
+) (
+	string, error,
+) {
+	cURL, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	query, err := url.ParseQuery(cURL.RawQuery)
+	if err != nil {
+		return "", err
+	}
+	query.Set(GatewayKey, gt.String())
+	query.Set(TransactionIDKey, id.String())
+	cURL.RawQuery = query.Encode()
+	return cURL.String(), nil
+}
+
+func (s *service) HandleTopUpCallback(
+	ctx context.Context,
+	gt paymentd.GatewayType,
+	data map[string][]string,
+) error {
+	log := appctx.Logger(ctx)
+
+	gateway, err := s.gateway(gt)
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, err)
+	}
+	ids := data[TransactionIDKey]
+	if len(ids) != 1 || common.ValidateID(ids[0]) != nil {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback)
+	}
+	capture, err := gateway.VerifyTransaction(ctx, data)
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback, err)
+	}
+	// the wallet transaction is named by the caller, so only the gateway
+	// transaction it was created with, captured in full, completes it
+	if capture.TransactionID == "" {
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback)
+	}
+	t, w, err := s.repo.CompleteTopUp(ctx, common.IDFromText(ids[0]), capture.TransactionID, capture.Amount)
+	if errors.Is(err, ErrTopUpSettled) {
+		log.Warn("wallet top-up already settled", zap.String("walletTransactionId", ids[0]))
+		return nil
+	}
+	if errors.Is(err, ErrTopUpMismatch) {
+		log.Warn("wallet top-up callback mismatch",
+			zap.String("walletTransactionId", ids[0]),
+			zap.String("transactionId", capture.TransactionID),
+			zap.Int64("capturedAmount", capture.Amount),
+		)
+		return fp.WrapErrors(ErrOnCallback, ErrInvalidCallback, err)
+	}
+	if err != nil {
+		return fp.WrapErrors(ErrOnCallback, err)
+	}
+	for _, fn := range s.onTopUp {
+		fn(ctx, t, w)
+	}
+	return nil
+}
+
+func (s *service) PayBill(
+	ctx context.Context,
+	userID, billID common.ID,
+	amount int64,
+) (
+	*paymentd.Payment, error,
+) {
+	apartmentID, err := s.repo.BillApartment(ctx, billID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPayBill, err)
+	}
+	if err = s.checkMember(ctx, userID, apartmentID); err != nil {
+		return nil, fp.WrapErrors(ErrOnPayBill, err)
+	}
+	w, err := s.repo.Get(ctx, userID, apartmentID)
+	if errors.Is(err, ErrWalletNotFound) {
+		return nil, fp.WrapErrors(ErrOnPayBill, ErrInsufficientCredit)
+	}
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPayBill, err)
+	}
+	balanceDue, err := s.repo.UserBillBalanceDue(ctx, userID, billID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPayBill, err)
+	}
+	if balanceDue <= 0 {
+		return nil, fp.WrapErrors(ErrOnPayBill, ErrNoBalanceDue)
+	}
+	switch {
+	case amount == 0:
+		amount = min(balanceDue, w.Balance)
+	case amount < 0 || amount > balanceDue:
+		return nil, fp.WrapErrors(ErrOnPayBill, ErrInvalidAmount)
+	}
+	if amount <= 0 || amount > w.Balance {
+		return nil, fp.WrapErrors(ErrOnPayBill, ErrInsufficientCredit)
+	}
+	p, err := s.pay(ctx, w, billID, amount)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnPayBill, err)
+	}
+	return p, nil
+}
+
+func (s *service) pay(
+	ctx context.Context,
+	w *domain.Wallet,
+	billID common.ID,
+	amount int64,
+) (
+	*paymentd.Payment, error,
+) {
+	p, err := s.repo.PayBill(ctx, w, &paymentd.Payment{
+		BillID:  billID,
+		PayerID: w.UserID,
+		Amount:  amount,
+		PaidAt:  time.Now().UTC(),
+		Status:  paymentd.PaymentPaid,
+		Gateway: paymentd.WalletGateway,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, fn := range s.onPaid {
+		fn(ctx, p)
+	}
+	return p, nil
+}
+
+// ApplyCredit pays as much of a new bill as each member's wallet allows. A
+// failure for one member doesn't stop the others.
+func (s *service) ApplyCredit(ctx context.Context, billID common.ID) error {
+	log := appctx.Logger(ctx)
+
+	wallets, err := s.repo.BillWallets(ctx, billID)
+	if err != nil {
+		return fp.WrapErrors(ErrOnApplyCredit, err)
+	}
+	for _, w := range wallets {
+		balanceDue, err := s.repo.UserBillBalanceDue(ctx, w.UserID, billID)
+		if err != nil {
+			log.Error("apply wallet credit", zap.Error(err),
+				zap.String("walletId", w.ID.String()), zap.String("billId", billID.String()))
+			continue
+		}
+		amount := min(balanceDue, w.Balance)
+		if amount <= 0 {
+			continue
+		}
+		if _, err = s.pay(ctx, w, billID, amount); err != nil {
+			log.Error("apply wallet credit", zap.Error(err),
+				zap.String("walletId", w.ID.String()), zap.String("billId", billID.String()))
+		}
+	}
+	return nil
+}
+
+func (s *service) Transactions(
+	ctx context.Context,
+	userID, apartmentID common.ID,
+) (
+	[]domain.Transaction, error,
+) {
+	if err := s.checkMember(ctx, userID, apartmentID); err != nil {
+		return nil, fp.WrapErrors(ErrOnTransactions, err)
+	}
+	w, err := s.repo.Get(ctx, userID, apartmentID)
+	if errors.Is(err, ErrWalletNotFound) {
+		return []domain.Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnTransactions, err)
+	}
+	ts, err := s.repo.Transactions(ctx, w.ID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnTransactions, err)
+	}
+	return ts, nil
+}
@@ -0,0 +1,284 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) IsApartmentMember(ctx context.Context, userID, apartmentID common.ID) (bool, error) {
+	args := m.Called(ctx, userID, apartmentID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepo) GetOrCreate(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error) {
+	args := m.Called(ctx, userID, apartmentID)
+	w, _ := args.Get(0).(*domain.Wallet)
+	return w, args.Error(1)
+}
+
+func (m *MockRepo) Get(ctx context.Context, userID, apartmentID common.ID) (*domain.Wallet, error) {
+	args := m.Called(ctx, userID, apartmentID)
+	w, _ := args.Get(0).(*domain.Wallet)
+	return w, args.Error(1)
+}
+
+func (m *MockRepo) BillWallets(ctx context.Context, billID common.ID) ([]*domain.Wallet, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).([]*domain.Wallet), args.Error(1)
+}
+
+func (m *MockRepo) BillApartment(ctx context.Context, billID common.ID) (common.ID, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) UserBillBalanceDue(ctx context.Context, userID, billID common.ID) (int64, error) {
+	args := m.Called(ctx, userID, billID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepo) CreateTransaction(ctx context.Context, t *domain.Transaction) (*domain.Transaction, error) {
+	args := m.Called(ctx, t)
+	res, _ := args.Get(0).(*domain.Transaction)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) SetGatewayTransactionID(ctx context.Context, id common.ID, transactionID string) error {
+	args := m.Called(ctx, id, transactionID)
+	return args.Error(0)
+}
+
+func (m *MockRepo) CompleteTopUp(
+	ctx context.Context, id common.ID, transactionID string, amount int64,
+) (*domain.Transaction, *domain.Wallet, error) {
+	args := m.Called(ctx, id, transactionID, amount)
+	t, _ := args.Get(0).(*domain.Transaction)
+	w, _ := args.Get(1).(*domain.Wallet)
+	return t, w, args.Error(2)
+}
+
+func (m *MockRepo) PayBill(ctx context.Context, w *domain.Wallet, p *paymentd.Payment) (*paymentd.Payment, error) {
+	args := m.Called(ctx, w, p)
+	res, _ := args.Get(0).(*paymentd.Payment)
+	return res, args.Error(1)
+}
+
+type MockGateway struct {
+	mock.Mock
+	paymentp.Gateway
+}
+
+func (m *MockGateway) CreateTransaction(
+	ctx context.Context, tx paymentd.Transaction,
+) (
+	*paymentd.RedirectGateway, error,
+) {
+	args := m.Called(ctx, tx)
+	redirect, _ := args.Get(0).(*paymentd.RedirectGateway)
+	return redirect, args.Error(1)
+}
+
+func (m *MockGateway) VerifyTransaction(ctx context.Context, data map[string][]string) (*paymentd.Capture, error) {
+	args := m.Called(ctx, data)
+	c, _ := args.Get(0).(*paymentd.Capture)
+	return c, args.Error(1)
+}
+
+func newTestService(repo *MockRepo, gw *MockGateway) port.Service {
+	return NewService(repo, map[paymentd.GatewayType]paymentp.Gateway{
+		paymentd.MockGateway: gw,
+	})
+}
+
+const callbackURL = "http://127.0.0.1:8080/api/v1/wallet/callback"
+
+// ----------- Tests -------------
+
+func TestTopUp_Success(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, aptID := common.NewRandomID(), common.NewRandomID()
+	w := &domain.Wallet{ID: common.NewRandomID(), UserID: userID, ApartmentID: aptID}
+	txID := common.NewRandomID()
+	redirect := &paymentd.RedirectGateway{Method: "POST", TransactionID: "tx-1"}
+
+	repo.On("IsApartmentMember", ctx, userID, aptID).Return(true, nil)
+	repo.On("GetOrCreate", ctx, userID, aptID).Return(w, nil)
+	repo.On("CreateTransaction", ctx, mock.MatchedBy(func(t *domain.Transaction) bool {
+		return t.WalletID == w.ID && t.Amount == 500 && t.Status == domain.TransactionPending
+	})).Return(&domain.Transaction{ID: txID}, nil)
+	gw.On("CreateTransaction", ctx, mock.MatchedBy(func(tx paymentd.Transaction) bool {
+		return tx.Amount == 500 && tx.Metadata[TransactionIDKey] == txID.String()
+	})).Return(redirect, nil)
+	repo.On("SetGatewayTransactionID", ctx, txID, "tx-1").Return(nil)
+
+	result, err := svc.TopUp(ctx, paymentd.MockGateway, userID, aptID, 500, callbackURL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, redirect, result)
+	repo.AssertExpectations(t)
+	gw.AssertExpectations(t)
+}
+
+func TestTopUp_NotMember(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	userID, aptID := common.NewRandomID(), common.NewRandomID()
+	repo.On("IsApartmentMember", ctx, userID, aptID).Return(false, nil)
+
+	_, err := svc.TopUp(ctx, paymentd.MockGateway, userID, aptID, 500, callbackURL)
+
+	assert.True(t, errors.Is(err, ErrNotMember))
+	repo.AssertNotCalled(t, "GetOrCreate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleTopUpCallback_CreditsWallet(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	txID := common.NewRandomID()
+	data := map[string][]string{TransactionIDKey: {txID.String()}, "token": {"t"}}
+
+	gw.On("VerifyTransaction", ctx, data).Return(&paymentd.Capture{TransactionID: "tx-1", Amount: 500}, nil)
+	repo.On("CompleteTopUp", ctx, txID, "tx-1", int64(500)).Return(&domain.Transaction{ID: txID}, &domain.Wallet{}, nil)
+
+	err := svc.HandleTopUpCallback(ctx, paymentd.MockGateway, data)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestHandleTopUpCallback_Mismatch(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	// a small capture naming another, larger top-up
+	txID := common.NewRandomID()
+	data := map[string][]string{TransactionIDKey: {txID.String()}, "token": {"t"}}
+	gw.On("VerifyTransaction", ctx, data).Return(&paymentd.Capture{TransactionID: "tx-small", Amount: 1}, nil)
+	repo.On("CompleteTopUp", ctx, txID, "tx-small", int64(1)).Return(nil, nil, ErrTopUpMismatch)
+
+	err := svc.HandleTopUpCallback(ctx, paymentd.MockGateway, data)
+	assert.ErrorIs(t, err, ErrInvalidCallback)
+
+	// a callback without a gateway transaction can't be matched
+	noTx := map[string][]string{TransactionIDKey: {txID.String()}}
+	gw.On("VerifyTransaction", ctx, noTx).Return(&paymentd.Capture{Amount: 500}, nil)
+	err = svc.HandleTopUpCallback(ctx, paymentd.MockGateway, noTx)
+	assert.ErrorIs(t, err, ErrInvalidCallback)
+	repo.AssertNumberOfCalls(t, "CompleteTopUp", 1)
+}
+
+func TestHandleTopUpCallback_VerifyFails(t *testing.T) {
+	repo := new(MockRepo)
+	gw := new(MockGateway)
+	svc := newTestService(repo, gw)
+
+	data := map[string][]string{TransactionIDKey: {common.NewRandomID().String()}}
+	gw.On("VerifyTransaction", ctx, data).Return(nil, errors.New("not paid"))
+
+	err := svc.HandleTopUpCallback(ctx, paymentd.MockGateway, data)
+
+	assert.True(t, errors.Is(err, ErrInvalidCallback))
+	repo.AssertNotCalled(t, "CompleteTopUp", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPayBill_FromWallet(t *testing.T) {
+	cases := map[string]struct {
+		amount, balance, due int64
+		paid                 int64
+		err                  error
+	}{
+		"as much as credit allows": {amount: 0, balance: 300, due: 500, paid: 300},
+		"whole balance due":        {amount: 0, balance: 800, due: 500, paid: 500},
+		"custom amount":            {amount: 200, balance: 300, due: 500, paid: 200},
+		"more than credit":         {amount: 400, balance: 300, due: 500, err: ErrInsufficientCredit},
+		"more than balance due":    {amount: 600, balance: 800, due: 500, err: ErrInvalidAmount},
+		"empty wallet":             {amount: 0, balance: 0, due: 500, err: ErrInsufficientCredit},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(MockRepo)
+			svc := newTestService(repo, new(MockGateway))
+
+			userID, aptID, billID := common.NewRandomID(), common.NewRandomID(), common.NewRandomID()
+			w := &domain.Wallet{ID: common.NewRandomID(), UserID: userID, Balance: c.balance}
+
+			repo.On("BillApartment", ctx, billID).Return(aptID, nil)
+			repo.On("IsApartmentMember", ctx, userID, aptID).Return(true, nil)
+			repo.On("Get", ctx, userID, aptID).Return(w, nil)
+			repo.On("UserBillBalanceDue", ctx, userID, billID).Return(c.due, nil)
+			repo.On("PayBill", ctx, w, mock.Anything).Return(&paymentd.Payment{Amount: c.paid}, nil)
+
+			_, err := svc.PayBill(ctx, userID, billID, c.amount)
+
+			if c.err != nil {
+				assert.True(t, errors.Is(err, c.err))
+				repo.AssertNotCalled(t, "PayBill", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			repo.AssertCalled(t, "PayBill", ctx, w, mock.MatchedBy(func(p *paymentd.Payment) bool {
+				return p.Amount == c.paid && p.Gateway == paymentd.WalletGateway &&
+					p.Status == paymentd.PaymentPaid && p.BillID == billID
+			}))
+		})
+	}
+}
+
+func TestApplyCredit_PaysNewBillFromWallets(t *testing.T) {
+	repo := new(MockRepo)
+	svc := newTestService(repo, new(MockGateway))
+
+	billID := common.NewRandomID()
+	rich := &domain.Wallet{ID: common.NewRandomID(), UserID: common.NewRandomID(), Balance: 1000}
+	poor := &domain.Wallet{ID: common.NewRandomID(), UserID: common.NewRandomID(), Balance: 100}
+	late := &domain.Wallet{ID: common.NewRandomID(), UserID: common.NewRandomID(), Balance: 100}
+
+	repo.On("BillWallets", ctx, billID).Return([]*domain.Wallet{rich, poor, late}, nil)
+	repo.On("UserBillBalanceDue", ctx, rich.UserID, billID).Return(int64(300), nil)
+	repo.On("UserBillBalanceDue", ctx, poor.UserID, billID).Return(int64(300), nil)
+	// joined after the bill was issued
+	repo.On("UserBillBalanceDue", ctx, late.UserID, billID).Return(int64(0), nil)
+	repo.On("PayBill", ctx, rich, mock.MatchedBy(func(p *paymentd.Payment) bool {
+		return p.Amount == 300
+	})).Return(&paymentd.Payment{}, nil)
+	repo.On("PayBill", ctx, poor, mock.MatchedBy(func(p *paymentd.Payment) bool {
+		return p.Amount == 100
+	})).Return(&paymentd.Payment{}, nil)
+
+	err := svc.ApplyCredit(ctx, billID)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	repo.AssertNumberOfCalls(t, "PayBill", 2)
+}
@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+	"go.uber.org/zap"
+)
+
+var ErrOnDeliver = errors.New("error on deliver webhooks")
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const (
+	DefaultDeliveryInterval = 5 * time.Second
+	DefaultMaxAttempts      = 8
+	DefaultBackoff          = 30 * time.Second
+	DefaultTimeout          = 10 * time.Second
+	DefaultBatchSize        = 50
+	// maxBackoff caps the delay between two attempts of a delivery.
+	maxBackoff = 6 * time.Hour
+	// claimLease is how long claimed deliveries are held for sending.
+	claimLease = 5 * time.Minute
+)
+
+// Sign returns the signature of a delivery body sent at timestamp, the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the endpoint secret.
+// Endpoints recompute it to verify the SignatureHeader.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type deliverer struct {
+	repo        port.Repo
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+}
+
+type DelivererOpt func(*deliverer)
+
+// WithDeliveryInterval sets how often pending deliveries are sent.
+func WithDeliveryInterval(interval time.Duration) DelivererOpt {
+	return func(d *deliverer) {
+		if interval > 0 {
+			d.interval = interval
+		}
+	}
+}
+
+// WithMaxAttempts sets after how many failed attempts a delivery is given
+// up on.
+func WithMaxAttempts(n int) DelivererOpt {
+	return func(d *deliverer) {
+		if n > 0 {
+			d.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry of a delivery. It
+// doubles with every further attempt.
+func WithBackoff(b time.Duration) DelivererOpt {
+	return func(d *deliverer) {
+		if b > 0 {
+			d.backoff = b
+		}
+	}
+}
+
+// WithTimeout sets how long an endpoint has to respond.
+func WithTimeout(t time.Duration) DelivererOpt {
+	return func(d *deliverer) {
+		if t > 0 {
+			d.client.Timeout = t
+		}
+	}
+}
+
+func NewDeliverer(repo port.Repo, opts ...DelivererOpt) port.Deliverer {
+	d := &deliverer{
+		repo:        repo,
+		client:      newClient(),
+		interval:    DefaultDeliveryInterval,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// newClient returns a client that only connects to public addresses and
+// doesn't follow redirects. The address is checked after DNS resolution, so
+// a hostname re-pointed at an internal address after the endpoint was
+// created is refused too.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: DefaultTimeout, Control: dialPublic}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would make the checked address the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublic refuses connections to addresses webhooks may not be sent to.
+func dialPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !domain.PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", domain.ErrPrivateURL, addrPort.Addr())
+	}
+	return nil
+}
+
+// Run sends pending deliveries every interval until ctx is done.
+func (d *deliverer) Run(ctx context.Context) {
+	log := appctx.Logger(ctx)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Deliver(ctx); err != nil {
+			log.Error("webhook deliverer", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver posts the deliveries due now to their endpoints. A failed
+// delivery is retried with exponential backoff and marked failed after
+// maxAttempts attempts.
+func (d *deliverer) Deliver(ctx context.Context) error {
+	log := appctx.Logger(ctx)
+
+	now := d.now()
+	ds, err := d.repo.ClaimDeliveries(ctx, now, now.Add(claimLease), DefaultBatchSize)
+	if err != nil {
+		return fp.WrapErrors(ErrOnDeliver, err)
+	}
+	endpoints := make(map[common.ID]*domain.Endpoint)
+	for _, dl := range ds {
+		ep, ok := endpoints[dl.EndpointID]
+		if !ok {
+			if ep, err = d.repo.GetEndpoint(ctx, dl.EndpointID); err != nil {
+				log.Error("webhook endpoint", zap.Error(err),
+					zap.String("deliveryId", dl.ID.String()))
+				continue
+			}
+			endpoints[dl.EndpointID] = ep
+		}
+
+		status, err := d.send(ctx, ep, &dl)
+		if err == nil {
+			err = d.repo.MarkDelivered(ctx, dl.ID, status)
+		} else {
+			err = d.fail(ctx, &dl, status, err)
+		}
+		if err != nil {
+			log.Error("record webhook delivery", zap.Error(err),
+				zap.String("deliveryId", dl.ID.String()))
+		}
+	}
+	return nil
+}
+
+// send posts the delivery and returns the response status, zero if there
+// was no response. Responses other than 2xx are errors, redirects too.
+func (d *deliverer) send(ctx context.Context, ep *domain.Endpoint, dl *domain.Delivery) (int, error) {
+	timestamp := d.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, timestamp, dl.Payload))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventHeader, dl.EventType.String())
+	req.Header.Set(DeliveryHeader, dl.ID.String())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *deliverer) fail(ctx context.Context, dl *domain.Delivery, status int, cause error) error {
+	log := appctx.Logger(ctx)
+
+	attempts := dl.Attempts + 1
+	if attempts >= d.maxAttempts {
+		log.Warn("webhook delivery given up", zap.Error(cause),
+			zap.String("deliveryId", dl.ID.String()), zap.Int("attempts", attempts))
+		return d.repo.MarkFailed(ctx, dl.ID, status, cause.Error(), nil)
+	}
+	retryAt := d.now().Add(d.retryDelay(attempts))
+	return d.repo.MarkFailed(ctx, dl.ID, status, cause.Error(), &retryAt)
+}
+
+// retryDelay returns the delay after the given number of failed attempts.
+func (d *deliverer) retryDelay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDeliverer(repo *MockRepo, now time.Time, opts ...DelivererOpt) *deliverer {
+	d := NewDeliverer(repo, opts...).(*deliverer)
+	d.now = func() time.Time { return now }
+	// test servers listen on loopback, which deliveries are refused
+	d.client.Transport.(*http.Transport).DialContext = (&net.Dialer{}).DialContext
+	return d
+}
+
+func testDelivery(endpointID common.ID, attempts int) domain.Delivery {
+	return domain.Delivery{
+		ID:         common.NewRandomID(),
+		EndpointID: endpointID,
+		EventID:    common.NewRandomID(),
+		EventType:  eventd.BillCreated,
+		Payload:    []byte(`{"type":"bill.created"}`),
+		Status:     domain.DeliveryPending,
+		Attempts:   attempts,
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac whsec_test
+	assert.Equal(t,
+		"sha256=35495024f4ef3f94e5a93e22221544c4b75e9a42300cd965ab81cb85cd994e91",
+		Sign("whsec_test", 1700000000, []byte("{}")),
+	)
+	assert.NotEqual(t, Sign("whsec_test", 1700000000, []byte("{}")), Sign("whsec_test", 1700000001, []byte("{}")))
+	assert.NotEqual(t, Sign("whsec_test", 1700000000, []byte("{}")), Sign("whsec_other", 1700000000, []byte("{}")))
+}
+
+func TestDeliver_SignsAndMarksDelivered(t *testing.T) {
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	endpoint := &domain.Endpoint{ID: common.NewRandomID(), Secret: "whsec_test"}
+	d := testDelivery(endpoint.ID, 0)
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	endpoint.URL = srv.URL
+
+	repo := new(MockRepo)
+	dl := newTestDeliverer(repo, now)
+	repo.On("ClaimDeliveries", ctx, now, now.Add(claimLease), DefaultBatchSize).
+		Return([]domain.Delivery{d}, nil)
+	repo.On("GetEndpoint", ctx, endpoint.ID).Return(endpoint, nil)
+	repo.On("MarkDelivered", ctx, d.ID, http.StatusNoContent).Return(nil)
+
+	assert.NoError(t, dl.Deliver(ctx))
+	repo.AssertExpectations(t)
+
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, []byte(d.Payload), body)
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), got.Header.Get(TimestampHeader))
+	assert.Equal(t, Sign(endpoint.Secret, now.Unix(), body), got.Header.Get(SignatureHeader))
+	assert.Equal(t, eventd.BillCreated.String(), got.Header.Get(EventHeader))
+	assert.Equal(t, d.ID.String(), got.Header.Get(DeliveryHeader))
+}
+
+func TestDeliver_RetriesWithBackoff(t *testing.T) {
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	endpoint := &domain.Endpoint{ID: common.NewRandomID(), URL: srv.URL, Secret: "whsec_test"}
+	first, second := testDelivery(endpoint.ID, 0), testDelivery(endpoint.ID, 2)
+
+	repo := new(MockRepo)
+	dl := newTestDeliverer(repo, now, WithBackoff(time.Minute))
+	repo.On("ClaimDeliveries", ctx, now, now.Add(claimLease), DefaultBatchSize).
+		Return([]domain.Delivery{first, second}, nil)
+	// the endpoint is looked up once per batch
+	repo.On("GetEndpoint", ctx, endpoint.ID).Return(endpoint, nil).Once()
+	firstRetry, secondRetry := now.Add(time.Minute), now.Add(4*time.Minute)
+	repo.On("MarkFailed", ctx, first.ID, http.StatusServiceUnavailable, mock.Anything, &firstRetry).Return(nil)
+	repo.On("MarkFailed", ctx, second.ID, http.StatusServiceUnavailable, mock.Anything, &secondRetry).Return(nil)
+
+	assert.NoError(t, dl.Deliver(ctx))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "MarkDelivered", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeliver_GivesUpAfterMaxAttempts(t *testing.T) {
+	now := time.Date(2025, 8, 8, 12, 0, 0, 0, time.UTC)
+	// nothing listens on the endpoint
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	endpoint := &domain.Endpoint{ID: common.NewRandomID(), URL: srv.URL, Secret: "whsec_test"}
+	d := testDelivery(endpoint.ID, 2)
+
+	repo := new(MockRepo)
+	dl := newTestDeliverer(repo, now, WithMaxAttempts(3))
+	repo.On("ClaimDeliveries", ctx, now, now.Add(claimLease), DefaultBatchSize).
+		Return([]domain.Delivery{d}, nil)
+	repo.On("GetEndpoint", ctx, endpoint.ID).Return(endpoint, nil)
+	repo.On("MarkFailed", ctx, d.ID, 0, mock.Anything, (*time.Time)(nil)).Return(nil)
+
+	assert.NoError(t, dl.Deliver(ctx))
+	repo.AssertExpectations(t)
+}
+
+func TestSend_RefusesPrivateAddress(t *testing.T) {
+	var hit bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+	endpoint := &domain.Endpoint{ID: common.NewRandomID(), URL: srv.URL, Secret: "whsec_test"}
+	d := testDelivery(endpoint.ID, 0)
+
+	dl := NewDeliverer(new(MockRepo)).(*deliverer)
+	status, err := dl.send(ctx, endpoint, &d)
+
+	assert.ErrorIs(t, err, domain.ErrPrivateURL)
+	assert.Zero(t, status)
+	assert.False(t, hit)
+}
+
+func TestSend_DoesNotFollowRedirects(t *testing.T) {
+	var redirected bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	endpoint := &domain.Endpoint{ID: common.NewRandomID(), URL: srv.URL + "/hooks", Secret: "whsec_test"}
+	d := testDelivery(endpoint.ID, 0)
+
+	dl := newTestDeliverer(new(MockRepo), time.Now())
+	status, err := dl.send(ctx, endpoint, &d)
+
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusTemporaryRedirect, status)
+	assert.False(t, redirected)
+}
+
+func TestRetryDelay_Capped(t *testing.T) {
+	dl := NewDeliverer(new(MockRepo), WithBackoff(time.Hour)).(*deliverer)
+	assert.Equal(t, time.Hour, dl.retryDelay(1))
+	assert.Equal(t, 4*time.Hour, dl.retryDelay(3))
+	assert.Equal(t, maxBackoff, dl.retryDelay(10))
+}
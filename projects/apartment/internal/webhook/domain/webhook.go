@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+)
+
+var (
+	ErrInvalidURL       = errors.New("webhook url must be an absolute http or https url")
+	ErrPrivateURL       = errors.New("webhook url must point to a public address")
+	ErrNoEventTypes     = errors.New("webhook must subscribe to at least one event type")
+	ErrUnknownEventType = errors.New("unknown webhook event type")
+)
+
+// EventTypes are the events endpoints can subscribe to.
+var EventTypes = []eventd.Type{
+	eventd.BillCreated,
+	eventd.BillOverdue,
+	eventd.PaymentSucceeded,
+	eventd.MemberJoined,
+}
+
+// Endpoint receives the events of an apartment it subscribed to.
+type Endpoint struct {
+	ID          common.ID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ApartmentID common.ID
+	URL         string
+	// Secret signs the deliveries so the endpoint can verify them.
+	Secret     string
+	EventTypes []eventd.Type
+}
+
+// Validate checks the URL and event types of e. Hostnames are resolved and
+// checked when the endpoint is created, and again on every delivery.
+func (e *Endpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return ErrPrivateURL
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !PublicAddr(addr) {
+		return ErrPrivateURL
+	}
+	if len(e.EventTypes) == 0 {
+		return ErrNoEventTypes
+	}
+	for _, t := range e.EventTypes {
+		if !slices.Contains(EventTypes, t) {
+			return ErrUnknownEventType
+		}
+	}
+	return nil
+}
+
+// PublicAddr reports whether webhooks may be sent to addr. Loopback,
+// private, link-local and unspecified addresses would let an apartment admin
+// reach the services next to the app.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsUnspecified()
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed ran out of attempts.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+func (s DeliveryStatus) String() string {
+	return string(s)
+}
+
+// Delivery is one event sent to one endpoint.
+type Delivery struct {
+	ID         common.ID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	EndpointID common.ID
+	EventID    common.ID
+	EventType  eventd.Type
+	// Payload is the JSON body posted to the endpoint.
+	Payload json.RawMessage
+	Status  DeliveryStatus
+	// Attempts counts the failed attempts.
+	Attempts      int
+	NextAttemptAt time.Time
+	// ResponseStatus is the HTTP status of the last attempt, zero if the
+	// endpoint couldn't be reached.
+	ResponseStatus int
+	LastError      string
+	DeliveredAt    *time.Time
+}
+
+// Body is the JSON posted to endpoints.
+type Body struct {
+	// ID of the event, the same on every delivery of it.
+	ID          common.ID       `json:"id"`
+	Type        eventd.Type     `json:"type"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	ApartmentID common.ID       `json:"apartmentId"`
+	Data        json.RawMessage `json:"data"`
+}
@@ -0,0 +1,56 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+)
+
+type Service interface {
+	CreateEndpoint(ctx context.Context, adminID common.ID, e *domain.Endpoint) (*domain.Endpoint, error)
+	Endpoints(ctx context.Context, adminID, apartmentID common.ID) ([]domain.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, adminID, endpointID common.ID) error
+	Deliveries(ctx context.Context, adminID, endpointID common.ID) ([]domain.Delivery, error)
+	// Redeliver sends a delivery of the endpoint again.
+	Redeliver(ctx context.Context, adminID, endpointID, deliveryID common.ID) (*domain.Delivery, error)
+	// Enqueue is an event subscriber that queues a delivery of e to every
+	// endpoint subscribed to it.
+	Enqueue(ctx context.Context, e eventd.Event) error
+}
+
+type Deliverer interface {
+	// Run delivers pending deliveries periodically until ctx is done.
+	Run(ctx context.Context)
+	// Deliver sends the deliveries due now.
+	Deliver(ctx context.Context) error
+}
+
+type Repo interface {
+	ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error)
+	BillApartment(ctx context.Context, billID common.ID) (common.ID, error)
+	CreateEndpoint(ctx context.Context, e *domain.Endpoint) (*domain.Endpoint, error)
+	GetEndpoint(ctx context.Context, id common.ID) (*domain.Endpoint, error)
+	Endpoints(ctx context.Context, apartmentID common.ID) ([]domain.Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id common.ID) error
+	// SubscribedEndpoints returns the endpoints of the apartment subscribed
+	// to events of type t.
+	SubscribedEndpoints(ctx context.Context, apartmentID common.ID, t eventd.Type) ([]domain.Endpoint, error)
+	// CreateDeliveries stores ds, skipping the ones already queued for the
+	// same endpoint and event.
+	CreateDeliveries(ctx context.Context, ds []domain.Delivery) error
+	GetDelivery(ctx context.Context, id common.ID) (*domain.Delivery, error)
+	// Deliveries returns the latest deliveries of an endpoint, newest first.
+	Deliveries(ctx context.Context, endpointID common.ID, limit int) ([]domain.Delivery, error)
+	// ClaimDeliveries returns up to limit pending deliveries due by now,
+	// oldest first, and holds them until leaseUntil.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Delivery, error)
+	MarkDelivered(ctx context.Context, id common.ID, responseStatus int) error
+	// MarkFailed records a failed attempt. The delivery is retried at
+	// retryAt, or given up on if retryAt is nil.
+	MarkFailed(ctx context.Context, id common.ID, responseStatus int, reason string, retryAt *time.Time) error
+	// ResetDelivery makes a delivery pending and due now, with no attempts.
+	ResetDelivery(ctx context.Context, id common.ID) (*domain.Delivery, error)
+}
@@ -0,0 +1,284 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
+)
+
+var (
+	ErrOnCreateEndpoint   = errors.New("error on create webhook")
+	ErrOnGetEndpoints     = errors.New("error on get webhooks")
+	ErrOnDeleteEndpoint   = errors.New("error on delete webhook")
+	ErrOnGetDeliveries    = errors.New("error on get webhook deliveries")
+	ErrOnRedeliver        = errors.New("error on redeliver webhook")
+	ErrOnEnqueue          = errors.New("error on enqueue webhook deliveries")
+	ErrNotAdmin           = errors.New("only the apartment admin can manage its webhooks")
+	ErrApartmentNotFound  = errors.New("apartment not found")
+	ErrEndpointNotFound   = errors.New("webhook not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrDeliveryInProgress = errors.New("webhook delivery is still pending")
+	ErrUnknownHost        = errors.New("webhook url host doesn't resolve")
+)
+
+// secretPrefix marks webhook secrets, e.g. in leaked config files.
+const secretPrefix = "whsec_"
+
+// deliveriesLimit bounds the delivery log returned for an endpoint.
+const deliveriesLimit = 100
+
+// LookupFunc resolves a hostname to its addresses.
+type LookupFunc func(ctx context.Context, host string) ([]netip.Addr, error)
+
+type service struct {
+	repo   port.Repo
+	lookup LookupFunc
+}
+
+type ServiceOpt func(*service)
+
+// WithLookup sets how endpoint hostnames are resolved, by default with the
+// system resolver.
+func WithLookup(lookup LookupFunc) ServiceOpt {
+	return func(s *service) {
+		if lookup != nil {
+			s.lookup = lookup
+		}
+	}
+}
+
+func NewService(repo port.Repo, opts ...ServiceOpt) port.Service {
+	s := &service{
+		repo: repo,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) CreateEndpoint(
+	ctx context.Context, adminID common.ID, e *domain.Endpoint,
+) (
+	*domain.Endpoint, error,
+) {
+	if err := e.Validate(); err != nil {
+		return nil, fp.WrapErrors(ErrOnCreateEndpoint, err)
+	}
+	if err := s.checkHost(ctx, e.URL); err != nil {
+		return nil, fp.WrapErrors(ErrOnCreateEndpoint, err)
+	}
+	if err := s.checkAdmin(ctx, adminID, e.ApartmentID); err != nil {
+		return nil, fp.WrapErrors(ErrOnCreateEndpoint, err)
+	}
+	if e.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, fp.WrapErrors(ErrOnCreateEndpoint, err)
+		}
+		e.Secret = secret
+	}
+	e, err := s.repo.CreateEndpoint(ctx, e)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnCreateEndpoint, err)
+	}
+	return e, nil
+}
+
+// checkHost rejects a validated endpoint URL whose host resolves to an
+// address webhooks may not be sent to.
+func (s *service) checkHost(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return domain.ErrInvalidURL
+	}
+	host := u.Hostname()
+	if _, err := netip.ParseAddr(host); err == nil {
+		// Validate checked the address
+		return nil
+	}
+	addrs, err := s.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrUnknownHost
+	}
+	for _, addr := range addrs {
+		if !domain.PublicAddr(addr) {
+			return domain.ErrPrivateURL
+		}
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+func (s *service) Endpoints(
+	ctx context.Context, adminID, apartmentID common.ID,
+) (
+	[]domain.Endpoint, error,
+) {
+	if err := s.checkAdmin(ctx, adminID, apartmentID); err != nil {
+		return nil, fp.WrapErrors(ErrOnGetEndpoints, err)
+	}
+	es, err := s.repo.Endpoints(ctx, apartmentID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetEndpoints, err)
+	}
+	return es, nil
+}
+
+func (s *service) DeleteEndpoint(ctx context.Context, adminID, endpointID common.ID) error {
+	if _, err := s.adminEndpoint(ctx, adminID, endpointID); err != nil {
+		return fp.WrapErrors(ErrOnDeleteEndpoint, err)
+	}
+	if err := s.repo.DeleteEndpoint(ctx, endpointID); err != nil {
+		return fp.WrapErrors(ErrOnDeleteEndpoint, err)
+	}
+	return nil
+}
+
+func (s *service) Deliveries(
+	ctx context.Context, adminID, endpointID common.ID,
+) (
+	[]domain.Delivery, error,
+) {
+	if _, err := s.adminEndpoint(ctx, adminID, endpointID); err != nil {
+		return nil, fp.WrapErrors(ErrOnGetDeliveries, err)
+	}
+	ds, err := s.repo.Deliveries(ctx, endpointID, deliveriesLimit)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnGetDeliveries, err)
+	}
+	return ds, nil
+}
+
+func (s *service) Redeliver(
+	ctx context.Context, adminID, endpointID, deliveryID common.ID,
+) (
+	*domain.Delivery, error,
+) {
+	if _, err := s.adminEndpoint(ctx, adminID, endpointID); err != nil {
+		return nil, fp.WrapErrors(ErrOnRedeliver, err)
+	}
+	d, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRedeliver, err)
+	}
+	if d.EndpointID != endpointID {
+		return nil, fp.WrapErrors(ErrOnRedeliver, ErrDeliveryNotFound)
+	}
+	if d.Status == domain.DeliveryPending {
+		return nil, fp.WrapErrors(ErrOnRedeliver, ErrDeliveryInProgress)
+	}
+	d, err = s.repo.ResetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, fp.WrapErrors(ErrOnRedeliver, err)
+	}
+	return d, nil
+}
+
+// Enqueue queues a delivery of e to every endpoint of its apartment
+// subscribed to its type. Redelivered events are queued once per endpoint.
+func (s *service) Enqueue(ctx context.Context, e eventd.Event) error {
+	if !slices.Contains(domain.EventTypes, e.Type) {
+		return nil
+	}
+	apartmentID, err := s.eventApartment(ctx, e)
+	if err != nil {
+		return fp.WrapErrors(ErrOnEnqueue, err)
+	}
+	endpoints, err := s.repo.SubscribedEndpoints(ctx, apartmentID, e.Type)
+	if err != nil {
+		return fp.WrapErrors(ErrOnEnqueue, err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(&domain.Body{
+		ID:          e.ID,
+		Type:        e.Type,
+		CreatedAt:   e.CreatedAt,
+		ApartmentID: apartmentID,
+		Data:        e.Payload,
+	})
+	if err != nil {
+		return fp.WrapErrors(ErrOnEnqueue, err)
+	}
+	ds := make([]domain.Delivery, 0, len(endpoints))
+	for _, ep := range endpoints {
+		ds = append(ds, domain.Delivery{
+			EndpointID: ep.ID,
+			EventID:    e.ID,
+			EventType:  e.Type,
+			Payload:    payload,
+			Status:     domain.DeliveryPending,
+		})
+	}
+	if err = s.repo.CreateDeliveries(ctx, ds); err != nil {
+		return fp.WrapErrors(ErrOnEnqueue, err)
+	}
+	return nil
+}
+
+// eventApartment returns the apartment e happened in, looking it up by
+// the bill for events that carry only the bill.
+func (s *service) eventApartment(ctx context.Context, e eventd.Event) (common.ID, error) {
+	var ref struct {
+		ApartmentID common.ID `json:"apartmentId"`
+		BillID      common.ID `json:"billId"`
+	}
+	if err := e.Decode(&ref); err != nil {
+		return common.NilID, err
+	}
+	if ref.ApartmentID != common.NilID {
+		return ref.ApartmentID, nil
+	}
+	return s.repo.BillApartment(ctx, ref.BillID)
+}
+
+func (s *service) checkAdmin(ctx context.Context, adminID, apartmentID common.ID) error {
+	id, err := s.repo.ApartmentAdmin(ctx, apartmentID)
+	if err != nil {
+		return err
+	}
+	if id != adminID {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+// adminEndpoint returns the endpoint if adminID administers its apartment.
+// Endpoints of other apartments are reported as not found.
+func (s *service) adminEndpoint(ctx context.Context, adminID, endpointID common.ID) (*domain.Endpoint, error) {
+	e, err := s.repo.GetEndpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkAdmin(ctx, adminID, e.ApartmentID); err != nil {
+		if errors.Is(err, ErrNotAdmin) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, err
+	}
+	return e, nil
+}
@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var (
+	log = logger.NewConsoleZapLogger(logger.ModeDevelopment)
+	ctx = appctx.New(context.Background(), appctx.WithLogger(log))
+)
+
+// ----------- Mocks -------------
+
+// lookup resolves internal.example.com to a private address and other
+// hosts to a public one, without DNS.
+func lookup(_ context.Context, host string) ([]netip.Addr, error) {
+	switch host {
+	case "internal.example.com":
+		return []netip.Addr{netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.5")}, nil
+	case "unknown.example.com":
+		return nil, errors.New("no such host")
+	}
+	return []netip.Addr{netip.MustParseAddr("93.184.215.14")}, nil
+}
+
+type MockRepo struct {
+	mock.Mock
+	port.Repo
+}
+
+func (m *MockRepo) ApartmentAdmin(ctx context.Context, apartmentID common.ID) (common.ID, error) {
+	args := m.Called(ctx, apartmentID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) BillApartment(ctx context.Context, billID common.ID) (common.ID, error) {
+	args := m.Called(ctx, billID)
+	return args.Get(0).(common.ID), args.Error(1)
+}
+
+func (m *MockRepo) CreateEndpoint(ctx context.Context, e *domain.Endpoint) (*domain.Endpoint, error) {
+	args := m.Called(ctx, e)
+	res, _ := args.Get(0).(*domain.Endpoint)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) GetEndpoint(ctx context.Context, id common.ID) (*domain.Endpoint, error) {
+	args := m.Called(ctx, id)
+	res, _ := args.Get(0).(*domain.Endpoint)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) DeleteEndpoint(ctx context.Context, id common.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) SubscribedEndpoints(ctx context.Context, apartmentID common.ID, t eventd.Type) ([]domain.Endpoint, error) {
+	args := m.Called(ctx, apartmentID, t)
+	res, _ := args.Get(0).([]domain.Endpoint)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) CreateDeliveries(ctx context.Context, ds []domain.Delivery) error {
+	args := m.Called(ctx, ds)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetDelivery(ctx context.Context, id common.ID) (*domain.Delivery, error) {
+	args := m.Called(ctx, id)
+	res, _ := args.Get(0).(*domain.Delivery)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Delivery, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	res, _ := args.Get(0).([]domain.Delivery)
+	return res, args.Error(1)
+}
+
+func (m *MockRepo) MarkDelivered(ctx context.Context, id common.ID, responseStatus int) error {
+	args := m.Called(ctx, id, responseStatus)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkFailed(ctx context.Context, id common.ID, responseStatus int, reason string, retryAt *time.Time) error {
+	args := m.Called(ctx, id, responseStatus, reason, retryAt)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ResetDelivery(ctx context.Context, id common.ID) (*domain.Delivery, error) {
+	args := m.Called(ctx, id)
+	res, _ := args.Get(0).(*domain.Delivery)
+	return res, args.Error(1)
+}
+
+// ----------- Tests -------------
+
+func TestCreateEndpoint_GeneratesSecret(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, WithLookup(lookup))
+
+	adminID, apartmentID := common.NewRandomID(), common.NewRandomID()
+	e := &domain.Endpoint{
+		ApartmentID: apartmentID,
+		URL:         "https://example.com/hooks",
+		EventTypes:  []eventd.Type{eventd.BillCreated},
+	}
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(adminID, nil)
+	repo.On("CreateEndpoint", ctx, mock.MatchedBy(func(e *domain.Endpoint) bool {
+		return strings.HasPrefix(e.Secret, secretPrefix) && len(e.Secret) == len(secretPrefix)+64
+	})).Return(e, nil)
+
+	got, err := svc.CreateEndpoint(ctx, adminID, e)
+	assert.NoError(t, err)
+	assert.Equal(t, e, got)
+	repo.AssertExpectations(t)
+}
+
+func TestCreateEndpoint_Invalid(t *testing.T) {
+	svc := NewService(new(MockRepo), WithLookup(lookup))
+	adminID := common.NewRandomID()
+
+	tests := []struct {
+		name     string
+		endpoint domain.Endpoint
+		err      error
+	}{
+		{"relative url", domain.Endpoint{URL: "/hooks", EventTypes: domain.EventTypes}, domain.ErrInvalidURL},
+		{"ftp url", domain.Endpoint{URL: "ftp://example.com", EventTypes: domain.EventTypes}, domain.ErrInvalidURL},
+		{"no events", domain.Endpoint{URL: "https://example.com"}, domain.ErrNoEventTypes},
+		{
+			"private event",
+			domain.Endpoint{URL: "https://example.com", EventTypes: []eventd.Type{eventd.InviteCreated}},
+			domain.ErrUnknownEventType,
+		},
+		{"loopback url", domain.Endpoint{URL: "http://127.0.0.1:8080/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"ipv6 loopback url", domain.Endpoint{URL: "http://[::1]/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"localhost url", domain.Endpoint{URL: "http://localhost/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"private url", domain.Endpoint{URL: "https://10.1.2.3/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"link-local url", domain.Endpoint{URL: "http://169.254.169.254/latest", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"unspecified url", domain.Endpoint{URL: "http://0.0.0.0/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"mapped private url", domain.Endpoint{URL: "http://[::ffff:192.168.1.1]/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"host resolves to private", domain.Endpoint{URL: "https://internal.example.com/hooks", EventTypes: domain.EventTypes}, domain.ErrPrivateURL},
+		{"host doesn't resolve", domain.Endpoint{URL: "https://unknown.example.com/hooks", EventTypes: domain.EventTypes}, ErrUnknownHost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateEndpoint(ctx, adminID, &tt.endpoint)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestCreateEndpoint_NotAdmin(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo, WithLookup(lookup))
+
+	apartmentID := common.NewRandomID()
+	repo.On("ApartmentAdmin", ctx, apartmentID).Return(common.NewRandomID(), nil)
+
+	_, err := svc.CreateEndpoint(ctx, common.NewRandomID(), &domain.Endpoint{
+		ApartmentID: apartmentID,
+		URL:         "https://example.com/hooks",
+		EventTypes:  []eventd.Type{eventd.MemberJoined},
+	})
+	assert.ErrorIs(t, err, ErrNotAdmin)
+	repo.AssertNotCalled(t, "CreateEndpoint", mock.Anything, mock.Anything)
+}
+
+func TestDeleteEndpoint_OtherApartmentNotFound(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	e := &domain.Endpoint{ID: common.NewRandomID(), ApartmentID: common.NewRandomID()}
+	repo.On("GetEndpoint", ctx, e.ID).Return(e, nil)
+	repo.On("ApartmentAdmin", ctx, e.ApartmentID).Return(common.NewRandomID(), nil)
+
+	err := svc.DeleteEndpoint(ctx, common.NewRandomID(), e.ID)
+	assert.ErrorIs(t, err, ErrEndpointNotFound)
+	repo.AssertNotCalled(t, "DeleteEndpoint", mock.Anything, mock.Anything)
+}
+
+func TestRedeliver(t *testing.T) {
+	adminID := common.NewRandomID()
+	e := &domain.Endpoint{ID: common.NewRandomID(), ApartmentID: common.NewRandomID()}
+
+	t.Run("failed delivery", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewService(repo)
+		d := &domain.Delivery{ID: common.NewRandomID(), EndpointID: e.ID, Status: domain.DeliveryFailed}
+		reset := &domain.Delivery{ID: d.ID, EndpointID: e.ID, Status: domain.DeliveryPending}
+
+		repo.On("GetEndpoint", ctx, e.ID).Return(e, nil)
+		repo.On("ApartmentAdmin", ctx, e.ApartmentID).Return(adminID, nil)
+		repo.On("GetDelivery", ctx, d.ID).Return(d, nil)
+		repo.On("ResetDelivery", ctx, d.ID).Return(reset, nil)
+
+		got, err := svc.Redeliver(ctx, adminID, e.ID, d.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, reset, got)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("pending delivery", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewService(repo)
+		d := &domain.Delivery{ID: common.NewRandomID(), EndpointID: e.ID, Status: domain.DeliveryPending}
+
+		repo.On("GetEndpoint", ctx, e.ID).Return(e, nil)
+		repo.On("ApartmentAdmin", ctx, e.ApartmentID).Return(adminID, nil)
+		repo.On("GetDelivery", ctx, d.ID).Return(d, nil)
+
+		_, err := svc.Redeliver(ctx, adminID, e.ID, d.ID)
+		assert.ErrorIs(t, err, ErrDeliveryInProgress)
+		repo.AssertNotCalled(t, "ResetDelivery", mock.Anything, mock.Anything)
+	})
+
+	t.Run("delivery of another endpoint", func(t *testing.T) {
+		repo := new(MockRepo)
+		svc := NewService(repo)
+		d := &domain.Delivery{ID: common.NewRandomID(), EndpointID: common.NewRandomID(), Status: domain.DeliveryFailed}
+
+		repo.On("GetEndpoint", ctx, e.ID).Return(e, nil)
+		repo.On("ApartmentAdmin", ctx, e.ApartmentID).Return(adminID, nil)
+		repo.On("GetDelivery", ctx, d.ID).Return(d, nil)
+
+		_, err := svc.Redeliver(ctx, adminID, e.ID, d.ID)
+		assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	})
+}
+
+func TestEnqueue_ResolvesApartmentByBill(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	billID, apartmentID := common.NewRandomID(), common.NewRandomID()
+	e, err := eventd.New(eventd.PaymentSucceeded, common.NewRandomID(), &eventd.PaymentSucceededPayload{
+		BillID: billID,
+		Amount: 1000,
+	})
+	assert.NoError(t, err)
+	e.ID = common.NewRandomID()
+	endpoints := []domain.Endpoint{{ID: common.NewRandomID()}, {ID: common.NewRandomID()}}
+
+	repo.On("BillApartment", ctx, billID).Return(apartmentID, nil)
+	repo.On("SubscribedEndpoints", ctx, apartmentID, eventd.PaymentSucceeded).Return(endpoints, nil)
+	repo.On("CreateDeliveries", ctx, mock.MatchedBy(func(ds []domain.Delivery) bool {
+		if len(ds) != 2 || ds[0].EndpointID != endpoints[0].ID || ds[1].EndpointID != endpoints[1].ID {
+			return false
+		}
+		var body domain.Body
+		if err := json.Unmarshal(ds[0].Payload, &body); err != nil {
+			return false
+		}
+		return ds[0].EventID == e.ID && body.ID == e.ID && body.ApartmentID == apartmentID &&
+			body.Type == eventd.PaymentSucceeded
+	})).Return(nil)
+
+	assert.NoError(t, svc.Enqueue(ctx, *e))
+	repo.AssertExpectations(t)
+}
+
+func TestEnqueue_IgnoresPrivateEvents(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	e, err := eventd.New(eventd.InviteCreated, common.NewRandomID(), &eventd.InviteCreatedPayload{
+		ApartmentID: common.NewRandomID(),
+		Token:       "secret-token",
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.Enqueue(ctx, *e))
+	repo.AssertNotCalled(t, "SubscribedEndpoints", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnqueue_NoSubscribers(t *testing.T) {
+	repo := new(MockRepo)
+	svc := NewService(repo)
+
+	apartmentID := common.NewRandomID()
+	e, err := eventd.New(eventd.MemberJoined, common.NewRandomID(), &eventd.MemberJoinedPayload{
+		ApartmentID: apartmentID,
+	})
+	assert.NoError(t, err)
+
+	repo.On("SubscribedEndpoints", ctx, apartmentID, eventd.MemberJoined).Return([]domain.Endpoint{}, nil)
+
+	assert.NoError(t, svc.Enqueue(ctx, *e))
+	repo.AssertNotCalled(t, "CreateDeliveries", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,49 @@
+package identity
+
+import (
+	"context"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc"
+)
+
+type oidcProvider struct {
+	client *oidc.Client
+}
+
+func NewOIDCProvider(client *oidc.Client) port.OIDCProvider {
+	return &oidcProvider{client: client}
+}
+
+func (p *oidcProvider) AuthURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	return p.client.AuthCodeURL(ctx, state, nonce, codeChallenge)
+}
+
+func (p *oidcProvider) Identity(
+	ctx context.Context, code, codeVerifier, nonce string,
+) (
+	*domain.OIDCIdentity, error,
+) {
+	idToken, err := p.client.Exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.client.Verify(ctx, idToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	firstName := claims.GivenName
+	if firstName == "" && claims.FamilyName == "" {
+		firstName = claims.Name
+	}
+	return &domain.OIDCIdentity{
+		Issuer:        p.client.Issuer(),
+		Subject:       claims.Subject,
+		Email:         common.Email(claims.Email),
+		EmailVerified: bool(claims.EmailVerified),
+		FirstName:     firstName,
+		LastName:      claims.FamilyName,
+	}, nil
+}
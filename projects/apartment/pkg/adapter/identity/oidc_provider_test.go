@@ -0,0 +1,51 @@
+package identity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCProvider_Identity(t *testing.T) {
+	ctx := context.Background()
+	p := oidctest.NewProvider("apartment", "secret")
+	defer p.Close()
+	provider := NewOIDCProvider(oidc.NewClient(oidc.Config{
+		Issuer:       p.Issuer(),
+		ClientID:     "apartment",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+	}, p.Client()))
+
+	verifier := "a-verifier-of-forty-three-characters-at-least"
+	authURL, err := provider.AuthURL(ctx, "state", "nonce", oidc.CodeChallenge(verifier))
+	require.NoError(t, err)
+	code, _, err := p.Authorize(authURL, oidctest.User{
+		Subject:       "42",
+		Email:         "bob@example.com",
+		EmailVerified: true,
+		GivenName:     "Bob",
+		FamilyName:    "Jones",
+	})
+	require.NoError(t, err)
+
+	i, err := provider.Identity(ctx, code, verifier, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, p.Issuer(), i.Issuer)
+	assert.Equal(t, "42", i.Subject)
+	assert.Equal(t, common.Email("bob@example.com"), i.Email)
+	assert.True(t, i.EmailVerified)
+	assert.Equal(t, "Bob", i.FirstName)
+	assert.Equal(t, "Jones", i.LastName)
+
+	// the nonce of another sign-in
+	code, _, err = p.Authorize(authURL, oidctest.User{Subject: "42"})
+	require.NoError(t, err)
+	_, err = provider.Identity(ctx, code, verifier, "other-nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
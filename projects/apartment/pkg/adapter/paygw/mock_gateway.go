@@ -1,13 +1,18 @@
 package paygw
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
 	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
 )
@@ -15,10 +20,42 @@ import (
 var (
 	ErrMissingToken       = errors.New("missing token")
 	ErrPaymentNotComplete = errors.New("payment not complete")
+	ErrPaymentDeclined    = errors.New("payment declined")
+	ErrPaymentCancelled   = errors.New("payment cancelled by user")
+	ErrAmountMismatch     = errors.New("captured amount does not match the transaction")
+	ErrMissingTransaction = errors.New("missing transaction id")
+	ErrUnknownTransaction = errors.New("unknown transaction")
 )
 
+// requestTimeout bounds each request to the gateway, so a hanging gateway
+// doesn't hold up a callback or the reconciler.
+const requestTimeout = 10 * time.Second
+
+const (
+	// transactionTTL is how long a transaction that is never verified is
+	// remembered, well past the time the reconciler gives up on it.
+	transactionTTL = 24 * time.Hour
+	// verifiedTTL is how long a verified transaction is remembered, so a
+	// repeated callback of the same capture still verifies.
+	verifiedTTL = 10 * time.Minute
+)
+
+// mockTransaction is a transaction created on the gateway.
+type mockTransaction struct {
+	amount    int64
+	expiresAt time.Time
+}
+
 type mockGateway struct {
 	gatewayBaseURL *url.URL
+	client         *http.Client
+
+	// transactions remembers the requested amount of each transaction so
+	// a capture of a different amount, or of a transaction this gateway
+	// didn't create, is caught.
+	mu           sync.Mutex
+	transactions map[string]mockTransaction
+	now          func() time.Time
 }
 
 func MustNewMockGateway(GatewayBaseURL string) paymentp.Gateway {
@@ -36,6 +73,9 @@ func NewMockGateway(GatewayBaseURL string) (paymentp.Gateway, error) {
 	}
 	return &mockGateway{
 		gatewayBaseURL: gbu,
+		client:         &http.Client{Timeout: requestTimeout},
+		transactions:   make(map[string]mockTransaction),
+		now:            time.Now,
 	}, nil
 }
 
@@ -55,18 +95,28 @@ func (g *mockGateway) CreateTransaction(
 	if _, err = url.ParseQuery(callbackURL.RawQuery); err != nil {
 		return nil, err
 	}
+	scenario := MockScenario(tx.Metadata[MockScenarioKey])
+	if scenario != "" && !scenario.IsValid() {
+		return nil, ErrUnknownScenario
+	}
 
+	transactionID := common.NewRandomID().String()
 	body, err := makeMapBody(&dto.PayRequest{
-		Amount:      tx.Amount,
-		CallbackURL: callbackURL.String(),
+		Amount:        tx.Amount,
+		CallbackURL:   callbackURL.String(),
+		TransactionID: transactionID,
+		Scenario:      scenario.String(),
+		Delay:         tx.Metadata[MockDelayKey],
 	})
 	if err != nil {
 		return nil, err
 	}
+	g.remember(transactionID, tx.Amount)
 	return &paymentd.RedirectGateway{
-		Method: http.MethodPost,
-		URL:    gatewayURL.String(),
-		Body:   body,
+		Method:        http.MethodPost,
+		URL:           gatewayURL.String(),
+		Body:          body,
+		TransactionID: transactionID,
 	}, nil
 }
 
@@ -85,29 +135,179 @@ func makeMapBody(body any) (map[string]any, error) {
 func (g *mockGateway) VerifyTransaction(
 	ctx context.Context,
 	data map[string][]string,
-) error {
+) (*paymentd.Capture, error) {
 	token, ok := data["token"]
 	if !ok {
-		return ErrMissingToken
+		return nil, ErrMissingToken
+	}
+	ids := data["transactionId"]
+	if len(ids) == 0 || ids[0] == "" {
+		return nil, ErrMissingTransaction
 	}
+	transactionID := ids[0]
+	query := url.Values{"token": token, "transactionId": {transactionID}}
 	verifyURL := *g.gatewayBaseURL
 	verifyURL.Path = "/api/v1/payment/mock-gateway/verify"
-	verifyURL.RawQuery = url.Values{"token": token}.Encode()
+	verifyURL.RawQuery = query.Encode()
 
-	resp, err := http.Get(verifyURL.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, verifyURL.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var respBody dto.VerifyResponse
 	err = json.NewDecoder(resp.Body).Decode(&respBody)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	switch respBody.Code {
+	case dto.VerifyOK:
+	case dto.VerifyDeclined:
+		return nil, ErrPaymentDeclined
+	case dto.VerifyCancelled:
+		return nil, ErrPaymentCancelled
+	default:
+		return nil, ErrPaymentNotComplete
+	}
+	if err = g.checkAmount(transactionID, respBody.Amount); err != nil {
+		return nil, err
+	}
+	return &paymentd.Capture{TransactionID: transactionID, Amount: respBody.Amount}, nil
+}
+
+// remember records a new transaction and forgets the expired ones.
+func (g *mockGateway) remember(transactionID string, amount int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	for id, t := range g.transactions {
+		if !now.Before(t.expiresAt) {
+			delete(g.transactions, id)
+		}
 	}
-	if respBody.Code != 0 {
-		return ErrPaymentNotComplete
+	g.transactions[transactionID] = mockTransaction{
+		amount:    amount,
+		expiresAt: now.Add(transactionTTL),
 	}
+}
 
+// checkAmount compares a captured amount with the requested one. A
+// transaction that matches is forgotten after verifiedTTL; a wrong capture
+// is kept for someone to look at until it expires.
+func (g *mockGateway) checkAmount(transactionID string, captured int64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	t, ok := g.transactions[transactionID]
+	if !ok || !now.Before(t.expiresAt) {
+		return ErrUnknownTransaction
+	}
+	if captured != t.amount {
+		return ErrAmountMismatch
+	}
+	if expiresAt := now.Add(verifiedTTL); expiresAt.Before(t.expiresAt) {
+		t.expiresAt = expiresAt
+		g.transactions[transactionID] = t
+	}
 	return nil
 }
+
+// forget drops a transaction that can't be captured anymore.
+func (g *mockGateway) forget(transactionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.transactions, transactionID)
+}
+
+func (g *mockGateway) InquireTransaction(
+	ctx context.Context,
+	transactionID string,
+) (
+	paymentd.PaymentStatus, error,
+) {
+	inquiryURL := *g.gatewayBaseURL
+	inquiryURL.Path = "/api/v1/payment/mock-gateway/inquiry"
+	inquiryURL.RawQuery = url.Values{"transactionId": {transactionID}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, inquiryURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		g.forget(transactionID)
+		return "", paymentd.ErrTransactionNotFound
+	case resp.StatusCode >= 400:
+		return "", fmt.Errorf("mock gateway inquiry: %s", resp.Status)
+	}
+
+	var respBody dto.InquiryResponse
+	if err = json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return "", err
+	}
+	status := paymentd.PaymentStatus(respBody.Status)
+	switch status {
+	case paymentd.PaymentPaid:
+		// a wrong capture is left pending for someone to look at
+		if err = g.checkAmount(transactionID, respBody.Amount); err != nil {
+			return "", err
+		}
+	case paymentd.PaymentFailed, paymentd.PaymentCancelled:
+		g.forget(transactionID)
+	}
+	return status, nil
+}
+
+func (g *mockGateway) RefundTransaction(
+	ctx context.Context,
+	transactionID string,
+	amount int64,
+) (
+	string, error,
+) {
+	refundURL := *g.gatewayBaseURL
+	refundURL.Path = "/api/v1/payment/mock-gateway/refund"
+
+	body, err := json.Marshal(&dto.GatewayRefundRequest{
+		TransactionID: transactionID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, refundURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", paymentd.ErrTransactionNotFound
+	case resp.StatusCode >= 400:
+		return "", fmt.Errorf("mock gateway refund: %s", resp.Status)
+	}
+
+	var respBody dto.GatewayRefundResponse
+	if err = json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return "", err
+	}
+	g.forget(transactionID)
+	return respBody.RefundID, nil
+}
@@ -0,0 +1,398 @@
+package paygw_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/handler"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/paygw"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// log discards the logs, which would otherwise go to the default logger
+// and its file in the package directory.
+var log = zap.NewNop()
+
+// memRepo keeps payments in memory for the calls a payment makes on its
+// way through the gateway.
+type memRepo struct {
+	port.Repo
+	mu       sync.Mutex
+	payments map[common.ID]*domain.Payment
+}
+
+func (r *memRepo) UserBillBalanceDue(ctx context.Context, userID, billID common.ID) (int64, error) {
+	return 10000, nil
+}
+
+func (r *memRepo) CreatePayment(ctx context.Context, p *domain.Payment) (*domain.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.ID = common.NewRandomID()
+	p.CreatedAt = time.Now()
+	r.payments[p.ID] = p
+	return p, nil
+}
+
+func (r *memRepo) SetTransactionID(ctx context.Context, ids []common.ID, transactionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		r.payments[id].TransactionID = transactionID
+	}
+	return nil
+}
+
+func (r *memRepo) SettlePending(ctx context.Context, ids []common.ID, s domain.PaymentStatus) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for _, id := range ids {
+		if p, ok := r.payments[id]; ok && p.Status == domain.PaymentPending {
+			p.Status = s
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) SettleCaptured(
+	ctx context.Context, ids []common.ID, gt domain.GatewayType, c domain.Capture,
+) (
+	int64, []common.ID, error,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, id := range ids {
+		p, ok := r.payments[id]
+		if !ok || p.IsRefund() || p.TransactionID != c.TransactionID || p.Gateway != gt.String() {
+			return 0, nil, payment.ErrCaptureMismatch
+		}
+		total += p.Amount
+	}
+	if total != c.Amount {
+		return 0, nil, payment.ErrCaptureMismatch
+	}
+	var (
+		n       int64
+		expired []common.ID
+	)
+	for _, id := range ids {
+		p := r.payments[id]
+		if p.Status != domain.PaymentPending && p.Status != domain.PaymentExpired {
+			continue
+		}
+		if p.Status == domain.PaymentExpired {
+			expired = append(expired, id)
+		}
+		p.Status = domain.PaymentPaid
+		n++
+	}
+	return n, expired, nil
+}
+
+func (r *memRepo) PendingPayments(ctx context.Context, createdBefore time.Time) ([]*domain.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ps []*domain.Payment
+	for _, p := range r.payments {
+		if p.Status == domain.PaymentPending {
+			cp := *p
+			ps = append(ps, &cp)
+		}
+	}
+	return ps, nil
+}
+
+func (r *memRepo) GetPayment(ctx context.Context, id common.ID) (*domain.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *r.payments[id]
+	return &cp, nil
+}
+
+func (r *memRepo) setStatus(id common.ID, s domain.PaymentStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payments[id].Status = s
+}
+
+func (r *memRepo) status(id common.ID) domain.PaymentStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.payments[id].Status
+}
+
+type env struct {
+	repo       *memRepo
+	svc        port.Service
+	reconciler port.Reconciler
+	server     *httptest.Server
+
+	mu   sync.Mutex
+	paid int
+}
+
+// newEnv serves the mock gateway and the payment callback, like the API
+// does, in front of a payment service using the mock gateway client.
+func newEnv(t *testing.T) *env {
+	e := &env{repo: &memRepo{payments: make(map[common.ID]*domain.Payment)}}
+	store := handler.NewMockGatewayStore()
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/v1/payment/mock-gateway/pay", handler.MockGatewayPay(store))
+	mux.Handle("GET /api/v1/payment/mock-gateway/verify", handler.MockGatewayVerify(store))
+	mux.Handle("GET /api/v1/payment/mock-gateway/inquiry", handler.MockGatewayInquiry(store))
+	mux.Handle("POST /api/v1/payment/callback", handler.CallbackHandler(
+		func(context.Context) port.Service { return e.svc }))
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(appctx.New(r.Context(), appctx.WithLogger(log))))
+	}))
+	t.Cleanup(e.server.Close)
+
+	gws := map[domain.GatewayType]port.Gateway{
+		domain.MockGateway: paygw.MustNewMockGateway(e.server.URL),
+	}
+	onPaid := func(context.Context, []*domain.Payment) {
+		e.mu.Lock()
+		e.paid++
+		e.mu.Unlock()
+	}
+	e.svc = payment.NewService(e.repo, gws, payment.WithOnPaymentPaid(onPaid))
+	e.reconciler = payment.NewReconciler(e.repo, gws, payment.WithReconcilerOnPaid(onPaid))
+	return e
+}
+
+func (e *env) paidHooks() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.paid
+}
+
+// pay starts a bill payment with md and follows the redirect to the
+// gateway like the user's browser would.
+func (e *env) pay(t *testing.T, md map[string]string) (common.ID, *http.Response) {
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+	appctx.SetValue(ctx, payment.MetadataKey, md)
+
+	redirect, err := e.svc.PayBill(ctx, domain.MockGateway, common.NewRandomID(), common.NewRandomID(),
+		5000, e.server.URL+"/api/v1/payment/callback", "")
+	require.NoError(t, err)
+
+	body, err := json.Marshal(redirect.Body)
+	require.NoError(t, err)
+	resp, err := http.Post(redirect.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	e.repo.mu.Lock()
+	defer e.repo.mu.Unlock()
+	for id, p := range e.repo.payments {
+		if p.TransactionID == redirect.TransactionID {
+			return id, resp
+		}
+	}
+	t.Fatal("payment not found")
+	return common.NilID, nil
+}
+
+func scenario(s paygw.MockScenario) map[string]string {
+	return map[string]string{paygw.MockScenarioKey: s.String()}
+}
+
+func TestMockGateway_Scenarios(t *testing.T) {
+	cases := map[paygw.MockScenario]struct {
+		code int
+		// status before and after reconciliation
+		status, reconciled domain.PaymentStatus
+		paidHooks          int
+	}{
+		paygw.MockSuccess:           {http.StatusOK, domain.PaymentPaid, domain.PaymentPaid, 1},
+		paygw.MockDuplicateCallback: {http.StatusOK, domain.PaymentPaid, domain.PaymentPaid, 1},
+		paygw.MockDecline:           {http.StatusOK, domain.PaymentPending, domain.PaymentFailed, 0},
+		paygw.MockUserCancel:        {http.StatusOK, domain.PaymentPending, domain.PaymentCancelled, 0},
+		paygw.MockTimeout:           {http.StatusGatewayTimeout, domain.PaymentPending, domain.PaymentExpired, 0},
+		// the merchant refuses the callback and never settles a wrong capture
+		paygw.MockAmountMismatch: {http.StatusBadGateway, domain.PaymentPending, domain.PaymentPending, 0},
+	}
+	for s, tc := range cases {
+		t.Run(s.String(), func(t *testing.T) {
+			e := newEnv(t)
+
+			id, resp := e.pay(t, scenario(s))
+			assert.Equal(t, tc.code, resp.StatusCode)
+			assert.Equal(t, tc.status, e.repo.status(id))
+
+			ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+			require.NoError(t, e.reconciler.Reconcile(ctx))
+			assert.Equal(t, tc.reconciled, e.repo.status(id))
+			assert.Equal(t, tc.paidHooks, e.paidHooks())
+		})
+	}
+}
+
+func TestMockGateway_DelayedCallback(t *testing.T) {
+	e := newEnv(t)
+	md := scenario(paygw.MockDelayedCallback)
+	md[paygw.MockDelayKey] = "50ms"
+
+	id, resp := e.pay(t, md)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, domain.PaymentPending, e.repo.status(id))
+
+	assert.Eventually(t, func() bool {
+		return e.repo.status(id) == domain.PaymentPaid
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, e.paidHooks())
+}
+
+func TestMockGateway_CallbackAfterExpiry(t *testing.T) {
+	e := newEnv(t)
+	md := scenario(paygw.MockDelayedCallback)
+	md[paygw.MockDelayKey] = "50ms"
+
+	id, _ := e.pay(t, md)
+	// the reconciler gave up on the payment before the callback arrived
+	e.repo.setStatus(id, domain.PaymentExpired)
+
+	assert.Eventually(t, func() bool {
+		return e.repo.status(id) == domain.PaymentPaid && e.paidHooks() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestMockGateway_VerifyUnknownTransaction(t *testing.T) {
+	store := handler.NewMockGatewayStore()
+	// captured on the gateway, but not created through this client
+	store.Capture("tx-elsewhere", 5000)
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/v1/payment/mock-gateway/verify", handler.MockGatewayVerify(store))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	gw := paygw.MustNewMockGateway(server.URL)
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+
+	_, err := gw.VerifyTransaction(ctx, map[string][]string{"token": {"mock-token"}})
+	assert.ErrorIs(t, err, paygw.ErrMissingTransaction)
+
+	_, err = gw.VerifyTransaction(ctx, map[string][]string{
+		"token":         {"mock-token"},
+		"transactionId": {"tx-elsewhere"},
+	})
+	assert.ErrorIs(t, err, paygw.ErrUnknownTransaction)
+}
+
+func TestMockGateway_UnknownScenario(t *testing.T) {
+	e := newEnv(t)
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+	appctx.SetValue(ctx, payment.MetadataKey, scenario("lost-in-space"))
+
+	_, err := e.svc.PayBill(ctx, domain.MockGateway, common.NewRandomID(), common.NewRandomID(),
+		5000, e.server.URL+"/api/v1/payment/callback", "")
+	assert.True(t, errors.Is(err, paygw.ErrUnknownScenario))
+}
+
+// newRecurringGateway serves the card endpoints of the mock gateway and
+// returns a client for them.
+func newRecurringGateway(t *testing.T) port.RecurringGateway {
+	store := handler.NewMockGatewayStore()
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/v1/payment/mock-gateway/tokenize", handler.MockGatewayTokenize(store))
+	mux.Handle("GET /api/v1/payment/mock-gateway/token", handler.MockGatewayToken(store))
+	mux.Handle("POST /api/v1/payment/mock-gateway/charge", handler.MockGatewayCharge(store))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	gw, ok := paygw.MustNewMockGateway(server.URL).(port.RecurringGateway)
+	require.True(t, ok)
+	return gw
+}
+
+// saveCard saves a card with md at the mock gateway like the user's
+// browser would and returns the callback query the gateway sent back.
+func saveCard(t *testing.T, gw port.RecurringGateway, md map[string]string) map[string][]string {
+	var (
+		mu    sync.Mutex
+		query map[string][]string
+	)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		query = r.URL.Query()
+		mu.Unlock()
+	}))
+	t.Cleanup(callback.Close)
+
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+	redirect, err := gw.Tokenize(ctx, domain.TokenRequest{
+		PayerID:     common.NewRandomID(),
+		CallbackURL: callback.URL + "?gateway=mock-gateway",
+		Metadata:    md,
+	})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(redirect.Body)
+	require.NoError(t, err)
+	resp, err := http.Post(redirect.URL, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return query
+}
+
+func TestMockGateway_ChargeToken(t *testing.T) {
+	cases := map[paygw.MockScenario]error{
+		paygw.MockSuccess:        nil,
+		paygw.MockDecline:        paygw.ErrPaymentDeclined,
+		paygw.MockAmountMismatch: paygw.ErrAmountMismatch,
+	}
+	for s, want := range cases {
+		t.Run(s.String(), func(t *testing.T) {
+			gw := newRecurringGateway(t)
+			query := saveCard(t, gw, scenario(s))
+			assert.Equal(t, []string{"mock-gateway"}, query["gateway"])
+
+			ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+			method, err := gw.VerifyToken(ctx, query)
+			require.NoError(t, err)
+			assert.NotEmpty(t, method.Mask)
+
+			transactionID, err := gw.ChargeToken(ctx, method.Token, domain.Transaction{
+				PaymentIDs: []common.ID{common.NewRandomID()},
+				Amount:     5000,
+			})
+			if want != nil {
+				assert.True(t, errors.Is(err, want), err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, transactionID)
+		})
+	}
+}
+
+func TestMockGateway_UnknownCardToken(t *testing.T) {
+	gw := newRecurringGateway(t)
+	ctx := appctx.New(context.Background(), appctx.WithLogger(log))
+
+	_, err := gw.VerifyToken(ctx, map[string][]string{paygw.CardTokenKey: {"missing"}})
+	assert.True(t, errors.Is(err, paygw.ErrUnknownCardToken))
+
+	_, err = gw.ChargeToken(ctx, "missing", domain.Transaction{Amount: 5000})
+	assert.True(t, errors.Is(err, paygw.ErrUnknownCardToken))
+}
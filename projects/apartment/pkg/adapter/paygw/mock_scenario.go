@@ -0,0 +1,53 @@
+package paygw
+
+import "errors"
+
+// Transaction metadata read by the mock gateway.
+const (
+	// MockScenarioKey selects how the mock gateway handles a transaction.
+	MockScenarioKey = "mock-scenario"
+	// MockDelayKey sets how long a delayed callback waits, e.g. "5s".
+	MockDelayKey = "mock-delay"
+)
+
+var ErrUnknownScenario = errors.New("unknown mock gateway scenario")
+
+// MockScenario is a scripted outcome of a mock gateway transaction.
+type MockScenario string
+
+const (
+	// MockSuccess captures the payment and calls back once.
+	MockSuccess MockScenario = "success"
+	// MockDecline fails the payment, e.g. for insufficient funds.
+	MockDecline MockScenario = "decline"
+	// MockTimeout leaves the payment pending and never calls back.
+	MockTimeout MockScenario = "timeout"
+	// MockDelayedCallback captures the payment and calls back after the
+	// delay in MockDelayKey.
+	MockDelayedCallback MockScenario = "delayed-callback"
+	// MockDuplicateCallback captures the payment and calls back twice.
+	MockDuplicateCallback MockScenario = "duplicate-callback"
+	// MockAmountMismatch captures half of the requested amount.
+	MockAmountMismatch MockScenario = "amount-mismatch"
+	// MockUserCancel calls back as if the user cancelled on the gateway.
+	MockUserCancel MockScenario = "cancel"
+)
+
+func (s MockScenario) String() string {
+	return string(s)
+}
+
+var validMockScenarios = map[MockScenario]struct{}{
+	MockSuccess:           {},
+	MockDecline:           {},
+	MockTimeout:           {},
+	MockDelayedCallback:   {},
+	MockDuplicateCallback: {},
+	MockAmountMismatch:    {},
+	MockUserCancel:        {},
+}
+
+func (s MockScenario) IsValid() bool {
+	_, ok := validMockScenarios[s]
+	return ok
+}
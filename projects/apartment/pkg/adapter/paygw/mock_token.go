@@ -0,0 +1,140 @@
+package paygw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/api/dto"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+// CardTokenKey is the callback query parameter carrying a saved card.
+const CardTokenKey = "cardToken"
+
+var ErrUnknownCardToken = errors.New("unknown card token")
+
+// Tokenize sends the payer to the mock gateway to save a card. The
+// scenario in the metadata scripts the charges made on it later.
+func (g *mockGateway) Tokenize(
+	ctx context.Context,
+	r paymentd.TokenRequest,
+) (
+	*paymentd.RedirectGateway, error,
+) {
+	gatewayURL := *g.gatewayBaseURL
+	gatewayURL.Path = "/api/v1/payment/mock-gateway/tokenize"
+
+	if _, err := url.Parse(r.CallbackURL); err != nil {
+		return nil, err
+	}
+	scenario := MockScenario(r.Metadata[MockScenarioKey])
+	if scenario != "" && !scenario.IsValid() {
+		return nil, ErrUnknownScenario
+	}
+	body, err := makeMapBody(&dto.TokenizeRequest{
+		PayerID:     r.PayerID.String(),
+		CallbackURL: r.CallbackURL,
+		Scenario:    scenario.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &paymentd.RedirectGateway{
+		Method: http.MethodPost,
+		URL:    gatewayURL.String(),
+		Body:   body,
+	}, nil
+}
+
+func (g *mockGateway) VerifyToken(
+	ctx context.Context,
+	data map[string][]string,
+) (
+	*paymentd.SavedMethod, error,
+) {
+	tokens := data[CardTokenKey]
+	if len(tokens) != 1 || tokens[0] == "" {
+		return nil, ErrMissingToken
+	}
+	tokenURL := *g.gatewayBaseURL
+	tokenURL.Path = "/api/v1/payment/mock-gateway/token"
+	tokenURL.RawQuery = url.Values{"token": tokens}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrUnknownCardToken
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("mock gateway token: %s", resp.Status)
+	}
+
+	var respBody dto.TokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return nil, err
+	}
+	return &paymentd.SavedMethod{Token: respBody.Token, Mask: respBody.CardMask}, nil
+}
+
+func (g *mockGateway) ChargeToken(
+	ctx context.Context,
+	token string,
+	tx paymentd.Transaction,
+) (
+	string, error,
+) {
+	chargeURL := *g.gatewayBaseURL
+	chargeURL.Path = "/api/v1/payment/mock-gateway/charge"
+
+	transactionID := common.NewRandomID().String()
+	body, err := json.Marshal(&dto.ChargeRequest{
+		Token:         token,
+		Amount:        tx.Amount,
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, chargeURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPaymentRequired:
+		return "", ErrPaymentDeclined
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrUnknownCardToken
+	case resp.StatusCode >= 400:
+		return "", fmt.Errorf("mock gateway charge: %s", resp.Status)
+	}
+
+	var respBody dto.ChargeResponse
+	if err = json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
+		return "", err
+	}
+	if respBody.Amount != tx.Amount {
+		return "", ErrAmountMismatch
+	}
+	return transactionID, nil
+}
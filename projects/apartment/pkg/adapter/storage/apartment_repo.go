@@ -10,6 +10,7 @@ import (
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/apartment/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/storage/types"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
 	"go.uber.org/zap"
@@ -66,7 +67,8 @@ func (r *apartmentRepo) Get(
 	*domain.Apartment, error,
 ) {
 	query := `
-		SELECT id, created_at, updated_at, deleted_at, name, address, unit_number, admin_id
+		SELECT id, created_at, updated_at, deleted_at, name, address, unit_number, admin_id,
+			require_admin_mfa
 		FROM apartments
 		WHERE deleted_at IS NULL
 	`
@@ -92,6 +94,7 @@ func (r *apartmentRepo) Get(
 		&apt.Address,
 		&apt.UnitNumber,
 		&apt.AdminID,
+		&apt.RequireAdminMFA,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -103,17 +106,37 @@ func (r *apartmentRepo) Get(
 	return types.ApartmentStorageToDomain(&apt), nil
 }
 
+func (r *apartmentRepo) SetRequireAdminMFA(
+	ctx context.Context, apartmentID common.ID, required bool,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE apartments SET require_admin_mfa = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, apartmentID, required)
+	return err
+}
+
 func (r *apartmentRepo) InviteMember(
 	ctx context.Context,
 	apartmentID common.ID,
 	invite *domain.Invite,
 ) (
-	*domain.Invite, error,
+	_ *domain.Invite, err error,
 ) {
 	log := appctx.Logger(ctx)
 
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
 	var id string
-	err := r.db.QueryRowContext(ctx, `
+	err = tx.QueryRowContext(ctx, `
 		INSERT INTO apartment_invites(apartment_id, invite_email, invite_status, invite_token, invite_expires_at)
 		VALUES($1, $2, $3, $4, $5)
 		RETURNING id;`,
@@ -130,6 +153,24 @@ func (r *apartmentRepo) InviteMember(
 		log.Error("failed to unmarshal invite id", zap.Error(err))
 		return nil, err
 	}
+
+	e, err := eventd.New(eventd.InviteCreated, apartmentID, eventd.InviteCreatedPayload{
+		InviteID:    invite.ID,
+		ApartmentID: apartmentID,
+		Email:       invite.Email.String(),
+		Token:       invite.Token,
+		ExpiresAt:   invite.ExpiresAt,
+		AcceptURL:   invite.AcceptURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = insertEvents(ctx, tx, e); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
 	return invite, nil
 }
 
@@ -193,6 +234,18 @@ func (r *apartmentRepo) AcceptInvite(ctx context.Context, token string) error {
 		return err
 	}
 
+	e, err := eventd.New(eventd.MemberJoined, common.IDFromText(aptId), eventd.MemberJoinedPayload{
+		ApartmentID: common.IDFromText(aptId),
+		UserID:      common.IDFromText(userId),
+		Email:       email,
+	})
+	if err != nil {
+		return err
+	}
+	if err = insertEvents(ctx, tx, e); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -0,0 +1,622 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
+	"github.com/lib/pq"
+)
+
+type authRepo struct {
+	db *sql.DB
+}
+
+func NewAuthRepo(db *sql.DB) port.Repo {
+	return &authRepo{db: db}
+}
+
+func (r *authRepo) CreateRefreshToken(ctx context.Context, t *domain.RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, t)
+}
+
+func insertRefreshToken(ctx context.Context, db execer, t *domain.RefreshToken) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (id, created_at, family_id, user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, t.ID, t.CreatedAt, t.FamilyID, t.UserID, t.ExpiresAt)
+	return err
+}
+
+func (r *authRepo) GetRefreshToken(
+	ctx context.Context, id common.ID,
+) (
+	*domain.RefreshToken, error,
+) {
+	var (
+		t                 domain.RefreshToken
+		usedAt, revokedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, created_at, family_id, user_id, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE id = $1
+	`, id).Scan(&t.ID, &t.CreatedAt, &t.FamilyID, &t.UserID, &t.ExpiresAt, &usedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, auth.ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+func (r *authRepo) RotateRefreshToken(
+	ctx context.Context, usedID common.ID, next *domain.RefreshToken,
+) (
+	err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+	`, usedID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrRefreshTokenReused
+	}
+	if err = insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *authRepo) RevokeFamily(ctx context.Context, familyID common.ID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	return err
+}
+
+func (r *authRepo) RevokeUserTokens(ctx context.Context, userID common.ID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	return err
+}
+
+func (r *authRepo) UserByEmail(
+	ctx context.Context, email common.Email,
+) (
+	userID common.ID, name string, err error,
+) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT id, COALESCE(first_name, '')
+		FROM users
+		WHERE email = $1 AND deleted_at IS NULL
+	`, email).Scan(&userID, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return common.NilID, "", auth.ErrUserNotFound
+	}
+	return userID, name, err
+}
+
+func (r *authRepo) CreatePasswordReset(ctx context.Context, pr *domain.PasswordReset) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO password_resets (id, created_at, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, pr.ID, pr.CreatedAt, pr.UserID, pr.TokenHash, pr.ExpiresAt)
+	return err
+}
+
+func (r *authRepo) ResetPassword(
+	ctx context.Context, tokenHash string, passwordHash []byte,
+) (
+	userID common.ID, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return common.NilID, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return common.NilID, auth.ErrInvalidResetToken
+	}
+	if err != nil {
+		return common.NilID, err
+	}
+
+	// the other links sent to the user stop working too
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		return common.NilID, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE users SET password = $2, updated_at = NOW()
+		WHERE id = $1
+	`, userID, string(passwordHash)); err != nil {
+		return common.NilID, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID); err != nil {
+		return common.NilID, err
+	}
+	return userID, tx.Commit()
+}
+
+func (r *authRepo) EmailVerification(
+	ctx context.Context, userID common.ID,
+) (
+	*domain.EmailVerification, error,
+) {
+	var (
+		ev                 domain.EmailVerification
+		verifiedAt, sentAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, email, COALESCE(first_name, ''), email_verified_at, email_verification_sent_at
+		FROM users
+		WHERE id = $1
+	`, userID).Scan(&ev.UserID, &ev.Email, &ev.Name, &verifiedAt, &sentAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, auth.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		ev.VerifiedAt = &verifiedAt.Time
+	}
+	if sentAt.Valid {
+		ev.SentAt = &sentAt.Time
+	}
+	return &ev, nil
+}
+
+func (r *authRepo) ClaimVerificationSend(
+	ctx context.Context, userID common.ID, now, sentBefore time.Time,
+) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET email_verification_sent_at = $2
+		WHERE id = $1 AND (email_verification_sent_at IS NULL OR email_verification_sent_at <= $3)
+	`, userID, now, sentBefore)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrVerificationThrottled
+	}
+	return nil
+}
+
+func (r *authRepo) MarkEmailVerified(ctx context.Context, userID common.ID, email common.Email) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $1 AND email = $2
+	`, userID, email)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+func (r *authRepo) TOTP(ctx context.Context, userID common.ID) (*domain.TOTP, error) {
+	var (
+		t                        domain.TOTP
+		confirmedAt, lockedUntil sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id, created_at, secret, confirmed_at, last_step, failed_attempts, locked_until
+		FROM user_totp
+		WHERE user_id = $1
+	`, userID).Scan(&t.UserID, &t.CreatedAt, &t.Secret, &confirmedAt, &t.LastStep,
+		&t.FailedAttempts, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, auth.ErrTOTPNotFound
+		}
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		t.ConfirmedAt = &confirmedAt.Time
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+	return &t, nil
+}
+
+func (r *authRepo) SavePendingTOTP(ctx context.Context, t *domain.TOTP) error {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, created_at, secret)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET created_at = EXCLUDED.created_at, secret = EXCLUDED.secret
+		WHERE user_totp.confirmed_at IS NULL
+	`, t.UserID, t.CreatedAt, t.Secret)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *authRepo) ConfirmTOTP(
+	ctx context.Context, userID common.ID, step int64, codeHashes []string,
+) (
+	err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_totp SET confirmed_at = NOW(), last_step = $2, failed_attempts = 0
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrMFAAlreadyEnabled
+	}
+	if err = replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *authRepo) UseTOTPStep(ctx context.Context, userID common.ID, step int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET last_step = $2, failed_attempts = 0
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_step < $2
+	`, userID, step)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (r *authRepo) UseRecoveryCode(ctx context.Context, userID common.ID, codeHash string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrInvalidMFACode
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE user_totp SET failed_attempts = 0 WHERE user_id = $1
+	`, userID)
+	return err
+}
+
+func (r *authRepo) RecordMFAFailure(
+	ctx context.Context, userID common.ID, maxAttempts int, lockedUntil time.Time,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET
+			failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+		WHERE user_id = $1
+	`, userID, maxAttempts, lockedUntil)
+	return err
+}
+
+func (r *authRepo) ReplaceRecoveryCodes(
+	ctx context.Context, userID common.ID, codeHashes []string,
+) (
+	err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, db execer, userID common.ID, codeHashes []string) error {
+	if _, err := db.ExecContext(ctx, `
+		DELETE FROM mfa_recovery_codes WHERE user_id = $1
+	`, userID); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO mfa_recovery_codes (user_id, code_hash)
+		SELECT $1, UNNEST($2::TEXT[])
+	`, userID, pq.Array(codeHashes))
+	return err
+}
+
+func (r *authRepo) DeleteTOTP(ctx context.Context, userID common.ID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `
+		DELETE FROM mfa_recovery_codes WHERE user_id = $1
+	`, userID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		DELETE FROM user_totp WHERE user_id = $1
+	`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *authRepo) MFARequired(ctx context.Context, userID common.ID) (required bool, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM apartments
+			WHERE admin_id = $1 AND require_admin_mfa AND deleted_at IS NULL
+		)
+	`, userID).Scan(&required)
+	return required, err
+}
+
+func (r *authRepo) UserByIdentity(
+	ctx context.Context, issuer, subject string,
+) (
+	userID common.ID, email common.Email, err error,
+) {
+	err = r.db.QueryRowContext(ctx, `
+		SELECT u.id, u.email
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = $1 AND i.subject = $2 AND u.deleted_at IS NULL
+	`, issuer, subject).Scan(&userID, &email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return common.NilID, "", auth.ErrIdentityNotFound
+	}
+	return userID, email, err
+}
+
+func (r *authRepo) LinkIdentity(ctx context.Context, i *domain.OIDCIdentity) error {
+	return insertIdentity(ctx, r.db, i)
+}
+
+func insertIdentity(ctx context.Context, db execer, i *domain.OIDCIdentity) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, i.Issuer, i.Subject, i.UserID, i.Email, i.CreatedAt)
+	return err
+}
+
+func (r *authRepo) CreateIdentityUser(
+	ctx context.Context, u *userDomain.User, i *domain.OIDCIdentity,
+) (
+	userID common.ID, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return common.NilID, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (email, password, first_name, last_name, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, u.Email, string(u.Password()), u.FirstName, u.LastName, i.CreatedAt).Scan(&userID)
+	if err != nil {
+		return common.NilID, err
+	}
+	i.UserID = userID
+	if err = insertIdentity(ctx, tx, i); err != nil {
+		return common.NilID, err
+	}
+	return userID, tx.Commit()
+}
+
+func (r *authRepo) CreatePersonalToken(ctx context.Context, t *domain.PersonalToken) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO personal_access_tokens
+			(id, created_at, user_id, name, token_hash, scopes, apartment_id, mfa, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, t.ID, t.CreatedAt, t.UserID, t.Name, t.TokenHash, pq.Array(scopeStrings(t.Scopes)),
+		t.ApartmentID, t.MFA, t.ExpiresAt)
+	return err
+}
+
+const personalTokenColumns = `
+	t.id, t.created_at, t.user_id, t.name, t.token_hash, t.scopes, t.apartment_id,
+	t.mfa, t.expires_at, t.last_used_at`
+
+func (r *authRepo) PersonalTokens(ctx context.Context, userID common.ID) ([]domain.PersonalToken, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+personalTokenColumns+`
+		FROM personal_access_tokens t
+		WHERE t.user_id = $1
+		ORDER BY t.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ts []domain.PersonalToken
+	for rows.Next() {
+		t, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, *t)
+	}
+	return ts, rows.Err()
+}
+
+func (r *authRepo) PersonalTokenByHash(ctx context.Context, tokenHash string) (*domain.PersonalToken, error) {
+	t, err := scanPersonalToken(r.db.QueryRowContext(ctx, `
+		SELECT `+personalTokenColumns+`
+		FROM personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND u.deleted_at IS NULL
+	`, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrPersonalTokenNotFound
+	}
+	return t, err
+}
+
+func (r *authRepo) DeletePersonalToken(ctx context.Context, userID, id common.ID) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return auth.ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+func (r *authRepo) TouchPersonalToken(ctx context.Context, id common.ID, usedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2
+	`, usedAt, id)
+	return err
+}
+
+func scanPersonalToken(row scanner) (*domain.PersonalToken, error) {
+	var (
+		t                   domain.PersonalToken
+		scopes              []string
+		apartmentID         sql.NullString
+		expiresAt, lastUsed sql.NullTime
+	)
+	err := row.Scan(
+		&t.ID, &t.CreatedAt, &t.UserID, &t.Name, &t.TokenHash, pq.Array(&scopes),
+		&apartmentID, &t.MFA, &expiresAt, &lastUsed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
+		t.Scopes = append(t.Scopes, domain.Scope(s))
+	}
+	t.ApartmentID = nullID(apartmentID)
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	return &t, nil
+}
+
+func scopeStrings(scopes []domain.Scope) []string {
+	ss := make([]string, len(scopes))
+	for i, s := range scopes {
+		ss[i] = s.String()
+	}
+	return ss
+}
@@ -0,0 +1,331 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/port"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	ledgerd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+const enrollmentColumns = `
+	e.id, e.created_at, e.updated_at, e.user_id, e.apartment_id, e.gateway,
+	COALESCE(e.token, ''), COALESCE(e.card_mask, ''), e.days_before_due, e.status,
+	e.failures, COALESCE(e.last_error, ''), e.last_failed_at, e.activated_at`
+
+type autopayRepo struct {
+	db       *sql.DB
+	payments *paymentRepo
+}
+
+func NewAutopayRepo(db *sql.DB) port.Repo {
+	return &autopayRepo{db: db, payments: &paymentRepo{db: db}}
+}
+
+func (r *autopayRepo) IsApartmentMember(
+	ctx context.Context, userID, apartmentID common.ID,
+) (
+	bool, error,
+) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM users_apartments
+			WHERE user_id = $1 AND apartment_id = $2 AND deleted_at IS NULL
+		)
+	`
+	var ok bool
+	err := r.db.QueryRowContext(ctx, query, userID, apartmentID).Scan(&ok)
+	return ok, err
+}
+
+func (r *autopayRepo) Enroll(
+	ctx context.Context, e *domain.Enrollment,
+) (
+	*domain.Enrollment, error,
+) {
+	query := `
+		INSERT INTO autopay_enrollments AS e (user_id, apartment_id, gateway, days_before_due, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, apartment_id) DO UPDATE SET
+			gateway = EXCLUDED.gateway, days_before_due = EXCLUDED.days_before_due,
+			status = EXCLUDED.status, token = NULL, card_mask = NULL, failures = 0,
+			last_error = NULL, last_failed_at = NULL, activated_at = NULL, updated_at = NOW()
+		WHERE e.status <> $6
+		RETURNING ` + enrollmentColumns
+	e, err := scanEnrollment(r.db.QueryRowContext(ctx, query,
+		e.UserID, e.ApartmentID, e.Gateway, e.DaysBeforeDue,
+		domain.EnrollmentPending, domain.EnrollmentActive,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, autopay.ErrAlreadyEnrolled
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *autopayRepo) Activate(
+	ctx context.Context, id common.ID, m *paymentd.SavedMethod,
+) (
+	*domain.Enrollment, error,
+) {
+	query := `
+		UPDATE autopay_enrollments e
+		SET token = $1, card_mask = $2, status = $3, activated_at = NOW(), updated_at = NOW()
+		WHERE e.id = $4 AND e.status = $5
+		RETURNING ` + enrollmentColumns
+	e, err := scanEnrollment(r.db.QueryRowContext(ctx, query,
+		m.Token, m.Mask, domain.EnrollmentActive, id, domain.EnrollmentPending,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, autopay.ErrEnrollmentSettled
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *autopayRepo) GetEnrollment(
+	ctx context.Context, id common.ID,
+) (
+	*domain.Enrollment, error,
+) {
+	query := `SELECT ` + enrollmentColumns + ` FROM autopay_enrollments e WHERE e.id = $1`
+	e, err := scanEnrollment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, autopay.ErrEnrollmentNotFound
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *autopayRepo) Enrollments(
+	ctx context.Context, userID common.ID,
+) (
+	[]domain.Enrollment, error,
+) {
+	query := `
+		SELECT ` + enrollmentColumns + `
+		FROM autopay_enrollments e
+		WHERE e.user_id = $1
+		ORDER BY e.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	es := []domain.Enrollment{}
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, *e)
+	}
+	return es, rows.Err()
+}
+
+func (r *autopayRepo) SetStatus(
+	ctx context.Context, id common.ID, s domain.EnrollmentStatus,
+) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE autopay_enrollments SET status = $1, updated_at = NOW() WHERE id = $2
+	`, s, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return autopay.ErrEnrollmentNotFound
+	}
+	return nil
+}
+
+func (r *autopayRepo) DueCharges(
+	ctx context.Context, now, retryAfter time.Time,
+) (
+	[]domain.Charge, error,
+) {
+	query := `
+		SELECT ` + enrollmentColumns + `,
+			b.id, COALESCE(b.name, ''), b.due_date, SUM(l.amount)
+		FROM autopay_enrollments e
+		JOIN bills b ON b.apartment_id = e.apartment_id
+			AND b.deleted_at IS NULL AND b.created_at >= e.activated_at
+		JOIN ledger_entries le ON le.bill_id = b.id
+		JOIN ledger_lines l ON l.entry_id = le.id
+		JOIN ledger_accounts a ON a.id = l.account_id
+			AND a.kind = $1 AND a.user_id = e.user_id
+		WHERE e.status = $2
+			AND b.due_date - e.days_before_due <= $3::date
+			AND (e.last_failed_at IS NULL OR e.last_failed_at < $4)
+			AND NOT EXISTS (
+				SELECT 1 FROM payments p
+				WHERE p.bill_id = b.id AND p.payer_id = e.user_id AND p.status = $5
+			)
+		GROUP BY e.id, b.id
+		HAVING SUM(l.amount) > 0
+		ORDER BY e.id, b.due_date, b.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query,
+		ledgerd.AccountReceivable, domain.EnrollmentActive, now,
+		retryAfter, paymentd.PaymentPending,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	charges := []domain.Charge{}
+	for rows.Next() {
+		var c domain.Charge
+		e, err := scanEnrollment(rows, &c.BillID, &c.BillName, &c.DueDate, &c.Amount)
+		if err != nil {
+			return nil, err
+		}
+		c.Enrollment = *e
+		charges = append(charges, c)
+	}
+	return charges, rows.Err()
+}
+
+func (r *autopayRepo) CreatePayment(
+	ctx context.Context, p *paymentd.Payment,
+) (
+	*paymentd.Payment, error,
+) {
+	return r.payments.CreatePayment(ctx, p)
+}
+
+func (r *autopayRepo) ChargeSucceeded(
+	ctx context.Context, enrollmentID common.ID, p *paymentd.Payment,
+) (
+	_ *paymentd.Payment, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockBills(ctx, tx, p.BillID); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payments
+		SET status = $1, paid_at = $2, transaction_id = $3, updated_at = NOW()
+		WHERE id = $4
+	`, paymentd.PaymentPaid, p.PaidAt, p.TransactionID, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = insertPaymentSucceeded(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE autopay_enrollments
+		SET failures = 0, last_error = NULL, last_failed_at = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.payments.GetPayment(ctx, p.ID)
+}
+
+func (r *autopayRepo) ChargeFailed(
+	ctx context.Context, enrollmentID, paymentID common.ID, reason string, maxFailures int,
+) (
+	_ *domain.Enrollment, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if paymentID != common.NilID {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments SET status = $1, description = $2, updated_at = NOW()
+			WHERE id = $3 AND status = $4
+		`, paymentd.PaymentFailed, reason, paymentID, paymentd.PaymentPending)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query := `
+		UPDATE autopay_enrollments e
+		SET failures = e.failures + 1, last_error = $1, last_failed_at = NOW(),
+			status = CASE WHEN e.failures + 1 >= $2 THEN $3 ELSE e.status END,
+			updated_at = NOW()
+		WHERE e.id = $4
+		RETURNING ` + enrollmentColumns
+	e, err := scanEnrollment(tx.QueryRowContext(ctx, query,
+		reason, maxFailures, domain.EnrollmentSuspended, enrollmentID,
+	))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, autopay.ErrEnrollmentNotFound
+		}
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *autopayRepo) UserEmail(ctx context.Context, userID common.ID) (string, error) {
+	var email string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT email FROM users WHERE id = $1 AND deleted_at IS NULL`, userID,
+	).Scan(&email)
+	return email, err
+}
+
+// scanEnrollment scans enrollmentColumns followed by extra.
+func scanEnrollment(row scanner, extra ...any) (*domain.Enrollment, error) {
+	var (
+		e                         domain.Enrollment
+		lastFailedAt, activatedAt sql.NullTime
+	)
+	dest := []any{
+		&e.ID, &e.CreatedAt, &e.UpdatedAt, &e.UserID, &e.ApartmentID, &e.Gateway,
+		&e.Token, &e.CardMask, &e.DaysBeforeDue, &e.Status,
+		&e.Failures, &e.LastError, &lastFailedAt, &activatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if lastFailedAt.Valid {
+		e.LastFailedAt = &lastFailedAt.Time
+	}
+	if activatedAt.Valid {
+		e.ActivatedAt = &activatedAt.Time
+	}
+	return &e, nil
+}
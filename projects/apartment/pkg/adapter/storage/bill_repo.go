@@ -5,11 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/bill/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	ledgerd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/storage/types"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/fp"
 )
@@ -22,7 +25,11 @@ func NewBillRepo(d *sql.DB) port.Repo {
 	return &billRepo{db: d}
 }
 
-func (r *billRepo) Create(ctx context.Context, b *domain.Bill) (*domain.Bill, error) {
+func NewBillOverdueRepo(d *sql.DB) port.OverdueRepo {
+	return &billRepo{db: d}
+}
+
+func (r *billRepo) Create(ctx context.Context, b *domain.Bill) (_ *domain.Bill, err error) {
 	query := `
 	INSERT INTO bills(
 		name,
@@ -43,7 +50,17 @@ func (r *billRepo) Create(ctx context.Context, b *domain.Bill) (*domain.Bill, er
 		b.Amount, b.DueDate, b.ImageID, b.ApartmentID,
 	}
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&b.ID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&b.ID)
 	if err != nil {
 		// If no ID was returned, it means bill_id already exists
 		if err == sql.ErrNoRows {
@@ -53,6 +70,23 @@ func (r *billRepo) Create(ctx context.Context, b *domain.Bill) (*domain.Bill, er
 		return nil, err
 	}
 
+	e, err := eventd.New(eventd.BillCreated, b.ID, eventd.BillCreatedPayload{
+		BillID:      b.ID,
+		ApartmentID: b.ApartmentID,
+		Name:        b.Name,
+		Type:        b.Type.String(),
+		Amount:      b.Amount,
+		DueDate:     b.DueDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = insertEvents(ctx, tx, e); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
 	return b, nil
 }
 
@@ -120,19 +154,26 @@ func (r *billRepo) GetUserBillShares(ctx context.Context, userID common.ID) ([]d
             b.id,
             b.name,
             b.amount,
-            COUNT(ua2.user_id) AS member_count,
-            (b.amount / COUNT(ua2.user_id)) AS user_share,
-            COALESCE(SUM(p.amount), 0) AS user_paid,
-            (b.amount / COUNT(ua2.user_id)) - COALESCE(SUM(p.amount), 0) AS balance_due
-        FROM users_apartments ua
-        JOIN bills b ON b.apartment_id = ua.apartment_id
-        JOIN users_apartments ua2 ON ua2.apartment_id = b.apartment_id AND ua2.created_at <= b.created_at
-        LEFT JOIN payments p ON p.bill_id = b.id AND p.payer_id = ua.user_id
-        WHERE ua.user_id = $1
+            (
+                SELECT COUNT(*)
+                FROM ledger_entries ce
+                JOIN ledger_lines cl ON cl.entry_id = ce.id
+                JOIN ledger_accounts ca ON ca.id = cl.account_id
+                WHERE ce.type = $3 AND ce.bill_id = b.id AND ca.kind = $2
+            ) AS member_count,
+            SUM(CASE WHEN e.type IN ($3, $4, $5) THEN l.amount ELSE 0 END) AS user_share,
+            -SUM(CASE WHEN e.type IN ($3, $4, $5) THEN 0 ELSE l.amount END) AS user_paid,
+            SUM(l.amount) AS balance_due
+        FROM ledger_lines l
+        JOIN ledger_accounts a ON a.id = l.account_id
+        JOIN ledger_entries e ON e.id = l.entry_id
+        JOIN bills b ON b.id = e.bill_id
+        WHERE a.kind = $2 AND a.user_id = $1
         GROUP BY b.id, b.name, b.amount;
     `
 
-	rows, err := r.db.Query(query, userID.String())
+	rows, err := r.db.Query(query, userID.String(), ledgerd.AccountReceivable,
+		ledgerd.EntryBillCharge, ledgerd.EntryFee, ledgerd.EntryAdjustment)
 	if err != nil {
 		return nil, err
 	}
@@ -162,22 +203,14 @@ func (r *billRepo) GetUserBillShares(ctx context.Context, userID common.ID) ([]d
 
 func (r *billRepo) GetUserTotalDebt(ctx context.Context, userID common.ID) (int, error) {
 	query := `
-        SELECT SUM(user_share - COALESCE(user_paid, 0)) AS total_debt FROM (
-            SELECT
-                b.id,
-                (b.amount / COUNT(ua2.user_id)) AS user_share,
-                COALESCE(SUM(p.amount), 0) AS user_paid
-            FROM users_apartments ua
-            JOIN bills b ON b.apartment_id = ua.apartment_id
-            JOIN users_apartments ua2 ON ua2.apartment_id = b.apartment_id AND ua2.created_at <= b.created_at
-            LEFT JOIN payments p ON p.bill_id = b.id AND p.payer_id = ua.user_id
-            WHERE ua.user_id = $1
-            GROUP BY b.id, b.amount
-        ) AS bill_balances;
+        SELECT SUM(l.amount) AS total_debt
+        FROM ledger_lines l
+        JOIN ledger_accounts a ON a.id = l.account_id
+        WHERE a.kind = $2 AND a.user_id = $1;
     `
 
 	var totalDebt sql.NullInt64
-	err := r.db.QueryRow(query, userID.String()).Scan(&totalDebt)
+	err := r.db.QueryRow(query, userID.String(), ledgerd.AccountReceivable).Scan(&totalDebt)
 	if err != nil {
 		return 0, err
 	}
@@ -187,3 +220,79 @@ func (r *billRepo) GetUserTotalDebt(ctx context.Context, userID common.ID) (int,
 	}
 	return int(totalDebt.Int64), nil
 }
+
+func (r *billRepo) MarkOverdue(ctx context.Context, today time.Time) (_ []domain.Bill, err error) {
+	query := `
+		WITH due AS (
+			SELECT b.id, SUM(l.amount) AS balance
+			FROM bills b
+			JOIN ledger_entries le ON le.bill_id = b.id
+			JOIN ledger_lines l ON l.entry_id = le.id
+			JOIN ledger_accounts a ON a.id = l.account_id AND a.kind = $1
+			WHERE b.deleted_at IS NULL AND b.overdue_at IS NULL AND b.due_date < $2::date
+			GROUP BY b.id
+			HAVING SUM(l.amount) > 0
+		)
+		UPDATE bills b SET overdue_at = NOW(), updated_at = NOW()
+		FROM due
+		WHERE b.id = due.id
+		RETURNING b.id, COALESCE(b.name, ''), b.bill_type, b.bill_id, b.amount,
+			b.due_date, b.apartment_id, due.balance
+	`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, query, ledgerd.AccountReceivable, today)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		bills    []domain.Bill
+		balances []int64
+	)
+	for rows.Next() {
+		var (
+			b       domain.Bill
+			balance int64
+		)
+		err = rows.Scan(&b.ID, &b.Name, &b.Type, &b.BillNumber, &b.Amount,
+			&b.DueDate, &b.ApartmentID, &balance)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		bills = append(bills, b)
+		balances = append(balances, balance)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, b := range bills {
+		e, err := eventd.New(eventd.BillOverdue, b.ID, eventd.BillOverduePayload{
+			BillID:      b.ID,
+			ApartmentID: b.ApartmentID,
+			Name:        b.Name,
+			DueDate:     b.DueDate,
+			BalanceDue:  balances[i],
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = insertEvents(ctx, tx, e); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
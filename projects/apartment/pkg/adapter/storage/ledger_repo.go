@@ -0,0 +1,362 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/port"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	walletd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+)
+
+type ledgerRepo struct {
+	db *sql.DB
+}
+
+func NewLedgerRepo(db *sql.DB) port.Repo {
+	return &ledgerRepo{db: db}
+}
+
+func (r *ledgerRepo) Post(ctx context.Context, e *domain.Entry) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO ledger_entries (type, reference, apartment_id, bill_id, description)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (type, reference) DO NOTHING
+		RETURNING id, created_at
+	`
+	err = tx.QueryRowContext(ctx, query,
+		e.Type, e.Reference, e.ApartmentID, e.BillID, e.Description,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	accountQuery := `
+		INSERT INTO ledger_accounts (code, kind, apartment_id, user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO UPDATE SET code = EXCLUDED.code
+		RETURNING id
+	`
+	lineQuery := `
+		INSERT INTO ledger_lines (entry_id, account_id, amount)
+		VALUES ($1, $2, $3)
+	`
+	for _, l := range e.Lines {
+		var userID *common.ID
+		if l.Account.UserID != common.NilID {
+			userID = &l.Account.UserID
+		}
+		var accountID common.ID
+		err = tx.QueryRowContext(ctx, accountQuery,
+			l.Account.Code(), l.Account.Kind, l.Account.ApartmentID, userID,
+		).Scan(&accountID)
+		if err != nil {
+			return false, err
+		}
+		if _, err = tx.ExecContext(ctx, lineQuery, e.ID, accountID, l.Amount); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+func (r *ledgerRepo) BillCharge(
+	ctx context.Context, billID common.ID,
+) (
+	common.ID, []domain.MemberShare, error,
+) {
+	var apartmentID common.ID
+	err := r.db.QueryRowContext(ctx,
+		`SELECT apartment_id FROM bills WHERE id = $1`, billID,
+	).Scan(&apartmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, nil, ledger.ErrBillNotFound
+		}
+		return common.NilID, nil, err
+	}
+
+	query := `
+		SELECT ua.user_id, b.amount / COUNT(*) OVER ()
+		FROM bills b
+		JOIN users_apartments ua
+			ON ua.apartment_id = b.apartment_id AND ua.created_at <= b.created_at
+			AND (ua.deleted_at IS NULL OR ua.deleted_at > b.created_at)
+		WHERE b.id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, billID)
+	if err != nil {
+		return common.NilID, nil, err
+	}
+	defer rows.Close()
+
+	var shares []domain.MemberShare
+	for rows.Next() {
+		var s domain.MemberShare
+		if err := rows.Scan(&s.UserID, &s.Amount); err != nil {
+			return common.NilID, nil, err
+		}
+		shares = append(shares, s)
+	}
+	return apartmentID, shares, rows.Err()
+}
+
+func (r *ledgerRepo) BillApartment(
+	ctx context.Context, billID common.ID,
+) (
+	apartmentID, adminID common.ID, err error,
+) {
+	query := `
+		SELECT b.apartment_id, a.admin_id
+		FROM bills b
+		JOIN apartments a ON a.id = b.apartment_id
+		WHERE b.id = $1
+	`
+	err = r.db.QueryRowContext(ctx, query, billID).Scan(&apartmentID, &adminID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ledger.ErrBillNotFound
+	}
+	return
+}
+
+func (r *ledgerRepo) IsApartmentMember(
+	ctx context.Context, userID, apartmentID common.ID,
+) (
+	bool, error,
+) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM users_apartments
+			WHERE user_id = $1 AND apartment_id = $2 AND deleted_at IS NULL
+		)
+	`
+	var ok bool
+	err := r.db.QueryRowContext(ctx, query, userID, apartmentID).Scan(&ok)
+	return ok, err
+}
+
+func (r *ledgerRepo) UnpostedBills(ctx context.Context) ([]common.ID, error) {
+	query := `
+		SELECT b.id
+		FROM bills b
+		WHERE NOT EXISTS (
+			SELECT 1 FROM ledger_entries e
+			WHERE e.type = $1 AND e.reference = b.id::text
+		)
+		ORDER BY b.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.EntryBillCharge)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []common.ID
+	for rows.Next() {
+		var id common.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *ledgerRepo) UnpostedPayments(ctx context.Context) ([]*paymentd.Payment, error) {
+	query := `
+		SELECT p.id, p.bill_id, p.payer_id, p.amount, p.status, p.gateway,
+			p.refund_of, COALESCE(p.description, '')
+		FROM payments p
+		WHERE p.status = $1 AND p.deleted_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM ledger_entries e
+			WHERE e.type IN ($2, $3, $4) AND e.reference = p.id::text
+		)
+		ORDER BY p.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, paymentd.PaymentPaid,
+		domain.EntryPayment, domain.EntryRefund, domain.EntryWalletPayment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*paymentd.Payment
+	for rows.Next() {
+		var (
+			p        paymentd.Payment
+			refundOf sql.NullString
+		)
+		err := rows.Scan(&p.ID, &p.BillID, &p.PayerID, &p.Amount, &p.Status,
+			&p.Gateway, &refundOf, &p.Description)
+		if err != nil {
+			return nil, err
+		}
+		if refundOf.Valid {
+			id := common.IDFromText(refundOf.String)
+			p.RefundOf = &id
+		}
+		payments = append(payments, &p)
+	}
+	return payments, rows.Err()
+}
+
+func (r *ledgerRepo) UnpostedTopUps(ctx context.Context) ([]domain.WalletTopUp, error) {
+	query := `
+		SELECT t.id, w.user_id, w.apartment_id, t.amount
+		FROM wallet_transactions t
+		JOIN wallets w ON w.id = t.wallet_id
+		WHERE t.type = $1 AND t.status = $2 AND NOT EXISTS (
+			SELECT 1 FROM ledger_entries e
+			WHERE e.type = $3 AND e.reference = t.id::text
+		)
+		ORDER BY t.created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query,
+		walletd.TransactionTopUp, walletd.TransactionCompleted, domain.EntryWalletTopUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topUps []domain.WalletTopUp
+	for rows.Next() {
+		var t domain.WalletTopUp
+		if err := rows.Scan(&t.Reference, &t.UserID, &t.ApartmentID, &t.Amount); err != nil {
+			return nil, err
+		}
+		topUps = append(topUps, t)
+	}
+	return topUps, rows.Err()
+}
+
+func (r *ledgerRepo) UnbalancedEntries(ctx context.Context) ([]common.ID, error) {
+	query := `
+		SELECT e.id
+		FROM ledger_entries e
+		LEFT JOIN ledger_lines l ON l.entry_id = e.id
+		GROUP BY e.id
+		HAVING COALESCE(SUM(l.amount), 0) <> 0 OR COUNT(l.id) < 2
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []common.ID
+	for rows.Next() {
+		var id common.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// BillMismatches rebuilds each member's bill balance from the bill shares,
+// the paid payments and the fees and adjustments, which only exist in the
+// ledger, and compares it with the member's receivable account.
+func (r *ledgerRepo) BillMismatches(ctx context.Context) ([]domain.BillMismatch, error) {
+	query := `
+		WITH shares AS (
+			SELECT ua.user_id, b.id AS bill_id,
+				b.amount / COUNT(*) OVER (PARTITION BY b.id) AS amount
+			FROM bills b
+			JOIN users_apartments ua
+				ON ua.apartment_id = b.apartment_id AND ua.created_at <= b.created_at
+				AND (ua.deleted_at IS NULL OR ua.deleted_at > b.created_at)
+		),
+		paid AS (
+			SELECT payer_id AS user_id, bill_id, SUM(amount) AS amount
+			FROM payments
+			WHERE status = $1 AND deleted_at IS NULL
+			GROUP BY payer_id, bill_id
+		),
+		receivables AS (
+			SELECT a.user_id, e.bill_id,
+				SUM(l.amount) AS amount,
+				SUM(CASE WHEN e.type IN ($3, $4) THEN l.amount ELSE 0 END) AS adjusted
+			FROM ledger_lines l
+			JOIN ledger_accounts a ON a.id = l.account_id
+			JOIN ledger_entries e ON e.id = l.entry_id
+			WHERE a.kind = $2 AND e.bill_id IS NOT NULL
+			GROUP BY a.user_id, e.bill_id
+		),
+		balances AS (
+			SELECT user_id, bill_id FROM shares
+			UNION SELECT user_id, bill_id FROM paid
+			UNION SELECT user_id, bill_id FROM receivables
+		)
+		SELECT * FROM (
+			SELECT k.user_id, k.bill_id,
+				COALESCE(s.amount, 0) + COALESCE(r.adjusted, 0) - COALESCE(p.amount, 0) AS expected,
+				COALESCE(r.amount, 0) AS actual
+			FROM balances k
+			LEFT JOIN shares s ON s.user_id = k.user_id AND s.bill_id = k.bill_id
+			LEFT JOIN paid p ON p.user_id = k.user_id AND p.bill_id = k.bill_id
+			LEFT JOIN receivables r ON r.user_id = k.user_id AND r.bill_id = k.bill_id
+		) AS b
+		WHERE expected <> actual
+	`
+	rows, err := r.db.QueryContext(ctx, query, paymentd.PaymentPaid,
+		domain.AccountReceivable, domain.EntryFee, domain.EntryAdjustment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []domain.BillMismatch
+	for rows.Next() {
+		var m domain.BillMismatch
+		if err := rows.Scan(&m.UserID, &m.BillID, &m.Expected, &m.Actual); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches, rows.Err()
+}
+
+// WalletMismatches compares each wallet balance with its ledger account,
+// which is credited on top-ups and debited on wallet payments.
+func (r *ledgerRepo) WalletMismatches(ctx context.Context) ([]domain.WalletMismatch, error) {
+	query := `
+		SELECT * FROM (
+			SELECT w.id, w.balance AS expected, COALESCE(-SUM(l.amount), 0) AS actual
+			FROM wallets w
+			LEFT JOIN ledger_accounts a
+				ON a.kind = $1 AND a.user_id = w.user_id AND a.apartment_id = w.apartment_id
+			LEFT JOIN ledger_lines l ON l.account_id = a.id
+			GROUP BY w.id, w.balance
+		) AS b
+		WHERE expected <> actual
+	`
+	rows, err := r.db.QueryContext(ctx, query, domain.AccountWallet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []domain.WalletMismatch
+	for rows.Next() {
+		var m domain.WalletMismatch
+		if err := rows.Scan(&m.WalletID, &m.Expected, &m.Actual); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches, rows.Err()
+}
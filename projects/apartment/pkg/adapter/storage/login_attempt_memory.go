@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+)
+
+// how often stale attempts are dropped from memory
+const attemptPruneInterval = time.Minute
+
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempts
+	prunedAt time.Time
+}
+
+// NewMemoryAttemptStore keeps failed sign-ins in memory. It suits a single
+// node; attempts are forgotten on restart.
+func NewMemoryAttemptStore() port.AttemptStore {
+	return &memoryAttemptStore{attempts: make(map[string]domain.LoginAttempts)}
+}
+
+func (s *memoryAttemptStore) LoginAttempts(_ context.Context, key string) (*domain.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok {
+		return &domain.LoginAttempts{Key: key}, nil
+	}
+	return &a, nil
+}
+
+func (s *memoryAttemptStore) AddLoginFailure(
+	_ context.Context, key string, now, windowStart time.Time,
+) (
+	*domain.LoginAttempts, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.prunedAt) >= attemptPruneInterval {
+		s.prune(now, windowStart)
+	}
+
+	a, ok := s.attempts[key]
+	if !ok || a.LastFailureAt.Before(windowStart) {
+		a.Key, a.Failures = key, 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	s.attempts[key] = a
+	return &a, nil
+}
+
+func (s *memoryAttemptStore) LockLogin(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.attempts[key]
+	if !ok {
+		return nil
+	}
+	a.Failures = 0
+	a.LockedUntil = &until
+	s.attempts[key] = a
+	return nil
+}
+
+func (s *memoryAttemptStore) ResetLoginAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// prune drops the keys that are neither locked nor failed since
+// windowStart.
+func (s *memoryAttemptStore) prune(now, windowStart time.Time) {
+	for k, a := range s.attempts {
+		locked := a.LockedUntil != nil && now.Before(*a.LockedUntil)
+		if !locked && a.LastFailureAt.Before(windowStart) {
+			delete(s.attempts, k)
+		}
+	}
+	s.prunedAt = now
+}
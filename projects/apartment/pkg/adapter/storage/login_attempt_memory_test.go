@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAttemptStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore()
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	a, err := store.LoginAttempts(ctx, "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, a.Failures)
+
+	for i := range 3 {
+		a, err = store.AddLoginFailure(ctx, "ip:10.0.0.1", now, now.Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, i+1, a.Failures)
+	}
+
+	// failures before the window are forgotten
+	later := now.Add(2 * time.Hour)
+	a, err = store.AddLoginFailure(ctx, "ip:10.0.0.1", later, later.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+
+	// locking starts counting again
+	until := later.Add(15 * time.Minute)
+	assert.NoError(t, store.LockLogin(ctx, "ip:10.0.0.1", until))
+	a, err = store.LoginAttempts(ctx, "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, a.Failures)
+	assert.Equal(t, until, *a.LockedUntil)
+
+	assert.NoError(t, store.ResetLoginAttempts(ctx, "ip:10.0.0.1"))
+	a, err = store.LoginAttempts(ctx, "ip:10.0.0.1")
+	assert.NoError(t, err)
+	assert.Nil(t, a.LockedUntil)
+}
+
+func TestMemoryAttemptStore_Prunes(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryAttemptStore().(*memoryAttemptStore)
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	_, _ = store.AddLoginFailure(ctx, "account:old@example.com", now, now.Add(-time.Hour))
+	_, _ = store.AddLoginFailure(ctx, "account:locked@example.com", now, now.Add(-time.Hour))
+	assert.NoError(t, store.LockLogin(ctx, "account:locked@example.com", now.Add(3*time.Hour)))
+
+	later := now.Add(2 * time.Hour)
+	_, _ = store.AddLoginFailure(ctx, "account:new@example.com", later, later.Add(-time.Hour))
+	assert.NotContains(t, store.attempts, "account:old@example.com")
+	assert.Contains(t, store.attempts, "account:locked@example.com")
+	assert.Contains(t, store.attempts, "account:new@example.com")
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/auth/port"
+)
+
+type loginAttemptRepo struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepo keeps failed sign-ins in the database, for when more
+// than one node serves sign-ins.
+func NewLoginAttemptRepo(db *sql.DB) port.AttemptStore {
+	return &loginAttemptRepo{db: db}
+}
+
+func (r *loginAttemptRepo) LoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	a, err := scanLoginAttempts(key, r.db.QueryRowContext(ctx, `
+		SELECT failures, last_failure_at, locked_until
+		FROM login_attempts
+		WHERE attempt_key = $1
+	`, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &domain.LoginAttempts{Key: key}, nil
+	}
+	return a, err
+}
+
+func (r *loginAttemptRepo) AddLoginFailure(
+	ctx context.Context, key string, now, windowStart time.Time,
+) (
+	*domain.LoginAttempts, error,
+) {
+	return scanLoginAttempts(key, r.db.QueryRowContext(ctx, `
+		INSERT INTO login_attempts (attempt_key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (attempt_key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = $2
+		RETURNING failures, last_failure_at, locked_until
+	`, key, now, windowStart))
+}
+
+func (r *loginAttemptRepo) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_attempts SET failures = 0, locked_until = $2
+		WHERE attempt_key = $1
+	`, key, until)
+	return err
+}
+
+func (r *loginAttemptRepo) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE attempt_key = $1`, key)
+	return err
+}
+
+func scanLoginAttempts(key string, row *sql.Row) (*domain.LoginAttempts, error) {
+	var (
+		a           = domain.LoginAttempts{Key: key}
+		lockedUntil sql.NullTime
+	)
+	if err := row.Scan(&a.Failures, &a.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		a.LockedUntil = &lockedUntil.Time
+	}
+	return &a, nil
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/notification/port"
+)
+
+type notificationRepo struct {
+	db *sql.DB
+}
+
+func NewNotificationRepo(db *sql.DB) port.Repo {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) ApartmentAdmin(
+	ctx context.Context, apartmentID common.ID,
+) (
+	common.ID, error,
+) {
+	query := `SELECT admin_id FROM apartments WHERE id = $1 AND deleted_at IS NULL`
+	var adminID common.ID
+	err := r.db.QueryRowContext(ctx, query, apartmentID).Scan(&adminID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, notification.ErrApartmentNotFound
+		}
+		return common.NilID, err
+	}
+	return adminID, nil
+}
+
+func (r *notificationRepo) BillApartment(
+	ctx context.Context, billID common.ID,
+) (
+	common.ID, error,
+) {
+	var apartmentID common.ID
+	err := r.db.QueryRowContext(ctx,
+		`SELECT apartment_id FROM bills WHERE id = $1`, billID,
+	).Scan(&apartmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, notification.ErrApartmentNotFound
+		}
+		return common.NilID, err
+	}
+	return apartmentID, nil
+}
+
+func (r *notificationRepo) ApartmentMembers(
+	ctx context.Context, apartmentID common.ID,
+) (
+	[]common.ID, error,
+) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id FROM users_apartments
+		WHERE apartment_id = $1 AND deleted_at IS NULL
+	`, apartmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []common.ID{}
+	for rows.Next() {
+		var id common.ID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *notificationRepo) PostAnnouncement(ctx context.Context, a *domain.Announcement) error {
+	e, err := eventd.New(eventd.AnnouncementPosted, a.ApartmentID, eventd.AnnouncementPostedPayload{
+		ApartmentID: a.ApartmentID,
+		AuthorID:    a.AuthorID,
+		Title:       a.Title,
+		Message:     a.Message,
+	})
+	if err != nil {
+		return err
+	}
+	return insertEvents(ctx, r.db, e)
+}
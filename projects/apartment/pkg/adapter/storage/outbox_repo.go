@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/port"
+	"github.com/lib/pq"
+)
+
+type outboxRepo struct {
+	db *sql.DB
+}
+
+func NewOutboxRepo(db *sql.DB) port.Repo {
+	return &outboxRepo{db: db}
+}
+
+func (r *outboxRepo) Claim(
+	ctx context.Context, now, leaseUntil time.Time, limit int,
+) (
+	[]domain.Event, error,
+) {
+	query := `
+		UPDATE outbox_events
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY created_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, created_at, type, aggregate_id, payload, status, attempts,
+			next_attempt_at, COALESCE(last_error, ''), dispatched_at,
+			ARRAY(
+				SELECT subscriber FROM outbox_deliveries
+				WHERE event_id = outbox_events.id
+			)
+	`
+	rows, err := r.db.QueryContext(ctx, query, leaseUntil, domain.StatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.Event{}
+	for rows.Next() {
+		var (
+			e            domain.Event
+			dispatchedAt sql.NullTime
+		)
+		err := rows.Scan(&e.ID, &e.CreatedAt, &e.Type, &e.AggregateID, &e.Payload, &e.Status,
+			&e.Attempts, &e.NextAttemptAt, &e.LastError, &dispatchedAt, pq.Array(&e.Delivered))
+		if err != nil {
+			return nil, err
+		}
+		if dispatchedAt.Valid {
+			e.DispatchedAt = &dispatchedAt.Time
+		}
+		events = append(events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func (r *outboxRepo) MarkDelivered(ctx context.Context, id common.ID, subscriber string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO outbox_deliveries (event_id, subscriber) VALUES ($1, $2)
+		ON CONFLICT (event_id, subscriber) DO NOTHING
+	`, id, subscriber)
+	return err
+}
+
+func (r *outboxRepo) MarkDispatched(ctx context.Context, id common.ID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox_events SET status = $1, dispatched_at = NOW() WHERE id = $2
+	`, domain.StatusDispatched, id)
+	return err
+}
+
+func (r *outboxRepo) MarkFailed(
+	ctx context.Context, id common.ID, reason string, retryAt *time.Time,
+) error {
+	status, next := domain.StatusPending, retryAt
+	if retryAt == nil {
+		status = domain.StatusFailed
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET status = $1, attempts = attempts + 1, last_error = $2,
+			next_attempt_at = COALESCE($3, next_attempt_at)
+		WHERE id = $4
+	`, status, reason, next, id)
+	return err
+}
+
+// insertEvents adds events to the outbox. Repos call it with the
+// transaction of the state change the events record.
+func insertEvents(ctx context.Context, db execer, events ...*domain.Event) error {
+	for _, e := range events {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO outbox_events (type, aggregate_id, payload, status)
+			VALUES ($1, $2, $3, $4)
+		`, e.Type, e.AggregateID, []byte(e.Payload), domain.StatusPending)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
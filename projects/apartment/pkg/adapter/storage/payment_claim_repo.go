@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+)
+
+const claimColumns = `
+	c.id, c.created_at, c.updated_at, c.bill_id, c.payer_id, c.amount, c.paid_at,
+	c.reference, c.proof_id, c.proof_name, c.proof_type, c.proof_size, c.status,
+	c.reviewed_by, c.reviewed_at, COALESCE(c.reject_reason, ''), c.payment_id`
+
+func (r *paymentRepo) CreateClaim(
+	ctx context.Context, c *paymentd.PaymentClaim,
+) (
+	*paymentd.PaymentClaim, error,
+) {
+	query := `
+		INSERT INTO payment_claims (
+			bill_id, payer_id, amount, paid_at, reference,
+			proof_id, proof_name, proof_type, proof_size, status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	var proof paymentd.Proof
+	if c.Proof != nil {
+		proof = *c.Proof
+	}
+	err := r.db.QueryRowContext(ctx, query,
+		c.BillID, c.PayerID, c.Amount, c.PaidAt, c.Reference,
+		c.ProofID, proof.Name, proof.Type, proof.Size, c.Status,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *paymentRepo) GetClaim(
+	ctx context.Context, id common.ID,
+) (
+	*paymentd.PaymentClaim, error,
+) {
+	query := `SELECT ` + claimColumns + ` FROM payment_claims c WHERE c.id = $1`
+	c, err := scanClaim(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, payment.ErrClaimNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *paymentRepo) Claims(
+	ctx context.Context, f paymentd.ClaimFilter,
+) (
+	[]paymentd.PaymentClaim, error,
+) {
+	query := `
+		SELECT ` + claimColumns + `
+		FROM payment_claims c
+		JOIN bills b ON b.id = c.bill_id
+		WHERE TRUE`
+
+	args := []interface{}{}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+cond, len(args))
+	}
+	if f.PayerID != common.NilID {
+		where("c.payer_id = $%d", f.PayerID)
+	}
+	if f.ApartmentID != common.NilID {
+		where("b.apartment_id = $%d", f.ApartmentID)
+	}
+	if f.Status != "" {
+		where("c.status = $%d", f.Status)
+	}
+	query += ` ORDER BY c.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cs := []paymentd.PaymentClaim{}
+	for rows.Next() {
+		c, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, *c)
+	}
+	return cs, rows.Err()
+}
+
+func (r *paymentRepo) ApproveClaim(
+	ctx context.Context, c *paymentd.PaymentClaim, p *paymentd.Payment,
+) (
+	_ *paymentd.Payment, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// the member may have paid online, or another claim was approved,
+	// since the claim was submitted
+	if err = lockBills(ctx, tx, p.BillID); err != nil {
+		return nil, err
+	}
+	balanceDue, err := userBillBalanceDue(ctx, tx, p.PayerID, p.BillID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Amount > balanceDue {
+		return nil, payment.ErrClaimExceedsBalance
+	}
+
+	callbackData, err := json.Marshal(&p.CallbackData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal callback date: %w", err)
+	}
+	var idStr string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (
+			bill_id, payer_id, amount, paid_at, status, gateway, transaction_id, callback_data
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, p.BillID, p.PayerID, p.Amount, p.PaidAt, p.Status, p.Gateway, p.TransactionID, callbackData,
+	).Scan(&idStr)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = common.IDFromText(idStr)
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+
+	if err = reviewClaim(ctx, tx, c, &p.ID); err != nil {
+		return nil, err
+	}
+	if err = insertPaymentSucceeded(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *paymentRepo) RejectClaim(ctx context.Context, c *paymentd.PaymentClaim) error {
+	return reviewClaim(ctx, r.db, c, nil)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// reviewClaim stores the review of c if it is still pending.
+func reviewClaim(
+	ctx context.Context, db execer, c *paymentd.PaymentClaim, paymentID *common.ID,
+) error {
+	res, err := db.ExecContext(ctx, `
+		UPDATE payment_claims
+		SET status = $1, reviewed_by = $2, reviewed_at = $3, reject_reason = $4,
+			payment_id = $5, updated_at = NOW()
+		WHERE id = $6 AND status = $7
+	`, c.Status, c.ReviewedBy, c.ReviewedAt, c.RejectReason, paymentID, c.ID, paymentd.ClaimPending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return payment.ErrClaimReviewed
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanClaim(row scanner) (*paymentd.PaymentClaim, error) {
+	var (
+		c                     paymentd.PaymentClaim
+		proof                 paymentd.Proof
+		reviewedBy, paymentID sql.NullString
+		reviewedAt            sql.NullTime
+	)
+	err := row.Scan(
+		&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.BillID, &c.PayerID, &c.Amount, &c.PaidAt,
+		&c.Reference, &c.ProofID, &proof.Name, &proof.Type, &proof.Size, &c.Status,
+		&reviewedBy, &reviewedAt, &c.RejectReason, &paymentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.Proof = &proof
+	c.ReviewedBy = nullID(reviewedBy)
+	c.PaymentID = nullID(paymentID)
+	if reviewedAt.Valid {
+		c.ReviewedAt = &reviewedAt.Time
+	}
+	return &c, nil
+}
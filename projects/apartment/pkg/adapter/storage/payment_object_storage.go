@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
+	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+// paymentProofStorage keeps the receipt images of offline payment claims.
+type paymentProofStorage struct {
+	client *minio.Client
+}
+
+const PaymentProofBucket = "payment-proof-bucket"
+
+func NewPaymentProofStorage(c *minio.Client) (port.ObjectStorage, error) {
+	storage := &paymentProofStorage{client: c}
+	found, err := c.BucketExists(context.Background(), PaymentProofBucket)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		err = c.MakeBucket(context.Background(), PaymentProofBucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return storage, nil
+}
+
+func (s *paymentProofStorage) FPut(ctx context.Context, key, filename string) error {
+	log := appctx.Logger(ctx)
+	opts := minio.PutObjectOptions{ContentType: "application/octet-stream"}
+	info, err := s.client.FPutObject(ctx, PaymentProofBucket, key, filename, opts)
+	if err != nil {
+		log.Error("PaymentProofStorage.FPut", zap.Error(err))
+		return err
+	}
+	log.Info("PaymentProofStorage.FPut", zap.Any("upload info", info))
+	return nil
+}
+
+func (s *paymentProofStorage) FGet(ctx context.Context, key, path string) error {
+	opts := minio.GetObjectOptions{}
+	return s.client.FGetObject(ctx, PaymentProofBucket, key, path, opts)
+}
+
+func (s *paymentProofStorage) Del(ctx context.Context, key string) error {
+	opts := minio.RemoveObjectOptions{
+		GovernanceBypass: true,
+	}
+	return s.client.RemoveObject(ctx, PaymentProofBucket, key, opts)
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	ledgerd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment"
 	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
 	paymentp "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/port"
 )
@@ -35,10 +39,12 @@ func (r *paymentRepo) CreatePayment(
 			status,
 			gateway,
 			transaction_id,
-			callback_data
+			callback_data,
+			refund_of,
+			description
 		)
 		VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 		)
 		RETURNING id
 	`
@@ -56,6 +62,8 @@ func (r *paymentRepo) CreatePayment(
 		p.Gateway,
 		p.TransactionID,
 		callbackData,
+		p.RefundOf,
+		p.Description,
 	}
 
 	var idStr string
@@ -175,6 +183,7 @@ func (r *paymentRepo) UpdateStatus(
 	query := fmt.Sprintf(`
 		UPDATE payments
 		SET status = $1,
+		    paid_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE paid_at END,
 		    updated_at = NOW()
 		WHERE id IN (%s)
 	`, strings.Join(placeholders, ", "))
@@ -194,24 +203,753 @@ func (r *paymentRepo) UpdateStatus(
 	return nil
 }
 
+func (r *paymentRepo) SettlePending(
+	ctx context.Context,
+	paymentIDs []common.ID,
+	status paymentd.PaymentStatus,
+) (
+	_ int64, err error,
+) {
+	if len(paymentIDs) == 0 {
+		return 0, nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	settled, err := settle(ctx, tx, paymentIDs, status, paymentd.PaymentPending)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(settled)), nil
+}
+
+func (r *paymentRepo) SettleCaptured(
+	ctx context.Context,
+	paymentIDs []common.ID,
+	gt paymentd.GatewayType,
+	capture paymentd.Capture,
+) (
+	_ int64, _ []common.ID, err error,
+) {
+	paymentIDs = uniqueIDs(paymentIDs)
+	if len(paymentIDs) == 0 || capture.TransactionID == "" {
+		return 0, nil, payment.ErrCaptureMismatch
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockPaymentBills(ctx, tx, paymentIDs); err != nil {
+		return 0, nil, err
+	}
+	// only payments started on the captured transaction are locked, and
+	// together they must be what was captured
+	args := []any{capture.TransactionID, gt.String()}
+	placeholders := make([]string, len(paymentIDs))
+	for i, id := range paymentIDs {
+		args = append(args, id)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	var matched int
+	var amount int64
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM (
+			SELECT amount FROM payments
+			WHERE id IN (%s) AND transaction_id = $1 AND gateway = $2
+				AND refund_of IS NULL AND deleted_at IS NULL
+			FOR UPDATE
+		) captured
+	`, strings.Join(placeholders, ", ")), args...).Scan(&matched, &amount)
+	if err != nil {
+		return 0, nil, err
+	}
+	if matched != len(paymentIDs) || amount != capture.Amount {
+		return 0, nil, payment.ErrCaptureMismatch
+	}
+
+	settled, err := settle(ctx, tx, paymentIDs, paymentd.PaymentPaid,
+		paymentd.PaymentPending, paymentd.PaymentExpired)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	var expired []common.ID
+	for _, p := range settled {
+		if p.from == paymentd.PaymentExpired {
+			expired = append(expired, p.ID)
+		}
+	}
+	return int64(len(settled)), expired, nil
+}
+
+// uniqueIDs returns ids without repeats, in their first order.
+func uniqueIDs(ids []common.ID) []common.ID {
+	seen := make(map[common.ID]struct{}, len(ids))
+	var unique []common.ID
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// settledPayment is a payment moved by settle and the status it had.
+type settledPayment struct {
+	*paymentd.Payment
+	from paymentd.PaymentStatus
+}
+
+// settle moves the given payments, but not refunds, that are in one of the
+// from statuses to status in tx, recording paid ones in the outbox.
+func settle(
+	ctx context.Context,
+	tx *sql.Tx,
+	paymentIDs []common.ID,
+	status paymentd.PaymentStatus,
+	from ...paymentd.PaymentStatus,
+) (
+	[]settledPayment, error,
+) {
+	args := make([]any, 0, len(from)+len(paymentIDs)+1)
+	args = append(args, status)
+	fromPlaceholders := make([]string, len(from))
+	for i, st := range from {
+		args = append(args, st)
+		fromPlaceholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	idPlaceholders := make([]string, len(paymentIDs))
+	for i, id := range paymentIDs {
+		args = append(args, id)
+		idPlaceholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	if status == paymentd.PaymentPaid {
+		// a claim approved meanwhile must see these payments
+		if err := lockPaymentBills(ctx, tx, paymentIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// the locked subquery keeps the status each payment had; refunds are
+	// settled by SettleRefund only
+	query := fmt.Sprintf(`
+		UPDATE payments p
+		SET status = $1,
+		    paid_at = CASE WHEN $1 = 'paid' THEN NOW() ELSE p.paid_at END,
+		    updated_at = NOW()
+		FROM (
+			SELECT id, status FROM payments
+			WHERE status IN (%s) AND id IN (%s) AND refund_of IS NULL
+			FOR UPDATE
+		) old
+		WHERE p.id = old.id
+		RETURNING p.id, p.bill_id, p.payer_id, p.amount, COALESCE(p.gateway, ''),
+			COALESCE(p.transaction_id, ''), COALESCE(p.paid_at, NOW()),
+			COALESCE(p.description, ''), old.status
+	`, strings.Join(fromPlaceholders, ", "), strings.Join(idPlaceholders, ", "))
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var settled []settledPayment
+	var paid []*paymentd.Payment
+	for rows.Next() {
+		p := settledPayment{Payment: &paymentd.Payment{Status: status}}
+		err = rows.Scan(&p.ID, &p.BillID, &p.PayerID, &p.Amount, &p.Gateway,
+			&p.TransactionID, &p.PaidAt, &p.Description, &p.from)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		settled = append(settled, p)
+		paid = append(paid, p.Payment)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if status == paymentd.PaymentPaid {
+		if err = insertPaymentSucceeded(ctx, tx, paid...); err != nil {
+			return nil, err
+		}
+	}
+	return settled, nil
+}
+
+// lockPaymentBills locks the bills of the given payments with lockBills.
+func lockPaymentBills(ctx context.Context, tx *sql.Tx, paymentIDs []common.ID) error {
+	args := make([]any, len(paymentIDs))
+	placeholders := make([]string, len(paymentIDs))
+	for i, id := range paymentIDs {
+		args[i] = id
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT bill_id FROM payments WHERE id IN (%s)
+	`, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var billIDs []common.ID
+	for rows.Next() {
+		var id common.ID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		billIDs = append(billIDs, id)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return lockBills(ctx, tx, billIDs...)
+}
+
+// insertPaymentSucceeded records in the outbox that ps were paid.
+func insertPaymentSucceeded(ctx context.Context, db execer, ps ...*paymentd.Payment) error {
+	for _, p := range ps {
+		e, err := eventd.New(eventd.PaymentSucceeded, p.ID, eventd.PaymentSucceededPayload{
+			PaymentID:     p.ID,
+			BillID:        p.BillID,
+			PayerID:       p.PayerID,
+			Amount:        p.Amount,
+			Gateway:       p.Gateway,
+			TransactionID: p.TransactionID,
+			Description:   p.Description,
+			PaidAt:        p.PaidAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err = insertEvents(ctx, db, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *paymentRepo) SetTransactionID(
+	ctx context.Context,
+	paymentIDs []common.ID,
+	transactionID string,
+) error {
+	if len(paymentIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(paymentIDs))
+	args := make([]any, 0, len(paymentIDs)+1)
+
+	args = append(args, transactionID)
+	for i, id := range paymentIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2) // start from $2
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE payments
+		SET transaction_id = $1,
+		    updated_at = NOW()
+		WHERE id IN (%s)
+	`, strings.Join(placeholders, ", "))
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *paymentRepo) PendingPayments(
+	ctx context.Context, createdBefore time.Time,
+) (
+	[]*paymentd.Payment, error,
+) {
+	query := `
+		SELECT id, created_at, updated_at, bill_id, payer_id, amount,
+			status, gateway, COALESCE(transaction_id, '')
+		FROM payments
+		WHERE status = $1 AND created_at < $2 AND refund_of IS NULL
+			AND deleted_at IS NULL
+		ORDER BY created_at
+	`
+	rows, err := r.db.QueryContext(ctx, query, paymentd.PaymentPending, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []*paymentd.Payment{}
+	for rows.Next() {
+		var p paymentd.Payment
+		err := rows.Scan(
+			&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.BillID, &p.PayerID,
+			&p.Amount, &p.Status, &p.Gateway, &p.TransactionID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, &p)
+	}
+	return payments, rows.Err()
+}
+
+func (r *paymentRepo) GetPayment(
+	ctx context.Context, id common.ID,
+) (
+	*paymentd.Payment, error,
+) {
+	query := `
+		SELECT id, created_at, updated_at, bill_id, payer_id, amount,
+			COALESCE(paid_at, created_at), status, gateway,
+			COALESCE(transaction_id, ''), refund_of, COALESCE(description, '')
+		FROM payments
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	var (
+		p        paymentd.Payment
+		refundOf sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.BillID, &p.PayerID, &p.Amount,
+		&p.PaidAt, &p.Status, &p.Gateway, &p.TransactionID, &refundOf, &p.Description,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, payment.ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if refundOf.Valid {
+		id := common.IDFromText(refundOf.String)
+		p.RefundOf = &id
+	}
+	return &p, nil
+}
+
+func (r *paymentRepo) ReserveRefund(
+	ctx context.Context, refund *paymentd.Payment,
+) (
+	_ *paymentd.Payment, err error,
+) {
+	if refund.RefundOf == nil {
+		return nil, payment.ErrNotRefundable
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// concurrent refunds of the payment wait here, so each sees what the
+	// others reserved
+	var (
+		amount int64
+		status paymentd.PaymentStatus
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT amount, status FROM payments
+		WHERE id = $1 AND refund_of IS NULL AND deleted_at IS NULL
+		FOR UPDATE
+	`, *refund.RefundOf).Scan(&amount, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, payment.ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != paymentd.PaymentPaid {
+		return nil, payment.ErrNotRefundable
+	}
+
+	// pending refunds count, their money may be on its way back
+	var refunded int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(-SUM(amount), 0)
+		FROM payments
+		WHERE refund_of = $1 AND status IN ($2, $3) AND deleted_at IS NULL
+	`, *refund.RefundOf, paymentd.PaymentPaid, paymentd.PaymentPending).Scan(&refunded)
+	if err != nil {
+		return nil, err
+	}
+	remaining := amount - refunded
+	if refund.Amount == 0 {
+		refund.Amount = -remaining
+	}
+	if refund.Amount >= 0 || -refund.Amount > remaining {
+		return nil, payment.ErrInvalidRefundAmount
+	}
+
+	var idStr string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (
+			bill_id, payer_id, amount, paid_at, status, gateway, transaction_id,
+			refund_of, description
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, refund.BillID, refund.PayerID, refund.Amount, refund.PaidAt, refund.Status,
+		refund.Gateway, refund.TransactionID, refund.RefundOf, refund.Description,
+	).Scan(&idStr)
+	if err != nil {
+		return nil, err
+	}
+	refund.ID = common.IDFromText(idStr)
+	if refund.Status == paymentd.PaymentPaid {
+		if err = insertPaymentRefunded(ctx, tx, refund); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	refund.CreatedAt = time.Now().UTC()
+	refund.UpdatedAt = refund.CreatedAt
+	return refund, nil
+}
+
+func (r *paymentRepo) SettleRefund(
+	ctx context.Context,
+	id common.ID,
+	transactionID string,
+	status paymentd.PaymentStatus,
+) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	p := paymentd.Payment{ID: id, Status: status, TransactionID: transactionID}
+	var refundOf common.ID
+	err = tx.QueryRowContext(ctx, `
+		UPDATE payments
+		SET status = $1, transaction_id = $2, updated_at = NOW()
+		WHERE id = $3 AND refund_of IS NOT NULL AND status = $4
+		RETURNING bill_id, payer_id, amount, COALESCE(paid_at, created_at), gateway, refund_of,
+			COALESCE(description, '')
+	`, status, transactionID, id, paymentd.PaymentPending).Scan(
+		&p.BillID, &p.PayerID, &p.Amount, &p.PaidAt, &p.Gateway, &refundOf, &p.Description,
+	)
+	if err != nil {
+		return err
+	}
+	p.RefundOf = &refundOf
+	if status == paymentd.PaymentPaid {
+		if err = insertPaymentRefunded(ctx, tx, &p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// insertPaymentRefunded records a paid refund in the outbox.
+func insertPaymentRefunded(ctx context.Context, db execer, p *paymentd.Payment) error {
+	e, err := eventd.New(eventd.PaymentRefunded, p.ID, eventd.PaymentRefundedPayload{
+		PaymentID:     p.ID,
+		RefundOf:      *p.RefundOf,
+		BillID:        p.BillID,
+		PayerID:       p.PayerID,
+		Amount:        p.Amount,
+		Gateway:       p.Gateway,
+		TransactionID: p.TransactionID,
+		Description:   p.Description,
+		PaidAt:        p.PaidAt,
+	})
+	if err != nil {
+		return err
+	}
+	return insertEvents(ctx, db, e)
+}
+
+func (r *paymentRepo) BillApartmentAdmin(
+	ctx context.Context, billID common.ID,
+) (
+	common.ID, error,
+) {
+	query := `
+		SELECT a.admin_id
+		FROM bills b
+		JOIN apartments a ON a.id = b.apartment_id
+		WHERE b.id = $1
+	`
+	var adminID common.ID
+	err := r.db.QueryRowContext(ctx, query, billID).Scan(&adminID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, payment.ErrPaymentNotFound
+		}
+		return common.NilID, err
+	}
+	return adminID, nil
+}
+
+func (r *paymentRepo) Receipt(
+	ctx context.Context, p *paymentd.Payment,
+) (
+	*paymentd.Receipt, error,
+) {
+	query := `
+		SELECT
+			p.id, COALESCE(p.paid_at, p.updated_at),
+			b.id, COALESCE(NULLIF(b.name, ''), b.bill_type::text),
+			a.id, a.name, a.admin_id, p.amount,
+			TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), u.email
+		FROM payments p
+		JOIN bills b ON b.id = p.bill_id
+		JOIN apartments a ON a.id = b.apartment_id
+		JOIN users u ON u.id = p.payer_id
+		WHERE p.payer_id = $1
+			AND (p.id = $2 OR ($4 <> '' AND p.gateway = $3 AND p.transaction_id = $4))
+			AND p.status = $5 AND p.refund_of IS NULL AND p.deleted_at IS NULL
+		ORDER BY p.created_at, p.id
+	`
+	rows, err := r.db.QueryContext(ctx, query,
+		p.PayerID, p.ID, p.Gateway, p.TransactionID, paymentd.PaymentPaid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipt := &paymentd.Receipt{
+		PayerID:       p.PayerID,
+		Gateway:       p.Gateway,
+		TransactionID: p.TransactionID,
+	}
+	for rows.Next() {
+		var (
+			item   paymentd.ReceiptItem
+			paidAt time.Time
+		)
+		err := rows.Scan(
+			&item.PaymentID, &paidAt,
+			&item.BillID, &item.BillName,
+			&item.ApartmentID, &item.ApartmentName, &item.ApartmentAdminID, &item.Amount,
+			&receipt.PayerName, &receipt.PayerEmail,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if receipt.PaidAt.IsZero() || paidAt.Before(receipt.PaidAt) {
+			receipt.PaidAt = paidAt
+		}
+		receipt.Items = append(receipt.Items, item)
+	}
+	return receipt, rows.Err()
+}
+
+func (r *paymentRepo) Payments(
+	ctx context.Context, f paymentd.PaymentFilter,
+) (
+	*paymentd.PaymentPage, error,
+) {
+	from := `
+		FROM payments p
+		JOIN bills b ON b.id = p.bill_id
+		JOIN apartments a ON a.id = b.apartment_id
+		JOIN users u ON u.id = p.payer_id
+		WHERE p.deleted_at IS NULL`
+
+	args := []interface{}{}
+	argIdx := 1
+	where := func(cond string, arg interface{}) {
+		from += fmt.Sprintf(" AND "+cond, argIdx)
+		args = append(args, arg)
+		argIdx++
+	}
+	if f.PayerID != common.NilID {
+		where("p.payer_id = $%d", f.PayerID)
+	}
+	if f.ApartmentID != common.NilID {
+		where("b.apartment_id = $%d", f.ApartmentID)
+	}
+	if f.BillID != common.NilID {
+		where("p.bill_id = $%d", f.BillID)
+	}
+	if f.Status != "" {
+		where("p.status = $%d", f.Status)
+	}
+	if f.Gateway != "" {
+		where("p.gateway = $%d", f.Gateway)
+	}
+	if !f.From.IsZero() {
+		where("COALESCE(p.paid_at, p.created_at) >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		where("COALESCE(p.paid_at, p.created_at) < $%d", f.To)
+	}
+
+	page := &paymentd.PaymentPage{
+		Records:  []paymentd.PaymentRecord{},
+		Page:     f.Page,
+		PageSize: f.PageSize,
+	}
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*)"+from, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			p.id, p.created_at, p.updated_at, p.bill_id, p.payer_id, p.amount,
+			COALESCE(p.paid_at, p.created_at), p.status, p.gateway,
+			COALESCE(p.transaction_id, ''), p.refund_of, COALESCE(p.description, ''),
+			COALESCE(NULLIF(b.name, ''), b.bill_type::text), a.id, a.name,
+			TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')), u.email` +
+		from + fmt.Sprintf(`
+		ORDER BY COALESCE(p.paid_at, p.created_at) DESC, p.id
+		LIMIT $%d OFFSET $%d`, argIdx, argIdx+1)
+	args = append(args, f.PageSize, f.Offset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rec      paymentd.PaymentRecord
+			refundOf sql.NullString
+		)
+		err := rows.Scan(
+			&rec.ID, &rec.CreatedAt, &rec.UpdatedAt, &rec.BillID, &rec.PayerID, &rec.Amount,
+			&rec.PaidAt, &rec.Status, &rec.Gateway,
+			&rec.TransactionID, &refundOf, &rec.Description,
+			&rec.BillName, &rec.ApartmentID, &rec.ApartmentName,
+			&rec.PayerName, &rec.PayerEmail,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if refundOf.Valid {
+			id := common.IDFromText(refundOf.String)
+			rec.RefundOf = &id
+		}
+		page.Records = append(page.Records, rec)
+	}
+	return page, rows.Err()
+}
+
+func (r *paymentRepo) ApartmentAdmin(
+	ctx context.Context, apartmentID common.ID,
+) (
+	common.ID, error,
+) {
+	query := `SELECT admin_id FROM apartments WHERE id = $1 AND deleted_at IS NULL`
+	var adminID common.ID
+	err := r.db.QueryRowContext(ctx, query, apartmentID).Scan(&adminID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, payment.ErrApartmentNotFound
+		}
+		return common.NilID, err
+	}
+	return adminID, nil
+}
+
+func (r *paymentRepo) UnpaidMembers(
+	ctx context.Context, apartmentID, billID common.ID,
+) (
+	[]paymentd.MemberBalance, error,
+) {
+	query := `
+		SELECT
+			a.user_id,
+			TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')),
+			u.email,
+			SUM(l.amount) AS balance_due
+		FROM ledger_lines l
+		JOIN ledger_accounts a ON a.id = l.account_id
+		JOIN ledger_entries e ON e.id = l.entry_id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.kind = $1 AND e.apartment_id = $2 AND e.bill_id = $3
+		GROUP BY a.user_id, u.first_name, u.last_name, u.email
+		HAVING SUM(l.amount) > 0
+		ORDER BY balance_due DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, ledgerd.AccountReceivable, apartmentID, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []paymentd.MemberBalance{}
+	for rows.Next() {
+		var m paymentd.MemberBalance
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &m.BalanceDue); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
 func (r *paymentRepo) UserBillBalanceDue(
 	ctx context.Context, userID, billID common.ID,
 ) (
 	int64, error,
+) {
+	return userBillBalanceDue(ctx, r.db, userID, billID)
+}
+
+// userBillBalanceDue returns what the user still owes on the bill: the
+// receivable in the ledger, less the paid payments not posted to it yet.
+func userBillBalanceDue(
+	ctx context.Context, db queryRower, userID, billID common.ID,
+) (
+	int64, error,
 ) {
 	query := `
-        SELECT
-            (b.amount / COUNT(ua2.user_id)) - COALESCE(SUM(p.amount), 0) AS balance_due
-        FROM users_apartments ua
-        JOIN bills b ON b.apartment_id = ua.apartment_id
-        JOIN users_apartments ua2 ON ua2.apartment_id = b.apartment_id AND ua2.created_at <= b.created_at
-        LEFT JOIN payments p ON p.bill_id = b.id AND p.payer_id = ua.user_id
-        WHERE ua.user_id = $1 AND b.id = $2
-        GROUP BY b.id, b.amount;
-    `
+		SELECT (
+			SELECT COALESCE(SUM(l.amount), 0)
+			FROM ledger_lines l
+			JOIN ledger_accounts a ON a.id = l.account_id
+			JOIN ledger_entries e ON e.id = l.entry_id
+			WHERE a.kind = $1 AND a.user_id = $2 AND e.bill_id = $3
+		) - (
+			SELECT COALESCE(SUM(p.amount), 0)
+			FROM payments p
+			WHERE p.payer_id = $2 AND p.bill_id = $3 AND p.status = $4
+				AND p.deleted_at IS NULL AND NOT EXISTS (
+					SELECT 1 FROM ledger_entries e
+					WHERE e.type IN ($5, $6, $7) AND e.reference = p.id::text
+				)
+		) AS balance_due
+	`
 
 	var balanceDue int64
-	err := r.db.QueryRowContext(ctx, query, userID.String(), billID.String()).Scan(&balanceDue)
+	err := db.QueryRowContext(ctx, query,
+		ledgerd.AccountReceivable, userID.String(), billID.String(), paymentd.PaymentPaid,
+		ledgerd.EntryPayment, ledgerd.EntryRefund, ledgerd.EntryWalletPayment,
+	).Scan(&balanceDue)
 	if err != nil {
 		return 0, err
 	}
@@ -219,28 +957,47 @@ func (r *paymentRepo) UserBillBalanceDue(
 	return balanceDue, nil
 }
 
+// lockBills serializes the payments of the given bills until tx ends, so
+// the balance due read in tx stays current. Bills are locked before the
+// payments, so two transactions can't wait on each other.
+func lockBills(ctx context.Context, tx *sql.Tx, billIDs ...common.ID) error {
+	if len(billIDs) == 0 {
+		return nil
+	}
+	args := make([]any, len(billIDs))
+	placeholders := make([]string, len(billIDs))
+	for i, id := range billIDs {
+		args[i] = id
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id FROM bills WHERE id IN (%s) ORDER BY id FOR NO KEY UPDATE
+	`, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
 func (r *paymentRepo) UserBillsBalanceDue(
 	ctx context.Context, userID common.ID,
 ) (
 	[]paymentd.BillWithAmount, error,
 ) {
 	query := `
-        SELECT
-			b.id AS bill_id,
-			ROUND((b.amount::numeric / COUNT(DISTINCT ua2.user_id)) - COALESCE(SUM(p.amount), 0), 2) AS balance_due
-		FROM users_apartments ua
-		JOIN bills b ON b.apartment_id = ua.apartment_id
-		JOIN users_apartments ua2 
-			ON ua2.apartment_id = b.apartment_id AND ua2.created_at <= b.created_at
-		LEFT JOIN payments p 
-			ON p.bill_id = b.id AND p.payer_id = ua.user_id AND p.deleted_at IS NULL
-		WHERE ua.user_id = $1
-		GROUP BY b.id, b.amount
-		HAVING ROUND((b.amount::numeric / COUNT(DISTINCT ua2.user_id)) - COALESCE(SUM(p.amount), 0), 2) > 0
-		ORDER BY b.created_at DESC;
-    `
-
-	rows, err := r.db.QueryContext(ctx, query, userID.String())
+		SELECT e.bill_id, SUM(l.amount) AS balance_due, b.due_date
+		FROM ledger_lines l
+		JOIN ledger_accounts a ON a.id = l.account_id
+		JOIN ledger_entries e ON e.id = l.entry_id
+		JOIN bills b ON b.id = e.bill_id
+		WHERE a.kind = $1 AND a.user_id = $2
+		GROUP BY e.bill_id, b.due_date, b.created_at
+		HAVING SUM(l.amount) > 0
+		ORDER BY b.due_date, b.created_at;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ledgerd.AccountReceivable, userID.String())
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +1006,7 @@ func (r *paymentRepo) UserBillsBalanceDue(
 	bills := []paymentd.BillWithAmount{}
 	for rows.Next() {
 		var bwa paymentd.BillWithAmount
-		err := rows.Scan(&bwa.BillID, &bwa.Amount)
+		err := rows.Scan(&bwa.BillID, &bwa.Amount, &bwa.DueDate)
 		if err != nil {
 			return nil, err
 		}
@@ -258,3 +1015,110 @@ func (r *paymentRepo) UserBillsBalanceDue(
 
 	return bills, nil
 }
+
+func (r *paymentRepo) ReserveIdempotencyKey(
+	ctx context.Context, k *paymentd.IdempotencyKey,
+) (
+	*paymentd.IdempotencyKey, bool, error,
+) {
+	existing, reserved, err := r.reserveIdempotencyKey(ctx, k)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the key was released between the insert and the read
+		existing, reserved, err = r.reserveIdempotencyKey(ctx, k)
+	}
+	return existing, reserved, err
+}
+
+func (r *paymentRepo) reserveIdempotencyKey(
+	ctx context.Context, k *paymentd.IdempotencyKey,
+) (
+	*paymentd.IdempotencyKey, bool, error,
+) {
+	// An expired key is taken over by the new request.
+	query := `
+		INSERT INTO payment_idempotency_keys (
+			idempotency_key,
+			user_id,
+			fingerprint,
+			expires_at
+		)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+			response = NULL,
+			expires_at = EXCLUDED.expires_at,
+			created_at = NOW(),
+			updated_at = NOW()
+		WHERE payment_idempotency_keys.expires_at < NOW()
+		RETURNING created_at
+	`
+	err := r.db.QueryRowContext(ctx, query,
+		k.Key, k.UserID, k.Fingerprint, k.ExpiresAt,
+	).Scan(&k.CreatedAt)
+	if err == nil {
+		return k, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	existing, err := r.getIdempotencyKey(ctx, k.UserID, k.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *paymentRepo) getIdempotencyKey(
+	ctx context.Context, userID common.ID, key string,
+) (
+	*paymentd.IdempotencyKey, error,
+) {
+	query := `
+		SELECT fingerprint, response, created_at, expires_at
+		FROM payment_idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	k := &paymentd.IdempotencyKey{Key: key, UserID: userID}
+	var response []byte
+	err := r.db.QueryRowContext(ctx, query, userID, key).
+		Scan(&k.Fingerprint, &response, &k.CreatedAt, &k.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) > 0 {
+		k.Response = new(paymentd.RedirectGateway)
+		if err = json.Unmarshal(response, k.Response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+		}
+	}
+	return k, nil
+}
+
+func (r *paymentRepo) SaveIdempotencyResponse(
+	ctx context.Context, k *paymentd.IdempotencyKey,
+) error {
+	response, err := json.Marshal(k.Response)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotent response: %w", err)
+	}
+	query := `
+		UPDATE payment_idempotency_keys
+		SET response = $1,
+			updated_at = NOW()
+		WHERE user_id = $2 AND idempotency_key = $3
+	`
+	_, err = r.db.ExecContext(ctx, query, response, k.UserID, k.Key)
+	return err
+}
+
+func (r *paymentRepo) DeleteIdempotencyKey(
+	ctx context.Context, userID common.ID, key string,
+) error {
+	query := `
+		DELETE FROM payment_idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, key)
+	return err
+}
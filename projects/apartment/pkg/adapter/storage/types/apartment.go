@@ -8,19 +8,21 @@ import (
 
 type Apartment struct {
 	Model
-	Name       string
-	Address    string
-	UnitNumber int64
-	AdminID    string
+	Name            string
+	Address         string
+	UnitNumber      int64
+	AdminID         string
+	RequireAdminMFA bool
 }
 
 func ApartmentDomainToStorage(a *aptDomain.Apartment) *Apartment {
 	return &Apartment{
-		Model:      Model{ID: a.ID.String()},
-		Name:       a.Name,
-		Address:    a.Address,
-		UnitNumber: a.UnitNumber,
-		AdminID:    a.AdminID.String(),
+		Model:           Model{ID: a.ID.String()},
+		Name:            a.Name,
+		Address:         a.Address,
+		UnitNumber:      a.UnitNumber,
+		AdminID:         a.AdminID.String(),
+		RequireAdminMFA: a.RequireAdminMFA,
 	}
 }
 
@@ -30,12 +32,13 @@ func ApartmentStorageToDomain(a *Apartment) *aptDomain.Apartment {
 	adminId := common.NilID
 	_ = adminId.UnmarshalText([]byte(a.AdminID))
 	return &aptDomain.Apartment{
-		ID:         id,
-		Name:       a.Name,
-		Address:    a.Address,
-		UnitNumber: a.UnitNumber,
-		AdminID:    adminId,
-		Members:    []aptDomain.ApartmentMember{},
-		Bills:      []bilDomain.Bill{},
+		ID:              id,
+		Name:            a.Name,
+		Address:         a.Address,
+		UnitNumber:      a.UnitNumber,
+		AdminID:         adminId,
+		RequireAdminMFA: a.RequireAdminMFA,
+		Members:         []aptDomain.ApartmentMember{},
+		Bills:           []bilDomain.Bill{},
 	}
 }
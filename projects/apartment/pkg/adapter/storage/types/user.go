@@ -8,29 +8,36 @@ import (
 )
 
 type User struct {
-	ID        string
-	Email     string
-	Password  string
-	FirstName sql.NullString
-	LastName  sql.NullString
+	ID              string
+	Email           string
+	Password        string
+	FirstName       sql.NullString
+	LastName        sql.NullString
+	Phone           sql.NullString
+	Locale          sql.NullString
+	AvatarURL       sql.NullString
+	EmailVerifiedAt sql.NullTime
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: len(s) > 0}
 }
 
 func UserDomainToStorage(u *userDomain.User) *User {
-	firstName := sql.NullString{
-		String: u.FirstName,
-		Valid:  len(u.FirstName) > 0,
-	}
-	lastName := sql.NullString{
-		String: u.LastName,
-		Valid:  len(u.LastName) > 0,
-	}
-	return &User{
+	user := &User{
 		ID:        u.ID.String(),
 		Email:     u.Email.String(),
 		Password:  string(u.Password()),
-		FirstName: firstName,
-		LastName:  lastName,
+		FirstName: nullString(u.FirstName),
+		LastName:  nullString(u.LastName),
+		Phone:     nullString(u.Phone),
+		Locale:    nullString(u.Locale),
+		AvatarURL: nullString(u.AvatarURL),
+	}
+	if u.EmailVerifiedAt != nil {
+		user.EmailVerifiedAt = sql.NullTime{Time: *u.EmailVerifiedAt, Valid: true}
 	}
+	return user
 }
 
 func UserStorageToDomain(u *User) *userDomain.User {
@@ -38,8 +45,17 @@ func UserStorageToDomain(u *User) *userDomain.User {
 	if err := uuid.Validate(u.ID); err == nil {
 		id = uuid.MustParse(u.ID)
 	}
-	return userDomain.NewUser(
-		id, u.Email, u.Password,
+	user := userDomain.NewUser(
+		id, u.Email, "",
 		u.FirstName.String, u.LastName.String,
 	)
+	// marks the stored bcrypt hash as hashed
+	user.SetPassword([]byte(u.Password))
+	user.Phone = u.Phone.String
+	user.Locale = u.Locale.String
+	user.AvatarURL = u.AvatarURL.String
+	if u.EmailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &u.EmailVerifiedAt.Time
+	}
+	return user
 }
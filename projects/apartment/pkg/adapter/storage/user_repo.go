@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"errors"
 
+	autopayd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/autopay/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	ledgerd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/ledger/domain"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/user"
 	userDomain "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/domain"
 	userPort "github.com/arcaptcha-internship-2025/momoein-apartment/internal/user/port"
 	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/adapter/storage/types"
 	appctx "github.com/arcaptcha-internship-2025/momoein-apartment/pkg/context"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -18,6 +22,14 @@ var (
 	ErrInvalidFilter     = errors.New("no valid filter provided")
 )
 
+const userColumns = `id, email, password, first_name, last_name, phone, locale, avatar_url, email_verified_at`
+
+// userFields returns the scan targets of userColumns.
+func userFields(u *types.User) []any {
+	return []any{&u.ID, &u.Email, &u.Password, &u.FirstName, &u.LastName,
+		&u.Phone, &u.Locale, &u.AvatarURL, &u.EmailVerifiedAt}
+}
+
 type userRepo struct {
 	db *sql.DB
 }
@@ -33,11 +45,11 @@ func (r *userRepo) Create(ctx context.Context, ud *userDomain.User) (*userDomain
 
 	var id string
 	err := r.db.QueryRowContext(ctx, `
-		INSERT INTO users(email, password)
-		VALUES($1, $2)
+		INSERT INTO users(email, password, first_name, last_name)
+		VALUES($1, $2, $3, $4)
 		ON CONFLICT (email) DO NOTHING
 		RETURNING id;`,
-		u.Email, u.Password,
+		u.Email, u.Password, u.FirstName, u.LastName,
 	).Scan(&id)
 
 	if err != nil {
@@ -67,13 +79,13 @@ func (r *userRepo) Get(
 
 	switch {
 	case filter.ID != userDomain.NilID && filter.Email != "":
-		query = `SELECT id, email, password, first_name, last_name FROM users WHERE id = $1 AND email = $2;`
+		query = `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND email = $2 AND deleted_at IS NULL;`
 		args = []any{filter.ID, filter.Email}
 	case filter.ID != userDomain.NilID:
-		query = `SELECT id, email, password, first_name, last_name FROM users WHERE id = $1;`
+		query = `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND deleted_at IS NULL;`
 		args = []any{filter.ID}
 	case filter.Email != "":
-		query = `SELECT id, email, password, first_name, last_name FROM users WHERE email = $1;`
+		query = `SELECT ` + userColumns + ` FROM users WHERE email = $1 AND deleted_at IS NULL;`
 		args = []any{filter.Email}
 	default:
 		return nil, errors.New("no valid filter provided")
@@ -81,7 +93,7 @@ func (r *userRepo) Get(
 
 	var u types.User
 	err := r.db.QueryRowContext(ctx, query, args...).
-		Scan(&u.ID, &u.Email, &u.Password, &u.FirstName, &u.LastName)
+		Scan(userFields(&u)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			log.Warn("user not found", zap.Any("filter", filter))
@@ -104,13 +116,13 @@ func (r *userRepo) Delete(ctx context.Context, f *userDomain.UserFilter) error {
 	)
 
 	if f.ID != userDomain.NilID && f.Email != "" {
-		query = `DELETE FROM users WHERE id = $1 AND email = $2;`
+		query = `DELETE FROM users WHERE id = $1 AND email = $2 AND deleted_at IS NULL;`
 		args = []any{f.ID, f.Email}
 	} else if f.ID != userDomain.NilID {
-		query = `DELETE FROM users WHERE id = $1;`
+		query = `DELETE FROM users WHERE id = $1 AND deleted_at IS NULL;`
 		args = []any{f.ID}
 	} else if f.Email != "" {
-		query = `DELETE FROM users WHERE email = $1;`
+		query = `DELETE FROM users WHERE email = $1 AND deleted_at IS NULL;`
 		args = []any{f.Email}
 	} else {
 		return ErrInvalidFilter
@@ -129,3 +141,216 @@ func (r *userRepo) Delete(ctx context.Context, f *userDomain.UserFilter) error {
 	)
 	return nil
 }
+
+func (r *userRepo) Update(ctx context.Context, ud *userDomain.User) (*userDomain.User, error) {
+	log := appctx.Logger(ctx)
+
+	u := types.UserDomainToStorage(ud)
+	// a changed email clears email_verification_sent_at so that the new
+	// address gets its verification email at once
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE users SET
+			email = $2,
+			password = $3,
+			first_name = $4,
+			last_name = $5,
+			phone = $6,
+			locale = $7,
+			avatar_url = $8,
+			email_verified_at = $9,
+			email_verification_sent_at = CASE WHEN email = $2 THEN email_verification_sent_at END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns,
+		ud.ID, u.Email, u.Password, u.FirstName, u.LastName,
+		u.Phone, u.Locale, u.AvatarURL, u.EmailVerifiedAt,
+	).Scan(userFields(u)...)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, user.ErrUserNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			return nil, user.ErrEmailTaken
+		}
+		log.Error("failed to update user", zap.Error(err))
+		return nil, err
+	}
+	return types.UserStorageToDomain(u), nil
+}
+
+func (r *userRepo) DeleteAccount(ctx context.Context, userID userDomain.UserID) (err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// locks the user against concurrent deletes
+	var email string
+	err = tx.QueryRowContext(ctx, `
+		SELECT email FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, userID).Scan(&email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var (
+		owned bool
+		debt  sql.NullInt64
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM apartments WHERE admin_id = $1 AND deleted_at IS NULL),
+			(SELECT SUM(l.amount)
+			 FROM ledger_lines l
+			 JOIN ledger_accounts a ON a.id = l.account_id
+			 WHERE a.kind = $2 AND a.user_id = $1)
+	`, userID, ledgerd.AccountReceivable).Scan(&owned, &debt)
+	if err != nil {
+		return err
+	}
+	if owned {
+		return user.ErrOwnsApartments
+	}
+	if debt.Int64 > 0 {
+		return user.ErrHasDebt
+	}
+
+	// payments, payment claims and ledger entries stay for the apartment's
+	// books, pointing at the scrubbed user
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE apartment_invites SET deleted_at = NOW(), updated_at = NOW()
+		  WHERE invite_email = $1 AND deleted_at IS NULL`, []any{email}},
+		{`UPDATE users_apartments SET deleted_at = NOW(), updated_at = NOW()
+		  WHERE user_id = $1 AND deleted_at IS NULL`, []any{userID}},
+		{`UPDATE autopay_enrollments SET status = $2, token = NULL, card_mask = NULL, updated_at = NOW()
+		  WHERE user_id = $1`, []any{userID, autopayd.EnrollmentCancelled}},
+		{`UPDATE refresh_tokens SET revoked_at = NOW()
+		  WHERE user_id = $1 AND revoked_at IS NULL`, []any{userID}},
+		{`DELETE FROM password_resets WHERE user_id = $1`, []any{userID}},
+		{`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, []any{userID}},
+		{`DELETE FROM user_totp WHERE user_id = $1`, []any{userID}},
+		{`DELETE FROM user_identities WHERE user_id = $1`, []any{userID}},
+		{`DELETE FROM personal_access_tokens WHERE user_id = $1`, []any{userID}},
+		{`UPDATE users SET
+			email = 'deleted-' || id::text || '@deleted.invalid',
+			password = '',
+			first_name = NULL,
+			last_name = NULL,
+			phone = NULL,
+			locale = NULL,
+			avatar_url = NULL,
+			email_verified_at = NULL,
+			email_verification_sent_at = NULL,
+			deleted_at = NOW(),
+			updated_at = NOW()
+		  WHERE id = $1`, []any{userID}},
+	}
+	for _, st := range stmts {
+		if _, err = tx.ExecContext(ctx, st.query, st.args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *userRepo) Export(ctx context.Context, userID userDomain.UserID) (*userDomain.Export, error) {
+	u, err := r.Get(ctx, &userDomain.UserFilter{ID: userID})
+	if err != nil {
+		return nil, err
+	}
+	e := &userDomain.Export{Profile: u}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, a.name, a.address, a.unit_number, FALSE, ua.created_at, ua.deleted_at
+		FROM users_apartments ua
+		JOIN apartments a ON a.id = ua.apartment_id
+		WHERE ua.user_id = $1
+		UNION ALL
+		SELECT a.id, a.name, a.address, a.unit_number, TRUE, a.created_at, a.deleted_at
+		FROM apartments a
+		WHERE a.admin_id = $1
+		ORDER BY 6
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			m      userDomain.Membership
+			leftAt sql.NullTime
+		)
+		err := rows.Scan(&m.ApartmentID, &m.Name, &m.Address, &m.UnitNumber,
+			&m.IsAdmin, &m.JoinedAt, &leftAt)
+		if err != nil {
+			return nil, err
+		}
+		if leftAt.Valid {
+			m.LeftAt = &leftAt.Time
+		}
+		e.Memberships = append(e.Memberships, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	shares, err := (&billRepo{db: r.db}).GetUserBillShares(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shares {
+		e.BillShares = append(e.BillShares, userDomain.BillShare{
+			BillID:     s.BillID,
+			BillName:   s.BillName,
+			BillAmount: int64(s.TotalAmount),
+			Share:      int64(s.SharePerUser),
+			Paid:       int64(s.UserPaid),
+			BalanceDue: int64(s.BalanceDue),
+		})
+	}
+
+	payments, err := r.db.QueryContext(ctx, `
+		SELECT id, created_at, bill_id, amount, status, gateway, paid_at, refund_of,
+			COALESCE(description, '')
+		FROM payments
+		WHERE payer_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer payments.Close()
+	for payments.Next() {
+		var (
+			p        userDomain.Payment
+			paidAt   sql.NullTime
+			refundOf sql.NullString
+		)
+		err := payments.Scan(&p.ID, &p.CreatedAt, &p.BillID, &p.Amount, &p.Status,
+			&p.Gateway, &paidAt, &refundOf, &p.Description)
+		if err != nil {
+			return nil, err
+		}
+		if paidAt.Valid {
+			p.PaidAt = &paidAt.Time
+		}
+		if refundOf.Valid {
+			id := common.IDFromText(refundOf.String)
+			p.RefundOf = &id
+		}
+		e.Payments = append(e.Payments, p)
+	}
+	return e, payments.Err()
+}
@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	paymentd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/payment/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/wallet/port"
+)
+
+type walletRepo struct {
+	db       *sql.DB
+	payments *paymentRepo
+}
+
+func NewWalletRepo(db *sql.DB) port.Repo {
+	return &walletRepo{db: db, payments: &paymentRepo{db: db}}
+}
+
+func (r *walletRepo) IsApartmentMember(
+	ctx context.Context, userID, apartmentID common.ID,
+) (
+	bool, error,
+) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM users_apartments
+			WHERE user_id = $1 AND apartment_id = $2 AND deleted_at IS NULL
+		)
+	`
+	var ok bool
+	err := r.db.QueryRowContext(ctx, query, userID, apartmentID).Scan(&ok)
+	return ok, err
+}
+
+func (r *walletRepo) GetOrCreate(
+	ctx context.Context, userID, apartmentID common.ID,
+) (
+	*domain.Wallet, error,
+) {
+	query := `
+		INSERT INTO wallets (user_id, apartment_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, apartment_id) DO UPDATE SET updated_at = wallets.updated_at
+		RETURNING id, created_at, updated_at, user_id, apartment_id, balance
+	`
+	var w domain.Wallet
+	err := r.db.QueryRowContext(ctx, query, userID, apartmentID).Scan(
+		&w.ID, &w.CreatedAt, &w.UpdatedAt, &w.UserID, &w.ApartmentID, &w.Balance,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *walletRepo) Get(
+	ctx context.Context, userID, apartmentID common.ID,
+) (
+	*domain.Wallet, error,
+) {
+	query := `
+		SELECT id, created_at, updated_at, user_id, apartment_id, balance
+		FROM wallets
+		WHERE user_id = $1 AND apartment_id = $2
+	`
+	var w domain.Wallet
+	err := r.db.QueryRowContext(ctx, query, userID, apartmentID).Scan(
+		&w.ID, &w.CreatedAt, &w.UpdatedAt, &w.UserID, &w.ApartmentID, &w.Balance,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, wallet.ErrWalletNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *walletRepo) BillWallets(
+	ctx context.Context, billID common.ID,
+) (
+	[]*domain.Wallet, error,
+) {
+	query := `
+		SELECT w.id, w.created_at, w.updated_at, w.user_id, w.apartment_id, w.balance
+		FROM bills b
+		JOIN wallets w ON w.apartment_id = b.apartment_id
+		WHERE b.id = $1 AND w.balance > 0
+	`
+	rows, err := r.db.QueryContext(ctx, query, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []*domain.Wallet{}
+	for rows.Next() {
+		var w domain.Wallet
+		err := rows.Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt, &w.UserID, &w.ApartmentID, &w.Balance)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, &w)
+	}
+	return wallets, rows.Err()
+}
+
+func (r *walletRepo) BillApartment(
+	ctx context.Context, billID common.ID,
+) (
+	common.ID, error,
+) {
+	var apartmentID common.ID
+	err := r.db.QueryRowContext(ctx,
+		`SELECT apartment_id FROM bills WHERE id = $1 AND deleted_at IS NULL`, billID,
+	).Scan(&apartmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, wallet.ErrBillNotFound
+		}
+		return common.NilID, err
+	}
+	return apartmentID, nil
+}
+
+func (r *walletRepo) UserBillBalanceDue(
+	ctx context.Context, userID, billID common.ID,
+) (
+	int64, error,
+) {
+	due, err := r.payments.UserBillBalanceDue(ctx, userID, billID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the bill was issued before the user joined
+		return 0, nil
+	}
+	return due, err
+}
+
+func (r *walletRepo) CreateTransaction(
+	ctx context.Context, t *domain.Transaction,
+) (
+	*domain.Transaction, error,
+) {
+	return createWalletTransaction(ctx, r.db, t)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func createWalletTransaction(
+	ctx context.Context, db queryRower, t *domain.Transaction,
+) (
+	*domain.Transaction, error,
+) {
+	query := `
+		INSERT INTO wallet_transactions (
+			wallet_id,
+			type,
+			amount,
+			status,
+			gateway,
+			transaction_id,
+			payment_id,
+			bill_id,
+			description
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	err := db.QueryRowContext(ctx, query,
+		t.WalletID,
+		t.Type,
+		t.Amount,
+		t.Status,
+		t.Gateway,
+		t.TransactionID,
+		t.PaymentID,
+		t.BillID,
+		t.Description,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *walletRepo) SetGatewayTransactionID(
+	ctx context.Context, id common.ID, transactionID string,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE wallet_transactions
+		SET transaction_id = $1, updated_at = NOW()
+		WHERE id = $2
+	`, transactionID, id)
+	return err
+}
+
+func (r *walletRepo) CompleteTopUp(
+	ctx context.Context, id common.ID, transactionID string, amount int64,
+) (
+	*domain.Transaction, *domain.Wallet, error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	t := domain.Transaction{ID: id, Type: domain.TransactionTopUp, Status: domain.TransactionCompleted}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE wallet_transactions
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND type = $3 AND status = $4
+			AND transaction_id = $5 AND amount = $6
+		RETURNING created_at, updated_at, wallet_id, amount, transaction_id
+	`, domain.TransactionCompleted, id, domain.TransactionTopUp, domain.TransactionPending,
+		transactionID, amount,
+	).Scan(&t.CreatedAt, &t.UpdatedAt, &t.WalletID, &t.Amount, &t.TransactionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, r.topUpNotCompleted(ctx, tx, id)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var w domain.Wallet
+	err = tx.QueryRowContext(ctx, `
+		UPDATE wallets SET balance = balance + $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING id, created_at, updated_at, user_id, apartment_id, balance
+	`, t.Amount, t.WalletID).Scan(
+		&w.ID, &w.CreatedAt, &w.UpdatedAt, &w.UserID, &w.ApartmentID, &w.Balance,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := eventd.New(eventd.WalletToppedUp, t.ID, eventd.WalletToppedUpPayload{
+		WalletTransactionID: t.ID,
+		WalletID:            w.ID,
+		UserID:              w.UserID,
+		ApartmentID:         w.ApartmentID,
+		Amount:              t.Amount,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = insertEvents(ctx, tx, e); err != nil {
+		return nil, nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return &t, &w, nil
+}
+
+// topUpNotCompleted tells why CompleteTopUp matched no row.
+func (r *walletRepo) topUpNotCompleted(ctx context.Context, tx *sql.Tx, id common.ID) error {
+	var status domain.TransactionStatus
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM wallet_transactions WHERE id = $1 AND type = $2
+	`, id, domain.TransactionTopUp).Scan(&status)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return wallet.ErrTopUpMismatch
+	case err != nil:
+		return err
+	case status != domain.TransactionPending:
+		return wallet.ErrTopUpSettled
+	}
+	return wallet.ErrTopUpMismatch
+}
+
+func (r *walletRepo) PayBill(
+	ctx context.Context, w *domain.Wallet, p *paymentd.Payment,
+) (
+	_ *paymentd.Payment, err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockBills(ctx, tx, p.BillID); err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE wallets SET balance = balance - $1, updated_at = NOW()
+		WHERE id = $2 AND balance >= $1
+	`, p.Amount, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, wallet.ErrInsufficientCredit
+	}
+
+	var idStr string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (bill_id, payer_id, amount, paid_at, status, gateway)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, p.BillID, p.PayerID, p.Amount, p.PaidAt, p.Status, p.Gateway).Scan(&idStr)
+	if err != nil {
+		return nil, err
+	}
+	p.ID = common.IDFromText(idStr)
+	p.CreatedAt = time.Now().UTC()
+	p.UpdatedAt = p.CreatedAt
+
+	_, err = createWalletTransaction(ctx, tx, &domain.Transaction{
+		WalletID:  w.ID,
+		Type:      domain.TransactionBillPayment,
+		Amount:    -p.Amount,
+		Status:    domain.TransactionCompleted,
+		PaymentID: &p.ID,
+		BillID:    &p.BillID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = insertPaymentSucceeded(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *walletRepo) Transactions(
+	ctx context.Context, walletID common.ID,
+) (
+	[]domain.Transaction, error,
+) {
+	query := `
+		SELECT id, created_at, updated_at, wallet_id, type, amount, status,
+			COALESCE(gateway, ''), COALESCE(transaction_id, ''),
+			payment_id, bill_id, COALESCE(description, '')
+		FROM wallet_transactions
+		WHERE wallet_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := []domain.Transaction{}
+	for rows.Next() {
+		var (
+			t                 domain.Transaction
+			paymentID, billID sql.NullString
+		)
+		err := rows.Scan(
+			&t.ID, &t.CreatedAt, &t.UpdatedAt, &t.WalletID, &t.Type, &t.Amount, &t.Status,
+			&t.Gateway, &t.TransactionID, &paymentID, &billID, &t.Description,
+		)
+		if err != nil {
+			return nil, err
+		}
+		t.PaymentID = nullID(paymentID)
+		t.BillID = nullID(billID)
+		ts = append(ts, t)
+	}
+	return ts, rows.Err()
+}
+
+func nullID(s sql.NullString) *common.ID {
+	if !s.Valid {
+		return nil
+	}
+	id := common.IDFromText(s.String)
+	return &id
+}
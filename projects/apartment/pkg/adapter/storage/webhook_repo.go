@@ -0,0 +1,362 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/common"
+	eventd "github.com/arcaptcha-internship-2025/momoein-apartment/internal/event/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/domain"
+	"github.com/arcaptcha-internship-2025/momoein-apartment/internal/webhook/port"
+	"github.com/lib/pq"
+)
+
+const endpointColumns = `id, created_at, updated_at, apartment_id, url, secret, event_types`
+
+const deliveryColumns = `
+	id, created_at, updated_at, endpoint_id, event_id, event_type, payload, status,
+	attempts, next_attempt_at, COALESCE(response_status, 0), COALESCE(last_error, ''),
+	delivered_at`
+
+type webhookRepo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepo(db *sql.DB) port.Repo {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) ApartmentAdmin(
+	ctx context.Context, apartmentID common.ID,
+) (
+	common.ID, error,
+) {
+	query := `SELECT admin_id FROM apartments WHERE id = $1 AND deleted_at IS NULL`
+	var adminID common.ID
+	err := r.db.QueryRowContext(ctx, query, apartmentID).Scan(&adminID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, webhook.ErrApartmentNotFound
+		}
+		return common.NilID, err
+	}
+	return adminID, nil
+}
+
+func (r *webhookRepo) BillApartment(
+	ctx context.Context, billID common.ID,
+) (
+	common.ID, error,
+) {
+	var apartmentID common.ID
+	err := r.db.QueryRowContext(ctx,
+		`SELECT apartment_id FROM bills WHERE id = $1`, billID,
+	).Scan(&apartmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return common.NilID, webhook.ErrApartmentNotFound
+		}
+		return common.NilID, err
+	}
+	return apartmentID, nil
+}
+
+func (r *webhookRepo) CreateEndpoint(
+	ctx context.Context, e *domain.Endpoint,
+) (
+	*domain.Endpoint, error,
+) {
+	query := `
+		INSERT INTO webhook_endpoints (apartment_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + endpointColumns
+	return scanEndpoint(r.db.QueryRowContext(ctx, query,
+		e.ApartmentID, e.URL, e.Secret, pq.Array(eventTypeStrings(e.EventTypes)),
+	))
+}
+
+func (r *webhookRepo) GetEndpoint(
+	ctx context.Context, id common.ID,
+) (
+	*domain.Endpoint, error,
+) {
+	query := `SELECT ` + endpointColumns + ` FROM webhook_endpoints WHERE id = $1`
+	e, err := scanEndpoint(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhook.ErrEndpointNotFound
+		}
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *webhookRepo) Endpoints(
+	ctx context.Context, apartmentID common.ID,
+) (
+	[]domain.Endpoint, error,
+) {
+	query := `
+		SELECT ` + endpointColumns + `
+		FROM webhook_endpoints
+		WHERE apartment_id = $1
+		ORDER BY created_at
+	`
+	return r.queryEndpoints(ctx, query, apartmentID)
+}
+
+func (r *webhookRepo) SubscribedEndpoints(
+	ctx context.Context, apartmentID common.ID, t eventd.Type,
+) (
+	[]domain.Endpoint, error,
+) {
+	query := `
+		SELECT ` + endpointColumns + `
+		FROM webhook_endpoints
+		WHERE apartment_id = $1 AND $2 = ANY(event_types)
+		ORDER BY created_at
+	`
+	return r.queryEndpoints(ctx, query, apartmentID, t)
+}
+
+func (r *webhookRepo) queryEndpoints(
+	ctx context.Context, query string, args ...any,
+) (
+	[]domain.Endpoint, error,
+) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	es := []domain.Endpoint{}
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, *e)
+	}
+	return es, rows.Err()
+}
+
+func (r *webhookRepo) DeleteEndpoint(ctx context.Context, id common.ID) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return webhook.ErrEndpointNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepo) CreateDeliveries(
+	ctx context.Context, ds []domain.Delivery,
+) (
+	err error,
+) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, d := range ds {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, status)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (endpoint_id, event_id) DO NOTHING
+		`, d.EndpointID, d.EventID, d.EventType, []byte(d.Payload), domain.DeliveryPending)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *webhookRepo) GetDelivery(
+	ctx context.Context, id common.ID,
+) (
+	*domain.Delivery, error,
+) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	d, err := scanDelivery(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhook.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *webhookRepo) Deliveries(
+	ctx context.Context, endpointID common.ID, limit int,
+) (
+	[]domain.Delivery, error,
+) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	return r.queryDeliveries(ctx, query, endpointID, limit)
+}
+
+func (r *webhookRepo) ClaimDeliveries(
+	ctx context.Context, now, leaseUntil time.Time, limit int,
+) (
+	[]domain.Delivery, error,
+) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY created_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+	ds, err := r.queryDeliveries(ctx, query, leaseUntil, domain.DeliveryPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].CreatedAt.Before(ds[j].CreatedAt)
+	})
+	return ds, nil
+}
+
+func (r *webhookRepo) queryDeliveries(
+	ctx context.Context, query string, args ...any,
+) (
+	[]domain.Delivery, error,
+) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ds := []domain.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, *d)
+	}
+	return ds, rows.Err()
+}
+
+func (r *webhookRepo) MarkDelivered(
+	ctx context.Context, id common.ID, responseStatus int,
+) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, response_status = $2, delivered_at = NOW(), updated_at = NOW()
+		WHERE id = $3
+	`, domain.DeliverySucceeded, nullStatus(responseStatus), id)
+	return err
+}
+
+func (r *webhookRepo) MarkFailed(
+	ctx context.Context, id common.ID, responseStatus int, reason string, retryAt *time.Time,
+) error {
+	status := domain.DeliveryPending
+	if retryAt == nil {
+		status = domain.DeliveryFailed
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, response_status = $2, last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at), updated_at = NOW()
+		WHERE id = $5
+	`, status, nullStatus(responseStatus), reason, retryAt, id)
+	return err
+}
+
+func (r *webhookRepo) ResetDelivery(
+	ctx context.Context, id common.ID,
+) (
+	*domain.Delivery, error,
+) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = NOW(), response_status = NULL,
+			last_error = NULL, delivered_at = NULL, updated_at = NOW()
+		WHERE id = $2 AND status <> $1
+		RETURNING ` + deliveryColumns
+	d, err := scanDelivery(r.db.QueryRowContext(ctx, query, domain.DeliveryPending, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhook.ErrDeliveryInProgress
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// nullStatus stores a missing HTTP response as NULL.
+func nullStatus(status int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(status), Valid: status != 0}
+}
+
+func eventTypeStrings(ts []eventd.Type) []string {
+	ss := make([]string, len(ts))
+	for i, t := range ts {
+		ss[i] = t.String()
+	}
+	return ss
+}
+
+func scanEndpoint(row scanner) (*domain.Endpoint, error) {
+	var (
+		e     domain.Endpoint
+		types []string
+	)
+	err := row.Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt, &e.ApartmentID, &e.URL, &e.Secret,
+		pq.Array(&types))
+	if err != nil {
+		return nil, err
+	}
+	e.EventTypes = make([]eventd.Type, len(types))
+	for i, t := range types {
+		e.EventTypes[i] = eventd.Type(t)
+	}
+	return &e, nil
+}
+
+func scanDelivery(row scanner) (*domain.Delivery, error) {
+	var (
+		d           domain.Delivery
+		deliveredAt sql.NullTime
+	)
+	err := row.Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt, &d.EndpointID, &d.EventID, &d.EventType,
+		&d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.ResponseStatus, &d.LastError,
+		&deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
@@ -8,29 +8,55 @@ import (
 )
 
 var (
-	ErrNilToken     = errors.New("invalid token (nil)")
-	ErrInvalidToken = errors.New("token is not valid")
+	ErrNilToken       = errors.New("invalid token (nil)")
+	ErrInvalidToken   = errors.New("token is not valid")
+	ErrWrongTokenType = errors.New("wrong token type")
 )
 
 const (
 	UserEmailKey appctx.CtxKey = "UserEmail"
 	UserIDKey    appctx.CtxKey = "UserID"
+	// MFAKey is set when the access token was issued after a second
+	// factor.
+	MFAKey appctx.CtxKey = "MFA"
+)
+
+// TokenType tells access tokens from refresh tokens, so that one can't be
+// used as the other.
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+	// EmailVerifyToken is sent in email verification links.
+	EmailVerifyToken TokenType = "email-verify"
+	// MFAChallengeToken is handed out on sign-in in place of tokens to
+	// users with two-factor authentication.
+	MFAChallengeToken TokenType = "mfa-challenge"
+	// OIDCStateToken keeps an OpenID Connect sign-in between the redirect
+	// to the provider and the callback.
+	OIDCStateToken TokenType = "oidc-state"
 )
 
 type UserClaims struct {
 	jwt.RegisteredClaims
 	UserID    string
 	UserEMail string
+	Type      TokenType `json:"typ"`
+	// FamilyID groups the refresh tokens rotated from one sign-in.
+	FamilyID string `json:"fid,omitempty"`
+	// MFA tells that the user passed two-factor authentication on the
+	// sign-in the token descends from.
+	MFA bool `json:"mfa,omitempty"`
 }
 
-func CreateToken(secret []byte, claims *UserClaims) (string, error) {
-	return jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(secret)
+func CreateToken(keys *KeySet, claims *UserClaims) (string, error) {
+	keys.register(&claims.RegisteredClaims)
+	return keys.sign(claims)
 }
 
-func ParseToken(tokenString string, secret []byte) (*UserClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+func ParseToken(tokenString string, keys *KeySet) (*UserClaims, error) {
+	token, err := keys.parse(tokenString, &UserClaims{})
 
 	if token == nil {
 		return nil, ErrNilToken
@@ -54,3 +80,15 @@ func ParseToken(tokenString string, secret []byte) (*UserClaims, error) {
 
 	return claim, nil
 }
+
+// ParseTokenOfType parses a token and checks it is of type typ.
+func ParseTokenOfType(tokenString string, keys *KeySet, typ TokenType) (*UserClaims, error) {
+	claims, err := ParseToken(tokenString, keys)
+	if err != nil {
+		return claims, err
+	}
+	if claims.Type != typ {
+		return claims, ErrWrongTokenType
+	}
+	return claims, nil
+}
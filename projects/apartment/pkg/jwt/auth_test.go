@@ -0,0 +1,27 @@
+package appjwt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenOfType(t *testing.T) {
+	keys := NewSecretKeySet([]byte("secret"))
+
+	token, err := CreateToken(keys, &UserClaims{UserID: "id", Type: AccessToken})
+	assert.NoError(t, err)
+
+	claims, err := ParseTokenOfType(token, keys, AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "id", claims.UserID)
+
+	_, err = ParseTokenOfType(token, keys, RefreshToken)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+
+	// tokens issued before token types are neither
+	legacy, err := CreateToken(keys, &UserClaims{UserID: "id"})
+	assert.NoError(t, err)
+	_, err = ParseTokenOfType(legacy, keys, AccessToken)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+}
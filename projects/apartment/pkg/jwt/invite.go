@@ -14,7 +14,7 @@ func CreateInviteToken(secret []byte, claims *InviteClaims) (string, error) {
 func ParseInviteToken(tokenString string, secret []byte) (*InviteClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &InviteClaims{}, func(t *jwt.Token) (interface{}, error) {
 		return secret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if token == nil {
 		return nil, ErrNilToken
@@ -0,0 +1,322 @@
+package appjwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey     = errors.New("token signed by an unknown key")
+	ErrWrongAlgorithm = errors.New("token algorithm doesn't match its key")
+	ErrNoSigningKey   = errors.New("signing key has no private key")
+	ErrDuplicateKeyID = errors.New("duplicate key id")
+	ErrUnsupportedKey = errors.New("unsupported key, use RSA of at least 2048 bits or Ed25519")
+)
+
+// minRSABits is the smallest RSA key tokens are signed or verified with.
+const minRSABits = 2048
+
+// Key signs or verifies tokens with one algorithm. Keys without a private
+// part only verify, e.g. keys rotated out that tokens are still around
+// for.
+type Key struct {
+	id      string
+	method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+// NewHMACKey returns an HS512 key of secret. Its id is empty, matching
+// tokens signed before key ids, and it is never published.
+func NewHMACKey(secret []byte) *Key {
+	return &Key{method: jwt.SigningMethodHS512, private: secret, public: secret}
+}
+
+// NewPrivateKey returns an RS256 key of an RSA key or an EdDSA key of an
+// Ed25519 key. Its id is the RFC 7638 thumbprint of the public key.
+func NewPrivateKey(priv crypto.Signer) (*Key, error) {
+	k, err := NewPublicKey(priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	k.private = priv
+	return k, nil
+}
+
+// NewPublicKey returns a key that only verifies tokens.
+func NewPublicKey(pub crypto.PublicKey) (*Key, error) {
+	k := &Key{public: pub}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, ErrUnsupportedKey
+		}
+		k.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	jwk := k.jwk()
+	thumb, err := json.Marshal(jwk.thumbprintMembers())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(thumb)
+	k.id = base64.RawURLEncoding.EncodeToString(sum[:])
+	return k, nil
+}
+
+// ParseKeyPEM parses a PKCS #8 or PKCS #1 private key, or a PKIX or
+// PKCS #1 public key.
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		return NewPrivateKey(signer)
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewPrivateKey(priv)
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewPublicKey(pub)
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return NewPublicKey(pub)
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+func (k *Key) ID() string {
+	return k.id
+}
+
+func (k *Key) Alg() string {
+	return k.method.Alg()
+}
+
+// CanSign tells if the key has its private part.
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *Key) symmetric() bool {
+	_, ok := k.public.([]byte)
+	return ok
+}
+
+// JWK is a public key as published in a JWKS, RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP, RFC 8037
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the key set served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k *Key) jwk() JWK {
+	jwk := JWK{Kid: k.id, Use: "sig", Alg: k.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// thumbprintMembers returns the required members of the key, which
+// json.Marshal writes in the lexicographic order RFC 7638 hashes.
+func (j JWK) thumbprintMembers() map[string]string {
+	if j.Kty == "RSA" {
+		return map[string]string{"e": j.E, "kty": j.Kty, "n": j.N}
+	}
+	return map[string]string{"crv": j.Crv, "kty": j.Kty, "x": j.X}
+}
+
+// KeySet signs tokens with its signing key and verifies them with any of
+// its keys, picked by the kid header. Rotating keeps the old key as a
+// verifying key until the tokens it signed expire.
+type KeySet struct {
+	signing  *Key
+	keys     map[string]*Key
+	issuer   string
+	audience string
+}
+
+type KeySetOpt func(*KeySet)
+
+// WithVerifyKeys adds keys tokens are accepted from but not signed with.
+func WithVerifyKeys(keys ...*Key) KeySetOpt {
+	return func(ks *KeySet) {
+		for _, k := range keys {
+			if k != nil {
+				ks.keys[k.id] = k
+			}
+		}
+	}
+}
+
+// WithIssuer sets the iss claim of tokens, and requires it of the tokens
+// parsed.
+func WithIssuer(iss string) KeySetOpt {
+	return func(ks *KeySet) {
+		if iss != "" {
+			ks.issuer = iss
+		}
+	}
+}
+
+// WithAudience sets the aud claim of tokens, and requires it of the tokens
+// parsed.
+func WithAudience(aud string) KeySetOpt {
+	return func(ks *KeySet) {
+		if aud != "" {
+			ks.audience = aud
+		}
+	}
+}
+
+func NewKeySet(signing *Key, opts ...KeySetOpt) (*KeySet, error) {
+	if signing == nil || !signing.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	if _, ok := ks.keys[signing.id]; ok {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateKeyID, signing.id)
+	}
+	ks.signing = signing
+	ks.keys[signing.id] = signing
+	return ks, nil
+}
+
+// NewSecretKeySet returns a key set that signs and verifies with secret,
+// as tokens were before key sets.
+func NewSecretKeySet(secret []byte, opts ...KeySetOpt) *KeySet {
+	ks, _ := NewKeySet(NewHMACKey(secret), opts...)
+	return ks
+}
+
+// SigningKey returns the key tokens are signed with.
+func (ks *KeySet) SigningKey() *Key {
+	return ks.signing
+}
+
+// JWKS returns the public keys of the set, for other services to verify
+// tokens with. Secrets are left out.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range slices.Sorted(maps.Keys(ks.keys)) {
+		if k := ks.keys[id]; !k.symmetric() {
+			jwks.Keys = append(jwks.Keys, k.jwk())
+		}
+	}
+	return jwks
+}
+
+// register sets the issuer and audience of the set on claims about to be
+// signed.
+func (ks *KeySet) register(claims *jwt.RegisteredClaims) {
+	if ks.issuer != "" {
+		claims.Issuer = ks.issuer
+	}
+	if ks.audience != "" {
+		claims.Audience = jwt.ClaimStrings{ks.audience}
+	}
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		t.Header["kid"] = ks.signing.id
+	}
+	return t.SignedString(ks.signing.private)
+}
+
+// parse verifies tokenString into claims. The key is picked by the kid
+// header, and the token has to be signed with the algorithm of the key, so
+// a public key can't be used as an HMAC secret.
+func (ks *KeySet) parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods(ks.algs()))
+	if ks.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(ks.issuer))
+	}
+	if ks.audience != "" {
+		opts = append(opts, jwt.WithAudience(ks.audience))
+	}
+	return jwt.ParseWithClaims(tokenString, claims, ks.keyFunc, opts...)
+}
+
+func (ks *KeySet) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != k.Alg() {
+		return nil, ErrWrongAlgorithm
+	}
+	return k.public, nil
+}
+
+func (ks *KeySet) algs() []string {
+	var algs []string
+	seen := make(map[string]bool)
+	for _, k := range ks.keys {
+		if !seen[k.Alg()] {
+			seen[k.Alg()] = true
+			algs = append(algs, k.Alg())
+		}
+	}
+	return algs
+}
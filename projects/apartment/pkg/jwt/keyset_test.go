@@ -0,0 +1,188 @@
+package appjwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAKey(t *testing.T) *Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k, err := NewPrivateKey(priv)
+	require.NoError(t, err)
+	return k
+}
+
+func newEd25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	k, err := NewPrivateKey(priv)
+	require.NoError(t, err)
+	return k
+}
+
+func accessClaims() *UserClaims {
+	return &UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           "id",
+		Type:             AccessToken,
+	}
+}
+
+func TestKeySet_SignAndParse(t *testing.T) {
+	for name, key := range map[string]*Key{
+		"RS256": newRSAKey(t),
+		"EdDSA": newEd25519Key(t),
+	} {
+		keys, err := NewKeySet(key)
+		require.NoError(t, err)
+		token, err := CreateToken(keys, accessClaims())
+		require.NoError(t, err, name)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &UserClaims{})
+		require.NoError(t, err, name)
+		assert.Equal(t, name, parsed.Method.Alg())
+		assert.Equal(t, key.ID(), parsed.Header["kid"], name)
+
+		claims, err := ParseTokenOfType(token, keys, AccessToken)
+		require.NoError(t, err, name)
+		assert.Equal(t, "id", claims.UserID, name)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := newRSAKey(t), newEd25519Key(t)
+	oldKeys, err := NewKeySet(oldKey)
+	require.NoError(t, err)
+	oldToken, err := CreateToken(oldKeys, accessClaims())
+	require.NoError(t, err)
+
+	// the old key verifies the tokens it signed until they expire
+	public, err := NewPublicKey(oldKey.public)
+	require.NoError(t, err)
+	assert.Equal(t, oldKey.ID(), public.ID())
+	keys, err := NewKeySet(newKey, WithVerifyKeys(public))
+	require.NoError(t, err)
+	_, err = ParseToken(oldToken, keys)
+	assert.NoError(t, err)
+
+	newToken, err := CreateToken(keys, accessClaims())
+	require.NoError(t, err)
+	_, err = ParseToken(newToken, keys)
+	assert.NoError(t, err)
+	_, err = ParseToken(newToken, oldKeys)
+	assert.Error(t, err)
+
+	assert.Len(t, keys.JWKS().Keys, 2)
+	_, err = NewKeySet(public)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+	_, err = NewKeySet(newKey, WithVerifyKeys(newKey))
+	assert.ErrorIs(t, err, ErrDuplicateKeyID)
+}
+
+func TestKeySet_LegacySecret(t *testing.T) {
+	secret := []byte("secret")
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS512, accessClaims()).SignedString(secret)
+	require.NoError(t, err)
+
+	keys, err := NewKeySet(newRSAKey(t), WithVerifyKeys(NewHMACKey(secret)))
+	require.NoError(t, err)
+	_, err = ParseToken(legacy, keys)
+	assert.NoError(t, err)
+	// the secret is never published
+	assert.Len(t, keys.JWKS().Keys, 1)
+}
+
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t)
+	keys, err := NewKeySet(key, WithVerifyKeys(NewHMACKey([]byte("secret"))))
+	require.NoError(t, err)
+
+	// the public key as an HMAC secret
+	pub := x509.MarshalPKCS1PublicKey(key.public.(*rsa.PublicKey))
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS512, accessClaims())
+	forged.Header["kid"] = key.ID()
+	s, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pub}))
+	require.NoError(t, err)
+	_, err = ParseToken(s, keys)
+	assert.ErrorIs(t, err, ErrWrongAlgorithm)
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, accessClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = ParseToken(none, keys)
+	assert.Error(t, err)
+
+	other := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims())
+	other.Header["kid"] = "made-up"
+	s, err = other.SignedString(key.private)
+	require.NoError(t, err)
+	_, err = ParseToken(s, keys)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestKeySet_IssuerAndAudience(t *testing.T) {
+	key := newEd25519Key(t)
+	keys, err := NewKeySet(key, WithIssuer("https://apartment.example.com"), WithAudience("apartment"))
+	require.NoError(t, err)
+	token, err := CreateToken(keys, accessClaims())
+	require.NoError(t, err)
+
+	claims, err := ParseToken(token, keys)
+	require.NoError(t, err)
+	assert.Equal(t, "https://apartment.example.com", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"apartment"}, claims.Audience)
+
+	for name, opts := range map[string][]KeySetOpt{
+		"issuer":   {WithIssuer("https://evil.example.com"), WithAudience("apartment")},
+		"audience": {WithIssuer("https://apartment.example.com"), WithAudience("billing")},
+	} {
+		other, err := NewKeySet(key, opts...)
+		require.NoError(t, err)
+		_, err = ParseToken(token, other)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	key, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.True(t, key.CanSign())
+	assert.Equal(t, "EdDSA", key.Alg())
+
+	der, err = x509.MarshalPKIXPublicKey(priv.Public())
+	require.NoError(t, err)
+	public, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.False(t, public.CanSign())
+	assert.Equal(t, key.ID(), public.ID())
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(small)}))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 8037 appendix A.3
+	jwk := JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+	x, err := jwt.NewParser().DecodeSegment(jwk.X)
+	require.NoError(t, err)
+	key, err := NewPublicKey(ed25519.PublicKey(x))
+	require.NoError(t, err)
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", key.ID())
+}
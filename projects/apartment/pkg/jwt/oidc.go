@@ -0,0 +1,34 @@
+package appjwt
+
+import "github.com/golang-jwt/jwt/v5"
+
+// OIDCStateClaims carry the secrets of an OpenID Connect sign-in, kept by
+// the browser in a cookie while the user is at the provider.
+type OIDCStateClaims struct {
+	jwt.RegisteredClaims
+	Type     TokenType `json:"typ"`
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+}
+
+func CreateOIDCStateToken(keys *KeySet, claims *OIDCStateClaims) (string, error) {
+	claims.Type = OIDCStateToken
+	keys.register(&claims.RegisteredClaims)
+	return keys.sign(claims)
+}
+
+func ParseOIDCStateToken(tokenString string, keys *KeySet) (*OIDCStateClaims, error) {
+	claims := &OIDCStateClaims{}
+	token, err := keys.parse(tokenString, claims, jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != OIDCStateToken {
+		return nil, ErrWrongTokenType
+	}
+	return claims, nil
+}
@@ -0,0 +1,38 @@
+package appjwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCStateToken(t *testing.T) {
+	keys := NewSecretKeySet([]byte("secret"))
+	token, err := CreateOIDCStateToken(keys, &OIDCStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		State:            "state",
+		Nonce:            "nonce",
+		Verifier:         "verifier",
+	})
+	assert.NoError(t, err)
+
+	claims, err := ParseOIDCStateToken(token, keys)
+	assert.NoError(t, err)
+	assert.Equal(t, "state", claims.State)
+	assert.Equal(t, "nonce", claims.Nonce)
+	assert.Equal(t, "verifier", claims.Verifier)
+
+	_, err = ParseOIDCStateToken(token, NewSecretKeySet([]byte("other")))
+	assert.Error(t, err)
+
+	// other tokens of the keys are not state tokens
+	access, err := CreateToken(keys, &UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		Type:             AccessToken,
+	})
+	assert.NoError(t, err)
+	_, err = ParseOIDCStateToken(access, keys)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+}
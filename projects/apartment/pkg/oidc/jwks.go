@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// signingAlgs are the ID token algorithms accepted. "none" and HMAC,
+// which would make the client secret a signing key, are not.
+var signingAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// the provider keys are fetched again for an unknown key id, but not more
+// often than this
+const minKeyRefresh = time.Minute
+
+type keySet struct {
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// key returns the public key of kid for alg, fetching the keys of the
+// provider when kid is unknown, as it is after they rotate them.
+func (c *Client) key(ctx context.Context, kid, alg string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k, ok := c.lookup(kid)
+	if !ok && (c.keys == nil || c.now().Sub(c.keys.fetchedAt) >= minKeyRefresh) {
+		if err := c.fetchKeys(ctx); err != nil {
+			return nil, err
+		}
+		k, ok = c.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if !keyFits(k, alg) {
+		return nil, fmt.Errorf("key %q does not fit %s", kid, alg)
+	}
+	return k, nil
+}
+
+// lookup finds kid, or the only key if the token names none.
+func (c *Client) lookup(kid string) (any, bool) {
+	if c.keys == nil {
+		return nil, false
+	}
+	if kid == "" && len(c.keys.keys) == 1 {
+		for _, k := range c.keys.keys {
+			return k, true
+		}
+	}
+	k, ok := c.keys.keys[kid]
+	return k, ok
+}
+
+func (c *Client) fetchKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.meta.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	status, err := c.do(req, &set)
+	if err != nil {
+		return fmt.Errorf("fetching keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fetching keys: status %d", status)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			// skip keys of types we don't know rather than fail on all
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	c.keys = &keySet{keys: keys, fetchedAt: c.now()}
+	return nil
+}
+
+func (k *jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func keyFits(key any, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	}
+	return false
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
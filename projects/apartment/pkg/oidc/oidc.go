@@ -0,0 +1,256 @@
+// Package oidc is an OpenID Connect relying party: it sends users to the
+// provider with the authorization code flow and PKCE, exchanges the code
+// for an ID token and verifies it against the keys the provider publishes.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrDiscovery      = errors.New("oidc: provider discovery failed")
+	ErrExchange       = errors.New("oidc: code exchange failed")
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+)
+
+// DefaultScopes ask for the email and name of the user.
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// responses larger than this are not read
+const maxResponseSize = 1 << 20
+
+type Config struct {
+	// Issuer is the provider, e.g. https://accounts.google.com. Its
+	// endpoints are discovered from Issuer/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends the user back with a code.
+	RedirectURL string
+	Scopes      []string
+}
+
+// Claims are the claims of an ID token.
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce           string     `json:"nonce"`
+	AuthorizedParty string     `json:"azp,omitempty"`
+	Email           string     `json:"email"`
+	EmailVerified   StringBool `json:"email_verified"`
+	Name            string     `json:"name"`
+	GivenName       string     `json:"given_name"`
+	FamilyName      string     `json:"family_name"`
+}
+
+// StringBool is a bool that some providers send as a string.
+type StringBool bool
+
+func (b *StringBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	case "false", "null", "":
+		*b = false
+	default:
+		return fmt.Errorf("oidc: invalid bool %s", data)
+	}
+	return nil
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to one provider. Its endpoints and keys are fetched on
+// first use and cached.
+type Client struct {
+	cfg  Config
+	http *http.Client
+	now  func() time.Time
+
+	mu   sync.Mutex
+	meta *metadata
+	keys *keySet
+}
+
+// NewClient returns a client of the provider in cfg. A nil httpClient
+// means http.DefaultClient.
+func NewClient(cfg Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = DefaultScopes
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Client{cfg: cfg, http: httpClient, now: time.Now}
+}
+
+// Issuer identifies the provider in the subjects it returns.
+func (c *Client) Issuer() string {
+	return c.cfg.Issuer
+}
+
+// AuthCodeURL returns the provider page that signs the user in and sends
+// them back to the redirect URL with a code and state. codeChallenge is
+// CodeChallenge of the verifier later given to Exchange.
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades the code the provider sent back for an ID token.
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	meta, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Join(ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.do(req, &body)
+	if err != nil {
+		return "", errors.Join(ErrExchange, err)
+	}
+	if status != http.StatusOK || body.Error != "" {
+		return "", fmt.Errorf("%w: %d %s %s", ErrExchange, status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: no id_token in response", ErrExchange)
+	}
+	return body.IDToken, nil
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an
+// ID token and returns its claims.
+func (c *Client) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	if _, err := c.discover(ctx); err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return c.key(ctx, kid, t.Method.Alg())
+		},
+		jwt.WithValidMethods(signingAlgs),
+		jwt.WithIssuer(c.cfg.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+		jwt.WithTimeFunc(c.now),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// discover fetches the endpoints of the provider, once.
+func (c *Client) discover(ctx context.Context) (*metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil {
+		return c.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, errors.Join(ErrDiscovery, err)
+	}
+	var meta metadata
+	status, err := c.do(req, &meta)
+	if err != nil {
+		return nil, errors.Join(ErrDiscovery, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, status)
+	}
+	if strings.TrimSuffix(meta.Issuer, "/") != c.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match", ErrDiscovery, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: missing endpoints", ErrDiscovery)
+	}
+	c.meta = &meta
+	return c.meta, nil
+}
+
+// do sends req and decodes a JSON response into dest.
+func (c *Client) do(req *http.Request, dest any) (int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(dest); err != nil {
+		return resp.StatusCode, fmt.Errorf("decoding response with status %d: %w", resp.StatusCode, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,203 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	clientID     = "apartment"
+	clientSecret = "s3cret/with+symbols"
+	redirectURL  = "http://localhost:8080/api/v1/auth/oidc/callback"
+	verifier     = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+var alice = oidctest.User{
+	Subject:       "1234",
+	Email:         "alice@example.com",
+	EmailVerified: true,
+	GivenName:     "Alice",
+	FamilyName:    "Smith",
+}
+
+func newClient(t *testing.T) (*Client, *oidctest.Provider) {
+	t.Helper()
+	p := oidctest.NewProvider(clientID, clientSecret)
+	t.Cleanup(p.Close)
+	c := NewClient(Config{
+		Issuer:       p.Issuer(),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	}, p.Client())
+	return c, p
+}
+
+// signIn runs the flow up to the ID token.
+func signIn(t *testing.T, c *Client, p *oidctest.Provider, u oidctest.User) string {
+	t.Helper()
+	ctx := context.Background()
+	authURL, err := c.AuthCodeURL(ctx, "the-state", "the-nonce", CodeChallenge(verifier))
+	require.NoError(t, err)
+	code, state, err := p.Authorize(authURL, u)
+	require.NoError(t, err)
+	assert.Equal(t, "the-state", state)
+	idToken, err := c.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+	return idToken
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge(verifier))
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	c, p := newClient(t)
+	authURL, err := c.AuthCodeURL(context.Background(), "st", "no", "ch")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, p.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, clientID, q.Get("client_id"))
+	assert.Equal(t, redirectURL, q.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "st", q.Get("state"))
+	assert.Equal(t, "no", q.Get("nonce"))
+	assert.Equal(t, "ch", q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+}
+
+func TestFlow(t *testing.T) {
+	c, p := newClient(t)
+	idToken := signIn(t, c, p, alice)
+
+	claims, err := c.Verify(context.Background(), idToken, "the-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "1234", claims.Subject)
+	assert.Equal(t, "alice@example.com", claims.Email)
+	assert.True(t, bool(claims.EmailVerified))
+	assert.Equal(t, "Alice", claims.GivenName)
+	assert.Equal(t, "Smith", claims.FamilyName)
+}
+
+func TestExchange_Rejected(t *testing.T) {
+	c, p := newClient(t)
+	ctx := context.Background()
+	authURL, err := c.AuthCodeURL(ctx, "st", "no", CodeChallenge(verifier))
+	require.NoError(t, err)
+	code, _, err := p.Authorize(authURL, alice)
+	require.NoError(t, err)
+
+	// a wrong PKCE verifier, and the code doesn't work twice
+	_, err = c.Exchange(ctx, code, "not-the-verifier-not-the-verifier-not-the-v")
+	assert.ErrorIs(t, err, ErrExchange)
+	_, err = c.Exchange(ctx, code, verifier)
+	assert.ErrorIs(t, err, ErrExchange)
+}
+
+func TestVerify_WrongNonce(t *testing.T) {
+	c, p := newClient(t)
+	idToken := signIn(t, c, p, alice)
+
+	_, err := c.Verify(context.Background(), idToken, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+	_, err = c.Verify(context.Background(), idToken, "")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerify_RejectsClaims(t *testing.T) {
+	c, p := newClient(t)
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   p.Issuer(),
+			"aud":   clientID,
+			"sub":   "1234",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+			"nonce": "n",
+		}
+	}
+	_, err := c.Verify(context.Background(), p.SignIDToken(valid()), "n")
+	require.NoError(t, err)
+
+	for name, change := range map[string]func(jwt.MapClaims){
+		"issuer":      func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"audience":    func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"expired":     func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"no expiry":   func(c jwt.MapClaims) { delete(c, "exp") },
+		"no subject":  func(c jwt.MapClaims) { delete(c, "sub") },
+		"other party": func(c jwt.MapClaims) { c["aud"] = []string{clientID, "other"}; c["azp"] = "other" },
+	} {
+		claims := valid()
+		change(claims)
+		_, err = c.Verify(context.Background(), p.SignIDToken(claims), "n")
+		assert.ErrorIs(t, err, ErrInvalidIDToken, name)
+	}
+}
+
+func TestVerify_RejectsUnsignedAndHMAC(t *testing.T) {
+	c, p := newClient(t)
+	claims := jwt.MapClaims{
+		"iss": p.Issuer(), "aud": clientID, "sub": "1234", "nonce": "n",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = c.Verify(context.Background(), none, "n")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	// the client secret is no signing key
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(clientSecret))
+	require.NoError(t, err)
+	_, err = c.Verify(context.Background(), hmac, "n")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestVerify_KeyRotation(t *testing.T) {
+	c, p := newClient(t)
+	_, err := c.Verify(context.Background(), signIn(t, c, p, alice), "the-nonce")
+	require.NoError(t, err)
+
+	// unknown keys fetch the keys again, but not more than once a minute,
+	// so that made-up key ids don't flood the provider
+	p.RotateKey()
+	_, err = c.Verify(context.Background(), signIn(t, c, p, alice), "the-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+	c.now = func() time.Time { return time.Now().Add(minKeyRefresh) }
+	_, err = c.Verify(context.Background(), signIn(t, c, p, alice), "the-nonce")
+	assert.NoError(t, err)
+}
+
+func TestDiscovery_IssuerMismatch(t *testing.T) {
+	p := oidctest.NewProvider(clientID, clientSecret)
+	defer p.Close()
+	c := NewClient(Config{Issuer: p.Issuer() + "/tenant", ClientID: clientID}, p.Client())
+
+	_, err := c.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.ErrorIs(t, err, ErrDiscovery)
+}
+
+func TestStringBool(t *testing.T) {
+	var v struct {
+		A, B, C StringBool
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"A":true,"B":"true","C":"false"}`), &v))
+	assert.True(t, bool(v.A))
+	assert.True(t, bool(v.B))
+	assert.False(t, bool(v.C))
+	assert.Error(t, json.Unmarshal([]byte(`{"A":"yes"}`), &v))
+}
@@ -0,0 +1,213 @@
+// Package oidctest runs a fake OpenID Connect provider for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User is who signs in at the provider.
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type grant struct {
+	user          User
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// Provider serves discovery, keys and the token endpoint of a provider
+// whose users sign in through Authorize.
+type Provider struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	keyID  int
+	grants map[string]grant
+}
+
+func NewProvider(clientID, clientSecret string) *Provider {
+	p := &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		grants:       make(map[string]grant),
+	}
+	p.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// Issuer is the URL of the provider.
+func (p *Provider) Issuer() string {
+	return p.URL
+}
+
+// RotateKey replaces the signing key, with a new key id.
+func (p *Provider) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = key
+	p.keyID++
+}
+
+// Authorize signs u in at authURL, as a browser would, and returns the
+// code and state the provider redirects back with.
+func (p *Provider) Authorize(authURL string, u User) (code, state string, err error) {
+	au, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := au.Query()
+	switch {
+	case q.Get("response_type") != "code":
+		return "", "", errors.New("response_type is not code")
+	case q.Get("client_id") != p.ClientID:
+		return "", "", errors.New("unknown client")
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		return "", "", errors.New("no S256 code challenge")
+	}
+
+	code = rand.Text()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants[code] = grant{
+		user:          u,
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	return code, q.Get("state"), nil
+}
+
+// SignIDToken signs claims with the current key, for tests of tokens the
+// token endpoint wouldn't issue.
+func (p *Provider) SignIDToken(claims jwt.Claims) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sign(claims)
+}
+
+func (p *Provider) sign(claims jwt.Claims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = p.kid()
+	s, err := t.SignedString(p.key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (p *Provider) kid() string {
+	return "key-" + strconv.Itoa(p.keyID)
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": p.kid(),
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	if id != p.ClientID || secret != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := r.PostFormValue("code")
+	g, ok := p.grants[code]
+	// codes work once
+	delete(p.grants, code)
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok ||
+		g.redirectURI != r.PostFormValue("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"id_token": p.sign(jwt.MapClaims{
+			"iss":            p.URL,
+			"aud":            p.ClientID,
+			"sub":            g.user.Subject,
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Hour).Unix(),
+			"nonce":          g.nonce,
+			"email":          g.user.Email,
+			"email_verified": g.user.EmailVerified,
+			"given_name":     g.user.GivenName,
+			"family_name":    g.user.FamilyName,
+		}),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
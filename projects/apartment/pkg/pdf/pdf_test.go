@@ -0,0 +1,41 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	d := New()
+	d.Text(50, 800, Bold, 18, "Receipt (copy)")
+	d.AddPage()
+	d.Text(50, 800, Regular, 10, "page two")
+
+	out := d.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), `(Receipt \(copy\)) Tj`)
+
+	// every xref offset must point at its object
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	xref, _ := strconv.Atoi(string(m[1]))
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[xref:], -1)
+	assert.Len(t, offsets, 8)
+	for i, o := range offsets {
+		off, _ := strconv.Atoi(string(o[1]))
+		assert.True(t, bytes.HasPrefix(out[off:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+	}
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c`, escape(`a(b)\c`))
+	assert.Equal(t, `caf\351`, escape("café"))
+	assert.Equal(t, "??", escape("سل"))
+}
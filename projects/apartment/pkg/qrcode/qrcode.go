@@ -0,0 +1,472 @@
+// Package qrcode encodes data as QR codes (ISO/IEC 18004) without external
+// dependencies. It is meant for short payloads such as otpauth URIs: data
+// is stored in byte mode with error correction level M, in the smallest
+// of versions 1 to 10 that fits, i.e. up to 213 bytes.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var ErrTooLong = errors.New("data too long for a qr code")
+
+// quietZone is the light border around the symbol, in modules.
+const quietZone = 4
+
+// ecBlocks is the error correction layout of a version at level M.
+type ecBlocks struct {
+	ecPerBlock int
+	// blocks of group 1 hold data1 data codewords, blocks of group 2 one
+	// more.
+	blocks1, data1 int
+	blocks2        int
+}
+
+var versions = [...]ecBlocks{
+	1:  {10, 1, 16, 0},
+	2:  {16, 1, 28, 0},
+	3:  {26, 1, 44, 0},
+	4:  {18, 2, 32, 0},
+	5:  {24, 2, 43, 0},
+	6:  {16, 4, 27, 0},
+	7:  {18, 4, 31, 0},
+	8:  {22, 2, 38, 2},
+	9:  {22, 3, 36, 2},
+	10: {26, 4, 43, 1},
+}
+
+var alignmentPositions = [...][]int{
+	1:  nil,
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+func (b ecBlocks) dataCodewords() int {
+	return b.blocks1*b.data1 + b.blocks2*(b.data1+1)
+}
+
+// capacity is the number of data bytes a version holds in byte mode.
+func capacity(version int) int {
+	bits := versions[version].dataCodewords()*8 - 4 - countBits(version)
+	return bits / 8
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// Code is an encoded QR symbol.
+type Code struct {
+	Version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Encode encodes data in the smallest version that fits it.
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v < len(versions); v++ {
+		if len(data) <= capacity(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	size := 17 + 4*version
+	c := &Code{
+		Version:  version,
+		size:     size,
+		modules:  newGrid(size),
+		function: newGrid(size),
+	}
+	c.drawFunctionPatterns()
+	c.drawCodewords(interleave(version, encodeData(version, data)))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // undo
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+	return c, nil
+}
+
+// Size is the width of the symbol in modules, without the quiet zone.
+func (c *Code) Size() int {
+	return c.size
+}
+
+// Dark tells whether the module at column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.size || y >= c.size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Image draws the symbol with scale pixels per module and a quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	scale = max(scale, 1)
+	width := (c.size + 2*quietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width),
+		color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if c.Dark(x/scale-quietZone, y/scale-quietZone) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// PNG returns the image of the symbol as PNG.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newGrid(size int) [][]bool {
+	g := make([][]bool, size)
+	for i := range g {
+		g[i] = make([]bool, size)
+	}
+	return g
+}
+
+func (c *Code) setFunction(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.setFunction(6, i, i%2 == 0)
+		c.setFunction(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.size-4, 3)
+	c.drawFinder(3, c.size-4)
+
+	pos := alignmentPositions[c.Version]
+	last := len(pos) - 1
+	for i, y := range pos {
+		for j, x := range pos {
+			// those overlapping finders
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// reserve the format areas, drawn once the mask is chosen
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+// drawFinder draws a finder pattern centered at x, y with its separator.
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= c.size || yy >= c.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the level M format information for
+// mask, and the dark module.
+func (c *Code) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+
+	for i := 0; i <= 5; i++ {
+		c.setFunction(8, i, bit(bits, i))
+	}
+	c.setFunction(8, 7, bit(bits, 6))
+	c.setFunction(8, 8, bit(bits, 7))
+	c.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.setFunction(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.setFunction(8, c.size-15+i, bit(bits, i))
+	}
+	c.setFunction(8, c.size-8, true)
+}
+
+// formatBits returns the BCH coded format information of level M, whose
+// bits are 00, and mask.
+func formatBits(mask int) int {
+	rem := mask
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (mask<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draws the version information of versions 7 and up.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		a, b := c.size-11+i%3, i/3
+		c.setFunction(a, b, bit(bits, i))
+		c.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the zigzag order, from the bottom
+// right corner upwards in columns of two.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			y := vert
+			if upward {
+				y = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask. Applying it twice
+// undoes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to read, by the four rules of the
+// standard. The mask with the lowest score is used.
+func (c *Code) penalty() int {
+	p := 0
+	dark := 0
+	for i := 0; i < c.size; i++ {
+		p += c.linePenalty(func(j int) bool { return c.modules[i][j] })
+		p += c.linePenalty(func(j int) bool { return c.modules[j][i] })
+	}
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x < c.size-1 && y < c.size-1 {
+				m := c.modules[y][x]
+				if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+					p += 3
+				}
+			}
+		}
+	}
+	total := c.size * c.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return p + max(k, 0)*10
+}
+
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty scores runs of five or more modules of one color and
+// patterns resembling finders in a row or column.
+func (c *Code) linePenalty(at func(int) bool) int {
+	p := 0
+	run := 1
+	for j := 1; j <= c.size; j++ {
+		if j < c.size && at(j) == at(j-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			p += 3 + run - 5
+		}
+		run = 1
+	}
+	for j := 0; j+len(finderLike[0]) <= c.size; j++ {
+		for _, pattern := range finderLike {
+			match := true
+			for k, want := range pattern {
+				if at(j+k) != want {
+					match = false
+					break
+				}
+			}
+			if match {
+				p += 40
+			}
+		}
+	}
+	return p
+}
+
+// encodeData returns the data codewords of data in byte mode, padded to
+// the capacity of version.
+func encodeData(version int, data []byte) []byte {
+	var w bitWriter
+	w.write(0b0100, 4)
+	w.write(len(data), countBits(version))
+	for _, b := range data {
+		w.write(int(b), 8)
+	}
+
+	capBits := versions[version].dataCodewords() * 8
+	w.write(0, min(4, capBits-w.n))
+	if w.n%8 != 0 {
+		w.write(0, 8-w.n%8)
+	}
+	for pad := 0xec; w.n < capBits; pad ^= 0xec ^ 0x11 {
+		w.write(pad, 8)
+	}
+	return w.buf
+}
+
+// interleave splits data into the blocks of version, appends their error
+// correction codewords and interleaves them.
+func interleave(version int, data []byte) []byte {
+	v := versions[version]
+	gen := generator(v.ecPerBlock)
+
+	var blocks, ecs [][]byte
+	for i := 0; i < v.blocks1+v.blocks2; i++ {
+		n := v.data1
+		if i >= v.blocks1 {
+			n++
+		}
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, remainder(data[:n], gen))
+		data = data[n:]
+	}
+
+	var out []byte
+	for i := 0; i <= v.data1; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+type bitWriter struct {
+	buf []byte
+	n   int
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v>>i&1 == 1 {
+			w.buf[w.n/8] |= 1 << (7 - w.n%8)
+		}
+		w.n++
+	}
+}
+
+func bit(v, i int) bool {
+	return v>>i&1 == 1
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
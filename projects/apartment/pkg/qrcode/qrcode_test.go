@@ -0,0 +1,81 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-M, from the worked example of the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	assert.Equal(t, want, remainder(data, generator(10)))
+}
+
+func TestFormatBits(t *testing.T) {
+	want := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, w := range want {
+		assert.Equal(t, w, formatBits(mask), mask)
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+	// mode 0100, length 00000001, 'a' 01100001, terminator, then padding
+	got := encodeData(1, []byte("a"))
+	assert.Len(t, got, 16)
+	assert.Equal(t, []byte{0x40, 0x16, 0x10, 0xec, 0x11, 0xec}, got[:6])
+}
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		n       int
+		version int
+	}{
+		{1, 1}, {14, 1}, {15, 2}, {134, 8}, {213, 10},
+	}
+	for _, c := range cases {
+		code, err := Encode([]byte(strings.Repeat("x", c.n)))
+		assert.NoError(t, err)
+		assert.Equal(t, c.version, code.Version, c.n)
+		assert.Equal(t, 17+4*c.version, code.Size())
+
+		// finder patterns in three corners, light separators
+		size := code.Size()
+		for _, corner := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+			x, y := corner[0], corner[1]
+			assert.True(t, code.Dark(x, y))
+			assert.True(t, code.Dark(x+3, y+3))
+			assert.False(t, code.Dark(x+1, y+1))
+		}
+		assert.False(t, code.Dark(7, 7))
+		assert.True(t, code.Dark(8, size-8), "dark module")
+	}
+
+	_, err := Encode(make([]byte, 214))
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestCode_PNG(t *testing.T) {
+	code, err := Encode([]byte("otpauth://totp/Apartment:a@b.c?secret=ABC"))
+	assert.NoError(t, err)
+
+	out, err := code.PNG(4)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(out))
+	assert.NoError(t, err)
+	width := (code.Size() + 2*quietZone) * 4
+	assert.Equal(t, width, img.Bounds().Dx())
+
+	// the quiet zone is light, the finder corner dark
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r)
+	r, _, _, _ = img.At(quietZone*4, quietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r)
+}
@@ -0,0 +1,62 @@
+package qrcode
+
+// Reed-Solomon error correction over GF(256) with the primitive polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+
+var expTable, logTable = gfTables()
+
+func gfTables() (exp [512]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	// so that products need no modulo
+	for i := 255; i < len(exp); i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+// generator returns the coefficients of (x - a^0)...(x - a^(degree-1)),
+// highest power first, without the leading 1.
+func generator(degree int) []byte {
+	g := make([]byte, degree)
+	g[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			g[j] = gfMul(g[j], root)
+			if j+1 < degree {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return g
+}
+
+// remainder returns the error correction codewords of data.
+func remainder(data, gen []byte) []byte {
+	rem := make([]byte, len(gen))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range gen {
+			rem[i] ^= gfMul(g, factor)
+		}
+	}
+	return rem
+}
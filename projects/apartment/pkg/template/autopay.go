@@ -0,0 +1,33 @@
+package template
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+)
+
+//go:embed autopay_email.html
+var autopayEmailTemplate string
+
+// AutopayFailedData describes an autopay charge that failed.
+type AutopayFailedData struct {
+	BillName string
+	DueDate  string
+	Amount   string
+	CardMask string
+	Reason   string
+	// Suspended is set when autopay was turned off after the failure.
+	Suspended bool
+}
+
+func NewAutopayFailed(data AutopayFailedData) ([]byte, error) {
+	tmpl, err := template.New("AutopayFailedEmail").Parse(autopayEmailTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		return nil, err
+	}
+	return tpl.Bytes(), nil
+}
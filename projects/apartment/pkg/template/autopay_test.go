@@ -0,0 +1,22 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAutopayFailed(t *testing.T) {
+	msg, err := NewAutopayFailed(AutopayFailedData{
+		BillName:  "Water <b>",
+		DueDate:   "2025-10-19",
+		Amount:    "50000",
+		CardMask:  "6037-****-****-1234",
+		Reason:    "payment declined",
+		Suspended: true,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "6037-****-****-1234")
+	assert.Contains(t, string(msg), "Autopay has been turned off")
+	assert.NotContains(t, string(msg), "<b>")
+}
@@ -0,0 +1,30 @@
+package template
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+)
+
+//go:embed email_verification_email.html
+var emailVerificationEmailTemplate string
+
+// EmailVerificationData describes an email verification link.
+type EmailVerificationData struct {
+	Name       string
+	VerifyLink string
+	// ExpiresIn is how long the link works, e.g. "24 hours".
+	ExpiresIn string
+}
+
+func NewEmailVerification(data EmailVerificationData) ([]byte, error) {
+	tmpl, err := template.New("EmailVerificationEmail").Parse(emailVerificationEmailTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		return nil, err
+	}
+	return tpl.Bytes(), nil
+}
@@ -0,0 +1,18 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEmailVerification(t *testing.T) {
+	msg, err := NewEmailVerification(EmailVerificationData{
+		Name:       "alex",
+		VerifyLink: "https://example.com/api/v1/auth/verify-email?token=abc",
+		ExpiresIn:  "24 hours",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "https://example.com/api/v1/auth/verify-email?token=abc")
+	assert.Contains(t, string(msg), "24 hours")
+}
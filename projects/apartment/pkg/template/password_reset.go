@@ -0,0 +1,30 @@
+package template
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+)
+
+//go:embed password_reset_email.html
+var passwordResetEmailTemplate string
+
+// PasswordResetData describes an emailed password reset link.
+type PasswordResetData struct {
+	Name      string
+	ResetLink string
+	// ExpiresIn is how long the link works, e.g. "30 minutes".
+	ExpiresIn string
+}
+
+func NewPasswordReset(data PasswordResetData) ([]byte, error) {
+	tmpl, err := template.New("PasswordResetEmail").Parse(passwordResetEmailTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		return nil, err
+	}
+	return tpl.Bytes(), nil
+}
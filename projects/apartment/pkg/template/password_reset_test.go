@@ -0,0 +1,19 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPasswordReset(t *testing.T) {
+	msg, err := NewPasswordReset(PasswordResetData{
+		Name:      "alex<script>",
+		ResetLink: "https://example.com/reset-password?token=abc",
+		ExpiresIn: "30 minutes",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "https://example.com/reset-password?token=abc")
+	assert.Contains(t, string(msg), "30 minutes")
+	assert.NotContains(t, string(msg), "<script>")
+}
@@ -0,0 +1,95 @@
+package template
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+
+	"github.com/arcaptcha-internship-2025/momoein-apartment/pkg/pdf"
+)
+
+//go:embed receipt_email.html
+var receiptEmailTemplate string
+
+type ReceiptItemData struct {
+	ApartmentName string
+	BillName      string
+	Amount        string
+}
+
+type ReceiptData struct {
+	Number        string
+	PaidAt        string
+	PayerName     string
+	PayerEmail    string
+	Gateway       string
+	TransactionID string
+	Items         []ReceiptItemData
+	Total         string
+}
+
+func NewReceipt(data ReceiptData) ([]byte, error) {
+	tmpl, err := template.New("ReceiptEmail").Parse(receiptEmailTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		return nil, err
+	}
+	return tpl.Bytes(), nil
+}
+
+// NewReceiptPDF lays the receipt out on A4 pages.
+func NewReceiptPDF(data ReceiptData) []byte {
+	const (
+		left   = 50.0
+		right  = pdf.PageWidth - 50
+		top    = pdf.PageHeight - 60
+		bottom = 60.0
+		line   = 16.0
+	)
+	doc := pdf.New()
+	y := top
+	next := func() {
+		y -= line
+		if y < bottom {
+			doc.AddPage()
+			y = top
+		}
+	}
+
+	doc.Text(left, y, pdf.Bold, 18, "Payment Receipt")
+	y -= 2 * line
+	for _, field := range [][2]string{
+		{"Receipt number", data.Number},
+		{"Paid at", data.PaidAt},
+		{"Payer", data.PayerName + " (" + data.PayerEmail + ")"},
+		{"Gateway", data.Gateway},
+		{"Reference", data.TransactionID},
+	} {
+		if field[1] == "" {
+			continue
+		}
+		doc.Text(left, y, pdf.Bold, 10, field[0]+":")
+		doc.Text(left+100, y, pdf.Regular, 10, field[1])
+		next()
+	}
+
+	next()
+	doc.Text(left, y, pdf.Bold, 10, "Apartment")
+	doc.Text(left+180, y, pdf.Bold, 10, "Bill")
+	doc.Text(right-80, y, pdf.Bold, 10, "Amount")
+	doc.Line(left, y-4, right, y-4)
+	next()
+	for _, item := range data.Items {
+		doc.Text(left, y, pdf.Regular, 10, item.ApartmentName)
+		doc.Text(left+180, y, pdf.Regular, 10, item.BillName)
+		doc.Text(right-80, y, pdf.Regular, 10, item.Amount)
+		next()
+	}
+	doc.Line(left, y+line-4, right, y+line-4)
+	doc.Text(left, y, pdf.Bold, 10, "Total")
+	doc.Text(right-80, y, pdf.Bold, 10, data.Total)
+	return doc.Bytes()
+}
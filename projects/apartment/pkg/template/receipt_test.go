@@ -0,0 +1,33 @@
+package template
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var receiptData = ReceiptData{
+	Number:     "RC-20251019-1A2B3C4D",
+	PaidAt:     "2025-10-19 10:00 UTC",
+	PayerName:  "Alex <script>",
+	PayerEmail: "alex@example.com",
+	Gateway:    "mock",
+	Items: []ReceiptItemData{
+		{ApartmentName: "Blue Tower", BillName: "Water", Amount: "50000"},
+	},
+	Total: "50000",
+}
+
+func TestNewReceipt(t *testing.T) {
+	msg, err := NewReceipt(receiptData)
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "RC-20251019-1A2B3C4D")
+	assert.NotContains(t, string(msg), "<script>")
+}
+
+func TestNewReceiptPDF(t *testing.T) {
+	doc := NewReceiptPDF(receiptData)
+	assert.True(t, bytes.HasPrefix(doc, []byte("%PDF-")))
+	assert.Contains(t, string(doc), "(Blue Tower) Tj")
+}
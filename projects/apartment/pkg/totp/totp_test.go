@@ -0,0 +1,74 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the SHA1 vectors of RFC 6238, truncated to 6 digits
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		code, err := Code(rfcSecret, Step(time.Unix(c.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, c.want, code, c.unix)
+	}
+
+	_, err := Code("not base32!", 1)
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	step, ok := Validate(rfcSecret, "005924", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// a period of drift is allowed, two are not
+	prev, _ := Code(rfcSecret, Step(now)-1)
+	step, ok = Validate(rfcSecret, prev, now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+
+	old, _ := Code(rfcSecret, Step(now)-2)
+	_, ok = Validate(rfcSecret, old, now)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestNewSecret(t *testing.T) {
+	s, err := NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, s, 32)
+
+	code, err := Code(s, Step(time.Now()))
+	assert.NoError(t, err)
+	_, ok := Validate(s, code, time.Now())
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("Apartment", "a@b.c", "ABC"))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Apartment:a@b.c", u.Path)
+	assert.Equal(t, "ABC", u.Query().Get("secret"))
+	assert.Equal(t, "Apartment", u.Query().Get("issuer"))
+}
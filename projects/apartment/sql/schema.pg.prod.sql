@@ -19,8 +19,45 @@ BEGIN
     IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'payment_status_type') THEN
         CREATE TYPE payment_status_type AS ENUM ('pending', 'paid', 'failed', 'cancelled');
     END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'payment_claim_status_type') THEN
+        CREATE TYPE payment_claim_status_type AS ENUM ('pending', 'approved', 'rejected');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'wallet_transaction_type') THEN
+        CREATE TYPE wallet_transaction_type AS ENUM ('top-up', 'bill-payment');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'wallet_transaction_status_type') THEN
+        CREATE TYPE wallet_transaction_status_type AS ENUM ('pending', 'completed', 'failed');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'autopay_enrollment_status_type') THEN
+        CREATE TYPE autopay_enrollment_status_type AS ENUM ('pending', 'active', 'cancelled', 'suspended');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'outbox_event_status_type') THEN
+        CREATE TYPE outbox_event_status_type AS ENUM ('pending', 'dispatched', 'failed');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'webhook_delivery_status_type') THEN
+        CREATE TYPE webhook_delivery_status_type AS ENUM ('pending', 'succeeded', 'failed');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'ledger_account_kind') THEN
+        CREATE TYPE ledger_account_kind AS ENUM ('receivable', 'wallet', 'income', 'cash');
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'ledger_entry_type') THEN
+        CREATE TYPE ledger_entry_type AS ENUM (
+            'bill-charge', 'payment', 'refund', 'wallet-top-up', 'wallet-payment', 'fee', 'adjustment'
+        );
+    END IF;
 END $$;
 
+-- Payments that were never confirmed by their gateway
+ALTER TYPE payment_status_type ADD VALUE IF NOT EXISTS 'expired';
+
 -- Users table
 CREATE TABLE IF NOT EXISTS users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -34,6 +71,91 @@ CREATE TABLE IF NOT EXISTS users (
 );
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 
+-- Email verification; email_verification_sent_at throttles resends
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verification_sent_at TIMESTAMPTZ;
+
+-- Profile fields
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT;
+
+-- Refresh tokens, one row per issued token. The tokens rotated from one
+-- sign-in share a family, revoked together when one is reused.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    family_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+-- Password resets, one row per emailed reset link. Only the SHA-256 of the
+-- token is stored; a row can be used once before it expires.
+CREATE TABLE IF NOT EXISTS password_resets (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets(user_id);
+
+-- Authenticator apps for two-factor authentication, pending until confirmed
+-- with a code. last_step is the time step of the last code used, so a code
+-- works once; failed attempts lock verification for a while.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ
+);
+
+-- Two-factor recovery codes. Only the SHA-256 of a code is stored; each
+-- works once.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+
+-- Accounts at OpenID Connect providers users sign in with, by the issuer
+-- of the provider and its id of the account. email is the one the
+-- provider gave when the account was linked.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+-- Failed sign-ins by "account:" and the email, or "ip:" and the address,
+-- for throttling and lockout. Emails need not belong to a user.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
 -- Apartments table
 CREATE TABLE IF NOT EXISTS apartments (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -46,6 +168,9 @@ CREATE TABLE IF NOT EXISTS apartments (
     admin_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
+-- the admin must sign in with two-factor authentication
+ALTER TABLE apartments ADD COLUMN IF NOT EXISTS require_admin_mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
 -- Users ↔ Apartments junction table
 CREATE TABLE IF NOT EXISTS users_apartments (
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
@@ -82,10 +207,14 @@ CREATE TABLE IF NOT EXISTS bills (
     -- status bill_status_type NOT NULL DEFAULT 'unpaid',
     -- paid_at TIMESTAMPTZ,
     due_date DATE NOT NULL,
+    -- Set once the bill is found unpaid after its due date
+    overdue_at TIMESTAMPTZ,
     image_id UUID,
     apartment_id UUID NOT NULL REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 
+ALTER TABLE bills ADD COLUMN IF NOT EXISTS overdue_at TIMESTAMPTZ;
+
 -- Payments table
 CREATE TABLE IF NOT EXISTS payments (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -94,10 +223,241 @@ CREATE TABLE IF NOT EXISTS payments (
     deleted_at TIMESTAMPTZ,
     bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
     payer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
-    amount INTEGER NOT NULL CHECK (amount >= 0),
+    amount INTEGER NOT NULL,
     paid_at TIMESTAMPTZ,
     status payment_status_type NOT NULL DEFAULT 'pending',
     gateway TEXT NOT NULL,
     transaction_id TEXT,
-    callback_data JSONB
+    callback_data JSONB,
+    refund_of UUID REFERENCES payments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    description TEXT
 );
+
+-- Refunds are negative entries linked to the refunded payment
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refund_of UUID REFERENCES payments(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS description TEXT;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_amount_check;
+ALTER TABLE payments ADD CONSTRAINT payments_amount_check CHECK (amount >= 0 OR refund_of IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_payments_status_created_at ON payments(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_payments_refund_of ON payments(refund_of);
+
+-- Payment idempotency keys
+CREATE TABLE IF NOT EXISTS payment_idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    fingerprint TEXT NOT NULL,
+    response JSONB,
+    expires_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, idempotency_key)
+);
+
+-- Offline payment claims, approved claims become offline payments
+CREATE TABLE IF NOT EXISTS payment_claims (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    bill_id UUID NOT NULL REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    payer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    paid_at TIMESTAMPTZ NOT NULL,
+    reference TEXT NOT NULL,
+    proof_id UUID NOT NULL,
+    proof_name TEXT NOT NULL,
+    proof_type TEXT NOT NULL,
+    proof_size BIGINT NOT NULL,
+    status payment_claim_status_type NOT NULL DEFAULT 'pending',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    reviewed_at TIMESTAMPTZ,
+    reject_reason TEXT,
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_claims_bill_id ON payment_claims(bill_id, status);
+CREATE INDEX IF NOT EXISTS idx_payment_claims_payer_id ON payment_claims(payer_id);
+
+-- Wallets
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    apartment_id UUID NOT NULL REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    UNIQUE (user_id, apartment_id)
+);
+
+-- Wallet transactions
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES wallets(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type wallet_transaction_type NOT NULL,
+    amount BIGINT NOT NULL,
+    status wallet_transaction_status_type NOT NULL DEFAULT 'pending',
+    gateway TEXT,
+    transaction_id TEXT,
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    bill_id UUID REFERENCES bills(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    description TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_wallet_id ON wallet_transactions(wallet_id, created_at);
+
+-- Autopay enrollments, one per member and apartment
+CREATE TABLE IF NOT EXISTS autopay_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    apartment_id UUID NOT NULL REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    gateway TEXT NOT NULL,
+    token TEXT,
+    card_mask TEXT,
+    days_before_due INTEGER NOT NULL DEFAULT 0 CHECK (days_before_due BETWEEN 0 AND 30),
+    status autopay_enrollment_status_type NOT NULL DEFAULT 'pending',
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_failed_at TIMESTAMPTZ,
+    activated_at TIMESTAMPTZ,
+    UNIQUE (user_id, apartment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_autopay_enrollments_status ON autopay_enrollments(status);
+
+-- Outbox of domain events, written in the transaction of the state change
+-- an event records and delivered to subscribers afterwards
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    status outbox_event_status_type NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    last_error TEXT,
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(status, next_attempt_at)
+    WHERE status = 'pending';
+
+-- Subscribers that handled an outbox event, skipped when it is retried
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    event_id UUID NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    delivered_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    PRIMARY KEY (event_id, subscriber)
+);
+
+-- Webhook endpoints, registered by apartment admins
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    apartment_id UUID NOT NULL REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL CHECK (cardinality(event_types) > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_apartment_id ON webhook_endpoints(apartment_id);
+
+-- Webhook deliveries, one per event and endpoint
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status_type NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id ON webhook_deliveries(endpoint_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at)
+    WHERE status = 'pending';
+
+-- Personal access tokens users create for scripts and integrations. Only
+-- the SHA-256 of a token is stored; it is shown once. scopes are
+-- bills:read, bills:write, payments:read and payments:write, and
+-- apartment_id restricts a token to one apartment.
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL CHECK (cardinality(scopes) > 0),
+    apartment_id UUID REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    mfa BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+-- Ledger accounts, one per member and kind or per apartment and kind.
+-- No foreign keys, the ledger outlives the rows it refers to.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    code TEXT UNIQUE NOT NULL,
+    kind ledger_account_kind NOT NULL,
+    apartment_id UUID NOT NULL,
+    user_id UUID
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_user_id ON ledger_accounts(user_id, kind);
+
+-- Ledger journal entries, posted once per type and source row
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    type ledger_entry_type NOT NULL,
+    reference TEXT NOT NULL,
+    apartment_id UUID NOT NULL,
+    bill_id UUID,
+    description TEXT,
+    UNIQUE (type, reference)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_bill_id ON ledger_entries(bill_id);
+
+-- Ledger lines, positive amounts are debits and negative amounts credits
+CREATE TABLE IF NOT EXISTS ledger_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL REFERENCES ledger_entries(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_lines_entry_id ON ledger_lines(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_lines_account_id ON ledger_lines(account_id);
+
+-- Journal entries and lines are immutable, mistakes are corrected by new entries
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger % rows are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS ledger_entries_immutable ON ledger_entries;
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+DROP TRIGGER IF EXISTS ledger_lines_immutable ON ledger_lines;
+CREATE TRIGGER ledger_lines_immutable BEFORE UPDATE OR DELETE ON ledger_lines
+    FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
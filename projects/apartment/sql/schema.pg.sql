@@ -1,6 +1,25 @@
 -- Enable UUID generator extension (for gen_random_uuid)
 CREATE EXTENSION IF NOT EXISTS pgcrypto;
 -- Drop tables if they already exist
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
+DROP TABLE IF EXISTS password_resets;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
+DROP TABLE IF EXISTS outbox_deliveries;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS ledger_lines;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP TABLE IF EXISTS autopay_enrollments;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS payment_claims;
+DROP TABLE IF EXISTS payment_idempotency_keys;
 DROP TABLE IF EXISTS payments;
 DROP TABLE IF EXISTS bills;
 DROP TABLE IF EXISTS users_apartments;
@@ -20,9 +39,86 @@ CREATE TABLE IF NOT EXISTS users (
     first_name TEXT,
     last_name TEXT,
     email TEXT UNIQUE NOT NULL,
-    password TEXT NOT NULL
+    password TEXT NOT NULL,
+    phone TEXT,
+    -- BCP 47 language tag
+    locale TEXT,
+    avatar_url TEXT,
+    email_verified_at TIMESTAMPTZ,
+    -- when the last verification email was sent, for throttling
+    email_verification_sent_at TIMESTAMPTZ
 );
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+-- Create refresh tokens table, one row per issued refresh token
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    -- the tokens rotated from one sign-in share a family
+    family_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+-- Create password resets table, one row per emailed reset link
+CREATE TABLE IF NOT EXISTS password_resets (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    user_id UUID NOT NULL,
+    -- SHA-256 of the token, the token itself is only in the email
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets(user_id);
+-- Create authenticator apps table, pending until confirmed with a code
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    -- time step of the last code used, a code works once
+    last_step BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create two-factor recovery codes table
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    user_id UUID NOT NULL,
+    -- SHA-256 of the code, the code itself is shown once
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+-- Create identity provider accounts table
+CREATE TABLE IF NOT EXISTS user_identities (
+    -- the OpenID Connect provider and its id of the account
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    -- email the provider gave when the account was linked
+    email TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+-- Create failed sign-in attempts table
+CREATE TABLE IF NOT EXISTS login_attempts (
+    -- "account:" and the email, or "ip:" and the address
+    attempt_key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
 -- Create apartments table
 CREATE TABLE IF NOT EXISTS apartments (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -33,6 +129,8 @@ CREATE TABLE IF NOT EXISTS apartments (
     address TEXT NOT NULL,
     unit_number INTEGER NOT NULL,
     admin_id UUID NOT NULL,
+    -- the admin must sign in with two-factor authentication
+    require_admin_mfa BOOLEAN NOT NULL DEFAULT FALSE,
     FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 -- Create enum type for invite status
@@ -96,6 +194,8 @@ CREATE TABLE IF NOT EXISTS bills (
     -- status bill_status_type NOT NULL DEFAULT 'unpaid', -- ??
     -- paid_at TIMESTAMPTZ, -- ??
     due_date DATE NOT NULL,
+    -- set once the bill is found unpaid after its due date
+    overdue_at TIMESTAMPTZ,
     image_id UUID,
     apartment_id UUID NOT NULL,
     FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
@@ -110,7 +210,7 @@ CREATE TABLE IF NOT EXISTS payments (
     payer_id UUID NOT NULL,
     amount INTEGER NOT NULL,
     paid_at TIMESTAMPTZ,
-    -- values: pending, paid, failed, cancelled
+    -- values: pending, paid, failed, cancelled, expired
     status TEXT NOT NULL DEFAULT 'pending',
     -- e.g., 'zarinpal', 'stripe', etc.
     gateway TEXT NOT NULL,
@@ -118,6 +218,245 @@ CREATE TABLE IF NOT EXISTS payments (
     transaction_id TEXT,
     -- optional: raw data from gateway callback for auditing
     callback_data JSONB,
+    -- set on refund entries, which carry a negative amount
+    refund_of UUID,
+    -- e.g., the reason of a refund
+    description TEXT,
+    FOREIGN KEY (refund_of) REFERENCES payments(id) ON DELETE CASCADE ON UPDATE CASCADE,
     FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
     FOREIGN KEY (payer_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
-);
\ No newline at end of file
+);
+CREATE INDEX IF NOT EXISTS idx_payments_status_created_at ON payments(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_payments_refund_of ON payments(refund_of);
+-- Create payment idempotency keys table
+CREATE TABLE IF NOT EXISTS payment_idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    -- value of the Idempotency-Key header
+    idempotency_key TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    -- hash of the request the key was first used with
+    fingerprint TEXT NOT NULL,
+    -- redirect returned for the first request, NULL while in progress
+    response JSONB,
+    expires_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create offline payment claims table
+CREATE TABLE IF NOT EXISTS payment_claims (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    bill_id UUID NOT NULL,
+    payer_id UUID NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    -- when the member says the money was transferred
+    paid_at TIMESTAMPTZ NOT NULL,
+    -- bank or card transfer reference number
+    reference TEXT NOT NULL,
+    -- object storage key of the receipt image
+    proof_id UUID NOT NULL,
+    proof_name TEXT NOT NULL,
+    proof_type TEXT NOT NULL,
+    proof_size BIGINT NOT NULL,
+    -- values: pending, approved, rejected
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by UUID,
+    reviewed_at TIMESTAMPTZ,
+    reject_reason TEXT,
+    -- offline payment created on approval
+    payment_id UUID,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (payer_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_payment_claims_bill_id ON payment_claims(bill_id, status);
+CREATE INDEX IF NOT EXISTS idx_payment_claims_payer_id ON payment_claims(payer_id);
+-- Create wallets table
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    user_id UUID NOT NULL,
+    apartment_id UUID NOT NULL,
+    -- prepaid credit, never negative
+    balance BIGINT NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    UNIQUE (user_id, apartment_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create wallet transactions table
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    wallet_id UUID NOT NULL,
+    -- values: top-up, bill-payment
+    type TEXT NOT NULL,
+    -- positive for credit, negative for spending
+    amount BIGINT NOT NULL,
+    -- values: pending, completed, failed
+    status TEXT NOT NULL DEFAULT 'pending',
+    gateway TEXT,
+    -- ID returned by the payment gateway for top-ups
+    transaction_id TEXT,
+    -- payment made from the wallet
+    payment_id UUID,
+    bill_id UUID,
+    description TEXT,
+    FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_wallet_id ON wallet_transactions(wallet_id, created_at);
+-- Create autopay enrollments table, one per member and apartment
+CREATE TABLE IF NOT EXISTS autopay_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    user_id UUID NOT NULL,
+    apartment_id UUID NOT NULL,
+    gateway TEXT NOT NULL,
+    -- gateway reference of the saved payment method
+    token TEXT,
+    card_mask TEXT,
+    -- bills are charged this many days before their due date
+    days_before_due INTEGER NOT NULL DEFAULT 0 CHECK (days_before_due BETWEEN 0 AND 30),
+    -- values: pending, active, cancelled, suspended
+    status TEXT NOT NULL DEFAULT 'pending',
+    -- failed charges in a row, suspended at the configured maximum
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_failed_at TIMESTAMPTZ,
+    -- only bills issued after it are charged
+    activated_at TIMESTAMPTZ,
+    UNIQUE (user_id, apartment_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_autopay_enrollments_status ON autopay_enrollments(status);
+-- Create outbox events table, written in the transaction of the state
+-- change an event records and delivered to subscribers afterwards
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    -- e.g., bill.created, payment.succeeded, invite.created, member.joined
+    type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    -- values: pending, dispatched, failed
+    status TEXT NOT NULL DEFAULT 'pending',
+    -- failed deliveries so far
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error TEXT,
+    dispatched_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(status, next_attempt_at);
+-- Create outbox deliveries table, the subscribers that handled an event
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    event_id UUID NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    subscriber TEXT NOT NULL,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, subscriber)
+);
+-- Create webhook endpoints table, registered by apartment admins
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    apartment_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- bill.created, bill.overdue, payment.succeeded, member.joined
+    event_types TEXT[] NOT NULL,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- Create webhook deliveries table, one row per event and endpoint
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    updated_at TIMESTAMPTZ DEFAULT now(),
+    endpoint_id UUID NOT NULL,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    -- pending, succeeded, failed
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    response_status INTEGER,
+    last_error TEXT,
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (endpoint_id, event_id),
+    FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at);
+-- Create personal access tokens table, for scripts and integrations
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT now(),
+    user_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    -- SHA-256 of the token, the token itself is only shown once
+    token_hash TEXT UNIQUE NOT NULL,
+    -- bills:read, bills:write, payments:read, payments:write
+    scopes TEXT[] NOT NULL,
+    -- restricts the token to one apartment
+    apartment_id UUID,
+    mfa BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+-- Create ledger accounts table, one account per member and kind or per
+-- apartment and kind. No foreign keys, the ledger outlives its sources.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    -- e.g., receivable:<apartment_id>:<user_id>
+    code TEXT UNIQUE NOT NULL,
+    -- values: receivable, wallet, income, cash
+    kind TEXT NOT NULL,
+    apartment_id UUID NOT NULL,
+    -- NULL for apartment accounts
+    user_id UUID
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_user_id ON ledger_accounts(user_id, kind);
+-- Create ledger entries table
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMPTZ DEFAULT now(),
+    -- values: bill-charge, payment, refund, wallet-top-up, wallet-payment, fee, adjustment
+    type TEXT NOT NULL,
+    -- ID of the source row, e.g., the bill or payment
+    reference TEXT NOT NULL,
+    apartment_id UUID NOT NULL,
+    bill_id UUID,
+    description TEXT,
+    UNIQUE (type, reference)
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_bill_id ON ledger_entries(bill_id);
+-- Create ledger lines table
+CREATE TABLE IF NOT EXISTS ledger_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entry_id UUID NOT NULL,
+    account_id UUID NOT NULL,
+    -- positive for debit, negative for credit
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+    FOREIGN KEY (account_id) REFERENCES ledger_accounts(id)
+);
+CREATE INDEX IF NOT EXISTS idx_ledger_lines_entry_id ON ledger_lines(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_lines_account_id ON ledger_lines(account_id);
+-- Reject changes to posted journal entries and lines
+CREATE OR REPLACE FUNCTION ledger_immutable() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'ledger % rows are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON ledger_entries FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+CREATE TRIGGER ledger_lines_immutable BEFORE UPDATE OR DELETE ON ledger_lines FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
\ No newline at end of file
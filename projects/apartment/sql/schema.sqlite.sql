@@ -1,6 +1,22 @@
 -- Optional: Enable foreign key constraints (run before anything else)
 PRAGMA foreign_keys = ON;
 -- Drop tables if they already exist
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
+DROP TABLE IF EXISTS password_resets;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_endpoints;
+DROP TABLE IF EXISTS outbox_deliveries;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS autopay_enrollments;
+DROP TABLE IF EXISTS wallet_transactions;
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS payment_claims;
+DROP TABLE IF EXISTS payment_idempotency_keys;
 DROP TABLE IF EXISTS payments;
 DROP TABLE IF EXISTS bills;
 DROP TABLE IF EXISTS users_apartments;
@@ -17,9 +33,72 @@ CREATE TABLE IF NOT EXISTS users (
     first_name TEXT,
     last_name TEXT,
     email TEXT UNIQUE NOT NULL,
-    password TEXT NOT NULL
+    password TEXT NOT NULL,
+    phone TEXT,
+    locale TEXT,
+    avatar_url TEXT,
+    email_verified_at DATETIME,
+    email_verification_sent_at DATETIME
 );
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+-- REFRESH_TOKENS table
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    family_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    revoked_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- PASSWORD_RESETS table
+CREATE TABLE IF NOT EXISTS password_resets (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_id TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- USER_TOTP table
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    secret TEXT NOT NULL,
+    confirmed_at DATETIME,
+    last_step INTEGER NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- MFA_RECOVERY_CODES table
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_id TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- USER_IDENTITIES table
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- LOGIN_ATTEMPTS table
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
 -- APARTMENTS table
 CREATE TABLE IF NOT EXISTS apartments (
     id TEXT PRIMARY KEY,
@@ -30,6 +109,7 @@ CREATE TABLE IF NOT EXISTS apartments (
     address TEXT NOT NULL,
     unit_number INTEGER NOT NULL,
     admin_id TEXT NOT NULL,
+    require_admin_mfa BOOLEAN NOT NULL DEFAULT 0,
     FOREIGN KEY (admin_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
 );
 -- USERS_APARTMENTS (many-to-many)
@@ -73,6 +153,7 @@ CREATE TABLE IF NOT EXISTS bills (
     status TEXT NOT NULL DEFAULT 'unpaid',
     paid_at DATETIME,
     due_date DATE NOT NULL,
+    overdue_at DATETIME,
     image_id TEXT,
     apartment_id TEXT NOT NULL,
     FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE,
@@ -89,6 +170,191 @@ CREATE TABLE IF NOT EXISTS payments (
     payer_id TEXT NOT NULL,
     amount INTEGER NOT NULL,
     payment_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    refund_of TEXT,
+    description TEXT,
+    FOREIGN KEY (refund_of) REFERENCES payments(id) ON DELETE CASCADE ON UPDATE CASCADE,
     FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
     FOREIGN KEY (payer_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- PAYMENT_IDEMPOTENCY_KEYS table
+CREATE TABLE IF NOT EXISTS payment_idempotency_keys (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    idempotency_key TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    response TEXT,
+    expires_at DATETIME NOT NULL,
+    UNIQUE (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- PAYMENT_CLAIMS table
+CREATE TABLE IF NOT EXISTS payment_claims (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    bill_id TEXT NOT NULL,
+    payer_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    paid_at DATETIME NOT NULL,
+    reference TEXT NOT NULL,
+    proof_id TEXT NOT NULL,
+    proof_name TEXT NOT NULL,
+    proof_type TEXT NOT NULL,
+    proof_size INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by TEXT,
+    reviewed_at DATETIME,
+    reject_reason TEXT,
+    payment_id TEXT,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (payer_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+-- WALLETS table
+CREATE TABLE IF NOT EXISTS wallets (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_id TEXT NOT NULL,
+    apartment_id TEXT NOT NULL,
+    balance INTEGER NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    UNIQUE (user_id, apartment_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- WALLET_TRANSACTIONS table
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    wallet_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    amount INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    gateway TEXT,
+    transaction_id TEXT,
+    payment_id TEXT,
+    bill_id TEXT,
+    description TEXT,
+    FOREIGN KEY (wallet_id) REFERENCES wallets(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    FOREIGN KEY (bill_id) REFERENCES bills(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+-- AUTOPAY_ENROLLMENTS table
+CREATE TABLE IF NOT EXISTS autopay_enrollments (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_id TEXT NOT NULL,
+    apartment_id TEXT NOT NULL,
+    gateway TEXT NOT NULL,
+    token TEXT,
+    card_mask TEXT,
+    days_before_due INTEGER NOT NULL DEFAULT 0 CHECK (days_before_due BETWEEN 0 AND 30),
+    status TEXT NOT NULL DEFAULT 'pending',
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_failed_at DATETIME,
+    activated_at DATETIME,
+    UNIQUE (user_id, apartment_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- OUTBOX_EVENTS table
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    dispatched_at DATETIME
+);
+-- OUTBOX_DELIVERIES table
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    event_id TEXT NOT NULL,
+    subscriber TEXT NOT NULL,
+    delivered_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, subscriber),
+    FOREIGN KEY (event_id) REFERENCES outbox_events(id) ON DELETE CASCADE
+);
+-- WEBHOOK_ENDPOINTS table
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    apartment_id TEXT NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT NOT NULL,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- WEBHOOK_DELIVERIES table
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    endpoint_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    delivered_at DATETIME,
+    UNIQUE (endpoint_id, event_id),
+    FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+-- PERSONAL_ACCESS_TOKENS table
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT NOT NULL,
+    apartment_id TEXT,
+    mfa BOOLEAN NOT NULL DEFAULT 0,
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (apartment_id) REFERENCES apartments(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+-- LEDGER_ACCOUNTS table
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    code TEXT UNIQUE NOT NULL,
+    kind TEXT NOT NULL,
+    apartment_id TEXT NOT NULL,
+    user_id TEXT
+);
+-- LEDGER_ENTRIES table
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id TEXT PRIMARY KEY,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    type TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    apartment_id TEXT NOT NULL,
+    bill_id TEXT,
+    description TEXT,
+    UNIQUE (type, reference)
+);
+-- LEDGER_LINES table
+CREATE TABLE IF NOT EXISTS ledger_lines (
+    id TEXT PRIMARY KEY,
+    entry_id TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount <> 0),
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+    FOREIGN KEY (account_id) REFERENCES ledger_accounts(id)
 );
\ No newline at end of file